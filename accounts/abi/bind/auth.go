@@ -21,7 +21,9 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"math/big"
 
+	"github.com/etherzero/go-etherzero/accounts"
 	"github.com/etherzero/go-etherzero/accounts/keystore"
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core/types"
@@ -60,3 +62,19 @@ func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
 		},
 	}
 }
+
+// NewWalletTransactor is a utility method to easily create a transaction signer
+// from an account living in any wallet backend (keystore or hardware wallet).
+// The wallet performs the actual signing, so hardware wallets will prompt their
+// user for confirmation.
+func NewWalletTransactor(wallet accounts.Wallet, account accounts.Account, chainID *big.Int) *TransactOpts {
+	return &TransactOpts{
+		From: account.Address,
+		Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account.Address {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			return wallet.SignTx(account, tx, chainID)
+		},
+	}
+}
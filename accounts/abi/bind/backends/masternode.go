@@ -0,0 +1,182 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/p2p/discv5"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// MasternodeBackend is a SimulatedBackend with the masternode contract deployed,
+// allowing to test masternode registration and witness election without running
+// a full node.
+type MasternodeBackend struct {
+	*SimulatedBackend
+
+	address  common.Address
+	contract *contract.Contract
+}
+
+// NewMasternodeBackend creates a new simulated blockchain with the masternode
+// contract deployed by the given key, which must be funded in alloc.
+//
+// Gas is paid with power, accrued block by block by the accounts holding at
+// least 0.01 ether. The contract calls are given infinite power and the chain
+// starts off with an empty block, so that the allocations can transact right
+// away.
+func NewMasternodeBackend(deployer *ecdsa.PrivateKey, alloc core.GenesisAlloc, gasLimit uint64) (*MasternodeBackend, error) {
+	backend := NewSimulatedBackend(alloc, gasLimit)
+	backend.powered = true
+	backend.Commit()
+
+	address, tx, binding, err := contract.DeployContract(bind.NewKeyedTransactor(deployer), backend)
+	if err != nil {
+		return nil, err
+	}
+	b := &MasternodeBackend{SimulatedBackend: backend, address: address, contract: binding}
+	if err := b.commit(tx); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Address returns the address the masternode contract is deployed at.
+func (b *MasternodeBackend) Address() common.Address {
+	return b.address
+}
+
+// Contract returns the binding of the deployed masternode contract.
+func (b *MasternodeBackend) Contract() *contract.Contract {
+	return b.contract
+}
+
+// Register registers the node key as a masternode owned by the given account,
+// paying the deposit, and pings it so it's eligible for the next election. The
+// owner must be funded above the deposit and may own a single masternode.
+func (b *MasternodeBackend) Register(owner *ecdsa.PrivateKey, node *ecdsa.PrivateKey) (string, error) {
+	deposit, err := b.contract.EtzPerNode(nil)
+	if err != nil {
+		return "", err
+	}
+	var (
+		id   = discv5.PubkeyID(&node.PublicKey)
+		id1  [32]byte
+		id2  [32]byte
+		opts = bind.NewKeyedTransactor(owner)
+	)
+	copy(id1[:], id[:32])
+	copy(id2[:], id[32:])
+	opts.Value = deposit
+
+	tx, err := b.contract.Register(opts, id1, id2)
+	if err != nil {
+		return "", err
+	}
+	if err := b.commit(tx); err != nil {
+		return "", err
+	}
+	if err := b.Ping(node); err != nil {
+		return "", err
+	}
+	return masternode.GetMasternodeID(id), nil
+}
+
+// Ping sends the heartbeat of the masternode with the given node key, paid for
+// by the share of the deposit forwarded to the node account on registration.
+func (b *MasternodeBackend) Ping(node *ecdsa.PrivateKey) error {
+	ctx := context.Background()
+
+	nonce, err := b.PendingNonceAt(ctx, crypto.PubkeyToAddress(node.PublicKey))
+	if err != nil {
+		return err
+	}
+	gasPrice, err := b.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := types.SignTx(types.NewTransaction(nonce, b.address, new(big.Int), 90000, gasPrice, nil), types.HomesteadSigner{}, node)
+	if err != nil {
+		return err
+	}
+	if err := b.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	return b.commit(tx)
+}
+
+// Masternodes returns the ids of the masternodes eligible for election at the
+// latest block.
+func (b *MasternodeBackend) Masternodes() ([]string, error) {
+	return masternode.GetIdsByBlockNumber(b.contract, b.blockchain.CurrentBlock().Number())
+}
+
+// AdvanceCycle imports a block opening the next cycle and returns the witnesses
+// elected for it from the masternodes eligible at the previous block, capped at
+// maxWitnessSize. Pending transactions are included in the block. The simulated
+// chain keeps no signing record, so no masternode is kicked out.
+func (b *MasternodeBackend) AdvanceCycle(maxWitnessSize int) ([]string, error) {
+	nodes, err := b.Masternodes()
+	if err != nil {
+		return nil, err
+	}
+	parent := b.blockchain.CurrentBlock().Header()
+
+	next := (parent.Time.Uint64()/params.Epoch + 1) * params.Epoch
+	if err := b.AdjustTime(time.Duration(next-parent.Time.Uint64()) * time.Second); err != nil {
+		return nil, err
+	}
+	b.Commit()
+
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		return nil, err
+	}
+	return devote.Elect(nil, devoteDB, b.blockchain.Genesis().Header(), parent, nodes, maxWitnessSize)
+}
+
+// commit imports the pending block and checks that the transaction succeeded.
+func (b *MasternodeBackend) commit(tx *types.Transaction) error {
+	b.Commit()
+
+	receipt, err := b.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt == nil {
+		return fmt.Errorf("transaction %x not mined", tx.Hash())
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("transaction %x failed", tx.Hash())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that masternodes registered through the simulated backend are elected
+// as witnesses, deterministically for a given chain.
+func TestMasternodeElection(t *testing.T) {
+	funds := new(big.Int).Mul(big.NewInt(100000), big.NewInt(params.Ether))
+
+	owners := make([]*ecdsa.PrivateKey, 4)
+	alloc := make(core.GenesisAlloc)
+	for i := range owners {
+		owners[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(owners[i].PublicKey)] = core.GenesisAccount{Balance: funds}
+	}
+	backend, err := NewMasternodeBackend(owners[0], alloc, 10000000)
+	if err != nil {
+		t.Fatalf("failed to deploy masternode contract: %v", err)
+	}
+	var ids []string
+	for _, owner := range owners {
+		node, _ := crypto.GenerateKey()
+		id, err := backend.Register(owner, node)
+		if err != nil {
+			t.Fatalf("failed to register masternode: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	nodes, err := backend.Masternodes()
+	if err != nil {
+		t.Fatalf("failed to list masternodes: %v", err)
+	}
+	sort.Strings(ids)
+	sort.Strings(nodes)
+	if !reflect.DeepEqual(nodes, ids) {
+		t.Fatalf("masternodes mismatch: have %v, want %v", nodes, ids)
+	}
+	head := backend.blockchain.CurrentBlock()
+
+	witnesses, err := backend.AdvanceCycle(3)
+	if err != nil {
+		t.Fatalf("failed to advance cycle: %v", err)
+	}
+	if len(witnesses) != 3 {
+		t.Fatalf("witness count mismatch: have %d, want 3", len(witnesses))
+	}
+	current := backend.blockchain.CurrentBlock()
+	if current.ParentHash() != head.Hash() {
+		t.Fatalf("cycle block not on top of head")
+	}
+	if current.Time().Uint64()/params.Epoch != head.Time().Uint64()/params.Epoch+1 {
+		t.Fatalf("cycle mismatch: have %d, want %d", current.Time().Uint64()/params.Epoch, head.Time().Uint64()/params.Epoch+1)
+	}
+	// The capped election must be the head of the full ranking of the cycle
+	devoteDB, _ := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	all, err := devote.Elect(nil, devoteDB, backend.blockchain.Genesis().Header(), head.Header(), nodes, len(nodes))
+	if err != nil {
+		t.Fatalf("failed to elect witnesses: %v", err)
+	}
+	if !reflect.DeepEqual(witnesses, all[:3]) {
+		t.Fatalf("witnesses mismatch: have %v, want %v", witnesses, all[:3])
+	}
+}
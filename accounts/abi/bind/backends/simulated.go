@@ -60,6 +60,8 @@ type SimulatedBackend struct {
 
 	events *filters.EventSystem // Event system for filtering log events live
 
+	powered bool // Whether contract calls are given infinite power besides balance
+
 	config *params.ChainConfig
 }
 
@@ -81,6 +83,11 @@ func NewSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBac
 	return backend
 }
 
+// Blockchain returns the underlying blockchain.
+func (b *SimulatedBackend) Blockchain() *core.BlockChain {
+	return b.blockchain
+}
+
 // Commit imports all the pending transactions as a single block and starts a
 // fresh new state.
 func (b *SimulatedBackend) Commit() {
@@ -276,9 +283,12 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 	if call.Value == nil {
 		call.Value = new(big.Int)
 	}
-	// Set infinite balance to the fake caller account.
+	// Set infinite balance to the fake caller account, and power if requested.
 	from := statedb.GetOrNewStateObject(call.From)
-	from.SetBalance(math.MaxBig256)
+	from.SetBalance(math.MaxBig256, block.Number())
+	if b.powered {
+		from.SetPower(math.MaxBig256)
+	}
 	// Execute the call.
 	msg := callmsg{call}
 
@@ -426,8 +436,9 @@ type filterBackend struct {
 	bc *core.BlockChain
 }
 
-func (fb *filterBackend) ChainDb() ethdb.Database  { return fb.db }
-func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
+func (fb *filterBackend) ChainDb() ethdb.Database          { return fb.db }
+func (fb *filterBackend) ChainConfig() *params.ChainConfig { return fb.bc.Config() }
+func (fb *filterBackend) EventMux() *event.TypeMux         { panic("not supported") }
 
 func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
 	if block == rpc.LatestBlockNumber {
@@ -476,6 +487,9 @@ func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Su
 func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return fb.bc.SubscribeRemovedLogsEvent(ch)
 }
+func (fb *filterBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return fb.bc.SubscribeReorgEvent(ch)
+}
 func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return fb.bc.SubscribeLogsEvent(ch)
 }
@@ -0,0 +1,99 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// ErrMasternodeKeyMismatch is returned if a migrated masternode key doesn't
+// decrypt back into the plaintext key it was created from.
+var ErrMasternodeKeyMismatch = errors.New("masternode key mismatch after migration")
+
+// StoreMasternodeKey encrypts the masternode private key with the given passphrase
+// and writes it to file using the same format as regular keystore accounts.
+func StoreMasternodeKey(file string, priv *ecdsa.PrivateKey, auth string, scryptN, scryptP int) error {
+	key := newKeyFromECDSA(priv)
+	keyjson, err := EncryptKey(key, auth, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(file, keyjson)
+}
+
+// LoadMasternodeKey reads an encrypted masternode key from file and decrypts it
+// with the given passphrase.
+func LoadMasternodeKey(file, auth string) (*ecdsa.PrivateKey, error) {
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DecryptKey(keyjson, auth)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+// MigrateMasternodeKey converts a plaintext (hex encoded) masternode key into an
+// encrypted key file. The plaintext file is only removed once the encrypted copy
+// was verified to decrypt back into the very same key.
+func MigrateMasternodeKey(plainfile, file, auth string, scryptN, scryptP int) (*ecdsa.PrivateKey, error) {
+	priv, err := crypto.LoadECDSA(plainfile)
+	if err != nil {
+		return nil, err
+	}
+	if err := StoreMasternodeKey(file, priv, auth, scryptN, scryptP); err != nil {
+		return nil, err
+	}
+	stored, err := LoadMasternodeKey(file, auth)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.FromECDSA(stored), crypto.FromECDSA(priv)) {
+		os.Remove(file)
+		return nil, ErrMasternodeKeyMismatch
+	}
+	if err := os.Remove(plainfile); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// UnlockMasternodeKey unlocks the account of the decrypted masternode key until
+// the program exits, so that the node seals blocks and pings the masternode
+// contract without an explicit unlock after every restart.
+func (ks *KeyStore) UnlockMasternodeKey(priv *ecdsa.PrivateKey) {
+	// Keep a copy of the key, as locking the account zeroes it
+	key := &Key{
+		Address:    crypto.PubkeyToAddress(priv.PublicKey),
+		PrivateKey: crypto.ToECDSAUnsafe(crypto.FromECDSA(priv)),
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if u, found := ks.unlocked[key.Address]; found && u.abort != nil {
+		close(u.abort) // Drop any timed unlock in favour of the indefinite one
+	}
+	ks.unlocked[key.Address] = &unlocked{Key: key}
+}
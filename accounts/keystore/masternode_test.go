@@ -0,0 +1,64 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// Tests that a plaintext masternode key can be migrated into an encrypted key
+// file and that the result only decrypts with the right passphrase.
+func TestMigrateMasternodeKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "masternodekey-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	priv, _ := crypto.GenerateKey()
+	plainfile := filepath.Join(dir, "nodekey")
+	file := filepath.Join(dir, "masternodekey.json")
+	if err := crypto.SaveECDSA(plainfile, priv); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := MigrateMasternodeKey(plainfile, file, "secret", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatalf("failed to migrate masternode key: %v", err)
+	}
+	if !bytes.Equal(crypto.FromECDSA(migrated), crypto.FromECDSA(priv)) {
+		t.Fatalf("migrated key mismatch")
+	}
+	if _, err := os.Stat(plainfile); !os.IsNotExist(err) {
+		t.Fatalf("plaintext key not removed after migration: %v", err)
+	}
+	if _, err := LoadMasternodeKey(file, "bad"); err != ErrDecrypt {
+		t.Fatalf("decryption with bad passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	loaded, err := LoadMasternodeKey(file, "secret")
+	if err != nil {
+		t.Fatalf("failed to load masternode key: %v", err)
+	}
+	if !bytes.Equal(crypto.FromECDSA(loaded), crypto.FromECDSA(priv)) {
+		t.Fatalf("loaded key mismatch")
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+// devote-sim simulates the witness elections of the devote engine over many
+// cycles and reports how evenly block production is spread across masternodes,
+// to evaluate changes to the election rule before scheduling a fork.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/cmd/utils"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+var (
+	ruleFlag = cli.StringFlag{
+		Name:  "rule",
+		Usage: fmt.Sprintf("election rule to simulate (%s)", strings.Join(ruleNames(), ", ")),
+		Value: "weight",
+	}
+	witnessesFlag = cli.IntFlag{
+		Name:  "witnesses",
+		Usage: "maximum number of witnesses elected per cycle",
+		Value: 21,
+	}
+	perNodeFlag = cli.BoolFlag{
+		Name:  "pernode",
+		Usage: "list the production of every masternode",
+	}
+	jsonFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "output JSON instead of human-readable format",
+	}
+	nodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "number of synthetic masternodes",
+		Value: 100,
+	}
+	cyclesFlag = cli.Uint64Flag{
+		Name:  "cycles",
+		Usage: "number of cycles to simulate",
+		Value: 1000,
+	}
+	seedFlag = cli.Int64Flag{
+		Name:  "seed",
+		Usage: "seed of the synthetic masternodes and blocks",
+		Value: 1,
+	}
+	chaindataFlag = cli.StringFlag{
+		Name:  "chaindata",
+		Usage: "chain database directory to replay (<datadir>/geth/chaindata)",
+	}
+	fromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "first block of the replayed range",
+		Value: 1,
+	}
+	toFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "last block of the replayed range (0 = head)",
+	}
+	masternodesFlag = cli.StringFlag{
+		Name:  "masternodes",
+		Usage: "file listing the candidate masternode ids, one per line (default = the witnesses of the range)",
+	}
+)
+
+func init() {
+	app = utils.NewApp(gitCommit, "a devote witness election simulator")
+	app.Commands = []cli.Command{
+		{
+			Name:   "synthetic",
+			Usage:  "Simulate the elections of a synthetic masternode set",
+			Action: synthetic,
+			Flags:  []cli.Flag{ruleFlag, witnessesFlag, perNodeFlag, jsonFlag, nodesFlag, cyclesFlag, seedFlag},
+			Description: `
+Elects the witnesses of a number of cycles from random masternode ids, each
+cycle following a block of random hash, and reports the distribution of the
+blocks scheduled to every masternode.`,
+		},
+		{
+			Name:   "history",
+			Usage:  "Replay the elections of a range of the chain",
+			Action: history,
+			Flags:  []cli.Flag{ruleFlag, witnessesFlag, perNodeFlag, jsonFlag, chaindataFlag, fromFlag, toFlag, masternodesFlag},
+			Description: `
+Runs the election rule on the last block of every cycle of a range of a chain
+database, reporting the distribution of the blocks it would have scheduled next
+to the one of the blocks actually produced. The node must be stopped.`,
+		},
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// electionRuleFromFlags returns the election rule selected on the command line.
+func electionRuleFromFlags(ctx *cli.Context) (electionRule, error) {
+	rule, ok := rules[ctx.String(ruleFlag.Name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown election rule %q, want one of %s", ctx.String(ruleFlag.Name), strings.Join(ruleNames(), ", "))
+	}
+	return rule, nil
+}
+
+func synthetic(ctx *cli.Context) error {
+	rule, err := electionRuleFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.Int(nodesFlag.Name) <= 0 {
+		return fmt.Errorf("invalid masternode count %d", ctx.Int(nodesFlag.Name))
+	}
+	var (
+		rng     = rand.New(rand.NewSource(ctx.Int64(seedFlag.Name)))
+		nodes   = make([]string, ctx.Int(nodesFlag.Name))
+		parents = make([]*types.Header, ctx.Uint64(cyclesFlag.Name))
+	)
+	for i := range nodes {
+		id := make([]byte, 8)
+		rng.Read(id)
+		nodes[i] = hex.EncodeToString(id)
+	}
+	for i := range parents {
+		var parentHash common.Hash
+		rng.Read(parentHash[:])
+
+		end := uint64(i+1)*params.Epoch - 1
+		parents[i] = &types.Header{
+			ParentHash: parentHash,
+			Number:     new(big.Int).SetUint64(end / params.Period),
+			Time:       new(big.Int).SetUint64(end),
+		}
+	}
+	simulated := simulate(rule, parents, nodes, ctx.Int(witnessesFlag.Name))
+	return report(ctx, simulated, nil)
+}
+
+func history(ctx *cli.Context) error {
+	rule, err := electionRuleFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if !ctx.IsSet(chaindataFlag.Name) {
+		return fmt.Errorf("missing --%s", chaindataFlag.Name)
+	}
+	db, err := ethdb.NewLDBDatabase(ctx.String(chaindataFlag.Name), 128, 16)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	from, to := ctx.Uint64(fromFlag.Name), ctx.Uint64(toFlag.Name)
+	if to == 0 {
+		head := rawdb.ReadHeadHeaderHash(db)
+		number := rawdb.ReadHeaderNumber(db, head)
+		if number == nil {
+			return fmt.Errorf("missing chain head")
+		}
+		to = *number
+	}
+	if from == 0 {
+		from = 1 // The genesis block has no witness
+	}
+	if from > to {
+		return fmt.Errorf("empty block range %d-%d", from, to)
+	}
+	// Collect the blocks closing every cycle and the actual production
+	var (
+		actual  = newTally(nil)
+		parents []*types.Header
+		prev    *types.Header
+	)
+	for number := from; number <= to; number++ {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, number), number)
+		if header == nil {
+			return fmt.Errorf("missing header #%d", number)
+		}
+		if prev != nil && prev.Time.Uint64()/params.Epoch != header.Time.Uint64()/params.Epoch {
+			parents = append(parents, prev)
+		}
+		actual.produce(header.Witness)
+		prev = header
+	}
+	actual.cycles = uint64(len(parents))
+
+	nodes := actual.nodes
+	if ctx.IsSet(masternodesFlag.Name) {
+		if nodes, err = readMasternodes(ctx.String(masternodesFlag.Name)); err != nil {
+			return err
+		}
+	}
+	simulated := simulate(rule, parents, nodes, ctx.Int(witnessesFlag.Name))
+	return report(ctx, simulated, actual)
+}
+
+// readMasternodes reads the masternode ids listed in a file, one per line,
+// skipping blank lines and # comments.
+func readMasternodes(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var nodes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		nodes = append(nodes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no masternode listed in %s", path)
+	}
+	return nodes, nil
+}
+
+// report prints the distribution of the simulated elections, and the one of
+// the actual production if replaying a chain.
+func report(ctx *cli.Context, simulated, actual *tally) error {
+	perNode := ctx.Bool(perNodeFlag.Name)
+	if ctx.Bool(jsonFlag.Name) {
+		out := map[string]*distribution{"simulated": simulated.distribution(perNode)}
+		if actual != nil {
+			out["actual"] = actual.distribution(perNode)
+		}
+		enc, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(enc))
+		return nil
+	}
+	fmt.Printf("Simulated elections (rule %s)\n", ctx.String(ruleFlag.Name))
+	fmt.Print(simulated.distribution(perNode))
+	if actual != nil {
+		fmt.Printf("\nActual production\n")
+		fmt.Print(actual.distribution(perNode))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// electionRule elects the witnesses of the cycle following the parent block
+// from the given masternodes, at most size of them.
+type electionRule func(parent *types.Header, nodes []string, size int) []string
+
+// rules are the election rules the simulation can run. Proposed changes to the
+// election are added here to be compared against the rule of the chain.
+var rules = map[string]electionRule{
+	"weight":   electWeight,
+	"rotation": electRotation,
+}
+
+// ruleNames returns the names of the election rules, sorted.
+func ruleNames() []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// electWeight elects by the weighted election of the chain. The simulation
+// keeps no signing record, so no masternode is kicked out.
+func electWeight(parent *types.Header, nodes []string, size int) []string {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		return nil
+	}
+	witnesses, err := devote.Elect(nil, devoteDB, parent, parent, nodes, size)
+	if err != nil {
+		return nil
+	}
+	return witnesses
+}
+
+// electRotation elects a window of consecutive masternodes moving forward by
+// one every cycle, the fairest possible rule, as a baseline.
+func electRotation(parent *types.Header, nodes []string, size int) []string {
+	sorted := make([]string, len(nodes))
+	copy(sorted, nodes)
+	sort.Strings(sorted)
+
+	if size > len(sorted) {
+		size = len(sorted)
+	}
+	start := int((parent.Time.Uint64()/params.Epoch + 1) % uint64(len(sorted)))
+
+	witnesses := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		witnesses = append(witnesses, sorted[(start+i)%len(sorted)])
+	}
+	return witnesses
+}
+
+// tally counts the cycles every masternode was elected in and the blocks it
+// was scheduled to produce.
+type tally struct {
+	nodes   []string
+	elected map[string]uint64
+	blocks  map[string]uint64
+	cycles  uint64
+}
+
+func newTally(nodes []string) *tally {
+	t := &tally{
+		elected: make(map[string]uint64),
+		blocks:  make(map[string]uint64),
+	}
+	for _, node := range nodes {
+		t.add(node)
+	}
+	return t
+}
+
+// add registers a masternode, so it counts in the statistics even if it never
+// gets elected.
+func (t *tally) add(node string) {
+	if _, ok := t.blocks[node]; !ok {
+		t.nodes = append(t.nodes, node)
+		t.blocks[node] = 0
+	}
+}
+
+// elect records the witnesses of a cycle, scheduling its slots round robin the
+// way the engine does.
+func (t *tally) elect(witnesses []string) {
+	t.cycles++
+	if len(witnesses) == 0 {
+		return
+	}
+	for _, witness := range witnesses {
+		t.add(witness)
+		t.elected[witness]++
+	}
+	for slot := uint64(0); slot < params.Epoch/params.Period; slot++ {
+		t.blocks[witnesses[slot%uint64(len(witnesses))]]++
+	}
+}
+
+// produce records a block actually produced by a witness.
+func (t *tally) produce(witness string) {
+	t.add(witness)
+	t.blocks[witness]++
+}
+
+// simulate runs the election rule for the cycle following each of the parents
+// and tallies the outcome.
+func simulate(rule electionRule, parents []*types.Header, nodes []string, size int) *tally {
+	t := newTally(nodes)
+	for _, parent := range parents {
+		t.elect(rule(parent, nodes, size))
+	}
+	return t
+}
+
+// distribution holds the statistics of the blocks produced by the masternodes.
+type distribution struct {
+	Nodes   int     `json:"nodes"`
+	Cycles  uint64  `json:"cycles"`
+	Blocks  uint64  `json:"blocks"`
+	Min     uint64  `json:"min"`
+	Max     uint64  `json:"max"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stddev"`
+	Gini    float64 `json:"gini"`
+	Idle    int     `json:"idle"` // Masternodes that never produced a block
+	PerNode []share `json:"perNode,omitempty"`
+}
+
+// share is the production of a single masternode.
+type share struct {
+	Node    string `json:"node"`
+	Elected uint64 `json:"elected"`
+	Blocks  uint64 `json:"blocks"`
+}
+
+// distribution computes the statistics of the tally, listing the production of
+// every masternode, most productive first, if perNode is set.
+func (t *tally) distribution(perNode bool) *distribution {
+	d := &distribution{Nodes: len(t.nodes), Cycles: t.cycles}
+	if len(t.nodes) == 0 {
+		return d
+	}
+	counts := make([]uint64, 0, len(t.nodes))
+	for _, node := range t.nodes {
+		counts = append(counts, t.blocks[node])
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i] < counts[j] })
+
+	d.Min, d.Max = counts[0], counts[len(counts)-1]
+	for _, count := range counts {
+		d.Blocks += count
+		if count == 0 {
+			d.Idle++
+		}
+	}
+	d.Mean = float64(d.Blocks) / float64(len(counts))
+	for _, count := range counts {
+		d.StdDev += (float64(count) - d.Mean) * (float64(count) - d.Mean)
+	}
+	d.StdDev = math.Sqrt(d.StdDev / float64(len(counts)))
+	d.Gini = gini(counts)
+
+	if perNode {
+		for _, node := range t.nodes {
+			d.PerNode = append(d.PerNode, share{Node: node, Elected: t.elected[node], Blocks: t.blocks[node]})
+		}
+		sort.SliceStable(d.PerNode, func(i, j int) bool {
+			if d.PerNode[i].Blocks != d.PerNode[j].Blocks {
+				return d.PerNode[i].Blocks > d.PerNode[j].Blocks
+			}
+			return d.PerNode[i].Node < d.PerNode[j].Node
+		})
+	}
+	return d
+}
+
+// gini returns the Gini coefficient of the counts sorted in ascending order,
+// 0 for an even distribution up to (n-1)/n when a single one holds it all.
+func gini(sorted []uint64) float64 {
+	var sum, weighted float64
+	for i, count := range sorted {
+		sum += float64(count)
+		weighted += float64(i+1) * float64(count)
+	}
+	if sum == 0 {
+		return 0
+	}
+	n := float64(len(sorted))
+	return 2*weighted/(n*sum) - (n+1)/n
+}
+
+// String formats the statistics for humans.
+func (d *distribution) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Masternodes: %d (%d idle)\n", d.Nodes, d.Idle)
+	fmt.Fprintf(&b, "Cycles:      %d\n", d.Cycles)
+	fmt.Fprintf(&b, "Blocks:      %d\n", d.Blocks)
+	fmt.Fprintf(&b, "Per node:    min %d, max %d, mean %.2f, stddev %.2f\n", d.Min, d.Max, d.Mean, d.StdDev)
+	fmt.Fprintf(&b, "Gini:        %.4f\n", d.Gini)
+	if len(d.PerNode) > 0 {
+		fmt.Fprintf(&b, "\n%-20s %10s %10s\n", "Masternode", "Elected", "Blocks")
+		for _, s := range d.PerNode {
+			fmt.Fprintf(&b, "%-20s %10d %10d\n", s.Node, s.Elected, s.Blocks)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		counts []uint64
+		want   float64
+	}{
+		{[]uint64{0, 0, 0}, 0},
+		{[]uint64{5, 5, 5, 5}, 0},
+		{[]uint64{0, 0, 0, 8}, 0.75},
+		{[]uint64{1, 2, 3, 4}, 0.25},
+	}
+	for i, tt := range tests {
+		if have := gini(tt.counts); math.Abs(have-tt.want) > 1e-9 {
+			t.Errorf("test %d: gini mismatch: have %f, want %f", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that rotating through the masternodes spreads the blocks evenly, idle
+// masternodes counting in the distribution.
+func TestSimulateRotation(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d"}
+
+	parents := make([]*types.Header, 8)
+	for i := range parents {
+		parents[i] = &types.Header{Time: new(big.Int).SetUint64(uint64(i+1)*params.Epoch - 1)}
+	}
+	d := simulate(electRotation, parents, nodes, 2).distribution(true)
+	if d.Gini != 0 || d.Idle != 0 {
+		t.Fatalf("uneven rotation: gini %f, idle %d", d.Gini, d.Idle)
+	}
+	if want := 8 * params.Epoch / params.Period; d.Blocks != want {
+		t.Fatalf("block count mismatch: have %d, want %d", d.Blocks, want)
+	}
+	for _, s := range d.PerNode {
+		if s.Elected != 4 {
+			t.Errorf("masternode %s: elected count mismatch: have %d, want 4", s.Node, s.Elected)
+		}
+	}
+	d = simulate(electRotation, parents[:1], append(nodes, "e", "f"), 2).distribution(false)
+	if d.Idle != 4 || d.Gini == 0 {
+		t.Fatalf("idle masternodes not counted: gini %f, idle %d", d.Gini, d.Idle)
+	}
+}
@@ -68,6 +68,7 @@ It expects the genesis file as argument.`,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.StateHistoryFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 		},
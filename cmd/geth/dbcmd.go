@@ -0,0 +1,108 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of go-etherzero.
+//
+// go-etherzero is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-etherzero is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-etherzero. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/cmd/utils"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Report the keys the migrations would rewrite without modifying the database",
+	}
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Manage the key layout of the chain database",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The db commands inspect and upgrade the key layout (schema) of the chain
+database in place.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "version",
+				Usage:  "Print the schema version of the chain database",
+				Action: utils.MigrateFlags(dbVersion),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+				},
+				Description: `
+Prints the schema version of the chain database and the one this version of
+geth expects.`,
+			},
+			{
+				Name:   "migrate",
+				Usage:  "Upgrade the chain database to the latest schema version",
+				Action: utils.MigrateFlags(dbMigrate),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					dryRunFlag,
+				},
+				Description: `
+Runs the migrations the chain database is missing, in order. The node must be
+stopped. With --dryrun the database is left untouched and the number of keys
+each migration would rewrite is reported instead.`,
+			},
+		},
+	}
+)
+
+func dbVersion(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	fmt.Println("Database schema version:", rawdb.DatabaseSchemaVersion(db))
+	fmt.Println("Latest schema version:  ", rawdb.SchemaVersion())
+	return nil
+}
+
+func dbMigrate(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	migratable, ok := db.(rawdb.MigratableDatabase)
+	if !ok {
+		utils.Fatalf("Chain database can't be migrated")
+	}
+	dryRun := ctx.Bool(dryRunFlag.Name)
+
+	results, err := rawdb.Migrate(migratable, dryRun)
+	for _, result := range results {
+		if dryRun {
+			fmt.Printf("Would migrate to schema version %d (%s): %d keys\n", result.Version, result.Name, result.Keys)
+		} else {
+			fmt.Printf("Migrated to schema version %d (%s): %d keys\n", result.Version, result.Name, result.Keys)
+		}
+	}
+	if err != nil {
+		utils.Fatalf("Migration failed: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("Database is up to date, schema version", rawdb.DatabaseSchemaVersion(db))
+	}
+	return nil
+}
@@ -75,23 +75,34 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolRemoteJournalFlag,
+		utils.TxPoolRemoteJournalSizeFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolReplacementPolicyFlag,
+		utils.TxPoolReplacementDelayFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolVoteLimitFlag,
+		utils.TxPoolVoteWindowFlag,
+		utils.TxPoolVoteMinPowerFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.StateHistoryFlag,
+		utils.FreezerCyclesFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.LightDevoteArchiveFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
 		utils.TrieCacheGenFlag,
+		utils.ParallelExecutionFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -109,6 +120,10 @@ var (
 		utils.MinerExtraDataFlag,
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
+		utils.MinerSystemGasFlag,
+		utils.MinerWatchdogFlag,
+		utils.DevoteSkipEmptyFlag,
+		utils.DevoteObserverFlag,
 		utils.MinerNoVerfiyFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
@@ -119,12 +134,28 @@ var (
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.MasternodeFlag,
+		utils.MasternodeDelegationFlag,
+		utils.MasternodeStandbyFlag,
+		utils.MasternodeSentinelFlag,
+		utils.MasternodeHostFlag,
+		utils.MasternodeEndpointsFlag,
+		utils.MasternodeRotationFlag,
+		utils.MasternodeBootstrapFlag,
+		utils.MasternodeGeoIPFlag,
+		utils.MasternodeRestakeFlag,
+		utils.MasternodeRestakeTargetsFlag,
+		utils.MasternodeRestakeFundersFlag,
+		utils.MasternodeRestakeReserveFlag,
+		utils.MasternodePasswordFlag,
 		utils.TestnetFlag,
 		utils.RinkebyFlag,
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateLimitBurstFlag,
+		utils.RPCRateLimitMethodsFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
@@ -164,6 +195,10 @@ var (
 		utils.MetricsInfluxDBUsernameFlag,
 		utils.MetricsInfluxDBPasswordFlag,
 		utils.MetricsInfluxDBHostTagFlag,
+		utils.ForkWebhookFlag,
+		utils.ForkAlertDepthFlag,
+		utils.AlertsURLFlag,
+		utils.AlertsMinPeersFlag,
 	}
 )
 
@@ -182,6 +217,8 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		// See dbcmd.go:
+		dbCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
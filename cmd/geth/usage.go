@@ -76,10 +76,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.StateHistoryFlag,
+			utils.FreezerCyclesFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.LightDevoteArchiveFlag,
 			utils.LightKDFFlag,
 		},
 	},
@@ -118,13 +121,20 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolRemoteJournalFlag,
+			utils.TxPoolRemoteJournalSizeFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolReplacementPolicyFlag,
+			utils.TxPoolReplacementDelayFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolVoteLimitFlag,
+			utils.TxPoolVoteWindowFlag,
+			utils.TxPoolVoteMinPowerFlag,
 		},
 	},
 	{
@@ -135,6 +145,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
+			utils.ParallelExecutionFlag,
 		},
 	},
 	{
@@ -160,6 +171,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCRateLimitBurstFlag,
+			utils.RPCRateLimitMethodsFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -181,6 +195,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
 			utils.MasternodeFlag,
+			utils.MasternodeDelegationFlag,
+			utils.MasternodeStandbyFlag,
+			utils.MasternodeSentinelFlag,
+			utils.MasternodeHostFlag,
+			utils.MasternodeEndpointsFlag,
+			utils.MasternodeRotationFlag,
+			utils.MasternodeBootstrapFlag,
+			utils.MasternodeGeoIPFlag,
+			utils.MasternodeRestakeFlag,
+			utils.MasternodeRestakeTargetsFlag,
+			utils.MasternodeRestakeFundersFlag,
+			utils.MasternodeRestakeReserveFlag,
+			utils.MasternodePasswordFlag,
 		},
 	},
 	{
@@ -195,6 +222,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerEtherbaseFlag,
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
+			utils.MinerSystemGasFlag,
+			utils.MinerWatchdogFlag,
+			utils.DevoteSkipEmptyFlag,
+			utils.DevoteObserverFlag,
 			utils.MinerNoVerfiyFlag,
 		},
 	},
@@ -230,6 +261,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MetricsInfluxDBUsernameFlag,
 			utils.MetricsInfluxDBPasswordFlag,
 			utils.MetricsInfluxDBHostTagFlag,
+			utils.ForkWebhookFlag,
+			utils.ForkAlertDepthFlag,
+			utils.AlertsURLFlag,
+			utils.AlertsMinPeersFlag,
 		},
 	},
 	{
@@ -123,30 +123,37 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			return err
 		}
 	}
-	stream := rlp.NewStream(reader, 0)
+	stream := core.NewExportReader(reader)
 
 	// Run actual the import.
 	blocks := make(types.Blocks, importBatchSize)
 	n := 0
 	for batch := 0; ; batch++ {
-		// Load a batch of RLP blocks.
+		// Load a batch of RLP blocks, storing the devote snapshots along the way
 		if checkInterrupt() {
 			return fmt.Errorf("interrupted")
 		}
 		i := 0
 		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
+			b, snapshot, err := stream.Next()
+			if err == io.EOF {
 				break
 			} else if err != nil {
 				return fmt.Errorf("at block %d: %v", n, err)
 			}
+			if snapshot != nil {
+				if err := chain.ImportDevoteSnapshot(snapshot); err != nil {
+					return err
+				}
+				i--
+				continue
+			}
 			// don't import first block
 			if b.NumberU64() == 0 {
 				i--
 				continue
 			}
-			blocks[i] = &b
+			blocks[i] = b
 			n++
 		}
 		if i == 0 {
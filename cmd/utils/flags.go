@@ -19,8 +19,10 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -32,6 +34,7 @@ import (
 	"github.com/etherzero/go-etherzero/accounts/keystore"
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/fdlimit"
+	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/consensus"
 	"github.com/etherzero/go-etherzero/consensus/clique"
 	"github.com/etherzero/go-etherzero/consensus/ethash"
@@ -56,6 +59,7 @@ import (
 	"github.com/etherzero/go-etherzero/p2p/nat"
 	"github.com/etherzero/go-etherzero/p2p/netutil"
 	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rpc"
 	whisper "github.com/etherzero/go-etherzero/whisper/whisperv6"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -168,6 +172,16 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "archive",
 	}
+	StateHistoryFlag = cli.Uint64Flag{
+		Name:  "state.history",
+		Usage: "Number of recent block states kept when pruning with --gcmode full",
+		Value: eth.DefaultConfig.StateHistory,
+	}
+	FreezerCyclesFlag = cli.Uint64Flag{
+		Name:  "freezer.cycles",
+		Usage: "Cycles after which masternode payments and devote snapshots are moved to the freezer (0 = disabled)",
+		Value: eth.DefaultConfig.FreezerCycles,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -178,6 +192,10 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: eth.DefaultConfig.LightPeers,
 	}
+	LightDevoteArchiveFlag = cli.BoolFlag{
+		Name:  "lightdevotearchive",
+		Usage: "Serve the devote proofs of any cycle to LES clients, not only of the recent ones",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -251,6 +269,16 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote transactions to survive node restarts",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteJournalSizeFlag = cli.Uint64Flag{
+		Name:  "txpool.remotejournalsize",
+		Usage: "Maximum number of remote transactions to journal (0 = disabled)",
+		Value: core.DefaultTxPoolConfig.RemoteJournalSize,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -261,6 +289,16 @@ var (
 		Usage: "Price bump percentage to replace an already existing transaction",
 		Value: eth.DefaultConfig.TxPool.PriceBump,
 	}
+	TxPoolReplacementPolicyFlag = cli.StringFlag{
+		Name:  "txpool.replacementpolicy",
+		Usage: `Rule to replace an already existing transaction ("price", "power" or "time")`,
+		Value: eth.DefaultConfig.TxPool.ReplacementPolicy,
+	}
+	TxPoolReplacementDelayFlag = cli.DurationFlag{
+		Name:  "txpool.replacementdelay",
+		Usage: "Time a transaction must be pooled before it may be replaced under the time policy",
+		Value: eth.DefaultConfig.TxPool.ReplacementDelay,
+	}
 	TxPoolAccountSlotsFlag = cli.Uint64Flag{
 		Name:  "txpool.accountslots",
 		Usage: "Minimum number of executable transaction slots guaranteed per account",
@@ -286,6 +324,21 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolVoteLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.votelimit",
+		Usage: "Maximum number of voting transactions accepted per account within the vote window (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.VoteLimit,
+	}
+	TxPoolVoteWindowFlag = cli.DurationFlag{
+		Name:  "txpool.votewindow",
+		Usage: "Time window of the per account voting transaction rate limit",
+		Value: eth.DefaultConfig.TxPool.VoteWindow,
+	}
+	TxPoolVoteMinPowerFlag = cli.Uint64Flag{
+		Name:  "txpool.voteminpower",
+		Usage: "Minimum power an account needs to hold to send voting transactions",
+		Value: eth.DefaultConfig.TxPool.VoteMinPower,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -312,6 +365,11 @@ var (
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	ParallelExecutionFlag = cli.IntFlag{
+		Name:  "exec.parallel",
+		Usage: "Number of threads executing independent transactions concurrently (0 = serial execution)",
+		Value: 0,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -383,6 +441,23 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerSystemGasFlag = cli.Uint64Flag{
+		Name:  "miner.systemgas",
+		Usage: "Block gas reserved for masternode system transactions (pings, votes)",
+		Value: eth.DefaultConfig.MinerSystemGas,
+	}
+	MinerWatchdogFlag = cli.BoolFlag{
+		Name:  "miner.watchdog",
+		Usage: "Snapshot goroutine and CPU profiles into the datadir whenever a witness slot is missed",
+	}
+	DevoteSkipEmptyFlag = cli.BoolFlag{
+		Name:  "devote.skipempty",
+		Usage: "Skip sealing empty blocks while the txpool is empty, where consensus allows",
+	}
+	DevoteObserverFlag = cli.BoolFlag{
+		Name:  "devote.observer",
+		Usage: "Validate and serve the chain without ever signing, pinging or announcing as a masternode",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -437,6 +512,19 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.HTTPVirtualHosts, ","),
 	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit",
+		Usage: "Calls per second allowed to every remote HTTP-RPC and WS-RPC client, by IP (0 = unlimited)",
+	}
+	RPCRateLimitBurstFlag = cli.IntFlag{
+		Name:  "rpc.ratelimit.burst",
+		Usage: "Calls every remote RPC client may make at once (default = the rate, rounded up)",
+	}
+	RPCRateLimitMethodsFlag = cli.StringFlag{
+		Name:  "rpc.ratelimit.methods",
+		Usage: "Comma separated per-method quotas of every remote RPC client, as method=rate[:burst] (e.g. masternode_list=1:5)",
+		Value: "",
+	}
 	RPCApiFlag = cli.StringFlag{
 		Name:  "rpcapi",
 		Usage: "API's offered over the HTTP-RPC interface",
@@ -508,6 +596,70 @@ var (
 		Name:  "masternode",
 		Usage: "Enable masternode",
 	}
+	MasternodeDelegationFlag = cli.StringFlag{
+		Name:  "masternode.delegation",
+		Usage: "Hex encoded delegation from the cold masternode key allowing this node key to seal blocks",
+		Value: "",
+	}
+	MasternodeStandbyFlag = cli.Uint64Flag{
+		Name:  "masternode.standby",
+		Usage: "Run as standby host, taking over after the primary host misses this many slots (0 = disabled)",
+		Value: 0,
+	}
+	MasternodeSentinelFlag = cli.BoolFlag{
+		Name:  "masternode.sentinel",
+		Usage: "Expect host health confirmations from an external sentinel (masternode_sentinelPing) instead of the built-in checker",
+	}
+	MasternodeHostFlag = cli.StringFlag{
+		Name:  "masternode.host",
+		Usage: "DNS name the masternode is announced at instead of its IP address (for dynamic IPs)",
+		Value: "",
+	}
+	MasternodeEndpointsFlag = cli.StringFlag{
+		Name:  "masternode.endpoints",
+		Usage: "Comma separated addresses forwarding to the masternode it rotates among, against targeted DoS",
+		Value: "",
+	}
+	MasternodeRotationFlag = cli.DurationFlag{
+		Name:  "masternode.rotation",
+		Usage: "Interval between two rotations of the masternode endpoints",
+		Value: eth.DefaultConfig.MasternodeRotation,
+	}
+	MasternodeBootstrapFlag = cli.StringFlag{
+		Name:  "masternode.bootstrap",
+		Usage: "DNS name whose TXT records hold the signed list of masternodes dialed while syncing",
+		Value: "",
+	}
+	MasternodeGeoIPFlag = cli.StringFlag{
+		Name:  "masternode.geoip",
+		Usage: "ip2asn database (TSV) breaking masternode_counts down by country and ASN",
+		Value: "",
+	}
+	MasternodeRestakeFlag = cli.StringFlag{
+		Name:  "masternode.restake",
+		Usage: "Unlocked payout account whose rewards are compounded into new masternode registrations",
+		Value: "",
+	}
+	MasternodeRestakeTargetsFlag = cli.StringFlag{
+		Name:  "masternode.restake.targets",
+		Usage: "Comma separated enode URLs of the node keys registered with the compounded rewards",
+		Value: "",
+	}
+	MasternodeRestakeFundersFlag = cli.StringFlag{
+		Name:  "masternode.restake.funders",
+		Usage: "Comma separated unlocked fresh accounts, each registering the restake target of the same position",
+		Value: "",
+	}
+	MasternodeRestakeReserveFlag = cli.Uint64Flag{
+		Name:  "masternode.restake.reserve",
+		Usage: "Ether always left on the payout account when compounding rewards",
+		Value: eth.DefaultConfig.MasternodeRestakeReserve,
+	}
+	MasternodePasswordFlag = cli.StringFlag{
+		Name:  "masternode.password",
+		Usage: "Password file to encrypt the masternode key inside the keystore (migrates a plaintext nodekey)",
+		Value: "",
+	}
 	BootnodesV4Flag = cli.StringFlag{
 		Name:  "bootnodesv4",
 		Usage: "Comma separated enode URLs for P2P v4 discovery bootstrap (light server, full nodes)",
@@ -619,6 +771,24 @@ var (
 		Usage: "InfluxDB `host` tag attached to all measurements",
 		Value: "localhost",
 	}
+	ForkWebhookFlag = cli.StringFlag{
+		Name:  "forkmonitor.webhook",
+		Usage: "URL the forks longer than the alerting depth are posted to as JSON",
+	}
+	ForkAlertDepthFlag = cli.Uint64Flag{
+		Name:  "forkmonitor.depth",
+		Usage: "Blocks a side branch must exceed for its fork to be posted to the webhook",
+		Value: eth.DefaultConfig.ForkAlertDepth,
+	}
+	AlertsURLFlag = cli.StringFlag{
+		Name:  "alerts.url",
+		Usage: "URL missed slots, masternode expiry, payments, low peer count and clock drift are posted to as JSON",
+	}
+	AlertsMinPeersFlag = cli.IntFlag{
+		Name:  "alerts.minpeers",
+		Usage: "Peer count below which an alert is posted (0 = disabled)",
+		Value: eth.DefaultConfig.AlertsMinPeers,
+	}
 
 	EWASMInterpreterFlag = cli.StringFlag{
 		Name:  "vm.ewasm",
@@ -717,7 +887,7 @@ func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 }
 
 func setMasternode(ctx *cli.Context, cfg *p2p.Config) {
-	if ctx.GlobalIsSet(MasternodeFlag.Name) {
+	if ctx.GlobalIsSet(MasternodeFlag.Name) && !ctx.GlobalBool(DevoteObserverFlag.Name) {
 		cfg.IsMasternode = true
 	}
 }
@@ -767,6 +937,10 @@ func setNAT(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NAT = natif
 	}
+	// Masternodes must be reachable, map their port unless told otherwise
+	if ctx.GlobalIsSet(MasternodeFlag.Name) && !ctx.GlobalIsSet(NATFlag.Name) && cfg.NAT == nil {
+		cfg.NAT = nat.Any()
+	}
 }
 
 // splitAndTrim splits input separated by a comma
@@ -803,6 +977,49 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setRPCRateLimit configures the quotas of the remote RPC clients from the set
+// command line flags.
+func setRPCRateLimit(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimit.Rate = ctx.GlobalFloat64(RPCRateLimitFlag.Name)
+		cfg.RPCRateLimit.Burst = int(math.Ceil(cfg.RPCRateLimit.Rate))
+	}
+	if ctx.GlobalIsSet(RPCRateLimitBurstFlag.Name) {
+		cfg.RPCRateLimit.Burst = ctx.GlobalInt(RPCRateLimitBurstFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateLimitMethodsFlag.Name) {
+		cfg.RPCRateLimit.Methods = make(map[string]rpc.Quota)
+		for _, spec := range splitAndTrim(ctx.GlobalString(RPCRateLimitMethodsFlag.Name)) {
+			method, quota, err := parseQuota(spec)
+			if err != nil {
+				Fatalf("Invalid --%s quota %q: %v", RPCRateLimitMethodsFlag.Name, spec, err)
+			}
+			cfg.RPCRateLimit.Methods[method] = quota
+		}
+	}
+}
+
+// parseQuota parses a method quota given as method=rate[:burst], the burst
+// defaulting to the rate, rounded up.
+func parseQuota(spec string) (string, rpc.Quota, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", rpc.Quota{}, errors.New("want method=rate[:burst]")
+	}
+	limits := strings.SplitN(parts[1], ":", 2)
+	rate, err := strconv.ParseFloat(limits[0], 64)
+	if err != nil || rate < 0 {
+		return "", rpc.Quota{}, fmt.Errorf("invalid rate %q", limits[0])
+	}
+	quota := rpc.Quota{Rate: rate, Burst: int(math.Ceil(rate))}
+	if len(limits) == 2 {
+		if quota.Burst, err = strconv.Atoi(limits[1]); err != nil || quota.Burst < 1 {
+			return "", rpc.Quota{}, fmt.Errorf("invalid burst %q", limits[1])
+		}
+	}
+	return parts[0], quota, nil
+}
+
 // setWS creates the WebSocket RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func setWS(ctx *cli.Context, cfg *node.Config) {
@@ -991,6 +1208,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	SetP2PConfig(ctx, &cfg.P2P)
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
+	setRPCRateLimit(ctx, cfg)
 	setWS(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
@@ -1005,6 +1223,21 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	setMasternodePassword(ctx, cfg)
+}
+
+// setMasternodePassword reads the passphrase protecting the masternode key from
+// the file given on the command line, if any.
+func setMasternodePassword(ctx *cli.Context, cfg *node.Config) {
+	path := ctx.GlobalString(MasternodePasswordFlag.Name)
+	if path == "" {
+		return
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		Fatalf("Failed to read masternode password file: %v", err)
+	}
+	cfg.MasternodeKeyPassword = strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
 }
 
 func setDataDir(ctx *cli.Context, cfg *node.Config) {
@@ -1049,12 +1282,24 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalSizeFlag.Name) {
+		cfg.RemoteJournalSize = ctx.GlobalUint64(TxPoolRemoteJournalSizeFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
 	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolReplacementPolicyFlag.Name) {
+		cfg.ReplacementPolicy = ctx.GlobalString(TxPoolReplacementPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolReplacementDelayFlag.Name) {
+		cfg.ReplacementDelay = ctx.GlobalDuration(TxPoolReplacementDelayFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1070,6 +1315,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolVoteLimitFlag.Name) {
+		cfg.VoteLimit = ctx.GlobalUint64(TxPoolVoteLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolVoteWindowFlag.Name) {
+		cfg.VoteWindow = ctx.GlobalDuration(TxPoolVoteWindowFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolVoteMinPowerFlag.Name) {
+		cfg.VoteMinPower = ctx.GlobalUint64(TxPoolVoteMinPowerFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1168,6 +1422,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightDevoteArchiveFlag.Name) {
+		cfg.LightDevoteArchive = ctx.GlobalBool(LightDevoteArchiveFlag.Name)
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1181,6 +1438,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	if ctx.GlobalIsSet(StateHistoryFlag.Name) {
+		cfg.StateHistory = ctx.GlobalUint64(StateHistoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(FreezerCyclesFlag.Name) {
+		cfg.FreezerCycles = ctx.GlobalUint64(FreezerCyclesFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
@@ -1188,6 +1451,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieDirtyCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(ParallelExecutionFlag.Name) {
+		cfg.ParallelExecution = ctx.GlobalInt(ParallelExecutionFlag.Name)
+	}
 	if ctx.GlobalIsSet(MinerNotifyFlag.Name) {
 		cfg.MinerNotify = strings.Split(ctx.GlobalString(MinerNotifyFlag.Name), ",")
 	}
@@ -1221,6 +1487,82 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.MinerNoverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerSystemGasFlag.Name) {
+		cfg.MinerSystemGas = ctx.GlobalUint64(MinerSystemGasFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerWatchdogFlag.Name) {
+		cfg.MinerWatchdog = ctx.GlobalBool(MinerWatchdogFlag.Name)
+	}
+	if ctx.GlobalIsSet(DevoteSkipEmptyFlag.Name) {
+		cfg.DevoteSkipEmpty = ctx.GlobalBool(DevoteSkipEmptyFlag.Name)
+	}
+	if ctx.GlobalIsSet(DevoteObserverFlag.Name) {
+		cfg.DevoteObserver = ctx.GlobalBool(DevoteObserverFlag.Name)
+	}
+	if ctx.GlobalIsSet(ForkWebhookFlag.Name) {
+		cfg.ForkWebhook = ctx.GlobalString(ForkWebhookFlag.Name)
+	}
+	if ctx.GlobalIsSet(ForkAlertDepthFlag.Name) {
+		cfg.ForkAlertDepth = ctx.GlobalUint64(ForkAlertDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(AlertsURLFlag.Name) {
+		cfg.AlertsURL = ctx.GlobalString(AlertsURLFlag.Name)
+	}
+	if ctx.GlobalIsSet(AlertsMinPeersFlag.Name) {
+		cfg.AlertsMinPeers = ctx.GlobalInt(AlertsMinPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeDelegationFlag.Name) {
+		delegation, err := hexutil.Decode(ctx.GlobalString(MasternodeDelegationFlag.Name))
+		if err != nil {
+			Fatalf("Invalid masternode delegation: %v", err)
+		}
+		cfg.MasternodeDelegation = delegation
+	}
+	if ctx.GlobalIsSet(MasternodeStandbyFlag.Name) {
+		cfg.MasternodeStandby = ctx.GlobalUint64(MasternodeStandbyFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeSentinelFlag.Name) {
+		cfg.MasternodeSentinel = ctx.GlobalBool(MasternodeSentinelFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeHostFlag.Name) {
+		cfg.MasternodeHost = ctx.GlobalString(MasternodeHostFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeEndpointsFlag.Name) {
+		cfg.MasternodeEndpoints = splitAndTrim(ctx.GlobalString(MasternodeEndpointsFlag.Name))
+	}
+	if ctx.GlobalIsSet(MasternodeRotationFlag.Name) {
+		cfg.MasternodeRotation = ctx.GlobalDuration(MasternodeRotationFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeBootstrapFlag.Name) {
+		cfg.MasternodeBootstrap = ctx.GlobalString(MasternodeBootstrapFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeGeoIPFlag.Name) {
+		cfg.MasternodeGeoIP = ctx.GlobalString(MasternodeGeoIPFlag.Name)
+	}
+	if ctx.GlobalIsSet(MasternodeRestakeFlag.Name) {
+		account := ctx.GlobalString(MasternodeRestakeFlag.Name)
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid masternode restake account: %s", account)
+		}
+		cfg.MasternodeRestake = common.HexToAddress(account)
+		cfg.MasternodeRestakeTargets = splitAndTrim(ctx.GlobalString(MasternodeRestakeTargetsFlag.Name))
+		if len(cfg.MasternodeRestakeTargets) == 0 {
+			Fatalf("Masternode restaking requires --%s", MasternodeRestakeTargetsFlag.Name)
+		}
+		cfg.MasternodeRestakeFunders = nil
+		for _, funder := range splitAndTrim(ctx.GlobalString(MasternodeRestakeFundersFlag.Name)) {
+			if !common.IsHexAddress(funder) {
+				Fatalf("Invalid masternode restake funding account: %s", funder)
+			}
+			cfg.MasternodeRestakeFunders = append(cfg.MasternodeRestakeFunders, common.HexToAddress(funder))
+		}
+		if len(cfg.MasternodeRestakeFunders) != len(cfg.MasternodeRestakeTargets) {
+			Fatalf("Masternode restaking requires one --%s account per target", MasternodeRestakeFundersFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(MasternodeRestakeReserveFlag.Name) {
+		cfg.MasternodeRestakeReserve = ctx.GlobalUint64(MasternodeRestakeReserveFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1425,6 +1767,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		TrieCleanLimit: eth.DefaultConfig.TrieCleanCache,
 		TrieDirtyLimit: eth.DefaultConfig.TrieDirtyCache,
 		TrieTimeLimit:  eth.DefaultConfig.TrieTimeout,
+		TriesInMemory:  ctx.GlobalUint64(StateHistoryFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cache.TrieCleanLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
@@ -108,6 +108,15 @@ type Engine interface {
 	Close() error
 }
 
+// SystemReceipter is a consensus engine applying operations outside of any
+// transaction, such as masternode payments, which it records in the system
+// receipt of every block it finalizes.
+type SystemReceipter interface {
+	// SystemReceipt returns the system receipt of a block finalized by the
+	// engine, or nil if it's not known anymore.
+	SystemReceipt(header *types.Header) *types.Receipt
+}
+
 // PoW is a consensus engine based on proof-of-work.
 type PoW interface {
 	Engine
@@ -26,6 +26,8 @@ import (
 	"github.com/etherzero/go-etherzero/core/types/devotedb"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/internal/rpccache"
 )
 // API is a user facing RPC API to allow controlling the delegate and voting
 // mechanisms of the delegated-proof-of-stake
@@ -45,13 +47,17 @@ func (api *API) GetSigners(number *rpc.BlockNumber) ([]string, error) {
 	if header == nil {
 		return nil, errUnknownBlock
 	}
-	currentEpoch:=header.Time.Uint64()/params.Epoch
-	devoteDB,_:=devotedb.New(devotedb.NewDatabase(api.devote.db),header.Protocol.CycleHash,header.Protocol.StatsHash)
-	signers, err := devoteDB.GetWitnesses(currentEpoch)
+	signers, err := api.devote.cache().Do("devote_getSigners", header.Hash(), nil, func() (interface{}, error) {
+		devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(api.devote.db), header.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		return devoteDB.GetWitnesses(header.Time.Uint64() / params.Epoch)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return signers, nil
+	return signers.([]string), nil
 }
 
 // GetSignersByEpoch retrieves the list of the Witnesses by round
@@ -62,12 +68,120 @@ func (api *API) GetSignersByEpoch(epoch uint64) ([]string, error) {
 	if epoch > currentEpoch{
 		return []string{} , nil
 	}
-	devoteDB,_:=devotedb.New(devotedb.NewDatabase(api.devote.db), header.Protocol.CycleHash, header.Protocol.StatsHash)
-	signers, err := devoteDB.GetWitnesses(epoch)
+	signers, err := api.devote.cache().Do("devote_getSignersByEpoch", header.Hash(), []interface{}{epoch}, func() (interface{}, error) {
+		devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(api.devote.db), header.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		return devoteDB.GetWitnesses(epoch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signers.([]string), nil
+}
+
+// GetStandbys retrieves the standby witnesses of the cycle of the specified
+// block, which may seal the slots its witnesses miss.
+func (api *API) GetStandbys(number *rpc.BlockNumber) ([]string, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(api.devote.db), header.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	standbys, err := devoteDB.GetStandbys(header.Time.Uint64() / params.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if standbys == nil {
+		standbys = []string{}
+	}
+	return standbys, nil
+}
+
+// GetMasternodesAtCycle returns the masternode set the given cycle was elected
+// from, along with the merkle proof of its hash in the devote trie of the current
+// head, letting auditors verify a masternode was registered at the time even
+// after the contract state changed.
+func (api *API) GetMasternodesAtCycle(cycle uint64) (*MasternodeSetProof, error) {
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(api.devote.db), header.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := api.devote.masternodeSetProof(devoteDB, cycle)
+	if err != nil {
+		return nil, err
+	}
+	proof.Number = hexutil.Uint64(header.Number.Uint64())
+	proof.BlockHash = header.Hash()
+	return proof, nil
+}
+
+// GetVRFKey returns the VRF public key of the local signer, which it registers in
+// the governance contract to seal blocks in the VRF mode.
+func (api *API) GetVRFKey() (hexutil.Bytes, error) {
+	return api.devote.VRFPublicKey()
+}
+
+// WitnessStats returns the witnesses of the cycles between begin and end (both
+// inclusive) ranked by their produced versus expected blocks, along with the
+// missed slots and the average propagation delay observed by the local node.
+func (api *API) WitnessStats(begin, end uint64) ([]*WitnessStats, error) {
+	head := api.chain.CurrentHeader()
+	stats, err := api.devote.cache().Do("devote_witnessStats", head.Hash(), []interface{}{begin, end}, func() (interface{}, error) {
+		return api.devote.WitnessStats(head, begin, end)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats.([]*WitnessStats), nil
+}
+
+// DevoteRoots are the devote trie roots committed to by a header. Since the
+// unified trie fork headers carry the root of the single trie only, CycleHash
+// and StatsHash then being the roots of the legacy tries it was forked from.
+type DevoteRoots struct {
+	DevoteRoot common.Hash `json:"devoteRoot"`
+	CycleHash  common.Hash `json:"cycleHash"`
+	StatsHash  common.Hash `json:"statsHash"`
+	Unified    bool        `json:"unified"`
+}
+
+// GetRoots retrieves the expanded devote trie roots of the specified block.
+func (api *API) GetRoots(number *rpc.BlockNumber) (*DevoteRoots, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(api.devote.db), header.Protocol)
 	if err != nil {
 		return nil, err
 	}
-	return signers, nil
+	roots := &DevoteRoots{
+		DevoteRoot: header.Protocol.Root(),
+		Unified:    header.Protocol.Unified(),
+	}
+	if legacy := devoteDB.LegacyProtocol(); legacy != nil {
+		roots.CycleHash, roots.StatsHash = legacy.CycleHash, legacy.StatsHash
+	}
+	return roots, nil
 }
 
 // GetConfirmedBlockNumber retrieves the latest irreversible block
@@ -121,6 +235,30 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]string, error) {
 	return snap.signers(), nil
 }
 
+// GetBlockPayee retrieves the masternode paid by the specified block, for
+// explorers reconstructing the payment history.
+func (api *API) GetBlockPayee(number *rpc.BlockNumber) (*BlockPayee, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.devote.blockPayee(api.chain, header)
+}
+
+// GetBlockPayeeAtHash retrieves the masternode paid by the specified block.
+func (api *API) GetBlockPayeeAtHash(hash common.Hash) (*BlockPayee, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.devote.blockPayee(api.chain, header)
+}
+
 // Proposals returns the current proposals the node tries to uphold and vote on.
 func (api *API) Proposals() map[string]bool {
 	api.devote.lock.RLock()
@@ -150,3 +288,20 @@ func (api *API) Discard(signer string) {
 
 	delete(api.devote.proposals, signer)
 }
+
+// APICache sets the cache of the results of the expensive API calls, which its
+// owner purges on every new chain head.
+func (d *Devote) APICache(cache *rpccache.Cache) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.apiCache = cache
+}
+
+// cache returns the cache of the API results, nil if caching is disabled.
+func (d *Devote) cache() *rpccache.Cache {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.apiCache
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// inmemoryAuthors is the number of recent block authors to keep in memory.
+const inmemoryAuthors = 4096
+
+// authorProbe is the message signed by the local signer to find out the
+// account of its masternode key.
+var authorProbe = crypto.Keccak256([]byte("etz-author"))
+
+// errInvalidAuthor is returned if a block without masternode payee credits its
+// reward to another account than the masternode which sealed it.
+var errInvalidAuthor = errors.New("coinbase is not the block author")
+
+// Author implements consensus.Engine, returning the account credited with the
+// block. Before the author fork that's the coinbase the sealer chose. From the
+// fork on it's the account of the masternode which sealed the block, recovered
+// from the seal instead of trusted from the header.
+func (d *Devote) Author(header *types.Header) (common.Address, error) {
+	if !d.config.IsAuthor(header.Number) {
+		return header.Coinbase, nil
+	}
+	hash := header.Hash()
+	if author, ok := d.authors.Get(hash); ok {
+		return author.(common.Address), nil
+	}
+	author, err := SealAuthor(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	d.authors.Add(hash, author)
+	return author, nil
+}
+
+// LocalAuthor returns the author the header will have once sealed by the local
+// signer, for executing its transactions before it's sealed.
+func (d *Devote) LocalAuthor(header *types.Header) (common.Address, error) {
+	if !d.config.IsAuthor(header.Number) {
+		return header.Coinbase, nil
+	}
+	return d.localAuthor()
+}
+
+// localAuthor returns the account of the local masternode, recovered from a
+// probe signature so that a hot key resolves to its cold key like in Author.
+func (d *Devote) localAuthor() (common.Address, error) {
+	d.lock.RLock()
+	signer, signFn, enc := d.signer, d.signFn, d.delegation
+	if d.authorSigner == signer && d.authorAccount != (common.Address{}) {
+		author := d.authorAccount
+		d.lock.RUnlock()
+		return author, nil
+	}
+	d.lock.RUnlock()
+
+	if signFn == nil {
+		return common.Address{}, errors.New("no local signer")
+	}
+	sig, err := signFn(signer, authorProbe)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubkey, err := crypto.Ecrecover(authorProbe, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if enc != nil {
+		var delegate common.Address
+		copy(delegate[:], crypto.Keccak256(pubkey[1:])[12:])
+
+		delegation, err := masternode.DecodeDelegation(delegate, enc)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if pubkey, err = delegation.RecoverPubkey(delegation.Expiry); err != nil {
+			return common.Address{}, err
+		}
+	}
+	var author common.Address
+	copy(author[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	d.lock.Lock()
+	d.authorSigner, d.authorAccount = signer, author
+	d.lock.Unlock()
+
+	return author, nil
+}
+
+// SealAuthor recovers the account of the masternode which sealed the header.
+// Unlike Sealer, a block sealed by a hot key yields the cold masternode key
+// which delegated the sealing.
+func SealAuthor(header *types.Header) (common.Address, error) {
+	pubkey, err := masternodeKey(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var author common.Address
+	copy(author[:], crypto.Keccak256(pubkey[1:])[12:])
+	return author, nil
+}
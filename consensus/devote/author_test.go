@@ -0,0 +1,105 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the author is the coinbase before the fork, and the masternode
+// recovered from the seal from then on, also when sealed by a hot key.
+func TestAuthor(t *testing.T) {
+	cold, _ := crypto.GenerateKey()
+	hot, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(cold.PublicKey)
+
+	d := NewDevote(&params.DevoteConfig{AuthorBlock: big.NewInt(100)}, ethdb.NewMemDatabase())
+
+	// Before the fork the coinbase is trusted
+	header := sealTestHeader()
+	header.Number = big.NewInt(99)
+	sealTestHeaderWith(t, header, cold)
+	if author, err := d.Author(header); err != nil || author != header.Coinbase {
+		t.Errorf("pre-fork author mismatch: have %x/%v, want %x", author, err, header.Coinbase)
+	}
+	// From the fork on the masternode key sealing the block is the author
+	header = sealTestHeader()
+	sealTestHeaderWith(t, header, cold)
+	if author, err := d.Author(header); err != nil || author != account {
+		t.Errorf("author mismatch: have %x/%v, want %x", author, err, account)
+	}
+	// A hot key seals on behalf of the cold key delegating to it
+	delegation, err := masternode.SignDelegation(cold, crypto.PubkeyToAddress(hot.PublicKey), header.Time.Uint64()/params.Epoch)
+	if err != nil {
+		t.Fatalf("failed to sign delegation: %v", err)
+	}
+	header = sealTestHeader()
+	header.Extra = append(append(make([]byte, extraVanity), delegation.Encode()...), make([]byte, extraSeal)...)
+	sealTestHeaderWith(t, header, hot)
+	if author, err := d.Author(header); err != nil || author != account {
+		t.Errorf("delegated author mismatch: have %x/%v, want %x", author, err, account)
+	}
+	if sealer, _ := Sealer(header); sealer != crypto.PubkeyToAddress(hot.PublicKey) {
+		t.Errorf("delegated sealer mismatch: have %x, want %x", sealer, crypto.PubkeyToAddress(hot.PublicKey))
+	}
+}
+
+// Tests that the local author known before sealing is the author recovered
+// once the block is sealed.
+func TestLocalAuthor(t *testing.T) {
+	cold, _ := crypto.GenerateKey()
+	hot, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(cold.PublicKey)
+
+	signWith := func(key *ecdsa.PrivateKey) SignerFn {
+		return func(id string, hash []byte) ([]byte, error) {
+			return crypto.Sign(hash, key)
+		}
+	}
+	d := NewDevote(&params.DevoteConfig{AuthorBlock: big.NewInt(0)}, ethdb.NewMemDatabase())
+	if _, err := d.LocalAuthor(sealTestHeader()); err == nil {
+		t.Fatalf("local author known without a signer")
+	}
+	d.Authorize("cold", signWith(cold))
+	if author, err := d.LocalAuthor(sealTestHeader()); err != nil || author != account {
+		t.Errorf("local author mismatch: have %x/%v, want %x", author, err, account)
+	}
+	// Switching to a hot key must keep the author of the cold key
+	delegation, err := masternode.SignDelegation(cold, crypto.PubkeyToAddress(hot.PublicKey), 1<<32)
+	if err != nil {
+		t.Fatalf("failed to sign delegation: %v", err)
+	}
+	d.Authorize("hot", signWith(hot))
+	d.Delegate(delegation)
+	if author, err := d.LocalAuthor(sealTestHeader()); err != nil || author != account {
+		t.Errorf("delegated local author mismatch: have %x/%v, want %x", author, err, account)
+	}
+	// Before the fork the coinbase stays the author
+	d = NewDevote(&params.DevoteConfig{}, ethdb.NewMemDatabase())
+	d.Authorize("cold", signWith(cold))
+	if author, _ := d.LocalAuthor(sealTestHeader()); author != (common.Address{0x02}) {
+		t.Errorf("pre-fork local author mismatch: have %x, want %x", author, common.Address{0x02})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// beaconOnionLength is the number of cycles covered by a hash onion, each cycle
+// peeling one layer. A witness commits to a fresh onion every that many cycles.
+const beaconOnionLength = 4096
+
+// beaconSeedPrefix is signed by the witnesses to derive the seed of their onions.
+var beaconSeedPrefix = []byte("devote-beacon")
+
+// onionLayer returns the layer of the hash onion grown from seed revealed in the
+// given cycle. Layers are revealed from the outside in, each one hashing to the
+// layer of the previous cycle.
+func onionLayer(seed common.Hash, cycle uint64) common.Hash {
+	layer := seed
+	for i := cycle % beaconOnionLength; i < beaconOnionLength-1; i++ {
+		layer = crypto.Keccak256Hash(layer[:])
+	}
+	return layer
+}
+
+// peels reports whether hashing reveal once per cycle elapsed since the last
+// reveal of the witness yields that last reveal, i.e. whether reveal is the next
+// layer of the same onion.
+func peels(last *devotedb.BeaconReveal, reveal common.Hash, cycle uint64) bool {
+	if last.Cycle >= cycle || last.Cycle/beaconOnionLength != cycle/beaconOnionLength {
+		return false
+	}
+	for i := last.Cycle; i < cycle; i++ {
+		reveal = crypto.Keccak256Hash(reveal[:])
+	}
+	return reveal == last.Value
+}
+
+// beaconSeed derives the seed of the onion of the local signer for the given
+// onion epoch by signing it, so that it's never stored yet can't be predicted
+// by anyone else.
+func (d *Devote) beaconSeed(epoch uint64) (common.Hash, error) {
+	d.lock.RLock()
+	signer, signFn := d.signer, d.signFn
+	if d.beaconSigner == signer && d.beaconEpoch == epoch && d.beaconSeedHash != (common.Hash{}) {
+		seed := d.beaconSeedHash
+		d.lock.RUnlock()
+		return seed, nil
+	}
+	d.lock.RUnlock()
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, epoch)
+	sig, err := signFn(signer, crypto.Keccak256(beaconSeedPrefix, msg))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	seed := crypto.Keccak256Hash(sig)
+
+	d.lock.Lock()
+	d.beaconSigner, d.beaconEpoch, d.beaconSeedHash = signer, epoch, seed
+	d.lock.Unlock()
+
+	return seed, nil
+}
+
+// beaconReveal returns the onion layer the local signer has to reveal in the
+// vanity of the header, or nil if the header isn't its first block of the
+// cycle and the vanity is free.
+func (d *Devote) beaconReveal(parent, header *types.Header) ([]byte, error) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), parent.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	cycle := header.Time.Uint64() / params.Epoch
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	if devoteDB.GetStatsNumber(append(key, []byte(header.Witness)...)) > 0 {
+		return nil, nil
+	}
+	seed, err := d.beaconSeed(cycle / beaconOnionLength)
+	if err != nil {
+		return nil, err
+	}
+	layer := onionLayer(seed, cycle)
+	return layer[:], nil
+}
+
+// applyBeacon processes the vanity of the first block of a witness in the cycle
+// of the header. If it peels the onion the witness revealed last, it's mixed into
+// the beacon of the cycle. Otherwise it commits to a new onion, which defaults
+// the witness if its previous onion wasn't exhausted yet.
+func applyBeacon(devoteDB *devotedb.DevoteDB, header *types.Header) error {
+	cycle := header.Time.Uint64() / params.Epoch
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	if devoteDB.GetStatsNumber(append(key, []byte(header.Witness)...)) > 0 {
+		return nil
+	}
+	reveal := common.BytesToHash(header.Extra[:extraVanity])
+
+	last, err := devoteDB.GetBeaconReveal(header.Witness)
+	if err != nil {
+		return err
+	}
+	record := &devotedb.BeaconReveal{Value: reveal, Cycle: cycle}
+	switch {
+	case last != nil && peels(last, reveal, cycle):
+		mix, err := devoteDB.GetBeacon(cycle)
+		if err != nil {
+			return err
+		}
+		if err := devoteDB.SetBeacon(cycle, crypto.Keccak256Hash(mix[:], reveal[:])); err != nil {
+			return err
+		}
+	case last != nil && last.Cycle/beaconOnionLength == cycle/beaconOnionLength:
+		log.Warn("Witness defaulted on its beacon reveal", "number", header.Number, "cycle", cycle, "witness", header.Witness)
+		record.Defaulted = true
+	}
+	return devoteDB.SetBeaconReveal(header.Witness, record)
+}
+
+// defaulters removes the candidate nodes which elected as witnesses of the given
+// cycle either sealed no block in it while having committed to an onion before,
+// or broke their onion. The eviction is skipped if it would leave fewer than
+// safeSize candidates.
+func (snap *Snapshot) defaulters(cycle uint64, nodes []string, safeSize int) []string {
+	witnesses, err := snap.devoteDB.GetWitnesses(cycle)
+	if err != nil || len(witnesses) == 0 {
+		return nodes
+	}
+	evicted := make(map[string]struct{})
+	for _, witness := range witnesses {
+		last, err := snap.devoteDB.GetBeaconReveal(witness)
+		if err != nil || last == nil {
+			continue
+		}
+		if last.Cycle < cycle || (last.Cycle == cycle && last.Defaulted) {
+			evicted[witness] = struct{}{}
+			log.Debug("beacon defaulter", "cycle", cycle, "witness", witness, "last", last.Cycle)
+		}
+	}
+	if len(evicted) == 0 {
+		return nodes
+	}
+	list := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := evicted[node]; !ok {
+			list = append(list, node)
+		}
+	}
+	if len(list) < safeSize {
+		log.Warn("Skipping beacon defaulter eviction, too few candidates left", "cycle", cycle, "evicted", len(evicted), "left", len(list), "safesize", safeSize)
+		return nodes
+	}
+	return list
+}
+
+// beaconWeight returns the weight of a masternode in the election of the cycle
+// following the one which mixed the given beacon.
+func beaconWeight(mix common.Hash, masternode string) *big.Int {
+	hash := make([]byte, 8)
+	hash = append(hash, []byte(masternode)...)
+	hash = append(hash, mix.Bytes()...)
+	return big.NewInt(int64(binary.LittleEndian.Uint32(crypto.Keccak512(hash))))
+}
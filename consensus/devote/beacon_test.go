@@ -0,0 +1,81 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the reveals peeling the onion of a witness are mixed into the
+// beacon of their cycle, while broken onions default the witness.
+func TestApplyBeacon(t *testing.T) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	seed := common.HexToHash("0x01")
+	apply := func(cycle uint64, reveal common.Hash) *devotedb.BeaconReveal {
+		header := &types.Header{
+			Number:  big.NewInt(int64(cycle)),
+			Time:    new(big.Int).SetUint64(cycle * params.Epoch),
+			Witness: "a",
+			Extra:   append(reveal.Bytes(), make([]byte, extraSeal)...),
+		}
+		if err := applyBeacon(devoteDB, header); err != nil {
+			t.Fatalf("cycle %d: failed to apply beacon: %v", cycle, err)
+		}
+		last, err := devoteDB.GetBeaconReveal("a")
+		if err != nil || last == nil {
+			t.Fatalf("cycle %d: failed to get reveal: %v", cycle, err)
+		}
+		return last
+	}
+	// The first layer only commits to the onion
+	if last := apply(1, onionLayer(seed, 1)); last.Defaulted {
+		t.Errorf("commitment defaulted")
+	}
+	if mix, _ := devoteDB.GetBeacon(1); mix != (common.Hash{}) {
+		t.Errorf("commitment mixed: %x", mix)
+	}
+	// Layers peeled across skipped cycles are mixed
+	reveal := onionLayer(seed, 3)
+	if last := apply(3, reveal); last.Defaulted || last.Cycle != 3 {
+		t.Errorf("reveal mismatch: have %+v", last)
+	}
+	if mix, _ := devoteDB.GetBeacon(3); mix != crypto.Keccak256Hash(make([]byte, 32), reveal[:]) {
+		t.Errorf("beacon mismatch: have %x", mix)
+	}
+	// A layer of another onion defaults the witness
+	if last := apply(4, onionLayer(common.HexToHash("0x02"), 4)); !last.Defaulted {
+		t.Errorf("broken onion not defaulted")
+	}
+	if mix, _ := devoteDB.GetBeacon(4); mix != (common.Hash{}) {
+		t.Errorf("broken onion mixed: %x", mix)
+	}
+	// A new onion epoch commits afresh
+	if last := apply(beaconOnionLength, onionLayer(seed, beaconOnionLength)); last.Defaulted {
+		t.Errorf("new onion defaulted")
+	}
+}
@@ -19,21 +19,26 @@ package devote
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/consensus"
 	"github.com/etherzero/go-etherzero/consensus/misc"
+	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/crypto/sha3"
 	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/internal/rpccache"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
@@ -42,21 +47,17 @@ import (
 )
 
 const (
-	checkpointInterval = 600  // Number of blocks after which to save the snapshot to the database
-	extraVanity        = 32   // Fixed number of extra-data prefix bytes reserved for signer vanity
-	extraSeal          = 65   // Fixed number of extra-data suffix bytes reserved for signer seal
-	inmemorySnapshots  = 128  // Number of recent snapshots to keep in memory
-	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
-
-	//maxWitnessSize uint64 = 0
-	//safeSize              = maxWitnessSize*2/3 + 1
-	//consensusSize         = maxWitnessSize*2/3 + 1
+	checkpointInterval = rawdb.DevoteCheckpointInterval // Number of blocks after which to save the snapshot to the database
+	extraVanity        = 32                             // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal          = 65                             // Fixed number of extra-data suffix bytes reserved for signer seal
+	extraDelegation    = 73                             // Optional extra-data bytes reserved for a hot key delegation (masternode.DelegationLength)
+	inmemorySnapshots  = 128                            // Number of recent snapshots to keep in memory
+	inmemorySignatures = 4096                           // Number of recent block signatures to keep in memory
+	inmemoryReceipts   = 128                            // Number of recently finalized block system receipts to keep in memory
+	maxEmptySkip       = 60                             // Seconds after the last block from which witnesses seal even empty blocks
 )
 
 var (
-	etherzeroBlockReward = big.NewInt(0.3375e+18) // Block reward in wei to masternode account when successfully mining a block
-	rewardToCommunity    = big.NewInt(0.1125e+18) // Block reward in wei to community account when successfully mining a block
-
 	timeOfFirstBlock   = uint64(0)
 	confirmedBlockHead = []byte("confirmed-block-head")
 	uncleHash          = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
@@ -66,19 +67,31 @@ var (
 	// errUnknownBlock is returned when the list of signers is requested for a block
 	// that is not part of the local blockchain.
 	errUnknownBlock = errors.New("unknown block")
+	// errUnknownSnapshot is returned if no snapshot was stored for a block.
+	errUnknownSnapshot = errors.New("unknown snapshot")
 	// errMissingVanity is returned if a block's extra-data section is shorter than
 	// 32 bytes, which is required to store the signer vanity.
 	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
 	// errMissingSignature is returned if a block's extra-data section doesn't seem
 	// to contain a 65 byte secp256k1 signature.
 	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+	// errInvalidSignature is returned if a block's signature isn't in the
+	// canonical form, which would make the block hash malleable.
+	errInvalidSignature = errors.New("non-canonical signature")
 	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
 	errInvalidMixDigest = errors.New("non-zero mix digest")
 	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
-	errInvalidUncleHash  = errors.New("non empty uncle hash")
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+	// errUnclesNotAllowed is returned if a block carries uncles. Side blocks of
+	// witnesses don't earn anything in devote, so there is no point including them.
+	errUnclesNotAllowed  = errors.New("uncles not allowed")
 	errInvalidDifficulty = errors.New("invalid difficulty")
-	// errUnauthorizedSigner is returned if a header is signed by a non-authorized entity.
-	errUnauthorizedSigner = errors.New("unauthorized signer")
+	// errInvalidProtocol is returned if a header commits to the devote tries in
+	// the layout of the other side of the unified trie fork.
+	errInvalidProtocol = errors.New("invalid devote protocol layout")
+	// errDelegationTooEarly is returned if a block carries a hot key delegation
+	// before the delegation fork.
+	errDelegationTooEarly = errors.New("hot key delegation before fork")
 
 	// ErrInvalidTimestamp is returned if the timestamp of a block is lower than
 	// the previous block's timestamp + the minimum block period.
@@ -89,6 +102,12 @@ var (
 	ErrNilBlockHeader           = errors.New("nil block header returned")
 	ErrMismatchSignerAndWitness = errors.New("mismatch block signer and witness")
 	ErrInvalidMinerBlockTime    = errors.New("invalid time to miner the block")
+	// ErrSealFenced is returned if sealing was fenced off because the masternode
+	// key is in use on another host (standby mode).
+	ErrSealFenced = errors.New("sealing fenced, masternode key active elsewhere")
+	// ErrObserver is returned if sealing was requested from an engine running
+	// in observer mode.
+	ErrObserver = errors.New("observer mode, sealing disabled")
 )
 
 // SignerFn
@@ -100,15 +119,20 @@ type MasternodeListFn func(number *big.Int) ([]string, error)
 
 type GetGovernanceContractAddress func(number *big.Int) (common.Address, error)
 
-// NOTE: sigHash was copy from clique
-// sigHash returns the hash which is used as input for the proof-of-authority
-// signing. It is the hash of the entire header apart from the 65 byte signature
-// contained at the end of the extra data.
+// SealHash returns the hash which is used as input for the witness signing. It
+// is the hash of the entire header apart from the 65 byte signature contained
+// at the end of the extra data, the only field written after sealing. The
+// devote tries are committed to through the root of the protocol, a header
+// without protocol hashing to the empty root.
 //
 // Note, the method requires the extra data to be at least 65 bytes, otherwise it
 // panics. This is done to avoid accidentally using both forms (signature present
 // or not), which could be abused to produce different hashes for the same header.
-func sigHash(header *types.Header) (hash common.Hash) {
+func SealHash(header *types.Header) (hash common.Hash) {
+	var protocol common.Hash
+	if header.Protocol != nil {
+		protocol = header.Protocol.Root()
+	}
 	hasher := sha3.NewKeccak256()
 
 	rlp.Encode(hasher, []interface{}{
@@ -125,21 +149,49 @@ func sigHash(header *types.Header) (hash common.Hash) {
 		header.GasLimit,
 		header.GasUsed,
 		header.Time,
-		header.Extra[:len(header.Extra)-65], // Yes, this will panic if extra is too short
+		header.Extra[:len(header.Extra)-extraSeal], // Yes, this will panic if extra is too short
 		header.MixDigest,
 		header.Nonce,
-		header.Protocol.Root(),
+		protocol,
 	})
 	hasher.Sum(hash[:0])
 	return hash
 }
 
+// sealSignature returns the signature sealing the header.
+func sealSignature(header *types.Header) ([]byte, error) {
+	if len(header.Extra) < extraSeal {
+		return nil, errMissingSignature
+	}
+	return header.Extra[len(header.Extra)-extraSeal:], nil
+}
+
+// verifySignature checks that the seal signature of the header is in its
+// canonical form, with a low s value, from the low s fork on. As the signature
+// is part of the block hash, the other form would give a second hash to the
+// same sealed block. Sealers always produce the canonical form.
+func (d *Devote) verifySignature(header *types.Header) error {
+	if !d.config.IsLowS(header.Number) {
+		return nil
+	}
+	signature, err := sealSignature(header)
+	if err != nil {
+		return err
+	}
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:64])
+	if !crypto.ValidateSignatureValues(signature[64], r, s, true) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
 type Devote struct {
 	config *params.DevoteConfig // Consensus engine configuration parameters
 	db     ethdb.Database       // Database to store and retrieve snapshot checkpoints
 
 	signer     string          // master node nodeid
 	signFn     SignerFn        // signature function
+	delegation []byte          // encoded delegation if sealing with a hot key on behalf of signer
 	recents    *lru.ARCCache   // Snapshots for recent block to speed up reorgs
 	signatures *lru.ARCCache   // Signatures of recent blocks to speed up mining
 	proposals  map[string]bool // Current list of proposals we are pushing
@@ -147,6 +199,31 @@ type Devote struct {
 	confirmedBlockHeader        *types.Header
 	masternodeListFn            MasternodeListFn             //get current all masternodes
 	governanceContractAddressFn GetGovernanceContractAddress //get current GovernanceContractAddress
+	paymentCandidatesFn         PaymentCandidatesFn          // masternodes ranked by the payment queue
+	budgetFn                    BudgetFn                     // approved governance budgets paid by superblocks
+	treasuryFn                  TreasuryFn                   // governance treasury taking a cut of the coinbase reward
+	maxWitnessesFn              MaxWitnessesFn               // governance override of the witnesses elected per cycle
+	vrfKeyFn                    VRFKeyFn                     // VRF public keys registered by the masternodes
+	registrySeedFn              RegistrySeedFn               // masternodes the registry is seeded from
+
+	payments *lru.ARCCache   // Last paid index of recent blocks to speed up the payment queue
+	receipts *lru.ARCCache   // System receipts of recently finalized blocks, by seal hash
+	apiCache *rpccache.Cache // Results of the expensive API calls, purged on every new head
+
+	fenced       uint32        // Whether sealing is refused to avoid double signing with another host
+	observer     uint32        // Whether the engine only validates, never signing anything
+	delayTracker *delayTracker // Locally observed block propagation delays
+
+	beaconSigner   string      // Signer the cached onion seed belongs to
+	beaconEpoch    uint64      // Onion epoch the cached seed belongs to
+	beaconSeedHash common.Hash // Seed of the onion of the local signer, derived by signing
+
+	vrfSigner    string   // Signer the cached VRF secret key belongs to
+	vrfSecretKey *big.Int // VRF secret key of the local signer, derived by signing
+
+	authors       *lru.ARCCache  // Authors of recent blocks, recovered from their seal
+	authorSigner  string         // Signer the cached local author belongs to
+	authorAccount common.Address // Account of the local masternode, derived by signing
 
 	mu   sync.RWMutex
 	lock sync.RWMutex
@@ -157,12 +234,19 @@ func NewDevote(config *params.DevoteConfig, db ethdb.Database) *Devote {
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
 	signatures, _ := lru.NewARC(inmemorySignatures)
+	payments, _ := lru.NewARC(inmemoryPayments)
+	receipts, _ := lru.NewARC(inmemoryReceipts)
+	authors, _ := lru.NewARC(inmemoryAuthors)
 	return &Devote{
-		config:     config,
-		db:         db,
-		signatures: signatures,
-		recents:    recents,
-		proposals:  make(map[string]bool),
+		config:       config,
+		db:           db,
+		signatures:   signatures,
+		authors:      authors,
+		recents:      recents,
+		payments:     payments,
+		receipts:     receipts,
+		proposals:    make(map[string]bool),
+		delayTracker: newDelayTracker(),
 	}
 }
 
@@ -183,7 +267,7 @@ func (d *Devote) snapshot(chain consensus.ChainReader, number uint64, hash commo
 			}
 			// If an on-disk checkpoint snapshot can be found, use that
 			if number%checkpointInterval == 0 {
-				if s, err := loadSnapshot(d.config, d.signatures, d.db, hash); err == nil {
+				if s, err := loadSnapshot(d.config, d.signatures, d.db, number, hash); err == nil {
 					log.Info("Loaded voting snapshot from disk", "number", number, "hash", hash)
 					snap = s
 					break
@@ -261,6 +345,13 @@ func (d *Devote) Prepare(chain consensus.ChainReader, header *types.Header) erro
 		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
 	}
 	header.Extra = header.Extra[:extraVanity]
+	d.lock.RLock()
+	delegation := d.delegation
+	d.lock.RUnlock()
+	if delegation != nil && !d.config.IsDelegation(header.Number) {
+		return errDelegationTooEarly
+	}
+	header.Extra = append(header.Extra, delegation...)
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 	parent := chain.GetHeader(header.ParentHash, number-1)
 	if parent == nil {
@@ -268,46 +359,124 @@ func (d *Devote) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 	header.Difficulty = d.CalcDifficulty(chain, header.Time.Uint64(), parent)
 	header.Witness = d.signer
+
+	// Prove the eligibility for the slot in the VRF mode
+	if d.config.IsVRF(header.Number) {
+		proof, eligible, err := d.vrfProof(parent, header.Time.Uint64())
+		if err != nil {
+			return err
+		}
+		if eligible {
+			if err := d.checkVRFKey(chain, parent); err != nil {
+				return err
+			}
+			header.MixDigest = proof
+		}
+	}
+	// Reveal the next layer of the onion in the first block of the cycle
+	if d.config.IsBeacon(header.Number) {
+		reveal, err := d.beaconReveal(parent, header)
+		if err != nil {
+			return err
+		}
+		copy(header.Extra[:extraVanity], reveal)
+	}
+	// Pay the block reward to the masternode at the head of the payment queue,
+	// or from the author fork on to the local masternode if there's none
+	var payee *masternode.Masternode
+	if d.config.IsPaymentQueue(header.Number) {
+		var err error
+		if payee, err = d.Payee(chain, parent); err != nil {
+			return err
+		}
+	}
+	switch {
+	case payee != nil:
+		header.Coinbase = payee.Account
+	case d.config.IsAuthor(header.Number):
+		if author, err := d.localAuthor(); err == nil {
+			header.Coinbase = author
+		}
+	}
 	return nil
 }
 
 // AccumulateRewards credits the coinbase of the given block with the mining
-// reward.  The devote consensus allowed uncle block .
-func AccumulateRewards(govAddress common.Address, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+// reward and the community fund with its share, following the reward schedule
+// of the chain config. If a treasury is given, its cut is taken from the
+// coinbase reward. There are no uncle rewards, as devote blocks have no uncles.
+// It returns the payments made, for the system receipt of the block.
+func AccumulateRewards(config *params.DevoteConfig, govAddress common.Address, treasury *masternode.Treasury, state *state.StateDB, header *types.Header) ([]*types.Payment, error) {
 	// Select the correct block reward based on chain progression
-	blockReward := etherzeroBlockReward
+	reward, rewardForCommunity, err := config.BlockReward(header.Number)
+	if err != nil {
+		return nil, err
+	}
+	var payments []*types.Payment
 
+	// Pay the treasury cut out of the masternode reward
+	if treasury != nil {
+		cut := new(big.Int).Mul(reward, new(big.Int).SetUint64(treasury.Share))
+		cut.Div(cut, big.NewInt(100))
+
+		reward.Sub(reward, cut)
+		state.AddBalance(treasury.Account, cut, header.Number)
+		payments = append(payments, &types.Payment{Kind: types.PaymentTreasury, To: treasury.Account, Amount: cut})
+	}
 	// Accumulate the rewards for the masternode and any included uncles
-	reward := new(big.Int).Set(blockReward)
 	state.AddBalance(header.Coinbase, reward, header.Number)
+	payments = append(payments, &types.Payment{Kind: types.PaymentMasternode, To: header.Coinbase, Amount: reward})
 
 	//  Accumulate the rewards to community account
-	rewardForCommunity := new(big.Int).Set(rewardToCommunity)
 	state.AddBalance(govAddress, rewardForCommunity, header.Number)
+	payments = append(payments, &types.Payment{Kind: types.PaymentCommunity, To: govAddress, Amount: rewardForCommunity})
+	return payments, nil
 }
 
-// Finalize implements consensus.Engine, accumulating the block and uncle rewards,
-// setting the final state and assembling the block.
+// Finalize implements consensus.Engine, accumulating the block rewards, setting
+// the final state and assembling the block. Uncles are refused.
 func (d *Devote) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
 	uncles []*types.Header, receipts []*types.Receipt, devoteDB *devotedb.DevoteDB) (*types.Block, error) {
-	maxWitnessSize := int64(21)
-	safeSize := int(15)
-	if chain.Config().ChainID.Cmp(big.NewInt(90)) != 0 {
-		maxWitnessSize = 1
-		safeSize = 1
+	if len(uncles) > 0 {
+		return nil, errUnclesNotAllowed
 	}
 	parent := chain.GetHeaderByHash(header.ParentHash)
-	stableBlockNumber := new(big.Int).Sub(parent.Number, big.NewInt(maxWitnessSize))
-	if stableBlockNumber.Cmp(big.NewInt(0)) < 0 {
-		stableBlockNumber = big.NewInt(0)
-	}
+	stableBlockNumber := stableNumber(chain, parent)
+
 	// Accumulate block rewards and commit the final state root
 	govaddress, err := d.governanceContractAddressFn(stableBlockNumber)
 	if err != nil {
 		return nil, fmt.Errorf("get current gov address failed from contract, err:%s", err)
 	}
-	AccumulateRewards(govaddress, state, header, uncles)
+	maxWitnessSize, err := d.maxWitnesses(chain, header, govaddress, stableBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("get max witnesses failed from contract, err:%s", err)
+	}
+	safeSize := witnessQuorum(maxWitnessSize)
+	var treasury *masternode.Treasury
+	if d.config.IsTreasury(header.Number) {
+		if treasury, err = d.treasury(govaddress, stableBlockNumber); err != nil {
+			return nil, fmt.Errorf("get treasury failed from contract, err:%s", err)
+		}
+	}
+	payments, err := AccumulateRewards(d.config, govaddress, treasury, state, header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reward schedule, err:%s", err)
+	}
+	if d.isSuperblock(parent, header) {
+		budgets, err := d.payBudget(govaddress, state, header, stableBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, budgets...)
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	// Move the devote records into the unified trie, before anything is written
+	if d.config.IsUnifiedTrie(header.Number) {
+		if err := devoteDB.Unify(); err != nil {
+			return nil, fmt.Errorf("unify devote tries failed, err:%s", err)
+		}
+	}
 	cycle := header.Time.Uint64() / params.Epoch
 	devoteDB.SetCycle(cycle)
 	snap := &Snapshot{
@@ -315,34 +484,91 @@ func (d *Devote) Finalize(chain consensus.ChainReader, header *types.Header, sta
 		devoteDB: devoteDB}
 	snap.TimeStamp = header.Time.Uint64()
 
+	// Order the election by the beacon mixed during the previous cycle
+	if d.config.IsBeacon(header.Number) {
+		if snap.beacon, err = devoteDB.GetBeacon(parent.Time.Uint64() / params.Epoch); err != nil {
+			return nil, fmt.Errorf("get beacon failed, err:%s", err)
+		}
+	}
+
 	if timeOfFirstBlock == 0 {
 		if firstBlockHeader := chain.GetHeaderByNumber(1); firstBlockHeader != nil {
 			timeOfFirstBlock = firstBlockHeader.Time.Uint64()
 		}
 	}
-	nodes, err := d.masternodeListFn(stableBlockNumber)
+	// Follow the masternode registrations of the block
+	if d.config.IsMasternodeRegistry(header.Number) {
+		if err := d.updateRegistry(devoteDB, header, receipts); err != nil {
+			return nil, fmt.Errorf("update masternode registry failed, err:%s", err)
+		}
+	}
+	nodes, err := d.masternodeList(chain, stableBlockNumber)
 	if err != nil {
-		return nil, fmt.Errorf("get current masternodes failed from contract, err:%s", err)
+		return nil, fmt.Errorf("get current masternodes failed, err:%s", err)
 	}
 	genesis := chain.GetHeaderByNumber(0)
 	log.Debug("finalize get masternode ", "blockNumber", header.Number, "cycle", cycle, "nodes", nodes)
 
 	//Record the current witness list into the blockchain
-	list, err := snap.election(genesis, parent, nodes, safeSize, maxWitnessSize)
+	standbySize := 0
+	if d.config.IsStandby(header.Number) {
+		standbySize = int(d.config.StandbyWitnesses)
+	}
+	start := time.Now()
+	list, err := snap.election(genesis, parent, nodes, safeSize, int64(maxWitnessSize), standbySize)
 	if err != nil {
 		return nil, err
 	}
+	if parent.Time.Uint64()/params.Epoch != cycle {
+		cycleRolloverTimer.UpdateSince(start)
+		cycleWitnessesGauge.Update(int64(len(list)))
+	}
 	d.signatures.Add(cycle, list)
+
+	// Record the masternode set the cycle was elected from, for audits
+	if d.config.IsMasternodeSet(header.Number) && parent.Time.Uint64()/params.Epoch != cycle {
+		if err := d.recordMasternodeSet(devoteDB, cycle, nodes); err != nil {
+			return nil, fmt.Errorf("record masternode set failed, err:%s", err)
+		}
+	}
+
+	// Summarize the election in the first block of the cycle
+	if d.isSummaryBlock(parent, header) {
+		summary, err := d.epochSummary(genesis, parent, cycle, devoteDB, nodes, safeSize)
+		if err != nil {
+			return nil, err
+		}
+		if header.Extra, err = embedSummary(header.Extra, summary); err != nil {
+			return nil, err
+		}
+	}
+	if d.config.IsBeacon(header.Number) {
+		if err := applyBeacon(devoteDB, header); err != nil {
+			return nil, fmt.Errorf("apply beacon failed, err:%s", err)
+		}
+	}
 	//accumulating the signer of block
 	log.Debug("rolling ", "Number", header.Number, "parentTime", parent.Time.Uint64(), "headerTime", header.Time.Uint64(), "witness", header.Witness)
 	header.Protocol = snap.recording(parent.Time.Uint64(), header.Time.Uint64(), header.Witness)
-	return types.NewBlock(header, txs, uncles, receipts), nil
+
+	// Record the operations applied outside of the transactions for the chain to
+	// store along the receipts, the election only if it opened a new cycle
+	var witnesses []string
+	if parent.Time.Uint64()/params.Epoch != cycle {
+		witnesses = list
+	}
+	d.receipts.Add(d.SealHash(header), types.NewSystemReceipt(payments, witnesses))
+
+	return types.NewBlock(header, txs, nil, receipts), nil
 }
 
-// Author implements consensus.Engine, returning the header's coinbase as the
-// proof-of-stake verified author of the block.
-func (d *Devote) Author(header *types.Header) (common.Address, error) {
-	return header.Coinbase, nil
+// SystemReceipt implements consensus.SystemReceipter, returning the payments and
+// election applied by the engine when finalizing the block.
+func (d *Devote) SystemReceipt(header *types.Header) *types.Receipt {
+	if receipt, ok := d.receipts.Get(d.SealHash(header)); ok {
+		return receipt.(*types.Receipt)
+	}
+	return nil
 }
 
 // verifyHeader checks whether a header conforms to the consensus rules of the
@@ -358,7 +584,7 @@ func (d *Devote) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	number := header.Number.Uint64()
 	// Unnecssary to verify the block from feature
 	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
-		return consensus.ErrFutureBlock
+		return ErrFutureSlot
 	}
 	// Check that the extra-data contains both the vanity and signature
 	if len(header.Extra) < extraVanity {
@@ -367,18 +593,29 @@ func (d *Devote) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
-	// Ensure that the mix digest is zero as we don't have fork protection currently
-	if header.MixDigest != (common.Hash{}) {
+	if err := d.verifySignature(header); err != nil {
+		return err
+	}
+	// Ensure that blocks are sealed by hot keys only from the delegation fork on
+	if _, delegation := splitExtra(header.Extra); delegation != nil && !d.config.IsDelegation(header.Number) {
+		return errDelegationTooEarly
+	}
+	// Ensure that the mix digest is zero, unless it holds the VRF proof of the slot
+	if header.MixDigest != (common.Hash{}) && !d.config.IsVRF(header.Number) {
 		return errInvalidMixDigest
 	}
-	// Difficulty always 1
-	if header.Difficulty.Uint64() != 1 {
-		return errInvalidDifficulty
+	// Ensure that the block weighs as one of its fork may
+	if err := verifyDifficulty(d.config, header); err != nil {
+		return err
 	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in devote
 	if header.UncleHash != uncleHash {
 		return errInvalidUncleHash
 	}
+	// Ensure that the header commits to the devote trie layout of its fork
+	if header.Protocol == nil || header.Protocol.Unified() != d.config.IsUnifiedTrie(header.Number) {
+		return errInvalidProtocol
+	}
 	// If all checks passed, validate any special fields for hard forks
 	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
 		log.Error("devote consensus verifyHeader was failed ", "err", err)
@@ -400,28 +637,11 @@ func (d *Devote) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	return nil
 }
 
-func (d *Devote) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
-	abort := make(chan struct{})
-	results := make(chan error, len(headers))
-
-	go func() {
-		for i, header := range headers {
-			err := d.verifyHeader(chain, header, headers[:i])
-			select {
-			case <-abort:
-				return
-			case results <- err:
-			}
-		}
-	}()
-	return abort, results
-}
-
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (d *Devote) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
 	if len(block.Uncles()) > 0 {
-		return errors.New("uncles not allowed")
+		return errUnclesNotAllowed
 	}
 	return nil
 }
@@ -456,13 +676,32 @@ func (d *Devote) verifySeal(chain consensus.ChainReader, header *types.Header, p
 	snap := newSnapshot(d.config, devoteDB)
 	snap.sigcache = d.signatures
 
-	witness, err := snap.lookup(header.Time.Uint64())
-	if err != nil {
-		return err
+	var witness string
+	if d.config.IsVRF(header.Number) {
+		if witness, err = d.vrfSealer(chain, parent, header); err != nil {
+			return err
+		}
+	} else {
+		if witness, err = snap.lookup(header.Time.Uint64()); err != nil {
+			return err
+		}
+		if d.config.IsStandby(header.Number) {
+			if witness, err = d.slotSealer(snap, parent, header, witness); err != nil {
+				return err
+			}
+		}
 	}
 	if err := d.verifyBlockSigner(witness, header); err != nil {
 		return err
 	}
+	if err := d.verifySummary(chain, parent, header); err != nil {
+		return err
+	}
+	if err := d.verifyPayee(chain, parent, header); err != nil {
+		return err
+	}
+	// Only count the propagation delay of blocks sealed by their witness
+	d.delayTracker.observe(header, time.Now())
 	return d.updateConfirmedBlockHeader(chain)
 }
 
@@ -472,7 +711,8 @@ func (d *Devote) verifyBlockSigner(witness string, header *types.Header) error {
 		return err
 	}
 	if signer != witness {
-		return fmt.Errorf("invalid block witness signer: %s,witness: %s\n", signer, witness)
+		log.Debug("Block sealed by wrong witness", "number", header.Number, "signer", signer, "witness", witness)
+		return ErrWrongWitness
 	}
 	if signer != header.Witness {
 		return ErrMismatchSignerAndWitness
@@ -493,27 +733,96 @@ func (d *Devote) checkTime(lastBlock *types.Block, now uint64) error {
 	return ErrWaitForPrevBlock
 }
 
-func (d *Devote) CheckWitness(lastBlock *types.Block, now int64) error {
-	if err := d.checkTime(lastBlock, uint64(now)); err != nil {
-		return err
-	}
-	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), lastBlock.Header().Protocol)
+// WitnessAt returns the witness scheduled to seal the slot at the given time,
+// based on the witness list recorded by the last block.
+func (d *Devote) WitnessAt(lastBlock *types.Header, slot uint64) (string, error) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), lastBlock.Protocol)
 	if err != nil {
-		return err
+		return "", err
 	}
-	currentCycle := lastBlock.Time().Uint64() / params.Epoch
-	devoteDB.SetCycle(currentCycle)
+	devoteDB.SetCycle(lastBlock.Time.Uint64() / params.Epoch)
 	snap := newSnapshot(d.config, devoteDB)
 	snap.sigcache = d.signatures
 
-	witness, err := snap.lookup(uint64(now))
+	return snap.lookup(slot)
+}
+
+// Witnesses returns the witness list recorded by the block for its cycle.
+func (d *Devote) Witnesses(header *types.Header) ([]string, error) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), header.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	return devoteDB.GetWitnesses(header.Time.Uint64() / params.Epoch)
+}
+
+// IsWitnessAt reports whether the local signer is scheduled to seal the slot at
+// the given time, based on the witness list recorded by the last block. In the
+// VRF mode it reports whether the VRF output of the signer makes it eligible.
+func (d *Devote) IsWitnessAt(lastBlock *types.Header, slot uint64) bool {
+	if d.config.IsVRF(new(big.Int).Add(lastBlock.Number, common.Big1)) {
+		_, eligible, err := d.vrfProof(lastBlock, slot)
+		return err == nil && eligible
+	}
+	witness, err := d.WitnessAt(lastBlock, slot)
+	if err != nil || witness == "" {
+		return false
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return witness == d.signer
+}
+
+// MaySkipEmpty reports whether the local witness may leave the slot at the given
+// time empty instead of sealing a block without transactions. Skipping is only
+// allowed after the skip empty fork, within the cycle of the last block, once
+// the witness already sealed a block in it (keeping it eligible for the next
+// election) and while the chain went without blocks for less than maxEmptySkip.
+func (d *Devote) MaySkipEmpty(lastBlock *types.Header, slot uint64) bool {
+	if !d.config.IsSkipEmpty(new(big.Int).Add(lastBlock.Number, common.Big1)) {
+		return false
+	}
+	cycle := lastBlock.Time.Uint64() / params.Epoch
+	if slot/params.Epoch != cycle || slot >= lastBlock.Time.Uint64()+maxEmptySkip {
+		return false
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), lastBlock.Protocol)
+	if err != nil {
+		return false
+	}
+	d.lock.RLock()
+	signer := d.signer
+	d.lock.RUnlock()
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	return devoteDB.GetStatsNumber(append(key, []byte(signer)...)) > 0
+}
+
+func (d *Devote) CheckWitness(lastBlock *types.Block, now int64) error {
+	if err := d.checkTime(lastBlock, uint64(now)); err != nil {
+		return err
+	}
+	// Nobody else knows whether the local signer is eligible in the VRF mode
+	if d.config.IsVRF(new(big.Int).Add(lastBlock.Number(), common.Big1)) {
+		if !d.IsWitnessAt(lastBlock.Header(), uint64(now)) {
+			return ErrInvalidBlockWitness
+		}
+		log.Info("Sealing slot as eligible witness", "signer", d.signer, "number", lastBlock.NumberU64()+1)
+		return nil
+	}
+	witness, err := d.WitnessAt(lastBlock.Header(), uint64(now))
 	if err != nil {
 		return err
 	}
-	log.Info("devote checkWitness lookup", " witness", witness, "signer", d.signer, "cycle", currentCycle, "blockNumber", lastBlock.Number())
+	log.Info("devote checkWitness lookup", " witness", witness, "signer", d.signer, "cycle", lastBlock.Time().Uint64()/params.Epoch, "blockNumber", lastBlock.Number())
 
 	if (witness == "") || witness != d.signer {
-		return ErrInvalidBlockWitness
+		if !d.IsStandbyAt(lastBlock.Header(), uint64(now)) {
+			return ErrInvalidBlockWitness
+		}
+		log.Info("Sealing missed slot as standby witness", "witness", witness, "signer", d.signer, "number", lastBlock.NumberU64()+1)
 	}
 	logTime := time.Now().Format("[2006-01-02 15:04:05]")
 	fmt.Printf("%s [CheckWitness] Found my witness(%s)\n", logTime, witness)
@@ -529,6 +838,10 @@ func (d *Devote) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if number == 0 {
 		return nil, errUnknownBlock
 	}
+	// Observers never sign, whatever they were asked to
+	if d.Observer() {
+		return nil, ErrObserver
+	}
 	// Don't hold the signer fields for the entire sealing procedure
 	d.lock.RLock()
 	_, signFn := d.signer, d.signFn
@@ -569,20 +882,66 @@ func (d *Devote) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 		}
 	}
 
-	// time's up, sign the block
-	sighash, err := signFn(d.signer, sigHash(header).Bytes())
+	// time's up, sign the block unless another host took over the masternode key
+	if d.Fenced() {
+		return nil, ErrSealFenced
+	}
+	sighash, err := signFn(d.signer, SealHash(header).Bytes())
 	if err != nil {
 		return nil, err
 	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
+	d.delayTracker.observe(header, time.Now())
 	return block.WithSeal(header), nil
 }
 
+// verifyDifficulty checks that the difficulty of the header is one of the
+// weights allowed at its block: diffStandby before the standby fork, diffInTurn
+// or diffStandby from then on, and diffInTurn only in the VRF mode. Whether the
+// sealer was entitled to the weight is checked along the seal. The difficulty
+// is compared as a whole, as a truncated one would let a block weigh anything
+// in the fork choice.
+func verifyDifficulty(config *params.DevoteConfig, header *types.Header) error {
+	if header.Difficulty == nil || !header.Difficulty.IsUint64() {
+		return errInvalidDifficulty
+	}
+	diff := header.Difficulty.Uint64()
+	switch {
+	case config.IsVRF(header.Number):
+		if diff != diffInTurn {
+			return errInvalidDifficulty
+		}
+	case config.IsStandby(header.Number):
+		if diff != diffInTurn && diff != diffStandby {
+			return errInvalidDifficulty
+		}
+	default:
+		if diff != diffStandby {
+			return errInvalidDifficulty
+		}
+	}
+	return nil
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. Blocks are weighted 1,
+// except from the standby fork where the blocks sealed by the scheduled witness
+// weigh 2, winning over the blocks of a standby for the same slot. In the VRF
+// mode only eligible witnesses seal, all weighing 2.
 func (d *Devote) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
-	return big.NewInt(1)
+	if d.config.IsVRF(new(big.Int).Add(parent.Number, common.Big1)) {
+		return new(big.Int).SetUint64(diffInTurn)
+	}
+	if !d.config.IsStandby(new(big.Int).Add(parent.Number, common.Big1)) || !d.IsWitnessAt(parent, time) {
+		return new(big.Int).SetUint64(diffStandby)
+	}
+	return new(big.Int).SetUint64(diffInTurn)
 }
 
 func (d *Devote) Authorize(signer string, signFn SignerFn) {
+	if d.Observer() {
+		log.Warn("Refusing devote signer in observer mode", "signer", signer)
+		return
+	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -591,6 +950,48 @@ func (d *Devote) Authorize(signer string, signFn SignerFn) {
 	log.Info("devote Authorize ", "signer", signer)
 }
 
+// Delegate sets the delegation issued by the cold masternode key which allows
+// the locally configured hot key to seal blocks on behalf of the signer. A nil
+// delegation reverts to sealing with the masternode key itself.
+func (d *Devote) Delegate(delegation *masternode.Delegation) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.authorAccount = common.Address{} // the delegation may change the key resolved to
+	if delegation == nil {
+		d.delegation = nil
+		return
+	}
+	d.delegation = delegation.Encode()
+}
+
+// Fence stops the engine from sealing any further blocks, guarding against
+// double signing when the masternode key is active on another host.
+func (d *Devote) Fence() {
+	atomic.StoreUint32(&d.fenced, 1)
+}
+
+// Unfence allows the engine to seal blocks again.
+func (d *Devote) Unfence() {
+	atomic.StoreUint32(&d.fenced, 0)
+}
+
+// Observe switches the engine into observer mode for good, validating the chain
+// but refusing to authorize any signer or seal any block.
+func (d *Devote) Observe() {
+	atomic.StoreUint32(&d.observer, 1)
+}
+
+// Observer reports whether the engine runs in observer mode.
+func (d *Devote) Observer() bool {
+	return atomic.LoadUint32(&d.observer) == 1
+}
+
+// Fenced reports whether sealing is currently fenced off.
+func (d *Devote) Fenced() bool {
+	return atomic.LoadUint32(&d.fenced) == 1
+}
+
 func (d *Devote) Masternodes(masternodeListFn MasternodeListFn) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -605,20 +1006,16 @@ func (d *Devote) GovernanceContract(fn GetGovernanceContractAddress) {
 	d.governanceContractAddressFn = fn
 }
 
-// ecrecover extracts the Masternode account ID from a signed header.
+// ecrecover extracts the Masternode account ID from a signed header. If the
+// header was sealed by a hot key, the ID is the one of the cold key which
+// delegated the signing rights, as long as the delegation hasn't expired.
 func ecrecover(header *types.Header, sigcache *lru.ARCCache) (string, error) {
 	// If the signature's already cached, return that
 	hash := header.Hash()
 	if signer, known := sigcache.Get(hash); known {
 		return signer.(string), nil
 	}
-	// Retrieve the signature from the header extra-data
-	if len(header.Extra) < extraSeal {
-		return "", errMissingSignature
-	}
-	signature := header.Extra[len(header.Extra)-extraSeal:]
-	// Recover the public key and the Ethereum address
-	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	pubkey, err := masternodeKey(header)
 	if err != nil {
 		return "", err
 	}
@@ -627,6 +1024,55 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (string, error) {
 	return id, nil
 }
 
+// masternodeKey recovers the uncompressed public key of the masternode which
+// sealed the header, following the delegation of a hot key to its cold key.
+func masternodeKey(header *types.Header) ([]byte, error) {
+	// Retrieve the signature from the header extra-data
+	signature, err := sealSignature(header)
+	if err != nil {
+		return nil, err
+	}
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.Ecrecover(SealHash(header).Bytes(), signature)
+	if err != nil {
+		return nil, err
+	}
+	if _, enc := splitExtra(header.Extra); enc != nil {
+		var delegate common.Address
+		copy(delegate[:], crypto.Keccak256(pubkey[1:])[12:])
+
+		delegation, err := masternode.DecodeDelegation(delegate, enc)
+		if err != nil {
+			log.Debug("Invalid block delegation", "number", header.Number, "err", err)
+			return nil, ErrUnauthorizedSigner
+		}
+		if pubkey, err = delegation.RecoverPubkey(header.Time.Uint64() / params.Epoch); err != nil {
+			log.Debug("Invalid block delegation", "number", header.Number, "err", err)
+			return nil, ErrUnauthorizedSigner
+		}
+	}
+	return pubkey, nil
+}
+
+// Sealer recovers the address of the key which sealed the header. If the block
+// was sealed by a hot key, that's the address of the delegate.
+func Sealer(header *types.Header) (common.Address, error) {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signature, err := sealSignature(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubkey, err := crypto.Ecrecover(SealHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var sealer common.Address
+	copy(sealer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return sealer, nil
+}
+
 func (d *Devote) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 	if d.confirmedBlockHeader == nil {
 		header, err := d.loadConfirmedBlockHeader(chain)
@@ -642,16 +1088,14 @@ func (d *Devote) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 	curHeader := chain.CurrentHeader()
 	cycle := uint64(0)
 	witnessMap := make(map[string]bool)
-	consensusSize := int(15)
-	if chain.Config().ChainID.Cmp(big.NewInt(90)) != 0 {
-		consensusSize = 1
-	}
+	consensusSize := 0
 	for d.confirmedBlockHeader.Hash() != curHeader.Hash() &&
 		d.confirmedBlockHeader.Number.Uint64() < curHeader.Number.Uint64() {
 		curCycle := curHeader.Time.Uint64() / params.Epoch
-		if curCycle != cycle {
+		if curCycle != cycle || consensusSize == 0 {
 			cycle = curCycle
 			witnessMap = make(map[string]bool)
+			consensusSize = d.consensusSize(chain, curHeader)
 		}
 		// fast return
 		// if block number difference less consensusSize-witnessNum
@@ -719,7 +1163,8 @@ func (c *Devote) Close() error {
 	return nil
 }
 
-// SealHash returns the hash of a block prior to it being sealed.
+// SealHash implements consensus.Engine, returning the hash of a block prior to
+// it being sealed.
 func (c *Devote) SealHash(header *types.Header) common.Hash {
-	return sigHash(header)
+	return SealHash(header)
 }
@@ -0,0 +1,223 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+// sealTestHeader returns an unsealed header with every field set.
+func sealTestHeader() *types.Header {
+	return &types.Header{
+		ParentHash: common.Hash{0x01},
+		UncleHash:  uncleHash,
+		Coinbase:   common.Address{0x02},
+		Root:       common.Hash{0x03},
+		Difficulty: big.NewInt(diffInTurn),
+		Number:     big.NewInt(100),
+		GasLimit:   10000000,
+		GasUsed:    21000,
+		Time:       big.NewInt(1531551970),
+		Extra:      make([]byte, extraVanity+extraSeal),
+		Witness:    "0102030405060708",
+		Protocol:   &devotedb.DevoteProtocol{CycleHash: common.Hash{0x04}},
+	}
+}
+
+// sealTestHeaderWith seals the header with the given key.
+func sealTestHeaderWith(t *testing.T, header *types.Header, key *ecdsa.PrivateKey) {
+	sig, err := crypto.Sign(SealHash(header).Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to seal header: %v", err)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// Tests that the seal hash covers every header field but the signature.
+func TestSealHash(t *testing.T) {
+	header := sealTestHeader()
+	hash := SealHash(header)
+
+	key, _ := crypto.GenerateKey()
+	sealTestHeaderWith(t, header, key)
+	if have := SealHash(header); have != hash {
+		t.Fatalf("seal hash changed by the signature: have %x, want %x", have, hash)
+	}
+	mutations := map[string]func(*types.Header){
+		"parent":     func(h *types.Header) { h.ParentHash[0]++ },
+		"coinbase":   func(h *types.Header) { h.Coinbase[0]++ },
+		"root":       func(h *types.Header) { h.Root[0]++ },
+		"difficulty": func(h *types.Header) { h.Difficulty = big.NewInt(diffStandby) },
+		"number":     func(h *types.Header) { h.Number = big.NewInt(101) },
+		"gasused":    func(h *types.Header) { h.GasUsed++ },
+		"time":       func(h *types.Header) { h.Time = big.NewInt(1531551971) },
+		"vanity":     func(h *types.Header) { h.Extra[0]++ },
+		"mixdigest":  func(h *types.Header) { h.MixDigest[0]++ },
+		"witness":    func(h *types.Header) { h.Witness = "0807060504030201" },
+		"protocol":   func(h *types.Header) { h.Protocol = &devotedb.DevoteProtocol{CycleHash: common.Hash{0x05}} },
+	}
+	for name, mutate := range mutations {
+		header := sealTestHeader()
+		mutate(header)
+		if SealHash(header) == hash {
+			t.Errorf("%s: seal hash not changed by the field", name)
+		}
+	}
+	// A header without protocol must hash, not panic
+	header = sealTestHeader()
+	header.Protocol = nil
+	if SealHash(header) == hash {
+		t.Errorf("seal hash of a header without protocol collides")
+	}
+}
+
+// Tests that the signer is recovered from both forms of the signature, but only
+// the canonical one is valid from the low s fork on, so a sealed block can't be
+// given a second hash by flipping its signature.
+func TestSealSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	header := sealTestHeader()
+	sealTestHeaderWith(t, header, key)
+
+	sealer, err := Sealer(header)
+	if err != nil {
+		t.Fatalf("failed to recover sealer: %v", err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); sealer != want {
+		t.Fatalf("sealer mismatch: have %x, want %x", sealer, want)
+	}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	id, err := ecrecover(header, sigcache)
+	if err != nil {
+		t.Fatalf("failed to recover witness: %v", err)
+	}
+	if want := fmt.Sprintf("%x", crypto.FromECDSAPub(&key.PublicKey)[1:9]); id != want {
+		t.Fatalf("witness mismatch: have %s, want %s", id, want)
+	}
+	// Flip the signature to its high s form
+	malleated := types.CopyHeader(header)
+	signature := malleated.Extra[len(malleated.Extra)-extraSeal:]
+	s := new(big.Int).SetBytes(signature[32:64])
+	s.Sub(crypto.S256().Params().N, s)
+	copy(signature[32:64], common.LeftPadBytes(s.Bytes(), 32))
+	signature[64] ^= 1
+
+	if malleated.Hash() == header.Hash() {
+		t.Fatalf("malleated header kept its hash")
+	}
+	if have, err := Sealer(malleated); err != nil || have != sealer {
+		t.Errorf("sealer of malleated header mismatch: have %x, err %v, want %x", have, err, sealer)
+	}
+	if have, err := ecrecover(malleated, sigcache); err != nil || have != id {
+		t.Errorf("witness of malleated header mismatch: have %s, err %v, want %s", have, err, id)
+	}
+	fork := header.Number.Int64()
+	for i, tt := range []struct {
+		lowS   *big.Int
+		header *types.Header
+		err    error
+	}{
+		{nil, header, nil},
+		{nil, malleated, nil},
+		{big.NewInt(fork + 1), malleated, nil},
+		{big.NewInt(fork), header, nil},
+		{big.NewInt(fork), malleated, errInvalidSignature},
+	} {
+		d := NewDevote(&params.DevoteConfig{LowSBlock: tt.lowS}, ethdb.NewMemDatabase())
+		if err := d.verifySignature(tt.header); err != tt.err {
+			t.Errorf("test %d: signature verification mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Any change to the sealed fields must change the recovered sealer
+	tampered := types.CopyHeader(header)
+	tampered.Coinbase[0]++
+	if sealer, err := Sealer(tampered); err == nil && sealer == crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("tampered header still recovers the sealer")
+	}
+}
+
+// Tests that blocks carrying uncles are refused, both when verifying and when
+// assembling them.
+func TestUnclesRefused(t *testing.T) {
+	d := NewDevote(&params.DevoteConfig{}, ethdb.NewMemDatabase())
+
+	uncle := sealTestHeader()
+	block := types.NewBlock(sealTestHeader(), nil, []*types.Header{uncle}, nil)
+	if err := d.VerifyUncles(nil, block); err != errUnclesNotAllowed {
+		t.Errorf("uncle verification: have %v, want %v", err, errUnclesNotAllowed)
+	}
+	if err := d.VerifyUncles(nil, types.NewBlock(sealTestHeader(), nil, nil, nil)); err != nil {
+		t.Errorf("block without uncles refused: %v", err)
+	}
+	if _, err := d.Finalize(nil, sealTestHeader(), nil, nil, []*types.Header{uncle}, nil, nil); err != errUnclesNotAllowed {
+		t.Errorf("finalizing with uncles: have %v, want %v", err, errUnclesNotAllowed)
+	}
+	if reason := ErrorReason(errUnclesNotAllowed); reason != "uncles" {
+		t.Errorf("uncle error reason mismatch: have %q, want %q", reason, "uncles")
+	}
+}
+
+// Tests that only the weights of its fork are accepted as block difficulty,
+// and that they match the ones the sealers assign.
+func TestVerifyDifficulty(t *testing.T) {
+	config := &params.DevoteConfig{StandbyBlock: big.NewInt(100), StandbyWitnesses: 1, VRFBlock: big.NewInt(200), VRFLeaders: 1}
+	truncated := new(big.Int).Add(new(big.Int).Lsh(common.Big1, 64), big.NewInt(diffStandby))
+
+	tests := []struct {
+		number     int64
+		difficulty *big.Int
+		valid      bool
+	}{
+		{99, big.NewInt(diffStandby), true},
+		{99, big.NewInt(diffInTurn), false},
+		{99, truncated, false},
+		{99, nil, false},
+		{100, big.NewInt(diffStandby), true},
+		{100, big.NewInt(diffInTurn), true},
+		{100, big.NewInt(3), false},
+		{100, truncated, false},
+		{200, big.NewInt(diffInTurn), true},
+		{200, big.NewInt(diffStandby), false},
+	}
+	for i, tt := range tests {
+		header := &types.Header{Number: big.NewInt(tt.number), Difficulty: tt.difficulty}
+		if err := verifyDifficulty(config, header); (err == nil) != tt.valid {
+			t.Errorf("test %d: difficulty %v at block %d: have %v, want valid %v", i, tt.difficulty, tt.number, err, tt.valid)
+		}
+	}
+	// The difficulties assigned before the standby fork and in the VRF mode
+	// must pass verification
+	d := NewDevote(config, ethdb.NewMemDatabase())
+	for _, number := range []int64{98, 199} {
+		parent := &types.Header{Number: big.NewInt(number)}
+		header := &types.Header{Number: big.NewInt(number + 1), Difficulty: d.CalcDifficulty(nil, 0, parent)}
+		if err := verifyDifficulty(config, header); err != nil {
+			t.Errorf("block %d: calculated difficulty %v refused: %v", number+1, header.Difficulty, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+
+	"github.com/etherzero/go-etherzero/consensus"
+)
+
+// The consensus violations of remote blocks, as reported in the import logs, by
+// debug_getBadBlocks and when dropping the peers which sent them.
+var (
+	// ErrWrongWitness is returned if a block was sealed by a masternode which
+	// wasn't allowed to seal its slot.
+	ErrWrongWitness = errors.New("block sealed by wrong witness for slot")
+
+	// ErrFutureSlot is returned if a block is sealed for a slot ahead of the
+	// local clock. It's the generic future block error, so that the chain keeps
+	// such blocks queued for later instead of rejecting them.
+	ErrFutureSlot = consensus.ErrFutureBlock
+
+	// ErrBadDevoteRoot is returned if the devote trie root committed to by a
+	// block differs from the one computed locally.
+	ErrBadDevoteRoot = errors.New("invalid devote root")
+
+	// ErrUnauthorizedSigner is returned if a block was signed by a hot key
+	// without a valid delegation of the masternode it seals for.
+	ErrUnauthorizedSigner = errors.New("unauthorized signer")
+)
+
+// errorReasons are the short names of the consensus violations.
+var errorReasons = map[error]string{
+	ErrWrongWitness:             "wrong-witness",
+	ErrFutureSlot:               "future-slot",
+	ErrBadDevoteRoot:            "bad-devote-root",
+	ErrUnauthorizedSigner:       "unauthorized-signer",
+	ErrMismatchSignerAndWitness: "signer-mismatch",
+	ErrInvalidTimestamp:         "invalid-timestamp",
+	errInvalidDifficulty:        "invalid-difficulty",
+	errInvalidProtocol:          "invalid-protocol",
+	errInvalidSignature:         "invalid-signature",
+	errInvalidUncleHash:         "uncles",
+	errUnclesNotAllowed:         "uncles",
+	errStandbyTooEarly:          "standby-too-early",
+	errDelegationTooEarly:       "delegation-too-early",
+	errInvalidVRFProof:          "invalid-vrf-proof",
+	errNotEligible:              "not-eligible",
+}
+
+// ErrorReason returns the short name of the devote consensus violation err is,
+// or an empty string if it isn't one.
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return errorReasons[err]
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/consensus"
+)
+
+// Tests that the consensus violations are named, and nothing else is.
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{ErrWrongWitness, "wrong-witness"},
+		{consensus.ErrFutureBlock, "future-slot"},
+		{ErrBadDevoteRoot, "bad-devote-root"},
+		{ErrUnauthorizedSigner, "unauthorized-signer"},
+		{consensus.ErrUnknownAncestor, ""},
+		{errors.New("invalid devote root"), ""},
+		{nil, ""},
+	}
+	for i, tt := range tests {
+		if reason := ErrorReason(tt.err); reason != tt.reason {
+			t.Errorf("test %d: reason mismatch: have %q, want %q", i, reason, tt.reason)
+		}
+	}
+}
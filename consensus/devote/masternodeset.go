@@ -0,0 +1,127 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/trie"
+)
+
+var (
+	// errUnknownMasternodeSet is returned if no masternode set was recorded for
+	// the requested cycle.
+	errUnknownMasternodeSet = errors.New("unknown masternode set")
+
+	// errMasternodeSetMismatch is returned if a masternode set doesn't hash to
+	// the proven one.
+	errMasternodeSetMismatch = errors.New("masternode set mismatch")
+)
+
+// MasternodeSetHash returns the hash of a masternode set as recorded in the
+// devote trie: the keccak256 hash of the RLP encoded, sorted masternode ids.
+func MasternodeSetHash(nodes []string) common.Hash {
+	sorted := make([]string, len(nodes))
+	copy(sorted, nodes)
+	sort.Strings(sorted)
+
+	enc, _ := rlp.EncodeToBytes(sorted)
+	return crypto.Keccak256Hash(enc)
+}
+
+// recordMasternodeSet records the hash of the masternode set a cycle is elected
+// from in the devote trie, and the set itself in the database for it to be
+// served along with the proofs of the hash.
+func (d *Devote) recordMasternodeSet(devoteDB *devotedb.DevoteDB, cycle uint64, nodes []string) error {
+	sorted := make([]string, len(nodes))
+	copy(sorted, nodes)
+	sort.Strings(sorted)
+
+	hash := MasternodeSetHash(sorted)
+	rawdb.WriteMasternodeSet(d.db, hash, sorted)
+	return devoteDB.SetMasternodeSet(cycle, hash)
+}
+
+// MasternodeSetProof is the masternode set a cycle was elected from, along with
+// the merkle proof of its hash in the devote trie of a block.
+type MasternodeSetProof struct {
+	Cycle       hexutil.Uint64  `json:"cycle"`
+	Hash        common.Hash     `json:"hash"`        // Hash of the masternode set, see MasternodeSetHash
+	Masternodes []string        `json:"masternodes"` // Sorted masternode ids, nil if the set isn't stored locally
+	Number      hexutil.Uint64  `json:"number"`      // Block whose devote trie the proof is against
+	BlockHash   common.Hash     `json:"blockHash"`
+	Root        common.Hash     `json:"root"`  // Devote cycle trie root the proof is against
+	Proof       []hexutil.Bytes `json:"proof"` // Trie nodes from the root down to the hash
+}
+
+// proofList collects the trie nodes of a merkle proof.
+type proofList []hexutil.Bytes
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// masternodeSetProof proves the masternode set hash of a cycle in a devote trie,
+// leaving the block it belongs to for the caller to fill in.
+func (d *Devote) masternodeSetProof(devoteDB *devotedb.DevoteDB, cycle uint64) (*MasternodeSetProof, error) {
+	hash, err := devoteDB.GetMasternodeSet(cycle)
+	if err != nil {
+		return nil, err
+	}
+	if hash == (common.Hash{}) {
+		return nil, errUnknownMasternodeSet
+	}
+	var proof proofList
+	root, err := devoteDB.ProveMasternodeSet(cycle, &proof)
+	if err != nil {
+		return nil, err
+	}
+	return &MasternodeSetProof{
+		Cycle:       hexutil.Uint64(cycle),
+		Hash:        hash,
+		Masternodes: rawdb.ReadMasternodeSet(d.db, hash),
+		Root:        root,
+		Proof:       proof,
+	}, nil
+}
+
+// VerifyMasternodeSet checks that the proof holds against its root, and that its
+// masternodes hash to the proven hash.
+func VerifyMasternodeSet(proof *MasternodeSetProof) error {
+	nodes := ethdb.NewMemDatabase()
+	for _, node := range proof.Proof {
+		nodes.Put(crypto.Keccak256(node), node)
+	}
+	key := crypto.Keccak256(devotedb.MasternodeSetKey(uint64(proof.Cycle)))
+	value, _, err := trie.VerifyProof(proof.Root, key, nodes)
+	if err != nil {
+		return err
+	}
+	if common.BytesToHash(value) != proof.Hash || MasternodeSetHash(proof.Masternodes) != proof.Hash {
+		return errMasternodeSetMismatch
+	}
+	return nil
+}
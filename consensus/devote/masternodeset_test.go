@@ -0,0 +1,77 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+)
+
+// Tests that the masternode set recorded for a cycle is proven against the root
+// of the devote trie, and that tampered sets or proofs are rejected.
+func TestMasternodeSetProof(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	d := &Devote{db: db}
+
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(db), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	if err := devoteDB.Unify(); err != nil {
+		t.Fatalf("failed to unify devote db: %v", err)
+	}
+	devoteDB.SetWitnesses(7, []string{"b"})
+	if err := d.recordMasternodeSet(devoteDB, 7, []string{"c", "a", "b"}); err != nil {
+		t.Fatalf("failed to record masternode set: %v", err)
+	}
+	protocol, err := devoteDB.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit devote db: %v", err)
+	}
+	if devoteDB, err = devotedb.NewDevoteByProtocol(devotedb.NewDatabase(db), protocol); err != nil {
+		t.Fatalf("failed to reopen devote db: %v", err)
+	}
+	proof, err := d.masternodeSetProof(devoteDB, 7)
+	if err != nil {
+		t.Fatalf("failed to prove masternode set: %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(proof.Masternodes, want) {
+		t.Errorf("masternodes mismatch: have %v, want %v", proof.Masternodes, want)
+	}
+	if proof.Root != protocol.CycleHash {
+		t.Errorf("root mismatch: have %x, want %x", proof.Root, protocol.CycleHash)
+	}
+	if err := VerifyMasternodeSet(proof); err != nil {
+		t.Errorf("valid proof rejected: %v", err)
+	}
+	if _, err := d.masternodeSetProof(devoteDB, 8); err != errUnknownMasternodeSet {
+		t.Errorf("unrecorded cycle: have %v, want %v", err, errUnknownMasternodeSet)
+	}
+	tampered := *proof
+	tampered.Masternodes = []string{"a", "b", "d"}
+	if err := VerifyMasternodeSet(&tampered); err != errMasternodeSetMismatch {
+		t.Errorf("tampered set: have %v, want %v", err, errMasternodeSetMismatch)
+	}
+	tampered = *proof
+	tampered.Cycle = 8
+	if err := VerifyMasternodeSet(&tampered); err == nil {
+		t.Errorf("proof of other cycle accepted")
+	}
+}
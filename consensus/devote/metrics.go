@@ -0,0 +1,28 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the metrics collected by the devote engine.
+
+package devote
+
+import (
+	"github.com/etherzero/go-etherzero/metrics"
+)
+
+var (
+	cycleRolloverTimer  = metrics.NewRegisteredTimer("devote/cycle/rollover", nil)  // Electing the witnesses of a new cycle
+	cycleWitnessesGauge = metrics.NewRegisteredGauge("devote/cycle/witnesses", nil) // Witnesses elected for the current cycle
+)
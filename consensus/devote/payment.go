@@ -0,0 +1,264 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+const (
+	// paymentLookback is the number of recent blocks searched for the last
+	// payment of a masternode account. Accounts not paid within the window rank
+	// by their registration block instead.
+	paymentLookback = 4096
+
+	// inmemoryPayments is the number of recent blocks to keep the last paid
+	// index of in memory.
+	inmemoryPayments = 128
+)
+
+// errInvalidPayee is returned if the coinbase of a block doesn't match the
+// masternode selected by the payment queue.
+var errInvalidPayee = errors.New("invalid masternode payee")
+
+// PaymentCandidatesFn returns the masternodes registered at the given block,
+// with their state evaluated at that block.
+type PaymentCandidatesFn func(number *big.Int) ([]*masternode.Masternode, error)
+
+// PaymentCandidates sets the source of the masternodes ranked by the payment queue.
+func (d *Devote) PaymentCandidates(fn PaymentCandidatesFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paymentCandidatesFn = fn
+}
+
+// stableNumber returns the block the masternode contract is read at when
+// assembling the block on top of parent, far enough back to be irreversible.
+func stableNumber(chain consensus.ChainReader, parent *types.Header) *big.Int {
+	depth := int64(21)
+	if chain.Config().ChainID.Cmp(big.NewInt(90)) != 0 {
+		depth = 1
+	}
+	number := new(big.Int).Sub(parent.Number, big.NewInt(depth))
+	if number.Sign() < 0 {
+		number = new(big.Int)
+	}
+	return number
+}
+
+// lastPaid returns the most recent block within the lookback window up to and
+// including header at which each account received the coinbase payment.
+func (d *Devote) lastPaid(chain consensus.ChainReader, header *types.Header) map[common.Address]uint64 {
+	if paid, ok := d.payments.Get(header.Hash()); ok {
+		return paid.(map[common.Address]uint64)
+	}
+	number := header.Number.Uint64()
+
+	var oldest uint64
+	if number >= paymentLookback {
+		oldest = number - paymentLookback + 1
+	}
+	paid := make(map[common.Address]uint64)
+	if number > 0 {
+		if cached, ok := d.payments.Get(header.ParentHash); ok {
+			// Extend the index of the parent, dropping the blocks leaving the window
+			for account, block := range cached.(map[common.Address]uint64) {
+				if block >= oldest {
+					paid[account] = block
+				}
+			}
+		} else {
+			// Nothing cached, rebuild the index from the headers of the window
+			for parent := chain.GetHeader(header.ParentHash, number-1); parent != nil && parent.Number.Uint64() >= oldest && parent.Number.Sign() > 0; parent = chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1) {
+				if _, ok := paid[parent.Coinbase]; !ok {
+					paid[parent.Coinbase] = parent.Number.Uint64()
+				}
+			}
+		}
+	}
+	if number > 0 {
+		paid[header.Coinbase] = number
+	}
+	d.payments.Add(header.Hash(), paid)
+	return paid
+}
+
+// paymentQueue ranks the enabled masternodes by the block their account was
+// last paid at, falling back to their registration block, with ties broken by
+// the hash of their id. The head of the queue is the next payee.
+type paymentQueue struct {
+	nodes  []*masternode.Masternode
+	ranks  []uint64
+	hashes [][]byte
+}
+
+func (q *paymentQueue) Len() int { return len(q.nodes) }
+func (q *paymentQueue) Swap(i, j int) {
+	q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i]
+	q.ranks[i], q.ranks[j] = q.ranks[j], q.ranks[i]
+	q.hashes[i], q.hashes[j] = q.hashes[j], q.hashes[i]
+}
+func (q *paymentQueue) Less(i, j int) bool {
+	if q.ranks[i] != q.ranks[j] {
+		return q.ranks[i] < q.ranks[j]
+	}
+	return bytes.Compare(q.hashes[i], q.hashes[j]) < 0
+}
+
+// PaymentQueue returns the enabled masternodes in the order they are going to
+// be paid in the blocks following parent.
+func (d *Devote) PaymentQueue(chain consensus.ChainReader, parent *types.Header) ([]*masternode.Masternode, error) {
+	d.mu.RLock()
+	candidatesFn := d.paymentCandidatesFn
+	d.mu.RUnlock()
+
+	if candidatesFn == nil {
+		return nil, fmt.Errorf("masternode payment candidates unavailable")
+	}
+	nodes, err := candidatesFn(stableNumber(chain, parent))
+	if err != nil {
+		return nil, err
+	}
+	paid := d.lastPaid(chain, parent)
+
+	queue := new(paymentQueue)
+	for _, node := range nodes {
+		if node.State != masternode.MasternodeEnable {
+			continue
+		}
+		rank, ok := paid[node.Account]
+		if !ok {
+			rank = node.OriginBlock.Uint64()
+		}
+		queue.nodes = append(queue.nodes, node)
+		queue.ranks = append(queue.ranks, rank)
+		queue.hashes = append(queue.hashes, crypto.Keccak256([]byte(node.ID)))
+	}
+	sort.Sort(queue)
+	return queue.nodes, nil
+}
+
+// Payee returns the masternode to be paid by the block on top of parent, or nil
+// if there are no enabled masternodes.
+func (d *Devote) Payee(chain consensus.ChainReader, parent *types.Header) (*masternode.Masternode, error) {
+	queue, err := d.PaymentQueue(chain, parent)
+	if err != nil || len(queue) == 0 {
+		return nil, err
+	}
+	return queue[0], nil
+}
+
+// verifyPayee checks that the coinbase of the header is the account of the
+// masternode at the head of the payment queue. Without a payee, the coinbase
+// must be the author of the block from the author fork on.
+func (d *Devote) verifyPayee(chain consensus.ChainReader, parent, header *types.Header) error {
+	var payee *masternode.Masternode
+	if d.config.IsPaymentQueue(header.Number) {
+		var err error
+		if payee, err = d.Payee(chain, parent); err != nil {
+			return err
+		}
+	}
+	switch {
+	case payee != nil:
+		if header.Coinbase != payee.Account {
+			return errInvalidPayee
+		}
+	case d.config.IsAuthor(header.Number):
+		author, err := d.Author(header)
+		if err != nil {
+			return err
+		}
+		if header.Coinbase != author {
+			return errInvalidAuthor
+		}
+	}
+	return nil
+}
+
+// BlockPayee is the masternode paid by a block, as reconstructed from the chain
+// for the RPC API.
+type BlockPayee struct {
+	Number  hexutil.Uint64 `json:"number"`
+	Hash    common.Hash    `json:"hash"`
+	Account common.Address `json:"account"`          // Coinbase of the block, receiving the masternode reward
+	ID      string         `json:"id,omitempty"`     // Masternode of the account when the block was assembled, empty if none
+	Amount  *hexutil.Big   `json:"amount,omitempty"` // Masternode reward, nil if the system receipt is unavailable
+}
+
+// blockPayee recovers the masternode paid by the block of header. The payment
+// is taken from the system receipt of the block, and the account is resolved
+// to the masternode it belonged to at the block the payment queue was read at.
+func (d *Devote) blockPayee(chain consensus.ChainReader, header *types.Header) (*BlockPayee, error) {
+	payee := &BlockPayee{
+		Number:  hexutil.Uint64(header.Number.Uint64()),
+		Hash:    header.Hash(),
+		Account: header.Coinbase,
+	}
+	receipt := rawdb.ReadSystemReceipt(d.db, payee.Hash, header.Number.Uint64())
+	if receipt == nil {
+		receipt = d.SystemReceipt(header)
+	}
+	if receipt != nil {
+		for _, payment := range receipt.Payments {
+			if payment.Kind == types.PaymentMasternode {
+				payee.Amount = (*hexutil.Big)(payment.Amount)
+				break
+			}
+		}
+	}
+	if header.Number.Sign() == 0 {
+		return payee, nil
+	}
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	d.mu.RLock()
+	candidatesFn := d.paymentCandidatesFn
+	d.mu.RUnlock()
+
+	if candidatesFn == nil {
+		return nil, fmt.Errorf("masternode payment candidates unavailable")
+	}
+	nodes, err := candidatesFn(stableNumber(chain, parent))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Account == header.Coinbase {
+			payee.ID = node.ID
+			if node.State == masternode.MasternodeEnable {
+				break
+			}
+		}
+	}
+	return payee, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// Tests that the payment queue puts the longest unpaid masternodes first and
+// breaks ties by the hash of the masternode id.
+func TestPaymentQueueOrder(t *testing.T) {
+	ids := []string{"c34c967d399d38f0", "ffb14ca8e65770b4", "de4e2e0521f16469", "a1b2c3d4e5f60718"}
+	ranks := []uint64{30, 10, 20, 10}
+
+	queue := new(paymentQueue)
+	for i, id := range ids {
+		queue.nodes = append(queue.nodes, &masternode.Masternode{ID: id})
+		queue.ranks = append(queue.ranks, ranks[i])
+		queue.hashes = append(queue.hashes, crypto.Keccak256([]byte(id)))
+	}
+	sort.Sort(queue)
+
+	// The two nodes last paid at block 10 lead, ordered by their id hash
+	first, second := ids[1], ids[3]
+	if bytes.Compare(crypto.Keccak256([]byte(first)), crypto.Keccak256([]byte(second))) > 0 {
+		first, second = second, first
+	}
+	want := []string{first, second, ids[2], ids[0]}
+	for i, node := range queue.nodes {
+		if node.ID != want[i] {
+			t.Errorf("position %d: id mismatch: have %s, want %s", i, node.ID, want[i])
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/accounts/abi"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+var (
+	// errNoRegistrySeed is returned if the masternode registry has to be seeded
+	// without a source of the masternodes registered in the contract.
+	errNoRegistrySeed = errors.New("masternode registry source unavailable")
+
+	// errMissingRegistry is returned if the devote trie of a block past the
+	// registry fork holds no masternode registry.
+	errMissingRegistry = errors.New("missing masternode registry")
+)
+
+// registryABI is the ABI the events of the masternode contract are decoded with.
+// Every version of the contract must emit the join, quit and ping events of the
+// genesis one.
+var registryABI, _ = abi.JSON(strings.NewReader(contract.ContractABI))
+
+// RegistrySeedFn returns the masternodes registered in the masternode contract
+// in effect at the given header, as of its parent.
+type RegistrySeedFn func(header *types.Header) ([]*masternode.Masternode, error)
+
+// RegistrySeed sets the source the masternode registry is seeded from when the
+// registry fork or a new version of the masternode contract activates.
+func (d *Devote) RegistrySeed(fn RegistrySeedFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.registrySeedFn = fn
+}
+
+// updateRegistry applies the events of the masternode contract emitted by the
+// transactions of a block to the registry of its devote trie. If the block
+// activates the registry or a new version of the contract, the registry is first
+// seeded from the masternodes registered in the contract before the block.
+func (d *Devote) updateRegistry(devoteDB *devotedb.DevoteDB, header *types.Header, receipts []*types.Receipt) error {
+	address := d.config.MasternodeContractAt(header.Number).Address
+
+	registry, err := devoteDB.GetMasternodeRegistry()
+	if err != nil {
+		return err
+	}
+	changed := false
+	if registry == nil || registry.Contract != address {
+		if registry, err = d.seedRegistry(header, address); err != nil {
+			return err
+		}
+		changed = true
+	}
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			if l.Address == address && applyRegistryLog(registry, header.Number.Uint64(), l) {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return devoteDB.SetMasternodeRegistry(registry)
+}
+
+// seedRegistry creates a registry of the masternodes registered in the contract
+// at address before the given header.
+func (d *Devote) seedRegistry(header *types.Header, address common.Address) (*devotedb.MasternodeRegistry, error) {
+	d.mu.RLock()
+	registrySeedFn := d.registrySeedFn
+	d.mu.RUnlock()
+
+	if registrySeedFn == nil {
+		return nil, errNoRegistrySeed
+	}
+	nodes, err := registrySeedFn(header)
+	if err != nil {
+		return nil, err
+	}
+	registry := &devotedb.MasternodeRegistry{Contract: address}
+	for _, node := range nodes {
+		if node.ID == "" {
+			continue // Invalid node key, never listed
+		}
+		registry.Nodes = append(registry.Nodes, &devotedb.RegisteredMasternode{
+			ID:          node.ID,
+			OriginBlock: node.OriginBlock.Uint64(),
+			LastPing:    node.BlockLastPing.Uint64(),
+		})
+	}
+	sort.Slice(registry.Nodes, func(i, j int) bool { return registry.Nodes[i].ID < registry.Nodes[j].ID })
+
+	log.Info("Seeded masternode registry", "number", header.Number, "contract", address, "masternodes", len(registry.Nodes))
+	return registry, nil
+}
+
+// applyRegistryLog applies a log of the masternode contract emitted in the given
+// block to the registry, reporting whether it changed anything.
+func applyRegistryLog(registry *devotedb.MasternodeRegistry, number uint64, l *types.Log) bool {
+	if len(l.Topics) == 0 {
+		return false
+	}
+	var name string
+	for _, event := range []string{"join", "quit", "ping"} {
+		if l.Topics[0] == registryABI.Events[event].Id() {
+			name = event
+		}
+	}
+	if name == "" {
+		return false // Governance events
+	}
+	var event struct {
+		Id             [8]byte
+		Addr           common.Address
+		BlockOnlineAcc *big.Int
+		BlockLastPing  *big.Int
+	}
+	if err := registryABI.Unpack(&event, name, l.Data); err != nil {
+		log.Warn("Invalid masternode contract event", "number", number, "event", name, "err", err)
+		return false
+	}
+	id := fmt.Sprintf("%x", event.Id[:])
+
+	index := sort.Search(len(registry.Nodes), func(i int) bool { return registry.Nodes[i].ID >= id })
+	known := index < len(registry.Nodes) && registry.Nodes[index].ID == id
+
+	switch name {
+	case "join":
+		node := &devotedb.RegisteredMasternode{ID: id, OriginBlock: number}
+		if known {
+			registry.Nodes[index] = node
+			return true
+		}
+		registry.Nodes = append(registry.Nodes, nil)
+		copy(registry.Nodes[index+1:], registry.Nodes[index:])
+		registry.Nodes[index] = node
+
+	case "quit":
+		if !known {
+			return false
+		}
+		registry.Nodes = append(registry.Nodes[:index], registry.Nodes[index+1:]...)
+
+	case "ping":
+		if !known {
+			return false
+		}
+		registry.Nodes[index].LastPing = event.BlockLastPing.Uint64()
+	}
+	return true
+}
+
+// registryList returns the ids of the masternodes of the registry online at the
+// given block, as the masternode contract lists them: pinged within the last
+// PingExpiry blocks, or registered at genesis and never pinged.
+func registryList(registry *devotedb.MasternodeRegistry, number uint64) []string {
+	var ids []string
+	for _, node := range registry.Nodes {
+		if node.LastPing > 0 {
+			if node.LastPing < number && number-node.LastPing > masternode.PingExpiry {
+				continue
+			}
+		} else if node.OriginBlock > 0 {
+			continue
+		}
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+// masternodeList returns the masternodes the cycles sealed on top of the given
+// stable block are elected from: read from the registry of its devote trie once
+// the registry fork is active there, from the masternode contract before.
+func (d *Devote) masternodeList(chain consensus.ChainReader, stable *big.Int) ([]string, error) {
+	if !d.config.IsMasternodeRegistry(stable) {
+		return d.masternodeListFn(stable)
+	}
+	header := chain.GetHeaderByNumber(stable.Uint64())
+	if header == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), header.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := devoteDB.GetMasternodeRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if registry == nil {
+		return nil, errMissingRegistry
+	}
+	return registryList(registry, stable.Uint64()), nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// registryLog packs an event of the masternode contract into a log.
+func registryLog(t *testing.T, address common.Address, name string, args ...interface{}) *types.Log {
+	event := registryABI.Events[name]
+	data, err := event.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatalf("failed to pack %s event: %v", name, err)
+	}
+	return &types.Log{Address: address, Topics: []common.Hash{event.Id()}, Data: data}
+}
+
+// Tests that the registry follows the join, ping and quit events of the
+// masternode contract, and lists its masternodes as the contract does.
+func TestMasternodeRegistry(t *testing.T) {
+	var (
+		contract = common.Address{0x0c}
+		a        = [8]byte{0x0a}
+		b        = [8]byte{0x0b}
+		c        = [8]byte{0x0c}
+	)
+	registry := &devotedb.MasternodeRegistry{
+		Contract: contract,
+		Nodes:    []*devotedb.RegisteredMasternode{{ID: "0b00000000000000"}},
+	}
+	apply := func(number uint64, l *types.Log) bool {
+		return applyRegistryLog(registry, number, l)
+	}
+	if !apply(10, registryLog(t, contract, "join", c, common.Address{0x01})) || !apply(10, registryLog(t, contract, "join", a, common.Address{0x02})) {
+		t.Fatalf("join not applied")
+	}
+	if apply(11, registryLog(t, contract, "newVote", common.Address{0x01}, common.Address{0x02})) {
+		t.Errorf("governance event applied")
+	}
+	if apply(11, registryLog(t, contract, "ping", [8]byte{0x0d}, big.NewInt(0), big.NewInt(11))) {
+		t.Errorf("ping of unknown masternode applied")
+	}
+	if !apply(20, registryLog(t, contract, "ping", a, big.NewInt(0), big.NewInt(20))) {
+		t.Fatalf("ping not applied")
+	}
+	want := []*devotedb.RegisteredMasternode{
+		{ID: "0a00000000000000", OriginBlock: 10, LastPing: 20},
+		{ID: "0b00000000000000"},
+		{ID: "0c00000000000000", OriginBlock: 10},
+	}
+	if !reflect.DeepEqual(registry.Nodes, want) {
+		t.Fatalf("registry mismatch: have %v, want %v", registry.Nodes, want)
+	}
+	// The genesis masternode is listed until it quits, the pinged one until its
+	// ping expires, the one never pinged not at all
+	if have := registryList(registry, 20+masternode.PingExpiry); !reflect.DeepEqual(have, []string{"0a00000000000000", "0b00000000000000"}) {
+		t.Errorf("list mismatch: have %v", have)
+	}
+	if have := registryList(registry, 21+masternode.PingExpiry); !reflect.DeepEqual(have, []string{"0b00000000000000"}) {
+		t.Errorf("list mismatch after ping expiry: have %v", have)
+	}
+	if !apply(30, registryLog(t, contract, "quit", b, common.Address{0x03})) {
+		t.Fatalf("quit not applied")
+	}
+	if have := registryList(registry, 30); !reflect.DeepEqual(have, []string{"0a00000000000000"}) {
+		t.Errorf("list mismatch after quit: have %v", have)
+	}
+}
+
+// Tests that the registry is seeded from the masternode contract once, when the
+// fork activates, and again when a new version of the contract does, only the
+// events of the contract in effect being applied to it.
+func TestUpdateRegistry(t *testing.T) {
+	config := &params.DevoteConfig{
+		MasternodeRegistryBlock: big.NewInt(1),
+		MasternodeContract:      common.Address{0x0c},
+		MasternodeContracts:     []params.MasternodeContract{{Block: big.NewInt(3), Address: common.Address{0x0d}, Version: 1}},
+	}
+	d := NewDevote(config, ethdb.NewMemDatabase())
+
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(1)}
+	if err := d.updateRegistry(devoteDB, header, nil); err != errNoRegistrySeed {
+		t.Fatalf("seeding without a source: have %v, want %v", err, errNoRegistrySeed)
+	}
+	var seeded []*big.Int
+	d.RegistrySeed(func(header *types.Header) ([]*masternode.Masternode, error) {
+		seeded = append(seeded, header.Number)
+		return []*masternode.Masternode{
+			{ID: "0b00000000000000", OriginBlock: big.NewInt(0), BlockLastPing: big.NewInt(0)},
+			{},
+			{ID: "0a00000000000000", OriginBlock: big.NewInt(0), BlockLastPing: big.NewInt(0)},
+		}, nil
+	})
+	update := func(number int64, logs ...*types.Log) *devotedb.MasternodeRegistry {
+		if err := d.updateRegistry(devoteDB, &types.Header{Number: big.NewInt(number)}, []*types.Receipt{{Logs: logs}}); err != nil {
+			t.Fatalf("block %d: failed to update registry: %v", number, err)
+		}
+		registry, err := devoteDB.GetMasternodeRegistry()
+		if err != nil || registry == nil {
+			t.Fatalf("block %d: failed to get registry: %v", number, err)
+		}
+		return registry
+	}
+	if registry := update(1); len(registry.Nodes) != 2 || registry.Nodes[0].ID != "0a00000000000000" || registry.Contract != config.MasternodeContract {
+		t.Fatalf("registry not seeded: %v", registry)
+	}
+	// Only the events of the contract in effect count
+	registry := update(2,
+		registryLog(t, common.Address{0x0d}, "quit", [8]byte{0x0a}, common.Address{}),
+		registryLog(t, common.Address{0x0c}, "quit", [8]byte{0x0b}, common.Address{}),
+	)
+	if len(registry.Nodes) != 1 || registry.Nodes[0].ID != "0a00000000000000" {
+		t.Fatalf("contract events misapplied: %v", registry.Nodes)
+	}
+	if len(seeded) != 1 {
+		t.Fatalf("registry seeded again without an upgrade: %v", seeded)
+	}
+	// The upgrade reseeds the registry from the new contract
+	if registry := update(3); len(registry.Nodes) != 2 || registry.Contract != (common.Address{0x0d}) {
+		t.Fatalf("registry not reseeded: %v", registry)
+	}
+	if len(seeded) != 2 || seeded[1].Int64() != 3 {
+		t.Fatalf("reseed mismatch: %v", seeded)
+	}
+}
+
+// registryChain is a chain reader serving the headers of a test chain by number.
+type registryChain struct {
+	consensus.ChainReader
+	headers map[uint64]*types.Header
+}
+
+func (c *registryChain) GetHeaderByNumber(number uint64) *types.Header { return c.headers[number] }
+
+// Tests that the cycles are elected from the masternode contract before the
+// registry fork and from the registry committed at the stable block after it.
+func TestMasternodeListFork(t *testing.T) {
+	config := &params.DevoteConfig{MasternodeRegistryBlock: big.NewInt(10)}
+	db := ethdb.NewMemDatabase()
+	d := NewDevote(config, db)
+	d.Masternodes(func(number *big.Int) ([]string, error) {
+		return []string{"contract"}, nil
+	})
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(db), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	empty, err := devoteDB.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit devote db: %v", err)
+	}
+	if err := devoteDB.SetMasternodeRegistry(&devotedb.MasternodeRegistry{
+		Nodes: []*devotedb.RegisteredMasternode{{ID: "0a00000000000000"}, {ID: "0b00000000000000", OriginBlock: 5}},
+	}); err != nil {
+		t.Fatalf("failed to set registry: %v", err)
+	}
+	seeded, err := devoteDB.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit devote db: %v", err)
+	}
+	chain := &registryChain{headers: map[uint64]*types.Header{
+		9:  {Number: big.NewInt(9), Protocol: empty},
+		10: {Number: big.NewInt(10), Protocol: seeded},
+		11: {Number: big.NewInt(11), Protocol: empty},
+	}}
+	tests := []struct {
+		stable int64
+		nodes  []string
+		err    error
+	}{
+		{9, []string{"contract"}, nil},
+		{10, []string{"0a00000000000000"}, nil},
+		{11, nil, errMissingRegistry},
+		{12, nil, consensus.ErrUnknownAncestor},
+	}
+	for i, tt := range tests {
+		nodes, err := d.masternodeList(chain, big.NewInt(tt.stable))
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if !reflect.DeepEqual(nodes, tt.nodes) {
+			t.Errorf("test %d: masternodes mismatch: have %v, want %v", i, nodes, tt.nodes)
+		}
+	}
+}
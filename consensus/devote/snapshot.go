@@ -22,12 +22,14 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
 	"sync"
 
 	"encoding/json"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/core/types/devotedb"
 	"github.com/etherzero/go-etherzero/crypto"
@@ -51,6 +53,7 @@ type Snapshot struct {
 	Recents  map[uint64]string    // set of recent masternodes for spam protections
 
 	TimeStamp uint64
+	beacon    common.Hash // Beacon ordering the election, legacy ordering if zero
 	mu        sync.Mutex
 }
 
@@ -129,10 +132,10 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 }
 
 // loadSnapshot loads an existing snapshot from the database.
-func loadSnapshot(config *params.DevoteConfig, sigcache *lru.ARCCache, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
-	blob, err := db.Get(append([]byte("devote-"), hash[:]...))
-	if err != nil {
-		return nil, err
+func loadSnapshot(config *params.DevoteConfig, sigcache *lru.ARCCache, db ethdb.Database, number uint64, hash common.Hash) (*Snapshot, error) {
+	blob := rawdb.ReadDevoteSnapshot(db, hash, number)
+	if len(blob) == 0 {
+		return nil, errUnknownSnapshot
 	}
 	snap := new(Snapshot)
 	if err := json.Unmarshal(blob, snap); err != nil {
@@ -150,7 +153,8 @@ func (s *Snapshot) store(db ethdb.Database) error {
 	if err != nil {
 		return err
 	}
-	return db.Put(append([]byte("devote-"), s.Hash[:]...), blob)
+	rawdb.WriteDevoteSnapshot(db, s.Hash, blob)
+	return nil
 }
 
 // masternodes return  masternode list in the Cycle.
@@ -163,13 +167,10 @@ func (self *Snapshot) calculate(parent *types.Header, isFirstCycle bool, nodes [
 	list := make(map[string]*big.Int)
 	for i := 0; i < len(nodes); i++ {
 		masternode := nodes[i]
-		hash := make([]byte, 8)
-		hash = append(hash, []byte(masternode)...)
-		hash = append(hash, parent.Hash().Bytes()...)
-		weight := int64(binary.LittleEndian.Uint32(crypto.Keccak512(hash)))
-
-		score := big.NewInt(0)
-		score.Add(score, big.NewInt(weight))
+		score := electionWeight(parent, masternode)
+		if self.beacon != (common.Hash{}) {
+			score = beaconWeight(self.beacon, masternode)
+		}
 		log.Debug("masternodes ", "score", score.Uint64(), "masternode", masternode)
 		list[masternode] = score
 	}
@@ -177,6 +178,41 @@ func (self *Snapshot) calculate(parent *types.Header, isFirstCycle bool, nodes [
 	return list, nil
 }
 
+// electionWeight returns the weight of a masternode in the election of the
+// cycle following the parent block.
+func electionWeight(parent *types.Header, masternode string) *big.Int {
+	hash := make([]byte, 8)
+	hash = append(hash, []byte(masternode)...)
+	hash = append(hash, parent.Hash().Bytes()...)
+	return big.NewInt(int64(binary.LittleEndian.Uint32(crypto.Keccak512(hash))))
+}
+
+// Elect runs the election of the chain for the cycle following the parent
+// block, electing the witnesses from the given masternodes capped at
+// maxWitnessSize. The signing record of the cycle of the parent, which the
+// kickout and the beacon ordering depend on, is read from devoteDB, where the
+// outcome is recorded as well. Without any record the masternodes are elected
+// by weight alone.
+func Elect(config *params.DevoteConfig, devoteDB *devotedb.DevoteDB, genesis, parent *types.Header, nodes []string, maxWitnessSize int) ([]string, error) {
+	number := new(big.Int).Add(parent.Number, common.Big1)
+	cycle := parent.Time.Uint64()/params.Epoch + 1
+
+	devoteDB.SetCycle(cycle)
+	snap := &Snapshot{config: config, devoteDB: devoteDB, TimeStamp: cycle * params.Epoch}
+	if config.IsBeacon(number) {
+		beacon, err := devoteDB.GetBeacon(cycle - 1)
+		if err != nil {
+			return nil, err
+		}
+		snap.beacon = beacon
+	}
+	standbySize := 0
+	if config.IsStandby(number) {
+		standbySize = int(config.StandbyWitnesses)
+	}
+	return snap.election(genesis, parent, nodes, witnessQuorum(uint64(maxWitnessSize)), int64(maxWitnessSize), standbySize)
+}
+
 //Remove from candidate nodes when a node does't work in the current cycle
 func (snap *Snapshot) uncast(cycle uint64, nodes []string) ([]string, error) {
 
@@ -218,6 +254,76 @@ func (snap *Snapshot) uncast(cycle uint64, nodes []string) ([]string, error) {
 	return nodes, nil
 }
 
+// inactive returns the witnesses of the given cycle which sealed less than
+// threshold percent of their expected blocks, or no block at all, sorted.
+func (snap *Snapshot) inactive(cycle uint64, threshold uint64) []string {
+	witnesses, err := snap.devoteDB.GetWitnesses(cycle)
+	if err != nil || len(witnesses) == 0 {
+		return nil
+	}
+	expected := expectedSlots(cycle, witnesses, math.MaxUint64)
+
+	var inactive []string
+	for _, witness := range witnesses {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, cycle)
+		produced := snap.devoteDB.GetStatsNumber(append(key, []byte(witness)...))
+
+		required := (expected[witness]*threshold + 99) / 100
+		if required < 1 {
+			required = 1
+		}
+		if produced < required {
+			inactive = append(inactive, witness)
+			log.Debug("inactive masternode", "cycle", cycle, "witness", witness, "produced", produced, "required", required)
+		}
+	}
+	sort.Strings(inactive)
+	return inactive
+}
+
+// kickoutThreshold returns the share of their expected blocks the witnesses of
+// the cycle before block num had to seal. Once witnesses may skip empty slots,
+// a single block per cycle is all that can be required of them.
+func kickoutThreshold(config *params.DevoteConfig, num *big.Int) uint64 {
+	if !config.IsKickout(num) || config.IsSkipEmpty(num) {
+		return 0
+	}
+	return config.KickoutThreshold
+}
+
+// kickout removes the candidate nodes which sealed less than the given share
+// of their expected blocks as witnesses of the given cycle, returning the nodes
+// left and the sorted nodes evicted. The eviction is skipped if it would leave
+// fewer than safeSize candidates, as the next cycle couldn't be elected otherwise.
+func (snap *Snapshot) kickout(cycle uint64, threshold uint64, nodes []string, safeSize int) ([]string, []string) {
+	inactive := snap.inactive(cycle, threshold)
+	if len(inactive) == 0 {
+		return nodes, nil
+	}
+	evicted := make(map[string]struct{})
+	for _, witness := range inactive {
+		evicted[witness] = struct{}{}
+	}
+	var (
+		list   = make([]string, 0, len(nodes))
+		kicked []string
+	)
+	for _, node := range nodes {
+		if _, ok := evicted[node]; ok {
+			kicked = append(kicked, node)
+		} else {
+			list = append(list, node)
+		}
+	}
+	if len(list) < safeSize {
+		log.Warn("Skipping masternode kickout, too few candidates left", "cycle", cycle, "evicted", len(evicted), "left", len(list), "safesize", safeSize)
+		return nodes, nil
+	}
+	sort.Strings(kicked)
+	return list, kicked
+}
+
 func (snap *Snapshot) lookup(now uint64) (witness string, err error) {
 
 	var (
@@ -246,6 +352,23 @@ func (snap *Snapshot) lookup(now uint64) (witness string, err error) {
 	return
 }
 
+// lookupStandby returns the standby witness in line to seal the slot at the
+// given time if its witness missed it, the standbys taking turns like the
+// witnesses. It returns an empty string if no standby was elected.
+func (snap *Snapshot) lookupStandby(now uint64) (string, error) {
+	offset := now % params.Epoch
+	if offset%params.Period != 0 {
+		return "", ErrInvalidMinerBlockTime
+	}
+	offset /= params.Period
+
+	standbys, err := snap.devoteDB.GetStandbys(snap.devoteDB.GetCycle())
+	if err != nil || len(standbys) == 0 {
+		return "", err
+	}
+	return standbys[offset%uint64(len(standbys))], nil
+}
+
 // signers retrieves the list of current cycle authorized signers
 func (snap *Snapshot) signers() []string {
 	signers := make([]string, 0, len(snap.Signers))
@@ -269,7 +392,10 @@ func (snap *Snapshot) recording(parent uint64, header uint64, witness string) *d
 }
 
 //election record the current witness list into the Blockchain
-func (snap *Snapshot) election(genesis, parent *types.Header, nodes []string, safeSize int, maxWitnessSize int64) ([]string, error) {
+//
+// From the standby fork, up to standbySize of the masternodes ranking after the
+// witnesses are recorded as the standby witnesses of the cycle.
+func (snap *Snapshot) election(genesis, parent *types.Header, nodes []string, safeSize int, maxWitnessSize int64, standbySize int) ([]string, error) {
 
 	var (
 		sortedWitnesses []string
@@ -286,7 +412,14 @@ func (snap *Snapshot) election(genesis, parent *types.Header, nodes []string, sa
 		list := make([]string, len(nodes))
 		copy(list, nodes)
 		if !preisgenesis {
-			list, _ = snap.uncast(prevcycle, nodes)
+			if snap.config.IsKickout(parent.Number) {
+				list, _ = snap.kickout(prevcycle, kickoutThreshold(snap.config, parent.Number), list, safeSize)
+			} else {
+				list, _ = snap.uncast(prevcycle, nodes)
+			}
+			if snap.config.IsBeacon(parent.Number) {
+				list = snap.defaulters(prevcycle, list, safeSize)
+			}
 		}
 
 		count, err := snap.calculate(parent, preisgenesis, list)
@@ -302,8 +435,19 @@ func (snap *Snapshot) election(genesis, parent *types.Header, nodes []string, sa
 			return nil, fmt.Errorf(" too few masternodes ,cycle:%d, current :%d, safesize:%d",currentcycle, len(masternodes), safeSize)
 		}
 		sort.Sort(masternodes)
+		var standbys sortableAddresses
 		if len(masternodes) > int(maxWitnessSize) {
-			masternodes = masternodes[:maxWitnessSize]
+			masternodes, standbys = masternodes[:maxWitnessSize], masternodes[maxWitnessSize:]
+		}
+		if standbySize > 0 {
+			if len(standbys) > standbySize {
+				standbys = standbys[:standbySize]
+			}
+			standbyWitnesses := make([]string, 0, len(standbys))
+			for _, node := range standbys {
+				standbyWitnesses = append(standbyWitnesses, node.nodeid)
+			}
+			snap.devoteDB.SetStandbys(currentcycle, standbyWitnesses)
 		}
 		if parent.Number.Cmp(skipBlock) ==0 {
 			log.Info("Initializing a cycle ","parent.Number",parent.Number, "currentcycle", currentcycle, "count", len(sortedWitnesses), "sortedWitnesses", sortedWitnesses)
@@ -0,0 +1,103 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+const (
+	diffInTurn  = 2 // Block difficulty of the scheduled witness, from the standby fork
+	diffStandby = 1 // Block difficulty of a standby witness, and of all blocks before the fork
+)
+
+// errStandbyTooEarly is returned if a standby witness sealed a slot before the
+// chain went without a block for the grace period.
+var errStandbyTooEarly = errors.New("standby witness sealed within grace period")
+
+// StandbyAt returns the standby witness in line to seal the slot at the given
+// time if its witness misses it, based on the standby list recorded by the last
+// block. It returns an empty string if no standby was elected.
+func (d *Devote) StandbyAt(lastBlock *types.Header, slot uint64) (string, error) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), lastBlock.Protocol)
+	if err != nil {
+		return "", err
+	}
+	devoteDB.SetCycle(lastBlock.Time.Uint64() / params.Epoch)
+	snap := newSnapshot(d.config, devoteDB)
+	snap.sigcache = d.signatures
+
+	return snap.lookupStandby(slot)
+}
+
+// IsStandbyAt reports whether the local signer may seal the slot at the given
+// time on top of the last block as a standby witness: it's in line for the slot
+// and the chain went without a block for more than the grace period.
+func (d *Devote) IsStandbyAt(lastBlock *types.Header, slot uint64) bool {
+	if !d.config.IsStandby(new(big.Int).Add(lastBlock.Number, common.Big1)) {
+		return false
+	}
+	if slot <= lastBlock.Time.Uint64()+d.config.StandbyGrace {
+		return false
+	}
+	standby, err := d.StandbyAt(lastBlock, slot)
+	if err != nil || standby == "" {
+		return false
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return standby == d.signer
+}
+
+// slotSealer returns who was allowed to seal the header from the standby fork:
+// the scheduled witness with the in-turn difficulty, or once the grace period
+// passed without a block, the standby in line for the slot with the standby
+// difficulty. If the signer is neither, the scheduled witness is returned for
+// the signer check to fail on.
+func (d *Devote) slotSealer(snap *Snapshot, parent, header *types.Header, witness string) (string, error) {
+	signer, err := ecrecover(header, d.signatures)
+	if err != nil {
+		return "", err
+	}
+	if signer == witness {
+		if header.Difficulty.Uint64() != diffInTurn {
+			return "", errInvalidDifficulty
+		}
+		return witness, nil
+	}
+	standby, err := snap.lookupStandby(header.Time.Uint64())
+	if err != nil {
+		return "", err
+	}
+	if standby == "" || signer != standby {
+		return witness, nil
+	}
+	if header.Time.Uint64() <= parent.Time.Uint64()+d.config.StandbyGrace {
+		return "", errStandbyTooEarly
+	}
+	if header.Difficulty.Uint64() != diffStandby {
+		return "", errInvalidDifficulty
+	}
+	return standby, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the standby witnesses take turns on the slots of their cycle, and
+// that cycles without standbys leave the missed slots empty.
+func TestLookupStandby(t *testing.T) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	devoteDB.SetStandbys(2, []string{"a", "b", "c"})
+	snap := newSnapshot(&params.DevoteConfig{}, devoteDB)
+
+	devoteDB.SetCycle(2)
+	for i, want := range []string{"a", "b", "c", "a"} {
+		slot := 2*params.Epoch + uint64(i)*params.Period
+		if standby, err := snap.lookupStandby(slot); err != nil || standby != want {
+			t.Errorf("slot %d: standby mismatch: have %q/%v, want %q", slot, standby, err, want)
+		}
+	}
+	devoteDB.SetCycle(1)
+	if standby, err := snap.lookupStandby(params.Epoch); err != nil || standby != "" {
+		t.Errorf("standby without election: have %q/%v, want none", standby, err)
+	}
+}
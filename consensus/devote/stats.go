@@ -0,0 +1,221 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	maxStatsCycles   = 1024 // Maximum number of cycles aggregated by a single stats request
+	delayTrackCycles = 144  // Number of recent cycles to keep locally observed delays for
+	delayTrackBlocks = 4096 // Number of recent block hashes remembered to count each block once
+
+	// delayTrackHorizon is the age past which arriving blocks are taken as synced
+	// history rather than propagated ones, and their delay isn't recorded.
+	delayTrackHorizon = params.Epoch * 1000
+)
+
+// errInvalidCycleRange is returned if a stats request spans an invalid range.
+var errInvalidCycleRange = errors.New("invalid cycle range")
+
+// WitnessStats is the performance summary of a single witness over a range of
+// cycles.
+type WitnessStats struct {
+	Witness  string `json:"witness"`
+	Expected uint64 `json:"expected"` // Slots assigned to the witness
+	Produced uint64 `json:"produced"` // Blocks sealed according to the stats trie
+	Missed   uint64 `json:"missed"`   // Assigned slots without a block
+	Observed uint64 `json:"observed"` // Blocks with a locally observed arrival time
+	Delay    uint64 `json:"delay"`    // Average propagation delay in milliseconds
+}
+
+// delayStat accumulates the propagation delays observed for a witness.
+type delayStat struct {
+	total uint64 // Sum of delays in milliseconds
+	count uint64
+}
+
+// delayTracker records the time between the slot a block was sealed for and
+// its local arrival, aggregated per cycle and witness.
+type delayTracker struct {
+	seen   *lru.Cache
+	cycles map[uint64]map[string]*delayStat
+	lock   sync.Mutex
+}
+
+func newDelayTracker() *delayTracker {
+	seen, _ := lru.New(delayTrackBlocks)
+	return &delayTracker{
+		seen:   seen,
+		cycles: make(map[uint64]map[string]*delayStat),
+	}
+}
+
+// observe records the arrival of a header, counting every block at most once.
+// Blocks arriving more than delayTrackHorizon after their slot are ignored, as
+// their delay measures the sync rather than the witness.
+func (t *delayTracker) observe(header *types.Header, arrival time.Time) {
+	if header.Witness == "" {
+		return
+	}
+	slot := header.Time.Uint64() * 1000
+	now := uint64(arrival.UnixNano() / int64(time.Millisecond))
+	if now < slot {
+		now = slot
+	}
+	if now-slot > delayTrackHorizon {
+		return
+	}
+	if seen, _ := t.seen.ContainsOrAdd(header.Hash(), struct{}{}); seen {
+		return
+	}
+	cycle := header.Time.Uint64() / params.Epoch
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	witnesses, ok := t.cycles[cycle]
+	if !ok {
+		witnesses = make(map[string]*delayStat)
+		t.cycles[cycle] = witnesses
+		if cycle >= delayTrackCycles {
+			for old := range t.cycles {
+				if old <= cycle-delayTrackCycles {
+					delete(t.cycles, old)
+				}
+			}
+		}
+	}
+	stat, ok := witnesses[header.Witness]
+	if !ok {
+		stat = new(delayStat)
+		witnesses[header.Witness] = stat
+	}
+	stat.total += now - slot
+	stat.count++
+}
+
+// delays returns the delays observed for the witness within the given cycle.
+func (t *delayTracker) delays(cycle uint64, witness string) (total uint64, count uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if stat, ok := t.cycles[cycle][witness]; ok {
+		return stat.total, stat.count
+	}
+	return 0, 0
+}
+
+// WitnessStats aggregates the performance of all witnesses in the cycles
+// [begin, end] based on the devote state of the given head, ranked by their
+// share of produced blocks.
+func (d *Devote) WitnessStats(head *types.Header, begin, end uint64) ([]*WitnessStats, error) {
+	current := head.Time.Uint64() / params.Epoch
+	if end > current {
+		end = current
+	}
+	if begin > end || end-begin >= maxStatsCycles {
+		return nil, errInvalidCycleRange
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), head.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*WitnessStats)
+	for cycle := begin; cycle <= end; cycle++ {
+		witnesses, err := devoteDB.GetWitnesses(cycle)
+		if err != nil || len(witnesses) == 0 {
+			continue
+		}
+		expected := expectedSlots(cycle, witnesses, head.Time.Uint64())
+		for _, witness := range witnesses {
+			stat, ok := stats[witness]
+			if !ok {
+				stat = &WitnessStats{Witness: witness}
+				stats[witness] = stat
+			}
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, cycle)
+			produced := devoteDB.GetStatsNumber(append(key, []byte(witness)...))
+
+			stat.Expected += expected[witness]
+			stat.Produced += produced
+			if produced < expected[witness] {
+				stat.Missed += expected[witness] - produced
+			}
+			total, count := d.delayTracker.delays(cycle, witness)
+			stat.Delay += total
+			stat.Observed += count
+		}
+	}
+	ranked := make([]*WitnessStats, 0, len(stats))
+	for _, stat := range stats {
+		if stat.Observed > 0 {
+			stat.Delay /= stat.Observed
+		}
+		ranked = append(ranked, stat)
+	}
+	sort.Sort(witnessRanking(ranked))
+	return ranked, nil
+}
+
+// expectedSlots counts the slots assigned round robin to each witness within
+// the given cycle, up until (and including) the time last.
+func expectedSlots(cycle uint64, witnesses []string, last uint64) map[string]uint64 {
+	if end := (cycle+1)*params.Epoch - 1; last > end {
+		last = end
+	}
+	expected := make(map[string]uint64)
+	if len(witnesses) == 0 {
+		return expected
+	}
+	for slot := cycle * params.Epoch; slot <= last; slot += params.Period {
+		expected[witnesses[(slot%params.Epoch/params.Period)%uint64(len(witnesses))]]++
+	}
+	return expected
+}
+
+// witnessRanking orders witnesses by produced/expected ratio, then by the
+// number of missed slots and finally by their average delay.
+type witnessRanking []*WitnessStats
+
+func (r witnessRanking) Len() int      { return len(r) }
+func (r witnessRanking) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r witnessRanking) Less(i, j int) bool {
+	// Compare Produced_i/Expected_i with Produced_j/Expected_j without division
+	ri, rj := r[i].Produced*r[j].Expected, r[j].Produced*r[i].Expected
+	if ri != rj {
+		return ri > rj
+	}
+	if r[i].Missed != r[j].Missed {
+		return r[i].Missed < r[j].Missed
+	}
+	if r[i].Delay != r[j].Delay {
+		return r[i].Delay < r[j].Delay
+	}
+	return r[i].Witness < r[j].Witness
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that propagation delays are recorded once per block, and not at all for
+// blocks arriving long after their slot, as during a sync.
+func TestDelayTracker(t *testing.T) {
+	tracker := newDelayTracker()
+	slot := time.Unix(int64(10*params.Epoch), 0)
+
+	fresh := &types.Header{Number: big.NewInt(1), Time: big.NewInt(slot.Unix()), Witness: "a"}
+	tracker.observe(fresh, slot.Add(1500*time.Millisecond))
+	tracker.observe(fresh, slot.Add(3*time.Second))
+
+	synced := &types.Header{Number: big.NewInt(2), Time: big.NewInt(slot.Unix() + int64(params.Period)), Witness: "a"}
+	tracker.observe(synced, slot.Add(time.Duration(params.Epoch+params.Period+1)*time.Second))
+
+	if total, count := tracker.delays(10, "a"); total != 1500 || count != 1 {
+		t.Fatalf("delays mismatch: have %d ms over %d blocks, want 1500 ms over 1", total, count)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+var (
+	// errMissingSummary is returned if the first block of a cycle doesn't carry
+	// the epoch summary in its extra-data.
+	errMissingSummary = errors.New("missing epoch summary")
+	// errUnexpectedSummary is returned if a block within a cycle carries an
+	// epoch summary.
+	errUnexpectedSummary = errors.New("unexpected epoch summary")
+	// errInvalidSummary is returned if the epoch summary doesn't match the
+	// election recorded in the devote state.
+	errInvalidSummary = errors.New("invalid epoch summary")
+)
+
+// EpochSummary is the compact record of a cycle election, embedded into the
+// extra-data of the first block of each cycle so that elections can be followed
+// by parsing headers only.
+type EpochSummary struct {
+	Cycle     uint64   `json:"cycle"`
+	Witnesses []string `json:"witnesses"` // Witnesses elected for the cycle, in slot order
+	Kicked    []string `json:"kicked"`    // Witnesses of the previous cycle evicted for inactivity, sorted
+}
+
+// splitExtra returns the optional epoch summary and hot key delegation embedded
+// between the vanity and the seal of the header extra-data. The summary is an
+// RLP list, so its first byte never collides with the expiry of a delegation.
+func splitExtra(extra []byte) (summary []byte, delegation []byte) {
+	if len(extra) < extraVanity+extraSeal {
+		return nil, nil
+	}
+	body := extra[extraVanity : len(extra)-extraSeal]
+	if len(body) > 0 && body[0] >= 0xc0 {
+		if _, _, rest, err := rlp.Split(body); err == nil {
+			summary, body = body[:len(body)-len(rest)], rest
+		}
+	}
+	if len(body) == extraDelegation {
+		delegation = body
+	}
+	return summary, delegation
+}
+
+// DecodeEpochSummary parses the epoch summary from the header extra-data. It
+// returns nil if the header doesn't carry any summary.
+func DecodeEpochSummary(header *types.Header) (*EpochSummary, error) {
+	enc, _ := splitExtra(header.Extra)
+	if enc == nil {
+		return nil, nil
+	}
+	summary := new(EpochSummary)
+	if err := rlp.DecodeBytes(enc, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// embedSummary inserts the encoded summary into the extra-data right after the
+// vanity, replacing any previous one.
+func embedSummary(extra []byte, summary *EpochSummary) ([]byte, error) {
+	enc, err := rlp.EncodeToBytes(summary)
+	if err != nil {
+		return nil, err
+	}
+	_, delegation := splitExtra(extra)
+
+	embedded := make([]byte, 0, extraVanity+len(enc)+len(delegation)+extraSeal)
+	embedded = append(embedded, extra[:extraVanity]...)
+	embedded = append(embedded, enc...)
+	embedded = append(embedded, delegation...)
+	return append(embedded, extra[len(extra)-extraSeal:]...), nil
+}
+
+// epochSummary assembles the summary of the given cycle from the devote state
+// right after its election from the given masternodes.
+func (d *Devote) epochSummary(genesis, parent *types.Header, cycle uint64, devoteDB *devotedb.DevoteDB, nodes []string, safeSize int) (*EpochSummary, error) {
+	witnesses, err := devoteDB.GetWitnesses(cycle)
+	if err != nil {
+		return nil, err
+	}
+	summary := &EpochSummary{Cycle: cycle, Witnesses: witnesses}
+
+	// No eviction happens in the election following the genesis cycle
+	if genesis.Time.Uint64()/params.Epoch != parent.Time.Uint64()/params.Epoch {
+		snap := &Snapshot{config: d.config, devoteDB: devoteDB}
+		threshold := kickoutThreshold(d.config, parent.Number)
+		if d.config.IsKickout(parent.Number) {
+			_, summary.Kicked = snap.kickout(cycle-1, threshold, nodes, safeSize)
+		} else {
+			summary.Kicked = snap.inactive(cycle-1, threshold)
+		}
+	}
+	return summary, nil
+}
+
+// isSummaryBlock reports whether the header is the first block of a cycle and
+// must thus carry the epoch summary.
+func (d *Devote) isSummaryBlock(parent, header *types.Header) bool {
+	return d.config.IsEpochSummary(header.Number) && parent.Time.Uint64()/params.Epoch < header.Time.Uint64()/params.Epoch
+}
+
+// verifySummary checks the epoch summary of the header against the election
+// recorded in its devote state.
+func (d *Devote) verifySummary(chain consensus.ChainReader, parent, header *types.Header) error {
+	if !d.config.IsEpochSummary(header.Number) {
+		return nil
+	}
+	summary, err := DecodeEpochSummary(header)
+	if err != nil {
+		return err
+	}
+	if !d.isSummaryBlock(parent, header) {
+		if summary != nil {
+			return errUnexpectedSummary
+		}
+		return nil
+	}
+	if summary == nil {
+		return errMissingSummary
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), header.Protocol)
+	if err != nil {
+		return err
+	}
+	// Replay the eviction against the masternodes the cycle was elected from
+	stable := stableNumber(chain, parent)
+	governance, err := d.governanceContractAddressFn(stable)
+	if err != nil {
+		return err
+	}
+	maxWitnessSize, err := d.maxWitnesses(chain, header, governance, stable)
+	if err != nil {
+		return err
+	}
+	nodes, err := d.masternodeList(chain, stable)
+	if err != nil {
+		return err
+	}
+	expected, err := d.epochSummary(chain.GetHeaderByNumber(0), parent, header.Time.Uint64()/params.Epoch, devoteDB, nodes, witnessQuorum(maxWitnessSize))
+	if err != nil {
+		return err
+	}
+	have, _ := rlp.EncodeToBytes(summary)
+	want, _ := rlp.EncodeToBytes(expected)
+	if !bytes.Equal(have, want) {
+		return errInvalidSummary
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that epoch summaries can be embedded next to an optional delegation
+// and parsed back without disturbing either the vanity or the seal.
+func TestEpochSummaryEmbedding(t *testing.T) {
+	vanity := bytes.Repeat([]byte{0x01}, extraVanity)
+	seal := bytes.Repeat([]byte{0x02}, extraSeal)
+	delegation := append(make([]byte, 8), bytes.Repeat([]byte{0x03}, extraDelegation-8)...)
+
+	summary := &EpochSummary{
+		Cycle:     42,
+		Witnesses: []string{"c34c967d399d38f0", "ffb14ca8e65770b4"},
+		Kicked:    []string{"de4e2e0521f16469"},
+	}
+	for i, body := range [][]byte{nil, delegation} {
+		extra := append(append(append([]byte{}, vanity...), body...), seal...)
+		if enc, _ := splitExtra(extra); enc != nil {
+			t.Fatalf("test %d: summary found in plain extra-data", i)
+		}
+		embedded, err := embedSummary(extra, summary)
+		if err != nil {
+			t.Fatalf("test %d: failed to embed summary: %v", i, err)
+		}
+		// Embedding twice must replace the previous summary
+		if embedded, err = embedSummary(embedded, summary); err != nil {
+			t.Fatalf("test %d: failed to re-embed summary: %v", i, err)
+		}
+		if !bytes.Equal(embedded[:extraVanity], vanity) || !bytes.Equal(embedded[len(embedded)-extraSeal:], seal) {
+			t.Errorf("test %d: vanity or seal modified", i)
+		}
+		if _, have := splitExtra(embedded); !bytes.Equal(have, body) {
+			t.Errorf("test %d: delegation mismatch: have %x, want %x", i, have, body)
+		}
+		decoded, err := DecodeEpochSummary(&types.Header{Extra: embedded})
+		if err != nil {
+			t.Fatalf("test %d: failed to decode summary: %v", i, err)
+		}
+		if !reflect.DeepEqual(decoded, summary) {
+			t.Errorf("test %d: summary mismatch: have %+v, want %+v", i, decoded, summary)
+		}
+	}
+}
+
+// Tests that the summary only reports the witnesses the kickout actually evicted,
+// none if the eviction was skipped for leaving too few candidates.
+func TestEpochSummaryKicked(t *testing.T) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(ethdb.NewMemDatabase()), &devotedb.DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	// Only "a" sealed its share of the previous cycle
+	devoteDB.SetWitnesses(1, []string{"a", "b", "c"})
+	devoteDB.SetWitnesses(2, []string{"a", "d"})
+	for i := uint64(0); i < expectedSlots(1, []string{"a", "b", "c"}, math.MaxUint64)["a"]; i++ {
+		devoteDB.Rolling(params.Epoch, params.Epoch+params.Period, "a")
+	}
+	d := NewDevote(&params.DevoteConfig{KickoutBlock: big.NewInt(0), KickoutThreshold: 50}, ethdb.NewMemDatabase())
+	genesis := &types.Header{Number: big.NewInt(0), Time: new(big.Int)}
+	parent := &types.Header{Number: big.NewInt(10), Time: new(big.Int).SetUint64(2*params.Epoch - params.Period)}
+
+	tests := []struct {
+		nodes    []string
+		safeSize int
+		kicked   []string
+	}{
+		{[]string{"c", "a", "b", "d"}, 2, []string{"b", "c"}},
+		{[]string{"a", "b", "d"}, 2, []string{"b"}}, // c left on its own
+		{[]string{"a", "b", "c", "d"}, 3, nil},      // too few left, skipped
+	}
+	for i, tt := range tests {
+		summary, err := d.epochSummary(genesis, parent, 2, devoteDB, tt.nodes, tt.safeSize)
+		if err != nil {
+			t.Fatalf("test %d: failed to summarize: %v", i, err)
+		}
+		if !reflect.DeepEqual(summary.Kicked, tt.kicked) {
+			t.Errorf("test %d: kicked mismatch: have %v, want %v", i, summary.Kicked, tt.kicked)
+		}
+		if !reflect.DeepEqual(summary.Witnesses, []string{"a", "d"}) {
+			t.Errorf("test %d: witnesses mismatch: have %v, want [a d]", i, summary.Witnesses)
+		}
+	}
+}
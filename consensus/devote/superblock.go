@@ -0,0 +1,96 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// BudgetFn returns the budgets approved by the governance contract for the given
+// cycle, as seen at the given block.
+type BudgetFn func(governance common.Address, cycle uint64, number *big.Int) ([]masternode.Budget, error)
+
+// SuperblockBudget sets the source of the approved budgets paid by superblocks.
+func (d *Devote) SuperblockBudget(fn BudgetFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.budgetFn = fn
+}
+
+// isSuperblock reports whether the header is the first block of a budget cycle
+// and must thus pay out the approved governance budgets.
+func (d *Devote) isSuperblock(parent, header *types.Header) bool {
+	if !d.config.IsSuperblock(header.Number) {
+		return false
+	}
+	cycle := header.Time.Uint64() / params.Epoch
+	return parent.Time.Uint64()/params.Epoch < cycle && cycle%d.config.SuperblockCycle == 0
+}
+
+// payBudget transfers the budgets approved for the cycle of the header from the
+// community fund held by the governance contract to their payees. The list is
+// read at the stable block, so every node pays out the same budgets and any
+// deviation is caught by the state root check. If the fund can't cover the whole
+// list nothing is paid and the budgets are left to the next superblock. Without
+// a budget source, as in light and test setups, nothing is paid either. It
+// returns the payments made.
+func (d *Devote) payBudget(governance common.Address, state *state.StateDB, header *types.Header, stable *big.Int) ([]*types.Payment, error) {
+	d.mu.RLock()
+	budgetFn := d.budgetFn
+	d.mu.RUnlock()
+
+	if budgetFn == nil {
+		log.Warn("Governance budget unavailable, paying none", "number", header.Number)
+		return nil, nil
+	}
+	cycle := header.Time.Uint64() / params.Epoch
+	budgets, err := budgetFn(governance, cycle, stable)
+	if err != nil {
+		return nil, fmt.Errorf("get approved budget failed from contract, err:%s", err)
+	}
+	total := new(big.Int)
+	for _, budget := range budgets {
+		if budget.Amount.Sign() <= 0 {
+			continue
+		}
+		total.Add(total, budget.Amount)
+	}
+	if fund := state.GetBalance(governance); fund.Cmp(total) < 0 {
+		log.Warn("Community fund short of approved budget", "number", header.Number, "cycle", cycle, "fund", fund, "budget", total)
+		return nil, nil
+	}
+	var payments []*types.Payment
+	for _, budget := range budgets {
+		if budget.Amount.Sign() <= 0 {
+			continue
+		}
+		state.SubBalance(governance, budget.Amount, header.Number)
+		state.AddBalance(budget.Payee, budget.Amount, header.Number)
+		payments = append(payments, &types.Payment{Kind: types.PaymentBudget, From: governance, To: budget.Payee, Amount: budget.Amount})
+	}
+	log.Info("Paid superblock budget", "number", header.Number, "cycle", cycle, "proposals", len(budgets), "total", total)
+	return payments, nil
+}
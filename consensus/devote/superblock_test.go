@@ -0,0 +1,117 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that superblocks are the first blocks of every budget cycle from the
+// superblock fork on.
+func TestIsSuperblock(t *testing.T) {
+	d := NewDevote(&params.DevoteConfig{SuperblockBlock: big.NewInt(100), SuperblockCycle: 4}, ethdb.NewMemDatabase())
+
+	tests := []struct {
+		number       int64
+		parent, slot uint64 // Cycles of the parent and the block
+		superblock   bool
+	}{
+		{100, 7, 8, true},   // first block of a budget cycle
+		{100, 6, 8, true},   // first block of a budget cycle after skipped cycles
+		{100, 8, 8, false},  // later block of a budget cycle
+		{100, 8, 9, false},  // first block of another cycle
+		{99, 7, 8, false},   // before the fork
+		{100, 11, 12, true}, // next budget cycle
+	}
+	for i, tt := range tests {
+		parent := &types.Header{Number: big.NewInt(tt.number - 1), Time: new(big.Int).SetUint64(tt.parent*params.Epoch + params.Epoch/2)}
+		header := &types.Header{Number: big.NewInt(tt.number), Time: new(big.Int).SetUint64(tt.slot*params.Epoch + params.Period)}
+		if have := d.isSuperblock(parent, header); have != tt.superblock {
+			t.Errorf("test %d: superblock mismatch: have %v, want %v", i, have, tt.superblock)
+		}
+	}
+}
+
+// Tests that superblocks pay the approved budgets out of the community fund,
+// all or nothing, and nothing at all without a budget source.
+func TestPayBudget(t *testing.T) {
+	var (
+		governance = common.Address{0xff}
+		payees     = []common.Address{{0x01}, {0x02}}
+		header     = &types.Header{Number: big.NewInt(100), Time: new(big.Int).SetUint64(8 * params.Epoch)}
+	)
+	budgets := []masternode.Budget{
+		{Payee: payees[0], Amount: big.NewInt(100)},
+		{Payee: payees[1], Amount: big.NewInt(200)},
+		{Payee: common.Address{0x03}, Amount: new(big.Int)},
+	}
+	newState := func(fund int64) *state.StateDB {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		statedb.AddBalance(governance, big.NewInt(fund), header.Number)
+		return statedb
+	}
+	d := NewDevote(&params.DevoteConfig{SuperblockBlock: big.NewInt(0), SuperblockCycle: 4}, ethdb.NewMemDatabase())
+
+	// Without a budget source nothing is paid, and the block still finalizes
+	statedb := newState(1000)
+	if payments, err := d.payBudget(governance, statedb, header, big.NewInt(90)); err != nil || len(payments) != 0 {
+		t.Fatalf("no budget source: have %v, %v, want no payments", payments, err)
+	}
+	var cycle uint64
+	d.SuperblockBudget(func(address common.Address, c uint64, number *big.Int) ([]masternode.Budget, error) {
+		cycle = c
+		return budgets, nil
+	})
+	// A covered budget is paid in full, skipping empty amounts
+	payments, err := d.payBudget(governance, statedb, header, big.NewInt(90))
+	if err != nil {
+		t.Fatalf("failed to pay budget: %v", err)
+	}
+	if cycle != 8 {
+		t.Errorf("budget cycle mismatch: have %d, want 8", cycle)
+	}
+	if len(payments) != 2 {
+		t.Fatalf("payment count mismatch: have %d, want 2", len(payments))
+	}
+	for i, payment := range payments {
+		if payment.Kind != types.PaymentBudget || payment.From != governance || payment.To != payees[i] || payment.Amount.Cmp(budgets[i].Amount) != 0 {
+			t.Errorf("payment %d mismatch: have %+v, want %v to %x", i, payment, budgets[i].Amount, payees[i])
+		}
+		if balance := statedb.GetBalance(payees[i]); balance.Cmp(budgets[i].Amount) != 0 {
+			t.Errorf("payee %d balance mismatch: have %v, want %v", i, balance, budgets[i].Amount)
+		}
+	}
+	if fund := statedb.GetBalance(governance); fund.Cmp(big.NewInt(700)) != 0 {
+		t.Errorf("fund mismatch: have %v, want 700", fund)
+	}
+	// A fund short of the budget pays nothing
+	statedb = newState(299)
+	if payments, err := d.payBudget(governance, statedb, header, big.NewInt(90)); err != nil || len(payments) != 0 {
+		t.Fatalf("short fund: have %v, %v, want no payments", payments, err)
+	}
+	if fund := statedb.GetBalance(governance); fund.Cmp(big.NewInt(299)) != 0 {
+		t.Errorf("short fund touched: have %v, want 299", fund)
+	}
+}
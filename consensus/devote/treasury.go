@@ -0,0 +1,54 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+// TreasuryFn returns the treasury configured in the governance contract as seen
+// at the given block, or nil if there is none.
+type TreasuryFn func(governance common.Address, number *big.Int) (*masternode.Treasury, error)
+
+// Treasury sets the source of the governance treasury taking a cut of the
+// coinbase reward.
+func (d *Devote) Treasury(fn TreasuryFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.treasuryFn = fn
+}
+
+// treasury returns the treasury in effect for blocks sealed on top of the given
+// stable block. Like the community fund, it's read at the stable block so that
+// all nodes apply the same cut. Without a treasury source, as in light and test
+// setups, no cut is taken.
+func (d *Devote) treasury(governance common.Address, stable *big.Int) (*masternode.Treasury, error) {
+	d.mu.RLock()
+	treasuryFn := d.treasuryFn
+	d.mu.RUnlock()
+
+	if treasuryFn == nil {
+		log.Warn("Governance treasury unavailable, taking no cut", "stable", stable)
+		return nil, nil
+	}
+	return treasuryFn(governance, stable)
+}
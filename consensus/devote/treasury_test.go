@@ -0,0 +1,83 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the treasury cut is taken out of the coinbase reward, leaving the
+// community fund untouched.
+func TestTreasuryCut(t *testing.T) {
+	var (
+		coinbase   = common.Address{0x01}
+		governance = common.Address{0xff}
+		account    = common.Address{0x02}
+		config     = &params.DevoteConfig{Rewards: []params.RewardStage{{Block: big.NewInt(0), Reward: big.NewInt(1000), Community: 25}}}
+		header     = &types.Header{Number: big.NewInt(1), Coinbase: coinbase}
+	)
+	tests := []struct {
+		treasury            *masternode.Treasury
+		coinbase, treasured int64
+	}{
+		{nil, 750, 0},
+		{&masternode.Treasury{Account: account, Share: 10}, 675, 75},
+		{&masternode.Treasury{Account: account, Share: masternode.MaxTreasuryShare}, 600, 150},
+	}
+	for i, tt := range tests {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		payments, err := AccumulateRewards(config, governance, tt.treasury, statedb, header)
+		if err != nil {
+			t.Fatalf("test %d: failed to accumulate rewards: %v", i, err)
+		}
+		if balance := statedb.GetBalance(coinbase); balance.Int64() != tt.coinbase {
+			t.Errorf("test %d: coinbase reward mismatch: have %v, want %d", i, balance, tt.coinbase)
+		}
+		if balance := statedb.GetBalance(account); balance.Int64() != tt.treasured {
+			t.Errorf("test %d: treasury cut mismatch: have %v, want %d", i, balance, tt.treasured)
+		}
+		if balance := statedb.GetBalance(governance); balance.Int64() != 250 {
+			t.Errorf("test %d: community reward mismatch: have %v, want 250", i, balance)
+		}
+		want := 2
+		if tt.treasury != nil {
+			want++
+			if payments[0].Kind != types.PaymentTreasury || payments[0].To != account || payments[0].Amount.Int64() != tt.treasured {
+				t.Errorf("test %d: treasury payment mismatch: have %+v", i, payments[0])
+			}
+		}
+		if len(payments) != want {
+			t.Errorf("test %d: payment count mismatch: have %d, want %d", i, len(payments), want)
+		}
+	}
+}
+
+// Tests that blocks finalize without a treasury source, taking no cut.
+func TestTreasuryUnavailable(t *testing.T) {
+	d := NewDevote(&params.DevoteConfig{TreasuryBlock: big.NewInt(0)}, ethdb.NewMemDatabase())
+	if treasury, err := d.treasury(common.Address{0xff}, big.NewInt(1)); err != nil || treasury != nil {
+		t.Fatalf("no treasury source: have %v, %v, want no treasury", treasury, err)
+	}
+}
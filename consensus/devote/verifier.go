@@ -0,0 +1,175 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"runtime"
+
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. Besides the header fields, the witness signatures are recovered
+// (warming the signature cache for the seal verification on import) and the
+// slot assignment is checked wherever the witness list of the cycle is known,
+// either from the local devote state or from the epoch summaries in the batch.
+// The method returns a quit channel to abort the operations and a results
+// channel to retrieve the async verifications (the order is that of the input
+// slice).
+func (d *Devote) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort, results := make(chan struct{}), make(chan error, len(headers))
+	if len(headers) == 0 {
+		return abort, results
+	}
+	schedules := d.schedules(chain, headers)
+
+	// Spawn as many workers as allowed threads
+	workers := runtime.GOMAXPROCS(0)
+	if len(headers) < workers {
+		workers = len(headers)
+	}
+	var (
+		inputs = make(chan int)
+		done   = make(chan int, workers)
+		errors = make([]error, len(headers))
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for index := range inputs {
+				errors[index] = d.verifyHeaderWorker(chain, headers, seals, schedules, index)
+				done <- index
+			}
+		}()
+	}
+	go func() {
+		defer close(inputs)
+		var (
+			in, out = 0, 0
+			checked = make([]bool, len(headers))
+			inputs  = inputs
+		)
+		for {
+			select {
+			case inputs <- in:
+				if in++; in == len(headers) {
+					// Reached end of headers. Stop sending to workers.
+					inputs = nil
+				}
+			case index := <-done:
+				for checked[index] = true; checked[out]; out++ {
+					results <- errors[out]
+					if out == len(headers)-1 {
+						return
+					}
+				}
+			case <-abort:
+				return
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeaderWorker verifies a single header of a batch, including its witness
+// signature if requested.
+func (d *Devote) verifyHeaderWorker(chain consensus.ChainReader, headers []*types.Header, seals []bool, schedules map[uint64][]string, index int) error {
+	header := headers[index]
+	if err := d.verifyHeader(chain, header, headers[:index]); err != nil {
+		return err
+	}
+	if index >= len(seals) || !seals[index] {
+		return nil
+	}
+	signer, err := ecrecover(header, d.signatures)
+	if err != nil {
+		return err
+	}
+	if signer != header.Witness {
+		return ErrMismatchSignerAndWitness
+	}
+	// Witnesses are looked up in the cycle of the parent, see verifySeal
+	var parent *types.Header
+	if index > 0 {
+		parent = headers[index-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	witnesses := schedules[parent.Time.Uint64()/params.Epoch]
+	if len(witnesses) == 0 {
+		return nil // Schedule unknown, checked by the seal verification on import
+	}
+	return d.verifySlot(witnesses, header, signer)
+}
+
+// verifySlot checks the signer of a header against the witness list of its
+// cycle, as far as the list allows following the forks of verifySeal. Before
+// the standby fork only the witness scheduled for the slot may seal it. From
+// then on another signer may be a standby, whose turn only the seal
+// verification can tell. In the VRF mode any witness may seal a slot, its
+// eligibility proof being checked by the seal verification.
+func (d *Devote) verifySlot(witnesses []string, header *types.Header, signer string) error {
+	if d.config.IsVRF(header.Number) {
+		for _, witness := range witnesses {
+			if witness == signer {
+				return nil
+			}
+		}
+		return ErrWrongWitness
+	}
+	if slotWitness(witnesses, header.Time.Uint64()) == signer {
+		return nil
+	}
+	if d.config.IsStandby(header.Number) {
+		return nil // Possibly a standby, checked by the seal verification on import
+	}
+	return ErrWrongWitness
+}
+
+// schedules collects the witness lists of the cycles spanned by a batch of
+// headers. The list of the cycle the batch starts in is read from the local
+// devote state, the ones of later cycles from the epoch summaries opening them.
+// Cycles whose list can't be determined are missing from the result.
+func (d *Devote) schedules(chain consensus.ChainReader, headers []*types.Header) map[uint64][]string {
+	schedules := make(map[uint64][]string)
+
+	if parent := chain.GetHeader(headers[0].ParentHash, headers[0].Number.Uint64()-1); parent != nil {
+		if devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), parent.Protocol); err == nil {
+			cycle := parent.Time.Uint64() / params.Epoch
+			if witnesses, err := devoteDB.GetWitnesses(cycle); err == nil && len(witnesses) > 0 {
+				schedules[cycle] = witnesses
+			}
+		}
+	}
+	for _, header := range headers {
+		if !d.config.IsEpochSummary(header.Number) {
+			continue
+		}
+		if summary, err := DecodeEpochSummary(header); err == nil && summary != nil && summary.Cycle == header.Time.Uint64()/params.Epoch {
+			schedules[summary.Cycle] = summary.Witnesses
+		}
+	}
+	return schedules
+}
+
+// slotWitness returns the witness of the list scheduled to seal the slot at the
+// given time.
+func slotWitness(witnesses []string, slot uint64) string {
+	return witnesses[(slot%params.Epoch/params.Period)%uint64(len(witnesses))]
+}
@@ -0,0 +1,352 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	bn256 "github.com/etherzero/go-etherzero/crypto/bn256/cloudflare"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// The VRF is a BLS signature over the alt_bn128 curve: the proof of a message is
+// the secret key times the message hashed onto G1, verified against the public
+// key on G2 by a pairing check. BLS signatures are unique, so a witness can't
+// pick among several outputs. Proofs are carried compressed in the mix digest.
+
+var (
+	// vrfKeyPrefix is signed by the witnesses to derive their VRF secret keys.
+	vrfKeyPrefix = []byte("devote-vrf-key")
+
+	// vrfMessagePrefix domain separates the messages proven for the slots.
+	vrfMessagePrefix = []byte("devote-vrf")
+
+	// errVRFKeyUnregistered is returned if the signer of a block has no valid
+	// VRF key registered in the governance contract.
+	errVRFKeyUnregistered = errors.New("vrf key not registered")
+
+	// errVRFKeyMismatch is returned if the VRF key of the local signer differs
+	// from the one it registered in the governance contract.
+	errVRFKeyMismatch = errors.New("local vrf key differs from the registered one")
+
+	// errInvalidVRFProof is returned if the mix digest of a block isn't a valid
+	// VRF proof of its slot by the signer.
+	errInvalidVRFProof = errors.New("invalid vrf proof")
+
+	// errNotEligible is returned if the VRF output of the signer of a block
+	// doesn't make it eligible for the slot.
+	errNotEligible = errors.New("witness not eligible for slot")
+)
+
+// VRFKeyFn returns the VRF public key registered in the governance contract by
+// a masternode as seen at the given block, nil if none was registered.
+type VRFKeyFn func(governance common.Address, id string, number *big.Int) ([]byte, error)
+
+// VRFKeys sets the source of the VRF public keys of the masternodes.
+func (d *Devote) VRFKeys(fn VRFKeyFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.vrfKeyFn = fn
+}
+
+// curveY returns the non-negative root y of y² = x³ + 3 on alt_bn128, or nil if
+// x isn't the abscissa of a point.
+func curveY(x *big.Int) *big.Int {
+	rhs := new(big.Int).Exp(x, big.NewInt(3), bn256.P)
+	rhs.Add(rhs, big.NewInt(3))
+	rhs.Mod(rhs, bn256.P)
+	return new(big.Int).ModSqrt(rhs, bn256.P)
+}
+
+// hashToG1 maps msg onto G1 by try-and-increment, so that nobody knows its
+// discrete logarithm.
+func hashToG1(msg []byte) *bn256.G1 {
+	ctr := make([]byte, 4)
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(ctr, i)
+		x := new(big.Int).SetBytes(crypto.Keccak256(msg, ctr))
+		x.Mod(x, bn256.P)
+
+		y := curveY(x)
+		if y == nil {
+			continue
+		}
+		point := new(bn256.G1)
+		if _, err := point.Unmarshal(append(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32)...)); err == nil {
+			return point
+		}
+	}
+}
+
+// compressG1 encodes a point of G1 as its abscissa, the top bit holding the
+// parity of its ordinate. The field modulus leaves the top two bits unused.
+func compressG1(point *bn256.G1) common.Hash {
+	enc := point.Marshal()
+
+	compressed := common.BytesToHash(enc[:32])
+	if enc[63]&1 == 1 {
+		compressed[0] |= 0x80
+	}
+	return compressed
+}
+
+// decompressG1 decodes a point of G1 encoded by compressG1.
+func decompressG1(compressed common.Hash) (*bn256.G1, error) {
+	odd := compressed[0]&0x80 != 0
+	compressed[0] &^= 0x80
+
+	x := new(big.Int).SetBytes(compressed[:])
+	if x.Sign() == 0 || x.Cmp(bn256.P) >= 0 {
+		return nil, errInvalidVRFProof
+	}
+	y := curveY(x)
+	if y == nil {
+		return nil, errInvalidVRFProof
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(bn256.P, y)
+	}
+	point := new(bn256.G1)
+	if _, err := point.Unmarshal(append(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32)...)); err != nil {
+		return nil, errInvalidVRFProof
+	}
+	return point, nil
+}
+
+// vrfMessage returns the message proven for the slot at the given time, bound
+// to the beacon of the cycle before the slot so that the assignments can't be
+// computed ahead of it.
+func vrfMessage(beacon common.Hash, slot uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, slot)
+	return crypto.Keccak256(vrfMessagePrefix, beacon[:], enc)
+}
+
+// vrfProve returns the compressed VRF proof of msg under the secret key.
+func vrfProve(secret *big.Int, msg []byte) common.Hash {
+	return compressG1(new(bn256.G1).ScalarMult(hashToG1(msg), secret))
+}
+
+// vrfVerify checks the compressed proof of msg against the public key.
+func vrfVerify(key *bn256.G2, msg []byte, proof common.Hash) error {
+	point, err := decompressG1(proof)
+	if err != nil {
+		return err
+	}
+	hash := new(bn256.G1).Neg(hashToG1(msg))
+	base := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+
+	if !bn256.PairingCheck([]*bn256.G1{point, hash}, []*bn256.G2{base, key}) {
+		return errInvalidVRFProof
+	}
+	return nil
+}
+
+// vrfEligible reports whether the output of a proof makes one of size witnesses
+// eligible for its slot, leaders of them being expected to be.
+func vrfEligible(proof common.Hash, size int, leaders uint64) bool {
+	if size == 0 {
+		return false
+	}
+	output := crypto.Keccak256(proof[:])
+	return binary.BigEndian.Uint64(output[:8])%uint64(size) < leaders
+}
+
+// vrfSecret derives the VRF secret key of the local signer by signing, so that
+// it's never stored and follows the masternode key.
+func (d *Devote) vrfSecret() (*big.Int, error) {
+	d.lock.RLock()
+	signer, signFn := d.signer, d.signFn
+	if d.vrfSigner == signer && d.vrfSecretKey != nil {
+		secret := d.vrfSecretKey
+		d.lock.RUnlock()
+		return secret, nil
+	}
+	d.lock.RUnlock()
+
+	if signFn == nil {
+		return nil, errors.New("no local signer")
+	}
+	sig, err := signFn(signer, crypto.Keccak256(vrfKeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+	secret := new(big.Int).SetBytes(crypto.Keccak256(sig))
+	secret.Mod(secret, bn256.Order)
+
+	d.lock.Lock()
+	d.vrfSigner, d.vrfSecretKey = signer, secret
+	d.lock.Unlock()
+
+	return secret, nil
+}
+
+// VRFPublicKey returns the VRF public key of the local signer, to be registered
+// in the governance contract before the VRF fork.
+func (d *Devote) VRFPublicKey() ([]byte, error) {
+	secret, err := d.vrfSecret()
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G2).ScalarBaseMult(secret).Marshal(), nil
+}
+
+// vrfKey returns the VRF public key registered by the witness as seen at the
+// stable block of parent.
+func (d *Devote) vrfKey(chain consensus.ChainReader, parent *types.Header, witness string) ([]byte, error) {
+	d.mu.RLock()
+	vrfKeyFn := d.vrfKeyFn
+	d.mu.RUnlock()
+
+	if vrfKeyFn == nil {
+		return nil, errVRFKeyUnregistered
+	}
+	stable := stableNumber(chain, parent)
+	governance, err := d.governanceContractAddressFn(stable)
+	if err != nil {
+		return nil, err
+	}
+	key, err := vrfKeyFn(governance, witness, stable)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, errVRFKeyUnregistered
+	}
+	return key, nil
+}
+
+// vrfSlot returns the witnesses of the cycle recorded by the last block and the
+// message proven for the slot at the given time on top of it.
+func (d *Devote) vrfSlot(lastBlock *types.Header, slot uint64) ([]string, []byte, error) {
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), lastBlock.Protocol)
+	if err != nil {
+		return nil, nil, err
+	}
+	witnesses, err := devoteDB.GetWitnesses(lastBlock.Time.Uint64() / params.Epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	var beacon common.Hash
+	if cycle := slot / params.Epoch; cycle > 0 {
+		if beacon, err = devoteDB.GetBeacon(cycle - 1); err != nil {
+			return nil, nil, err
+		}
+	}
+	return witnesses, vrfMessage(beacon, slot), nil
+}
+
+// vrfProof returns the VRF proof of the local signer for the slot at the given
+// time on top of the last block, and whether it makes the signer eligible.
+func (d *Devote) vrfProof(lastBlock *types.Header, slot uint64) (common.Hash, bool, error) {
+	witnesses, msg, err := d.vrfSlot(lastBlock, slot)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	d.lock.RLock()
+	signer := d.signer
+	d.lock.RUnlock()
+
+	elected := false
+	for _, witness := range witnesses {
+		if witness == signer {
+			elected = true
+			break
+		}
+	}
+	if !elected {
+		return common.Hash{}, false, nil
+	}
+	secret, err := d.vrfSecret()
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	proof := vrfProve(secret, msg)
+	return proof, vrfEligible(proof, len(witnesses), d.config.VRFLeaders), nil
+}
+
+// checkVRFKey ensures the VRF key of the local signer is the one registered in
+// the governance contract, so that no block is sealed to be rejected.
+func (d *Devote) checkVRFKey(chain consensus.ChainReader, parent *types.Header) error {
+	d.lock.RLock()
+	signer := d.signer
+	d.lock.RUnlock()
+
+	registered, err := d.vrfKey(chain, parent, signer)
+	if err != nil {
+		return err
+	}
+	local, err := d.VRFPublicKey()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(registered, local) {
+		return errVRFKeyMismatch
+	}
+	return nil
+}
+
+// vrfSealer returns the signer of the header if it was allowed to seal its slot
+// in the VRF mode: it's a witness of the cycle, the mix digest holds its proof
+// for the slot under its registered key and the proof makes it eligible.
+func (d *Devote) vrfSealer(chain consensus.ChainReader, parent, header *types.Header) (string, error) {
+	signer, err := ecrecover(header, d.signatures)
+	if err != nil {
+		return "", err
+	}
+	witnesses, msg, err := d.vrfSlot(parent, header.Time.Uint64())
+	if err != nil {
+		return "", err
+	}
+	elected := false
+	for _, witness := range witnesses {
+		if witness == signer {
+			elected = true
+			break
+		}
+	}
+	if !elected {
+		return "", ErrWrongWitness
+	}
+	enc, err := d.vrfKey(chain, parent, signer)
+	if err != nil {
+		return "", err
+	}
+	key := new(bn256.G2)
+	if _, err := key.Unmarshal(enc); err != nil {
+		return "", errVRFKeyUnregistered
+	}
+	if err := vrfVerify(key, msg, header.MixDigest); err != nil {
+		return "", err
+	}
+	if !vrfEligible(header.MixDigest, len(witnesses), d.config.VRFLeaders) {
+		return "", errNotEligible
+	}
+	if header.Difficulty.Uint64() != diffInTurn {
+		return "", errInvalidDifficulty
+	}
+	return signer, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	bn256 "github.com/etherzero/go-etherzero/crypto/bn256/cloudflare"
+)
+
+// Tests that VRF proofs verify under the key they were made with only, and that
+// they survive the compression into the mix digest.
+func TestVRF(t *testing.T) {
+	secret := big.NewInt(0x1234567)
+	key := new(bn256.G2).ScalarBaseMult(secret)
+	other := new(bn256.G2).ScalarBaseMult(big.NewInt(0x7654321))
+
+	msg := vrfMessage(common.HexToHash("0x01"), 600)
+	proof := vrfProve(secret, msg)
+
+	point, err := decompressG1(proof)
+	if err != nil {
+		t.Fatalf("failed to decompress proof: %v", err)
+	}
+	if want := new(bn256.G1).ScalarMult(hashToG1(msg), secret); !bytes.Equal(point.Marshal(), want.Marshal()) {
+		t.Errorf("decompressed proof mismatch: have %x, want %x", point.Marshal(), want.Marshal())
+	}
+	if err := vrfVerify(key, msg, proof); err != nil {
+		t.Errorf("valid proof rejected: %v", err)
+	}
+	if err := vrfVerify(other, msg, proof); err != errInvalidVRFProof {
+		t.Errorf("proof under other key: have %v, want %v", err, errInvalidVRFProof)
+	}
+	if err := vrfVerify(key, vrfMessage(common.HexToHash("0x01"), 601), proof); err != errInvalidVRFProof {
+		t.Errorf("proof of other slot: have %v, want %v", err, errInvalidVRFProof)
+	}
+	if err := vrfVerify(key, msg, common.Hash{}); err != errInvalidVRFProof {
+		t.Errorf("empty proof: have %v, want %v", err, errInvalidVRFProof)
+	}
+	if vrfProve(secret, msg) != proof {
+		t.Errorf("proof not unique")
+	}
+}
+
+// Tests that about leaders out of the witnesses are eligible for each slot.
+func TestVRFEligible(t *testing.T) {
+	const (
+		size  = 21
+		slots = 2000
+	)
+	secret := big.NewInt(42)
+
+	eligible := 0
+	for slot := uint64(0); slot < slots; slot++ {
+		if vrfEligible(vrfProve(secret, vrfMessage(common.Hash{}, slot)), size, 1) {
+			eligible++
+		}
+	}
+	if want := slots / size; eligible < want/2 || eligible > want*2 {
+		t.Errorf("eligible slots off: have %d, want about %d", eligible, want)
+	}
+	if vrfEligible(common.Hash{}, 0, 1) {
+		t.Errorf("eligible without witnesses")
+	}
+}
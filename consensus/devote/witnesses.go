@@ -0,0 +1,98 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// legacyWitnesses is the number of witnesses elected per cycle on the main
+// network before the witnesses fork. Other networks elected a single witness.
+const legacyWitnesses = 21
+
+// MaxWitnessesFn returns the number of witnesses per cycle set in the governance
+// contract as seen at the given block, zero if it's left to the chain config.
+type MaxWitnessesFn func(governance common.Address, number *big.Int) (uint64, error)
+
+// MaxWitnesses sets the source of the governance override of the number of
+// witnesses elected per cycle.
+func (d *Devote) MaxWitnesses(fn MaxWitnessesFn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.maxWitnessesFn = fn
+}
+
+// witnessQuorum returns the number of distinct witnesses out of size needed to
+// confirm a block, which is also the fewest candidates a cycle is elected from.
+func witnessQuorum(size uint64) int {
+	return int(size*2/3 + 1)
+}
+
+// maxWitnesses returns the number of witnesses elected for the cycle opened by
+// header. From the witnesses fork it's the number set in the governance contract
+// at the stable block, falling back to the chain config if none is set.
+func (d *Devote) maxWitnesses(chain consensus.ChainReader, header *types.Header, governance common.Address, stable *big.Int) (uint64, error) {
+	if !d.config.IsWitnesses(header.Number) {
+		if chain.Config().ChainID.Cmp(big.NewInt(90)) != 0 {
+			return 1, nil
+		}
+		return legacyWitnesses, nil
+	}
+	d.mu.RLock()
+	maxWitnessesFn := d.maxWitnessesFn
+	d.mu.RUnlock()
+
+	if maxWitnessesFn != nil {
+		size, err := maxWitnessesFn(governance, stable)
+		if err != nil {
+			return 0, err
+		}
+		if size > 0 {
+			return size, nil
+		}
+	}
+	return d.config.MaxWitnesses, nil
+}
+
+// consensusSize returns the number of distinct witnesses which must seal on top
+// of a block in the cycle of header for it to be confirmed. From the witnesses
+// fork it's the quorum of the witnesses actually elected for the cycle.
+func (d *Devote) consensusSize(chain consensus.ChainReader, header *types.Header) int {
+	legacy := witnessQuorum(legacyWitnesses)
+	if chain.Config().ChainID.Cmp(big.NewInt(90)) != 0 {
+		legacy = 1
+	}
+	if !d.config.IsWitnesses(header.Number) {
+		return legacy
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(d.db), header.Protocol)
+	if err != nil {
+		return witnessQuorum(d.config.MaxWitnesses)
+	}
+	witnesses, err := devoteDB.GetWitnesses(header.Time.Uint64() / params.Epoch)
+	if err != nil || len(witnesses) == 0 {
+		return witnessQuorum(d.config.MaxWitnesses)
+	}
+	return witnessQuorum(uint64(len(witnesses)))
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// configChain is a chain reader only providing the chain config.
+type configChain struct {
+	consensus.ChainReader
+	config *params.ChainConfig
+}
+
+func (c *configChain) Config() *params.ChainConfig { return c.config }
+
+// Tests that the number of witnesses per cycle stays at the legacy size before
+// the witnesses fork and follows the governance contract after it, falling back
+// to the chain config.
+func TestMaxWitnesses(t *testing.T) {
+	config := &params.DevoteConfig{WitnessesBlock: big.NewInt(100), MaxWitnesses: 31}
+	chain := &configChain{config: &params.ChainConfig{ChainID: big.NewInt(90), Devote: config}}
+	d := &Devote{config: config}
+
+	governed, failure := uint64(0), error(nil)
+	d.MaxWitnesses(func(governance common.Address, number *big.Int) (uint64, error) {
+		return governed, failure
+	})
+	tests := []struct {
+		number   int64
+		governed uint64
+		size     uint64
+		quorum   int
+	}{
+		{99, 51, legacyWitnesses, 15},
+		{100, 0, 31, 21},
+		{100, 51, 51, 35},
+	}
+	for i, tt := range tests {
+		governed = tt.governed
+		size, err := d.maxWitnesses(chain, &types.Header{Number: big.NewInt(tt.number)}, common.Address{}, big.NewInt(tt.number-21))
+		if err != nil {
+			t.Fatalf("test %d: failed to get max witnesses: %v", i, err)
+		}
+		if size != tt.size {
+			t.Errorf("test %d: size mismatch: have %d, want %d", i, size, tt.size)
+		}
+		if quorum := witnessQuorum(size); quorum != tt.quorum {
+			t.Errorf("test %d: quorum mismatch: have %d, want %d", i, quorum, tt.quorum)
+		}
+	}
+	failure = errors.New("no governance")
+	if _, err := d.maxWitnesses(chain, &types.Header{Number: big.NewInt(100)}, common.Address{}, big.NewInt(79)); err != failure {
+		t.Errorf("error mismatch: have %v, want %v", err, failure)
+	}
+	chain.config.ChainID = big.NewInt(1)
+	if size, _ := d.maxWitnesses(chain, &types.Header{Number: big.NewInt(99)}, common.Address{}, big.NewInt(98)); size != 1 {
+		t.Errorf("test network size mismatch: have %d, want 1", size)
+	}
+}
@@ -19,10 +19,11 @@ package core
 import (
 	"fmt"
 	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/params"
-	"log"
 )
 
 // BlockValidator is responsible for validating block headers, uncles and
@@ -105,11 +106,11 @@ func (v *BlockValidator) ValidateDevoteState(block *types.Block) error {
 	header := block.Header()
 	localRoot := block.DevoteDB.Root()
 	remoteRoot := header.Protocol.Root()
-	if remoteRoot != localRoot{
-		log.Printf("StatsHash block hash:%x header: hash:%x \n", block.DevoteDB.Protocol().StatsHash, header.Protocol.StatsHash)
-		log.Printf("Cycle block hash:%x header:  hash:%x \n", block.DevoteDB.Protocol().CycleHash, header.Protocol.CycleHash)
-		log.Printf("invalid devote blockNumber %d ,root (remote: %x local: %x)", block.Number(),remoteRoot, localRoot)
-		return fmt.Errorf("invalid devote root (remote: %x local: %x)", remoteRoot, localRoot)
+	if remoteRoot != localRoot {
+		log.Warn("Invalid devote root", "number", block.Number(), "hash", block.Hash(), "remote", remoteRoot, "local", localRoot,
+			"remoteStats", header.Protocol.StatsHash, "localStats", block.DevoteDB.Protocol().StatsHash,
+			"remoteCycle", header.Protocol.CycleHash, "localCycle", block.DevoteDB.Protocol().CycleHash)
+		return devote.ErrBadDevoteRoot
 	}
 	return nil
 }
@@ -77,6 +77,7 @@ type CacheConfig struct {
 	TrieCleanLimit int           // Memory allowance (MB) to use for caching trie nodes in memory
 	TrieDirtyLimit int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+	TriesInMemory  uint64        // Number of recent block states kept before pruning (0 = triesInMemory)
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -107,6 +108,7 @@ type BlockChain struct {
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -151,6 +153,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			TrieTimeLimit:  5 * time.Minute,
 		}
 	}
+	if cacheConfig.TriesInMemory == 0 {
+		cacheConfig.TriesInMemory = triesInMemory
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	receiptsCache, _ := lru.New(receiptsCacheLimit)
@@ -369,6 +374,16 @@ func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.processor = processor
 }
 
+// SetParallelExecution sets the number of threads the state processor uses to
+// execute independent transactions concurrently (0 = serial execution).
+func (bc *BlockChain) SetParallelExecution(threads int) {
+	bc.procmu.Lock()
+	defer bc.procmu.Unlock()
+	if processor, ok := bc.processor.(*StateProcessor); ok {
+		processor.SetParallel(threads)
+	}
+}
+
 // SetValidator sets the validator which is used to validate incoming blocks.
 func (bc *BlockChain) SetValidator(validator Validator) {
 	bc.procmu.Lock()
@@ -463,7 +478,9 @@ func (bc *BlockChain) Export(w io.Writer) error {
 	return bc.ExportN(w, uint64(0), bc.CurrentBlock().NumberU64())
 }
 
-// ExportN writes a subset of the active chain to the given writer.
+// ExportN writes a subset of the active chain to the given writer. On devote
+// chains the devote tries needed to validate the first block and every block
+// opening a cycle are exported before them.
 func (bc *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
@@ -473,15 +490,35 @@ func (bc *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 	}
 	log.Info("Exporting batch of blocks", "count", last-first+1)
 
+	if err := rlp.Encode(w, &exportMarker{Tag: exportMarkerTag, Version: ExportVersion}); err != nil {
+		return err
+	}
+	var (
+		seen   = make(map[common.Hash]struct{})
+		parent *types.Header
+	)
+	if first > 0 {
+		parent = bc.GetHeaderByNumber(first - 1)
+	}
 	start, reported := time.Now(), time.Now()
 	for nr := first; nr <= last; nr++ {
 		block := bc.GetBlockByNumber(nr)
 		if block == nil {
 			return fmt.Errorf("export failed on #%d: not found", nr)
 		}
+		if bc.chainConfig.Devote != nil && parent != nil && parent.Protocol != nil && (nr == first || opensCycle(parent, block.Header())) {
+			snapshot, err := bc.devoteSnapshot(parent, seen)
+			if err != nil {
+				return fmt.Errorf("export failed on #%d devote tries: %v", parent.Number, err)
+			}
+			if err := rlp.Encode(w, snapshot); err != nil {
+				return err
+			}
+		}
 		if err := block.EncodeRLP(w); err != nil {
 			return err
 		}
+		parent = block.Header()
 		if time.Since(reported) >= statsReportLimit {
 			log.Info("Exporting blocks", "exported", block.NumberU64()-first, "elapsed", common.PrettyDuration(time.Since(start)))
 			reported = time.Now()
@@ -700,11 +737,11 @@ func (bc *BlockChain) Stop() {
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
-	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
+	//  - HEAD-N+1: So we have a hard limit on the number of blocks reexecuted
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 
-		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+		for _, offset := range []uint64{0, 1, bc.cacheConfig.TriesInMemory - 1} {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
 				recent := bc.GetBlockByNumber(number - offset)
 
@@ -964,7 +1001,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 		bc.triegc.Push(root, -int64(block.NumberU64()))
 
-		if current := block.NumberU64(); current > triesInMemory {
+		if current := block.NumberU64(); current > bc.cacheConfig.TriesInMemory {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
@@ -974,15 +1011,15 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Cap(limit - ethdb.IdealBatchSize)
 			}
 			// Find the next state trie we need to commit
-			header := bc.GetHeaderByNumber(current - triesInMemory)
+			header := bc.GetHeaderByNumber(current - bc.cacheConfig.TriesInMemory)
 			chosen := header.Number.Uint64()
 
 			// If we exceeded out time allowance, flush an entire trie to disk
 			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
 				// If we're exceeding limits but haven't reached a large enough memory gap,
 				// warn the user that the system is becoming unstable.
-				if chosen < lastWrite+triesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/triesInMemory)
+				if chosen < lastWrite+bc.cacheConfig.TriesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
+					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/float64(bc.cacheConfig.TriesInMemory))
 				}
 				// Flush an entire trie and restart the counters
 				triedb.Commit(header.Root, true)
@@ -1004,6 +1041,11 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Write other block data using a batch.
 	batch := bc.db.NewBatch()
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	if engine, ok := bc.engine.(consensus.SystemReceipter); ok {
+		if receipt := engine.SystemReceipt(block.Header()); receipt != nil {
+			rawdb.WriteSystemReceipt(batch, block.Hash(), block.NumberU64(), receipt)
+		}
+	}
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1520,6 +1562,20 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			}
 		}()
 	}
+	if len(oldChain) > 0 && len(newChain) > 0 {
+		ev := ReorgEvent{
+			Common:   commonBlock.Header(),
+			OldChain: make([]*types.Header, len(oldChain)),
+			NewChain: make([]*types.Header, len(newChain)),
+		}
+		for i, block := range oldChain {
+			ev.OldChain[i] = block.Header()
+		}
+		for i, block := range newChain {
+			ev.NewChain[i] = block.Header()
+		}
+		go bc.reorgFeed.Send(ev)
+	}
 
 	return nil
 }
@@ -1563,22 +1619,41 @@ func (bc *BlockChain) update() {
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
 	for _, hash := range bc.badBlocks.Keys() {
-		if blk, exist := bc.badBlocks.Peek(hash); exist {
-			block := blk.(*types.Block)
-			blocks = append(blocks, block)
+		if bad, exist := bc.badBlocks.Peek(hash); exist {
+			blocks = append(blocks, bad.(*badBlock).block)
 		}
 	}
 	return blocks
 }
 
+// BadBlockError returns the error a bad block was rejected with, or nil if the
+// block isn't known to be bad.
+func (bc *BlockChain) BadBlockError(hash common.Hash) error {
+	if bad, exist := bc.badBlocks.Peek(hash); exist {
+		return bad.(*badBlock).err
+	}
+	return nil
+}
+
+// badBlock is a block rejected by the chain along with the reason why.
+type badBlock struct {
+	block *types.Block
+	err   error
+}
+
 // addBadBlock adds a bad block to the bad-block LRU cache
-func (bc *BlockChain) addBadBlock(block *types.Block) {
-	bc.badBlocks.Add(block.Hash(), block)
+func (bc *BlockChain) addBadBlock(block *types.Block, err error) {
+	bc.badBlocks.Add(block.Hash(), &badBlock{block: block, err: err})
 }
 
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
-	bc.addBadBlock(block)
+	bc.addBadBlock(block, err)
+
+	if reason := devote.ErrorReason(err); reason != "" {
+		log.Error("Rejected block violating devote consensus", "number", block.Number(), "hash", block.Hash(),
+			"witness", block.Header().Witness, "reason", reason, "err", err)
+	}
 
 	var receiptString string
 	for i, receipt := range receipts {
@@ -1722,6 +1797,11 @@ func (bc *BlockChain) SubscribeRemovedLogsEvent(ch chan<- RemovedLogsEvent) even
 	return bc.scope.Track(bc.rmLogsFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeChainEvent registers a subscription of ChainEvent.
 func (bc *BlockChain) SubscribeChainEvent(ch chan<- ChainEvent) event.Subscription {
 	return bc.scope.Track(bc.chainFeed.Subscribe(ch))
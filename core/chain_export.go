@@ -0,0 +1,201 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rlp"
+	"github.com/etherzero/go-etherzero/trie"
+)
+
+// ExportVersion is the version of the chain export format. Version 1 files are
+// a plain sequence of RLP blocks. Version 2 files start with an export marker and
+// interleave the blocks with the devote snapshots needed to validate them, so a
+// chain can be imported into a database lacking the devote tries of its parent.
+const ExportVersion = 2
+
+const (
+	exportMarkerTag   = "etzexport"
+	devoteSnapshotTag = "devote"
+)
+
+var errExportItem = errors.New("unknown chain export item")
+
+// exportMarker opens a versioned chain export. Blocks are RLP lists starting
+// with their header, so items starting with a string tag are told apart.
+type exportMarker struct {
+	Tag     string
+	Version uint64
+}
+
+// DevoteSnapshot carries the devote trie nodes recorded by a block, needed to
+// validate the devote roots of its children. Snapshots of unified devote tries
+// carry the nodes of the legacy tries they still read from. Nodes already exported by earlier
+// snapshots of the same export are left out.
+type DevoteSnapshot struct {
+	Tag      string
+	Number   uint64
+	Hash     common.Hash
+	Protocol devotedb.DevoteProtocol
+	Nodes    [][]byte
+}
+
+// devoteSnapshot collects the devote trie nodes recorded by the header, skipping
+// the subtries rooted at nodes in seen and adding the collected ones to it.
+func (bc *BlockChain) devoteSnapshot(header *types.Header, seen map[common.Hash]struct{}) (*DevoteSnapshot, error) {
+	snapshot := &DevoteSnapshot{
+		Tag:      devoteSnapshotTag,
+		Number:   header.Number.Uint64(),
+		Hash:     header.Hash(),
+		Protocol: *header.Protocol,
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(bc.db), header.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	triedb := trie.NewDatabase(bc.db)
+	for _, root := range devoteDB.TrieRoots() {
+		t, err := trie.New(root, triedb)
+		if err != nil {
+			return nil, err
+		}
+		it := t.NodeIterator(nil)
+		for descend := true; it.Next(descend); {
+			descend = true
+
+			hash := it.Hash()
+			if hash == (common.Hash{}) {
+				continue // Embedded node or value
+			}
+			if _, ok := seen[hash]; ok {
+				descend = false
+				continue
+			}
+			blob, err := triedb.Node(hash)
+			if err != nil {
+				return nil, err
+			}
+			seen[hash] = struct{}{}
+			snapshot.Nodes = append(snapshot.Nodes, blob)
+		}
+		if it.Error() != nil {
+			return nil, it.Error()
+		}
+	}
+	return snapshot, nil
+}
+
+// opensCycle reports whether the header is the first one of a devote cycle.
+func opensCycle(parent, header *types.Header) bool {
+	return parent.Time.Uint64()/params.Epoch != header.Time.Uint64()/params.Epoch
+}
+
+// ImportDevoteSnapshot writes the devote trie nodes of an exported snapshot into
+// the database, checking every node against its hash.
+func (bc *BlockChain) ImportDevoteSnapshot(snapshot *DevoteSnapshot) error {
+	batch := bc.db.NewBatch()
+	for _, blob := range snapshot.Nodes {
+		if err := batch.Put(crypto.Keccak256(blob), blob); err != nil {
+			return err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(bc.db), &snapshot.Protocol)
+	if err != nil {
+		return fmt.Errorf("devote snapshot of #%d incomplete: %v", snapshot.Number, err)
+	}
+	triedb := trie.NewDatabase(bc.db)
+	for _, root := range devoteDB.TrieRoots() {
+		if _, err := trie.New(root, triedb); err != nil {
+			return fmt.Errorf("devote snapshot of #%d incomplete: %v", snapshot.Number, err)
+		}
+	}
+	return nil
+}
+
+// ExportReader reads the items of a chain export of any version.
+type ExportReader struct {
+	stream  *rlp.Stream
+	version uint64
+}
+
+// NewExportReader creates a reader of the chain export in r.
+func NewExportReader(r io.Reader) *ExportReader {
+	return &ExportReader{stream: rlp.NewStream(r, 0), version: 1}
+}
+
+// Version returns the export version read so far.
+func (r *ExportReader) Version() uint64 {
+	return r.version
+}
+
+// Next returns the next block or devote snapshot of the export, io.EOF once
+// there are no more.
+func (r *ExportReader) Next() (*types.Block, *DevoteSnapshot, error) {
+	for {
+		raw, err := r.stream.Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+		content, _, err := rlp.SplitList(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		kind, tag, _, err := rlp.Split(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		if kind == rlp.List {
+			block := new(types.Block)
+			if err := rlp.DecodeBytes(raw, block); err != nil {
+				return nil, nil, err
+			}
+			return block, nil, nil
+		}
+		switch string(tag) {
+		case exportMarkerTag:
+			var marker exportMarker
+			if err := rlp.DecodeBytes(raw, &marker); err != nil {
+				return nil, nil, err
+			}
+			if marker.Version > ExportVersion {
+				return nil, nil, fmt.Errorf("unsupported chain export version %d", marker.Version)
+			}
+			r.version = marker.Version
+
+		case devoteSnapshotTag:
+			snapshot := new(DevoteSnapshot)
+			if err := rlp.DecodeBytes(raw, snapshot); err != nil {
+				return nil, nil, err
+			}
+			return nil, snapshot, nil
+
+		default:
+			return nil, nil, errExportItem
+		}
+	}
+}
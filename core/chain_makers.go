@@ -245,13 +245,22 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 			Difficulty: parent.Difficulty(),
 			UncleHash:  parent.UncleHash(),
 		}),
-		Protocol:   &devotedb.DevoteProtocol{},
+		Protocol:   parentProtocol(parent),
 		GasLimit: CalcGasLimit(parent),
 		Number:   new(big.Int).Add(parent.Number(), common.Big1),
 		Time:     time,
 	}
 }
 
+// parentProtocol returns the devote protocol of the parent block, which engines
+// other than devote carry over unchanged.
+func parentProtocol(parent *types.Block) *devotedb.DevoteProtocol {
+	if protocol := parent.Header().Protocol; protocol != nil {
+		return protocol
+	}
+	return &devotedb.DevoteProtocol{}
+}
+
 // makeHeaderChain creates a deterministic chain of headers rooted at parent.
 func makeHeaderChain(parent *types.Header, n int, engine consensus.Engine, db ethdb.Database, seed int) []*types.Header {
 	blocks := makeBlockChain(types.NewBlockWithHeader(parent), n, engine, db, seed)
@@ -49,3 +49,11 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain is reorganised, with the headers
+// of the dropped and of the added blocks, newest first, and of their ancestor.
+type ReorgEvent struct {
+	Common   *types.Header
+	OldChain []*types.Header
+	NewChain []*types.Header
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/core/vm"
 )
@@ -48,6 +49,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		CanTransfer: CanTransfer,
 		Transfer:    Transfer,
 		GetHash:     GetHashFn(header, chain),
+		Witnesses:   WitnessesFn(header, chain),
 		Origin:      msg.From(),
 		Coinbase:    beneficiary,
 		BlockNumber: new(big.Int).Set(header.Number),
@@ -55,6 +57,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		Difficulty:  new(big.Int).Set(header.Difficulty),
 		GasLimit:    header.GasLimit,
 		GasPrice:    new(big.Int).Set(msg.GasPrice()),
+		Witness:     header.Witness,
 	}
 }
 
@@ -84,6 +87,34 @@ func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash
 	}
 }
 
+// WitnessesFn returns a WitnessesFunc which retrieves the witnesses recorded by
+// the parent of ref, if the chain runs the devote consensus.
+func WitnessesFn(ref *types.Header, chain ChainContext) func() ([]string, error) {
+	var (
+		witnesses []string
+		err       error
+		done      bool
+	)
+	return func() ([]string, error) {
+		if done {
+			return witnesses, err
+		}
+		done = true
+
+		engine, ok := chain.Engine().(*devote.Devote)
+		if !ok {
+			return nil, nil
+		}
+		parent := chain.GetHeader(ref.ParentHash, ref.Number.Uint64()-1)
+		if parent == nil {
+			err = consensus.ErrUnknownAncestor
+			return nil, err
+		}
+		witnesses, err = engine.Witnesses(parent)
+		return witnesses, err
+	}
+}
+
 // CanTransfer checks whether there are enough funds in the address' account to make a transfer.
 // This does not take the necessary gas in to account to make the transfer valid.
 func CanTransfer(db vm.StateDB, addr common.Address, amount *big.Int) bool {
@@ -235,7 +235,7 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	}
 
 	statedb, _ := state.New(g.StateRoot, state.NewDatabase(db))
-	for addr, account := range g.Alloc {
+	for addr, account := range g.systemAlloc() {
 		statedb.AddBalance(addr, account.Balance, big.NewInt(1))
 		statedb.SetCode(addr, account.Code)
 		statedb.SetNonce(addr, account.Nonce)
@@ -317,6 +317,24 @@ func GenesisBlockForTesting(db ethdb.Database, addr common.Address, balance *big
 	return g.MustCommit(db)
 }
 
+// systemAlloc returns the genesis allocation extended with the system contracts
+// the chain config deploys. Accounts allocated explicitly at the address of a
+// system contract take precedence over the generated one.
+func (g *Genesis) systemAlloc() GenesisAlloc {
+	if g.Config == nil || g.Config.Devote == nil || !g.Config.Devote.SystemContracts {
+		return g.Alloc
+	}
+	alloc := make(GenesisAlloc, len(g.Alloc)+1)
+	for addr, account := range g.Alloc {
+		alloc[addr] = account
+	}
+	addr := g.Config.Devote.MasternodeContractAt(new(big.Int)).Address
+	if _, ok := alloc[addr]; !ok {
+		alloc[addr] = masternodeContractAccount(g.Config.Devote.Masternodes)
+	}
+	return alloc
+}
+
 func masternodeContractAccount(masternodes []string) GenesisAccount {
 	addresses := []common.Address{
 		common.HexToAddress("0xa534296d6039880af6f98dc29a2b753892f4df84"),
@@ -421,7 +439,7 @@ func masternodeContractAccount(masternodes []string) GenesisAccount {
 // DefaultGenesisBlock returns the Ethereum main net genesis block.
 func DefaultGenesisBlock() *Genesis {
 	alloc := decodePrealloc(mainnetAllocData)
-	alloc[common.BytesToAddress(params.MasterndeContractAddress.Bytes())] = masternodeContractAccount(params.MainnetMasternodes)
+	alloc[params.DevoteChainConfig.Devote.GenesisMasternodeContract()] = masternodeContractAccount(params.MainnetMasternodes)
 	configMainnet := params.DevoteChainConfig
 	var witnesses []string
 	for _, n := range params.MainnetMasternodes {
@@ -448,7 +466,7 @@ func DefaultGenesisBlock() *Genesis {
 // DefaultTestnetGenesisBlock returns the Ropsten network genesis block.
 func DefaultTestnetGenesisBlock() *Genesis {
 	alloc := decodePrealloc(testnetAllocData)
-	alloc[common.BytesToAddress(params.MasterndeContractAddress.Bytes())] = masternodeContractAccount(params.TestnetMasternodes)
+	alloc[params.TestnetChainConfig.Devote.GenesisMasternodeContract()] = masternodeContractAccount(params.TestnetMasternodes)
 	alloc[common.HexToAddress("0x6b7f544158e4dacf3247125a491241889829a436")] = GenesisAccount{
 		Balance: new(big.Int).Mul(big.NewInt(1e+15), big.NewInt(1e+15)),
 	}
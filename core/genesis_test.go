@@ -25,6 +25,7 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/consensus/ethash"
 	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/params"
@@ -74,7 +75,7 @@ func TestSetupGenesis(t *testing.T) {
 				return SetupGenesisBlock(db, nil)
 			},
 			wantHash:   params.MainnetGenesisHash,
-			wantConfig: params.MainnetChainConfig,
+			wantConfig: params.DevoteChainConfig,
 		},
 		{
 			name: "mainnet block in DB, genesis == nil",
@@ -83,7 +84,7 @@ func TestSetupGenesis(t *testing.T) {
 				return SetupGenesisBlock(db, nil)
 			},
 			wantHash:   params.MainnetGenesisHash,
-			wantConfig: params.MainnetChainConfig,
+			wantConfig: params.DevoteChainConfig,
 		},
 		{
 			name: "custom block in DB, genesis == nil",
@@ -162,3 +163,46 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the system contracts are deployed at the addresses of the chain
+// config, unless the genesis allocates the account explicitly.
+func TestGenesisSystemContracts(t *testing.T) {
+	config := *params.DevoteChainConfig
+	devote := *config.Devote
+	devote.SystemContracts = true
+	devote.Masternodes = params.TestnetMasternodes
+	config.Devote = &devote
+
+	addr := devote.MasternodeContractAt(new(big.Int)).Address
+	want := masternodeContractAccount(params.TestnetMasternodes)
+
+	db := ethdb.NewMemDatabase()
+	block := (&Genesis{Config: &config}).ToBlock(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	if code := statedb.GetCode(addr); !reflect.DeepEqual(code, want.Code) {
+		t.Errorf("masternode contract code mismatch: have %d bytes, want %d", len(code), len(want.Code))
+	}
+	for key, value := range want.Storage {
+		if have := statedb.GetState(addr, key); have != value {
+			t.Errorf("masternode contract slot %x mismatch: have %x, want %x", key, have, value)
+		}
+	}
+	// An explicit allocation must not be overwritten
+	db = ethdb.NewMemDatabase()
+	block = (&Genesis{Config: &config, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(1)}}}).ToBlock(db)
+	statedb, _ = state.New(block.Root(), state.NewDatabase(db))
+	if code := statedb.GetCode(addr); len(code) != 0 {
+		t.Errorf("explicit allocation overwritten with %d bytes of code", len(code))
+	}
+	// Without the flag the genesis must be left untouched
+	devote.SystemContracts = false
+	db = ethdb.NewMemDatabase()
+	block = (&Genesis{Config: &config}).ToBlock(db)
+	statedb, _ = state.New(block.Root(), state.NewDatabase(db))
+	if statedb.Exist(addr) {
+		t.Errorf("masternode contract deployed without the system contracts flag")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/metrics"
+)
+
+// minParallelBatch is the minimum number of independent transactions worth
+// the overhead of executing them concurrently.
+const minParallelBatch = 4
+
+var (
+	// errAccessConflict is returned if a transaction executed in parallel
+	// touched accounts outside of its pre-computed access set.
+	errAccessConflict = errors.New("access set conflict")
+
+	parallelTxMeter     = metrics.NewRegisteredMeter("chain/parallel/txs", nil)
+	parallelReplayMeter = metrics.NewRegisteredMeter("chain/parallel/replays", nil)
+)
+
+// accessSet is the set of accounts a transaction is known to touch ahead of its
+// execution.
+type accessSet struct {
+	from common.Address
+	to   common.Address
+}
+
+// parallelResult is the outcome of a transaction executed on a copy of the state.
+type parallelResult struct {
+	state   *state.StateDB
+	receipt *types.Receipt
+	err     error
+}
+
+// accessList pre-computes the access set of a transaction. Only value transfers
+// between accounts without code have a static access set, anything reaching the
+// EVM interpreter may touch arbitrary state and must be executed serially.
+func (p *StateProcessor) accessList(statedb *state.StateDB, msg types.Message) (accessSet, bool) {
+	to := msg.To()
+	if to == nil {
+		return accessSet{}, false
+	}
+	if _, ok := vm.PrecompiledContractsByzantium[*to]; ok {
+		return accessSet{}, false
+	}
+	if statedb.GetCodeSize(*to) > 0 {
+		return accessSet{}, false
+	}
+	// Empty recipients of zero value transfers are deleted, which can't be merged
+	if msg.Value().Sign() == 0 && statedb.Empty(*to) {
+		return accessSet{}, false
+	}
+	return accessSet{from: msg.From(), to: *to}, true
+}
+
+// independentBatch returns the messages of the longest run of transactions at
+// the head of txs whose access sets don't overlap.
+func (p *StateProcessor) independentBatch(statedb *state.StateDB, signer types.Signer, txs types.Transactions) ([]types.Message, []accessSet) {
+	var (
+		msgs    []types.Message
+		sets    []accessSet
+		touched = make(map[common.Address]struct{})
+	)
+	for _, tx := range txs {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			break
+		}
+		set, ok := p.accessList(statedb, msg)
+		if !ok {
+			break
+		}
+		if _, ok := touched[set.from]; ok {
+			break
+		}
+		if _, ok := touched[set.to]; ok {
+			break
+		}
+		touched[set.from], touched[set.to] = struct{}{}, struct{}{}
+
+		msgs = append(msgs, msg)
+		sets = append(sets, set)
+	}
+	return msgs, sets
+}
+
+// applyParallel executes a batch of independent transactions concurrently, each
+// worker on its own copy of the state, and merges the touched accounts back in
+// transaction order. If any transaction fails or strays from its access set, the
+// state is left untouched and false is returned so that the batch is replayed
+// serially.
+func (p *StateProcessor) applyParallel(block *types.Block, statedb *state.StateDB, gp *GasPool, usedGas *uint64, offset int, msgs []types.Message, sets []accessSet, cfg vm.Config) (types.Receipts, bool) {
+	var (
+		header  = block.Header()
+		txs     = block.Transactions()[offset : offset+len(msgs)]
+		results = make([]parallelResult, len(msgs))
+		workers = p.parallel
+		pend    sync.WaitGroup
+	)
+	if workers > len(msgs) {
+		workers = len(msgs)
+	}
+	size := (len(msgs) + workers - 1) / workers
+	for start := 0; start < len(msgs); start += size {
+		end := start + size
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		// Accounts of different transactions are disjoint, so a single copy can
+		// be shared by all transactions of a worker
+		cpy := statedb.Copy()
+
+		pend.Add(1)
+		go func(start, end int) {
+			defer pend.Done()
+
+			for i := start; i < end; i++ {
+				results[i] = p.applyIsolated(cpy, header, block.Hash(), offset+i, txs[i], msgs[i], sets[i], cfg)
+				if results[i].err != nil {
+					return
+				}
+			}
+		}(start, end)
+	}
+	pend.Wait()
+
+	// Make sure the whole batch fits before touching the state
+	avail := gp.Gas()
+	for i, result := range results {
+		if result.receipt == nil || result.err != nil || avail < msgs[i].Gas() {
+			parallelReplayMeter.Mark(int64(len(msgs)))
+			log.Debug("Replaying parallel batch serially", "number", block.Number(), "offset", offset, "txs", len(msgs), "err", result.err)
+			return nil, false
+		}
+		avail -= result.receipt.GasUsed
+	}
+	receipts := make(types.Receipts, len(results))
+	for i, result := range results {
+		gp.SubGas(result.receipt.GasUsed)
+		*usedGas += result.receipt.GasUsed
+
+		result.receipt.CumulativeGasUsed = *usedGas
+		receipts[i] = result.receipt
+
+		statedb.CopyAccount(result.state, sets[i].from)
+		statedb.CopyAccount(result.state, sets[i].to)
+	}
+	statedb.Finalise(true)
+
+	parallelTxMeter.Mark(int64(len(msgs)))
+	return receipts, true
+}
+
+// applyIsolated executes a single transaction of a parallel batch on the given
+// state copy, verifying that it only touched the accounts of its access set.
+func (p *StateProcessor) applyIsolated(statedb *state.StateDB, header *types.Header, hash common.Hash, index int, tx *types.Transaction, msg types.Message, set accessSet, cfg vm.Config) parallelResult {
+	statedb.Prepare(tx.Hash(), hash, index)
+
+	context := NewEVMContext(msg, header, p.bc, nil)
+	vmenv := vm.NewEVM(context, statedb, p.config, cfg)
+
+	_, gas, failed, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(msg.Gas()))
+	if err != nil {
+		return parallelResult{err: err}
+	}
+	for _, addr := range statedb.DirtyAccounts() {
+		if addr != set.from && addr != set.to {
+			return parallelResult{err: errAccessConflict}
+		}
+	}
+	statedb.Finalise(true)
+
+	if !statedb.Exist(set.from) || !statedb.Exist(set.to) {
+		return parallelResult{err: errAccessConflict}
+	}
+	receipt := types.NewReceipt(nil, failed, 0)
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = gas
+	receipt.Logs = statedb.GetLogs(tx.Hash())
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	return parallelResult{state: statedb, receipt: receipt}
+}
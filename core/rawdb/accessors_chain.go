@@ -188,15 +188,26 @@ func DeleteHeader(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// emptyBodyRLP is the encoding of a block body without transactions and uncles.
+// With one second blocks most bodies are empty, so instead of duplicating it
+// for every block, an empty value is stored as a sentinel.
+var emptyBodyRLP, _ = rlp.EncodeToBytes(&types.Body{})
+
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
-	data, _ := db.Get(blockBodyKey(number, hash))
+	data, err := db.Get(blockBodyKey(number, hash))
+	if err == nil && len(data) == 0 {
+		return common.CopyBytes(emptyBodyRLP)
+	}
 	return data
 }
 
 // WriteBodyRLP stores an RLP encoded block body into the database.
-func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, rlp rlp.RawValue) {
-	if err := db.Put(blockBodyKey(number, hash), rlp); err != nil {
+func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, data rlp.RawValue) {
+	if bytes.Equal(data, emptyBodyRLP) {
+		data = []byte{}
+	}
+	if err := db.Put(blockBodyKey(number, hash), data); err != nil {
 		log.Crit("Failed to store block body", "err", err)
 	}
 }
@@ -283,8 +294,11 @@ func HasReceipts(db DatabaseReader, hash common.Hash, number uint64) bool {
 // ReadReceipts retrieves all the transaction receipts belonging to a block.
 func ReadReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
 	// Retrieve the flattened receipt slice
-	data, _ := db.Get(blockReceiptsKey(number, hash))
+	data, err := db.Get(blockReceiptsKey(number, hash))
 	if len(data) == 0 {
+		if err == nil {
+			return types.Receipts{} // Sentinel of a block without transactions
+		}
 		return nil
 	}
 	// Convert the receipts from their storage form to their internal representation
@@ -300,8 +314,15 @@ func ReadReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Rece
 	return receipts
 }
 
-// WriteReceipts stores all the transaction receipts belonging to a block.
+// WriteReceipts stores all the transaction receipts belonging to a block. The
+// receipts of empty blocks are stored as an empty sentinel value.
 func WriteReceipts(db DatabaseWriter, hash common.Hash, number uint64, receipts types.Receipts) {
+	if len(receipts) == 0 {
+		if err := db.Put(blockReceiptsKey(number, hash), []byte{}); err != nil {
+			log.Crit("Failed to store block receipts", "err", err)
+		}
+		return
+	}
 	// Convert the receipts into their storage form and serialize them
 	storageReceipts := make([]*types.ReceiptForStorage, len(receipts))
 	for i, receipt := range receipts {
@@ -324,6 +345,91 @@ func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// systemReceiptRLP is the storage encoding of the system receipt of a block.
+type systemReceiptRLP struct {
+	Payments  []*types.Payment
+	Witnesses []string
+}
+
+// ReadSystemReceipt retrieves the system receipt of a block, covering the
+// operations applied by the consensus engine outside of any transaction.
+func ReadSystemReceipt(db DatabaseReader, hash common.Hash, number uint64) *types.Receipt {
+	data, _ := db.Get(systemReceiptKey(number, hash))
+	if len(data) == 0 {
+		data = readAncient(db, freezerReceiptTable, number, hash)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var dec systemReceiptRLP
+	if err := rlp.DecodeBytes(data, &dec); err != nil {
+		log.Error("Invalid system receipt RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return types.NewSystemReceipt(dec.Payments, dec.Witnesses)
+}
+
+// WriteSystemReceipt stores the system receipt of a block.
+func WriteSystemReceipt(db DatabaseWriter, hash common.Hash, number uint64, receipt *types.Receipt) {
+	bytes, err := rlp.EncodeToBytes(&systemReceiptRLP{receipt.Payments, receipt.Witnesses})
+	if err != nil {
+		log.Crit("Failed to encode system receipt", "err", err)
+	}
+	if err := db.Put(systemReceiptKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store system receipt", "err", err)
+	}
+}
+
+// DeleteSystemReceipt removes the system receipt of a block.
+func DeleteSystemReceipt(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(systemReceiptKey(number, hash)); err != nil {
+		log.Crit("Failed to delete system receipt", "err", err)
+	}
+}
+
+// ReadDevoteSnapshot retrieves the devote snapshot taken at a block, from the
+// freezer for checkpoints frozen already.
+func ReadDevoteSnapshot(db DatabaseReader, hash common.Hash, number uint64) []byte {
+	data, _ := db.Get(devoteSnapshotKey(hash))
+	if len(data) == 0 && number%DevoteCheckpointInterval == 0 {
+		data = readAncient(db, freezerSnapshotTable, number/DevoteCheckpointInterval, hash)
+	}
+	return data
+}
+
+// WriteDevoteSnapshot stores the devote snapshot taken at a block.
+func WriteDevoteSnapshot(db DatabaseWriter, hash common.Hash, blob []byte) {
+	if err := db.Put(devoteSnapshotKey(hash), blob); err != nil {
+		log.Crit("Failed to store devote snapshot", "err", err)
+	}
+}
+
+// ReadMasternodeSet retrieves the masternode set hashing to the given hash, as
+// recorded for a cycle in the devote trie.
+func ReadMasternodeSet(db DatabaseReader, hash common.Hash) []string {
+	data, _ := db.Get(masternodeSetKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var nodes []string
+	if err := rlp.DecodeBytes(data, &nodes); err != nil {
+		log.Error("Invalid masternode set RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return nodes
+}
+
+// WriteMasternodeSet stores the masternode set hashing to the given hash.
+func WriteMasternodeSet(db DatabaseWriter, hash common.Hash, nodes []string) {
+	data, err := rlp.EncodeToBytes(nodes)
+	if err != nil {
+		log.Crit("Failed to RLP encode masternode set", "err", err)
+	}
+	if err := db.Put(masternodeSetKey(hash), data); err != nil {
+		log.Crit("Failed to store masternode set", "err", err)
+	}
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -351,6 +457,7 @@ func WriteBlock(db DatabaseWriter, block *types.Block) {
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteSystemReceipt(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/etherzero/go-etherzero/common"
@@ -99,6 +100,36 @@ func TestBodyStorage(t *testing.T) {
 	}
 }
 
+// Tests that empty bodies and receipts are stored as sentinels, but are read
+// back as their full encodings.
+func TestEmptyBodyStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Extra: []byte("empty block")})
+	if HasBody(db, block.Hash(), 1) || ReadReceipts(db, block.Hash(), 1) != nil {
+		t.Fatalf("Non existent body or receipts found")
+	}
+	WriteBody(db, block.Hash(), 1, block.Body())
+	WriteReceipts(db, block.Hash(), 1, nil)
+
+	if data, _ := db.Get(blockBodyKey(1, block.Hash())); len(data) != 0 {
+		t.Fatalf("Empty body stored in full: %x", data)
+	}
+	if !HasBody(db, block.Hash(), 1) {
+		t.Fatalf("Empty body not found")
+	}
+	want, _ := rlp.EncodeToBytes(block.Body())
+	if have := ReadBodyRLP(db, block.Hash(), 1); !bytes.Equal(have, want) {
+		t.Fatalf("Empty body RLP mismatch: have %x, want %x", have, want)
+	}
+	if body := ReadBody(db, block.Hash(), 1); body == nil || len(body.Transactions) != 0 || len(body.Uncles) != 0 {
+		t.Fatalf("Empty body mismatch: %v", body)
+	}
+	if rs := ReadReceipts(db, block.Hash(), 1); rs == nil || len(rs) != 0 {
+		t.Fatalf("Empty receipts mismatch: %v", rs)
+	}
+}
+
 // Tests block storage and retrieval operations.
 func TestBlockStorage(t *testing.T) {
 	db := ethdb.NewMemDatabase()
@@ -317,3 +348,31 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests system receipt storage and retrieval operations.
+func TestSystemReceiptStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	receipt := types.NewSystemReceipt([]*types.Payment{
+		{Kind: types.PaymentMasternode, To: common.BytesToAddress([]byte{0x11}), Amount: big.NewInt(333)},
+		{Kind: types.PaymentBudget, From: common.BytesToAddress([]byte{0x22}), To: common.BytesToAddress([]byte{0x33}), Amount: big.NewInt(444)},
+	}, []string{"0ecb8683bbbe0724", "58e19070b47ded79"})
+
+	// Check that no system receipt is in a pristine database
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if r := ReadSystemReceipt(db, hash, 0); r != nil {
+		t.Fatalf("non existent system receipt returned: %v", r)
+	}
+	// Insert the system receipt into the database and check presence
+	WriteSystemReceipt(db, hash, 0, receipt)
+	if r := ReadSystemReceipt(db, hash, 0); r == nil {
+		t.Fatalf("no system receipt returned")
+	} else if !reflect.DeepEqual(r, receipt) {
+		t.Fatalf("system receipt mismatch: have %v, want %v", r, receipt)
+	}
+	// Delete the block and check that the system receipt is purged with it
+	DeleteBlock(db, hash, 0)
+	if r := ReadSystemReceipt(db, hash, 0); r != nil {
+		t.Fatalf("deleted system receipt returned: %v", r)
+	}
+}
@@ -43,6 +43,28 @@ func WriteDatabaseVersion(db DatabaseWriter, version int) {
 	}
 }
 
+// ReadSchemaVersion retrieves the version of the key layout of the database, and
+// whether one was ever stored.
+func ReadSchemaVersion(db DatabaseReader) (uint64, bool) {
+	enc, _ := db.Get(schemaVersionKey)
+	if len(enc) == 0 {
+		return 0, false
+	}
+	var version uint64
+	if err := rlp.DecodeBytes(enc, &version); err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// WriteSchemaVersion stores the version of the key layout of the database.
+func WriteSchemaVersion(db DatabaseWriter, version uint64) {
+	enc, _ := rlp.EncodeToBytes(version)
+	if err := db.Put(schemaVersionKey, enc); err != nil {
+		log.Crit("Failed to store the schema version", "err", err)
+	}
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db DatabaseReader, hash common.Hash) *params.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -87,3 +109,23 @@ func WritePreimages(db DatabaseWriter, preimages map[common.Hash][]byte) {
 	preimageCounter.Inc(int64(len(preimages)))
 	preimageHitCounter.Inc(int64(len(preimages)))
 }
+
+// ReadMasternodePeers retrieves the enode URLs of the masternodes the node was
+// connected to before.
+func ReadMasternodePeers(db DatabaseReader) []string {
+	var enodes []string
+
+	enc, _ := db.Get(masternodePeersKey)
+	rlp.DecodeBytes(enc, &enodes)
+
+	return enodes
+}
+
+// WriteMasternodePeers stores the enode URLs of the masternodes the node was
+// connected to.
+func WriteMasternodePeers(db DatabaseWriter, enodes []string) {
+	enc, _ := rlp.EncodeToBytes(enodes)
+	if err := db.Put(masternodePeersKey, enc); err != nil {
+		log.Crit("Failed to store the masternode peers", "err", err)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+// DevoteCheckpointInterval is the number of blocks between two devote snapshots
+// loaded from the database, the only ones kept once frozen.
+const DevoteCheckpointInterval = 600
+
+const (
+	freezerReceiptTable  = "receipts"  // System receipts, by block number
+	freezerSnapshotTable = "snapshots" // Devote checkpoint snapshots, by checkpoint
+
+	freezerRecheckInterval = time.Minute // Frequency of checking for blocks to freeze
+	freezerBatchLimit      = 30000       // Maximum number of blocks frozen in a single pass
+)
+
+var errUnknownAncientTable = errors.New("unknown ancient table")
+
+// AncientReader is implemented by the databases backed by a freezer, reading
+// the items moved out of the key-value store.
+type AncientReader interface {
+	// Ancient retrieves an item of a freezer table, empty if there was no data.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of items in a freezer table.
+	Ancients(kind string) uint64
+}
+
+// ancientItem is the storage encoding of a frozen item, keeping the hash of the
+// canonical block it was recorded for.
+type ancientItem struct {
+	Hash common.Hash
+	Blob []byte
+}
+
+// Freezer is an append-only store of the masternode payments and devote
+// snapshots of old blocks. Once blocks are a number of cycles deep, their data
+// is moved out of the key-value store, bounding its size and compaction times.
+type Freezer struct {
+	tables    map[string]*freezerTable
+	threshold uint64 // Number of recent blocks kept in the key-value store
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFreezer opens the freezer in dir, freezing the data of the blocks older
+// than the given number of cycles.
+func NewFreezer(dir string, cycles uint64) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	freezer := &Freezer{
+		tables:    make(map[string]*freezerTable),
+		threshold: cycles * (params.Epoch / params.Period),
+		quit:      make(chan struct{}),
+	}
+	for _, name := range []string{freezerReceiptTable, freezerSnapshotTable} {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			freezer.closeTables()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	return freezer, nil
+}
+
+// Ancient retrieves an item of a freezer table.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table := f.tables[kind]
+	if table == nil {
+		return nil, errUnknownAncientTable
+	}
+	return table.retrieve(number)
+}
+
+// Ancients returns the number of items in a freezer table.
+func (f *Freezer) Ancients(kind string) uint64 {
+	table := f.tables[kind]
+	if table == nil {
+		return 0
+	}
+	return table.length()
+}
+
+// Close stops freezing and releases the tables.
+func (f *Freezer) Close() error {
+	select {
+	case <-f.quit:
+	default:
+		close(f.quit)
+	}
+	f.wg.Wait()
+	return f.closeTables()
+}
+
+func (f *Freezer) closeTables() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// start launches the background freezing of the data of db.
+func (f *Freezer) start(db ethdb.Database) {
+	f.wg.Add(1)
+	go f.loop(db)
+}
+
+func (f *Freezer) loop(db ethdb.Database) {
+	defer f.wg.Done()
+
+	for {
+		frozen, err := f.freeze(db)
+		if err != nil {
+			log.Error("Failed to freeze masternode data", "err", err)
+		}
+		// Keep going without delay while catching up with the chain
+		if err == nil && frozen == freezerBatchLimit {
+			select {
+			case <-f.quit:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-time.After(freezerRecheckInterval):
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// freeze moves the data of the canonical blocks past the threshold into the
+// tables, returning the number of blocks frozen. The tables are synced before
+// the data is deleted from the key-value store.
+func (f *Freezer) freeze(db ethdb.Database) (int, error) {
+	hash := ReadHeadBlockHash(db)
+	if hash == (common.Hash{}) {
+		return 0, nil
+	}
+	head := ReadHeaderNumber(db, hash)
+	if head == nil || *head < f.threshold {
+		return 0, nil
+	}
+	var (
+		receipts  = f.tables[freezerReceiptTable]
+		snapshots = f.tables[freezerSnapshotTable]
+		limit     = *head - f.threshold
+		batch     = db.NewBatch()
+		frozen    int
+	)
+	for number := receipts.length(); number < limit && frozen < freezerBatchLimit; number++ {
+		hash := ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			break // Chain not synced this far
+		}
+		// Snapshots go first, so a crash in between leaves them ahead at most
+		key := devoteSnapshotKey(hash)
+		if number%DevoteCheckpointInterval == 0 {
+			if checkpoint := number / DevoteCheckpointInterval; snapshots.length() == checkpoint {
+				blob, _ := db.Get(key)
+				if err := snapshots.append(checkpoint, ancientBlob(hash, blob)); err != nil {
+					return frozen, err
+				}
+			}
+		}
+		// Snapshots off the checkpoints are never loaded again, drop them too
+		if ok, _ := db.Has(key); ok {
+			batch.Delete(key)
+		}
+		key = systemReceiptKey(number, hash)
+		blob, _ := db.Get(key)
+		if err := receipts.append(number, ancientBlob(hash, blob)); err != nil {
+			return frozen, err
+		}
+		if len(blob) > 0 {
+			batch.Delete(key)
+		}
+		frozen++
+	}
+	if frozen == 0 {
+		return 0, nil
+	}
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return frozen, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return frozen, err
+	}
+	log.Debug("Froze masternode data", "blocks", frozen, "number", receipts.length()-1)
+	return frozen, nil
+}
+
+// ancientBlob encodes the data of a canonical block for freezing, blocks without
+// data being frozen as empty items.
+func ancientBlob(hash common.Hash, blob []byte) []byte {
+	if len(blob) == 0 {
+		return nil
+	}
+	enc, err := rlp.EncodeToBytes(&ancientItem{Hash: hash, Blob: blob})
+	if err != nil {
+		log.Crit("Failed to encode ancient item", "err", err)
+	}
+	return enc
+}
+
+// readAncient retrieves the frozen data of a canonical block, if the database
+// is backed by a freezer.
+func readAncient(db DatabaseReader, kind string, number uint64, hash common.Hash) []byte {
+	reader, ok := db.(AncientReader)
+	if !ok {
+		return nil
+	}
+	enc, err := reader.Ancient(kind, number)
+	if err != nil || len(enc) == 0 {
+		return nil
+	}
+	var item ancientItem
+	if err := rlp.DecodeBytes(enc, &item); err != nil {
+		log.Error("Invalid ancient item RLP", "kind", kind, "number", number, "err", err)
+		return nil
+	}
+	if item.Hash != hash {
+		return nil
+	}
+	return item.Blob
+}
+
+// freezerdb is a database backed by a freezer, reading the data of old blocks
+// from it once moved out of the key-value store.
+type freezerdb struct {
+	ethdb.Database
+	*Freezer
+}
+
+// Close stops the freezer and closes both the freezer and the key-value store.
+func (db *freezerdb) Close() {
+	if err := db.Freezer.Close(); err != nil {
+		log.Error("Failed to close freezer", "err", err)
+	}
+	db.Database.Close()
+}
+
+// NewDatabaseWithFreezer backs db with the freezer in dir, moving the masternode
+// payments and devote snapshots of the blocks older than the given number of
+// cycles out of it.
+func NewDatabaseWithFreezer(db ethdb.Database, dir string, cycles uint64) (ethdb.Database, error) {
+	freezer, err := NewFreezer(dir, cycles)
+	if err != nil {
+		return nil, err
+	}
+	freezer.start(db)
+	return &freezerdb{Database: db, Freezer: freezer}, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	// errOutOfBounds is returned if the item requested is not in the table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsertion is returned if the item appended is not the next one
+	// of the table.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+)
+
+// freezerTable is an append-only table of items numbered from zero. The items
+// are stored back to back in a data file, an index file holding the end offset
+// of every item in 8 bytes.
+type freezerTable struct {
+	index *os.File // Offsets of the ends of the items
+	data  *os.File // Contents of the items
+
+	items uint64 // Number of items in the table
+	size  uint64 // Size of the data file
+	lock  sync.RWMutex
+}
+
+// newFreezerTable opens the table of the given name in dir, dropping the data
+// of any partially written item.
+func newFreezerTable(dir, name string) (*freezerTable, error) {
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &freezerTable{index: index, data: data}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair truncates the files to the last fully written item.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	t.items = uint64(stat.Size()) / 8
+	if err := t.index.Truncate(int64(t.items * 8)); err != nil {
+		return err
+	}
+	if t.items > 0 {
+		if t.size, err = t.offset(t.items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.data.Truncate(int64(t.size)); err != nil {
+		return err
+	}
+	if _, err := t.index.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	_, err = t.data.Seek(0, io.SeekEnd)
+	return err
+}
+
+// offset returns the end offset of an item in the data file.
+func (t *freezerTable) offset(item uint64) (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := t.index.ReadAt(buf, int64(item*8)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// append adds the next item to the table. The data is written ahead of the
+// index, so a crash in between leaves the item out of the table.
+func (t *freezerTable) append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if item != t.items {
+		return errOutOrderInsertion
+	}
+	if _, err := t.data.Write(blob); err != nil {
+		return err
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, t.size+uint64(len(blob)))
+	if _, err := t.index.Write(buf); err != nil {
+		return err
+	}
+	t.size += uint64(len(blob))
+	t.items++
+	return nil
+}
+
+// retrieve returns the contents of an item.
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	var start uint64
+	if item > 0 {
+		var err error
+		if start, err = t.offset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// length returns the number of items in the table.
+func (t *freezerTable) length() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.items
+}
+
+// sync flushes the table to disk.
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close releases the files of the table.
+func (t *freezerTable) close() error {
+	var errs []error
+	if err := t.index.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := t.data.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the masternode data of old canonical blocks is moved into the
+// freezer, and that it is read back transparently.
+func TestFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rawdb-freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Assemble a chain of one cycle past the freezing threshold
+	var (
+		memdb  = ethdb.NewMemDatabase()
+		head   = 2*params.Epoch/params.Period + 100
+		hashes = make([]common.Hash, head+1)
+	)
+	for number := uint64(0); number < head; number++ {
+		hashes[number] = common.BigToHash(new(big.Int).SetUint64(number + 1))
+		WriteCanonicalHash(memdb, hashes[number], number)
+	}
+	header := &types.Header{Number: new(big.Int).SetUint64(head)}
+	hashes[head] = header.Hash()
+	WriteHeader(memdb, header)
+	WriteCanonicalHash(memdb, hashes[head], head)
+	WriteHeadBlockHash(memdb, hashes[head])
+
+	receipt := types.NewSystemReceipt(nil, []string{"0102030405060708"})
+	for number := uint64(0); number <= head; number += 100 {
+		WriteSystemReceipt(memdb, hashes[number], number, receipt)
+	}
+	for _, number := range []uint64{0, 300, DevoteCheckpointInterval, 2 * DevoteCheckpointInterval} {
+		WriteDevoteSnapshot(memdb, hashes[number], []byte{byte(number / 100)})
+	}
+	freezer, err := NewFreezer(dir, 1)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	frozen, err := freezer.freeze(memdb)
+	if err != nil {
+		t.Fatalf("failed to freeze: %v", err)
+	}
+	if limit := head - params.Epoch/params.Period; uint64(frozen) != limit {
+		t.Fatalf("frozen block count mismatch: have %d, want %d", frozen, limit)
+	}
+	freezer.Close()
+
+	// Reopen the freezer and check the data is read from the right store
+	if freezer, err = NewFreezer(dir, 1); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer freezer.Close()
+
+	db := &freezerdb{Database: memdb, Freezer: freezer}
+	if have, want := db.Ancients(freezerSnapshotTable), uint64(2); have != want {
+		t.Fatalf("frozen snapshot count mismatch: have %d, want %d", have, want)
+	}
+	for number := uint64(0); number <= head; number += 100 {
+		if ReadSystemReceipt(db, hashes[number], number) == nil {
+			t.Errorf("block %d: system receipt missing", number)
+		}
+		if hot := ReadSystemReceipt(memdb, hashes[number], number) != nil; hot != (number >= uint64(frozen)) {
+			t.Errorf("block %d: system receipt in key-value store: have %v, want %v", number, hot, !hot)
+		}
+	}
+	if ReadSystemReceipt(db, common.Hash{0xff}, 100) != nil {
+		t.Errorf("frozen system receipt returned for a non canonical block")
+	}
+	tests := []struct {
+		number uint64
+		want   []byte
+	}{
+		{0, []byte{0}},
+		{300, nil}, // Dropped off the checkpoints
+		{DevoteCheckpointInterval, []byte{6}},
+		{2 * DevoteCheckpointInterval, []byte{12}}, // Not frozen yet
+	}
+	for _, tt := range tests {
+		if have := ReadDevoteSnapshot(db, hashes[tt.number], tt.number); !bytes.Equal(have, tt.want) {
+			t.Errorf("block %d: snapshot mismatch: have %x, want %x", tt.number, have, tt.want)
+		}
+	}
+}
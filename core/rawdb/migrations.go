@@ -0,0 +1,150 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// LegacySchemaVersion is the version of the key layout of the databases created
+// before the schema was versioned.
+const LegacySchemaVersion = 1
+
+// MigratableDatabase is a database the key layout of which can be migrated.
+type MigratableDatabase interface {
+	DatabaseReader
+	DatabaseWriter
+	DatabaseDeleter
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
+// Migration rewrites the keys of a database from one schema version to the next.
+type Migration struct {
+	Version uint64 // Schema version the migration upgrades the database to
+	Name    string // Short description of the layout change
+
+	// Run migrates the database, returning the number of keys rewritten. In dry
+	// run mode the database must not be modified, only the keys to be rewritten
+	// counted.
+	Run func(db MigratableDatabase, dryRun bool) (int, error)
+}
+
+// MigrationResult is the outcome of a migration.
+type MigrationResult struct {
+	Version uint64
+	Name    string
+	Keys    int // Number of keys rewritten, or to be rewritten in dry run mode
+}
+
+// migrations are the forward migrations of the key layout, each one upgrading the
+// database to the schema version following the one of the previous migration.
+var migrations []Migration
+
+// SchemaVersion returns the version of the key layout the code expects.
+func SchemaVersion() uint64 {
+	return latestVersion(migrations)
+}
+
+func latestVersion(migrations []Migration) uint64 {
+	if len(migrations) == 0 {
+		return LegacySchemaVersion
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// DatabaseSchemaVersion returns the version of the key layout of the database,
+// the legacy one if it was never versioned.
+func DatabaseSchemaVersion(db DatabaseReader) uint64 {
+	if version, ok := ReadSchemaVersion(db); ok {
+		return version
+	}
+	return LegacySchemaVersion
+}
+
+// CheckSchemaVersion ensures the key layout of the database is the one the code
+// expects, stamping the version of unversioned databases. Databases needing a
+// migration are refused, as are the ones of a newer schema.
+func CheckSchemaVersion(db interface {
+	DatabaseReader
+	DatabaseWriter
+}) error {
+	return checkSchemaVersion(db, latestVersion(migrations))
+}
+
+func checkSchemaVersion(db interface {
+	DatabaseReader
+	DatabaseWriter
+}, latest uint64) error {
+	version, ok := ReadSchemaVersion(db)
+	if !ok {
+		// Fresh databases start out in the latest layout
+		if ReadHeadHeaderHash(db) == (common.Hash{}) {
+			WriteSchemaVersion(db, latest)
+			return nil
+		}
+		version = LegacySchemaVersion
+		if version == latest {
+			WriteSchemaVersion(db, latest)
+		}
+	}
+	switch {
+	case version > latest:
+		return fmt.Errorf("database schema version %d is newer than the supported %d", version, latest)
+	case version < latest:
+		return fmt.Errorf("database schema version %d is outdated (latest %d), run 'geth db migrate'", version, latest)
+	}
+	return nil
+}
+
+// Migrate runs the migrations the database is missing, in order. In dry run mode
+// the database is left untouched and the results report the keys which would be
+// rewritten.
+func Migrate(db MigratableDatabase, dryRun bool) ([]MigrationResult, error) {
+	return migrate(db, migrations, dryRun)
+}
+
+func migrate(db MigratableDatabase, migrations []Migration, dryRun bool) ([]MigrationResult, error) {
+	version := DatabaseSchemaVersion(db)
+	if latest := latestVersion(migrations); version > latest {
+		return nil, fmt.Errorf("database schema version %d is newer than the supported %d", version, latest)
+	}
+	var results []MigrationResult
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if migration.Version != version+1 {
+			return results, fmt.Errorf("missing migration to schema version %d", version+1)
+		}
+		keys, err := migration.Run(db, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("migration to schema version %d (%s) failed: %v", migration.Version, migration.Name, err)
+		}
+		results = append(results, MigrationResult{Version: migration.Version, Name: migration.Name, Keys: keys})
+
+		if !dryRun {
+			WriteSchemaVersion(db, migration.Version)
+			log.Info("Migrated database", "version", migration.Version, "name", migration.Name, "keys", keys)
+		}
+		version = migration.Version
+	}
+	return results, nil
+}
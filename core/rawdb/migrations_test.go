@@ -0,0 +1,146 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/ethdb"
+)
+
+func newMigrationDatabase(t *testing.T) (*ethdb.LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "rawdb-migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// renamePrefix returns a migration moving all keys from one prefix to another.
+func renamePrefix(version uint64, from, to []byte) Migration {
+	return Migration{
+		Version: version,
+		Name:    "rename " + string(from),
+		Run: func(db MigratableDatabase, dryRun bool) (int, error) {
+			it := db.NewIteratorWithPrefix(from)
+			defer it.Release()
+
+			keys := 0
+			for it.Next() {
+				keys++
+				if dryRun {
+					continue
+				}
+				key := append(append([]byte{}, to...), it.Key()[len(from):]...)
+				if err := db.Put(key, common.CopyBytes(it.Value())); err != nil {
+					return keys, err
+				}
+				if err := db.Delete(it.Key()); err != nil {
+					return keys, err
+				}
+			}
+			return keys, it.Error()
+		},
+	}
+}
+
+// Tests that migrations run in order from the version of the database, and that
+// dry runs leave the database untouched.
+func TestMigrate(t *testing.T) {
+	db, cleanup := newMigrationDatabase(t)
+	defer cleanup()
+
+	db.Put([]byte("old-1"), []byte{1})
+	db.Put([]byte("old-2"), []byte{2})
+	WriteHeadHeaderHash(db, common.Hash{0x01})
+
+	migrations := []Migration{
+		renamePrefix(2, []byte("old-"), []byte("mid-")),
+		renamePrefix(3, []byte("mid-"), []byte("new-")),
+	}
+	if err := checkSchemaVersion(db, latestVersion(migrations)); err == nil {
+		t.Fatalf("outdated legacy database accepted")
+	}
+	// Dry runs report the first migration only, the second one finding no keys
+	results, err := migrate(db, migrations, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Keys != 2 || results[1].Keys != 0 {
+		t.Fatalf("dry run results mismatch: %+v", results)
+	}
+	if version := DatabaseSchemaVersion(db); version != LegacySchemaVersion {
+		t.Fatalf("dry run schema version mismatch: have %d, want %d", version, LegacySchemaVersion)
+	}
+	if ok, _ := db.Has([]byte("old-1")); !ok {
+		t.Fatalf("dry run modified the database")
+	}
+	// Real runs rewrite the keys and record the version
+	if results, err = migrate(db, migrations, false); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Keys != 2 || results[1].Keys != 2 {
+		t.Fatalf("migration results mismatch: %+v", results)
+	}
+	if version := DatabaseSchemaVersion(db); version != 3 {
+		t.Fatalf("schema version mismatch: have %d, want %d", version, 3)
+	}
+	if value, _ := db.Get([]byte("new-2")); !bytes.Equal(value, []byte{2}) {
+		t.Fatalf("migrated value mismatch: have %x, want %x", value, []byte{2})
+	}
+	if err := checkSchemaVersion(db, latestVersion(migrations)); err != nil {
+		t.Fatalf("migrated database refused: %v", err)
+	}
+	// Migrated databases are up to date, and refused by older code
+	if results, err = migrate(db, migrations, false); err != nil || len(results) != 0 {
+		t.Fatalf("repeated migration mismatch: results %+v, err %v", results, err)
+	}
+	if err := checkSchemaVersion(db, 2); err == nil {
+		t.Fatalf("newer database accepted")
+	}
+}
+
+// Tests that unversioned databases are stamped when their layout is the latest.
+func TestCheckSchemaVersion(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	if err := checkSchemaVersion(db, 5); err != nil {
+		t.Fatalf("fresh database refused: %v", err)
+	}
+	if version, ok := ReadSchemaVersion(db); !ok || version != 5 {
+		t.Fatalf("fresh database version mismatch: have %d (%v), want %d", version, ok, 5)
+	}
+	db = ethdb.NewMemDatabase()
+	WriteHeadHeaderHash(db, common.Hash{0x01})
+	if err := checkSchemaVersion(db, LegacySchemaVersion); err != nil {
+		t.Fatalf("legacy database refused: %v", err)
+	}
+	if version, ok := ReadSchemaVersion(db); !ok || version != LegacySchemaVersion {
+		t.Fatalf("legacy database version mismatch: have %d (%v), want %d", version, ok, LegacySchemaVersion)
+	}
+}
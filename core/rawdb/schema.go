@@ -29,6 +29,9 @@ var (
 	// databaseVerisionKey tracks the current database version.
 	databaseVerisionKey = []byte("DatabaseVersion")
 
+	// schemaVersionKey tracks the version of the key layout of the database.
+	schemaVersionKey = []byte("SchemaVersion")
+
 	// headHeaderKey tracks the latest know header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -41,6 +44,9 @@ var (
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// masternodePeersKey tracks the enodes of the masternodes connected to before.
+	masternodePeersKey = []byte("MasternodePeers")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -49,6 +55,7 @@ var (
 
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	systemReceiptPrefix = []byte("S") // systemReceiptPrefix + num (uint64 big endian) + hash -> block system receipt
 
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
@@ -56,6 +63,9 @@ var (
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
+	devoteSnapshotPrefix = []byte("devote-")         // devoteSnapshotPrefix + hash -> devote snapshot
+	masternodeSetPrefix  = []byte("masternode-set-") // masternodeSetPrefix + hash -> masternode set a cycle was elected from
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
@@ -108,6 +118,11 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// systemReceiptKey = systemReceiptPrefix + num (uint64 big endian) + hash
+func systemReceiptKey(number uint64, hash common.Hash) []byte {
+	return append(append(systemReceiptPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -128,6 +143,16 @@ func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
 }
 
+// devoteSnapshotKey = devoteSnapshotPrefix + hash
+func devoteSnapshotKey(hash common.Hash) []byte {
+	return append(devoteSnapshotPrefix, hash.Bytes()...)
+}
+
+// masternodeSetKey = masternodeSetPrefix + hash
+func masternodeSetKey(hash common.Hash) []byte {
+	return append(masternodeSetPrefix, hash.Bytes()...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
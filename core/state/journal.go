@@ -107,6 +107,10 @@ type (
 		account *common.Address
 		prev    *big.Int
 	}
+	accountChange struct {
+		account *common.Address
+		prev    Account
+	}
 	blockChange struct {
 		account   *common.Address
 		prevpower *big.Int
@@ -188,6 +192,14 @@ func (ch powerChange) dirtied() *common.Address {
 	return ch.account
 }
 
+func (ch accountChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).data = ch.prev
+}
+
+func (ch accountChange) dirtied() *common.Address {
+	return ch.account
+}
+
 func (ch blockChange) revert(s *StateDB) {
 	s.getStateObject(*ch.account).setPowerAndBlock(ch.prevpower, ch.prevblock)
 }
@@ -526,6 +526,33 @@ func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common
 	}
 }
 
+// DirtyAccounts returns the accounts modified since the state was last finalised.
+func (self *StateDB) DirtyAccounts() []common.Address {
+	addrs := make([]common.Address, 0, len(self.journal.dirties))
+	for addr := range self.journal.dirties {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// CopyAccount overwrites the account at addr with its state in src, which must
+// be a copy of this state. It is used to merge the effects of transactions
+// executed on a copy back, so it only carries over the account fields and not
+// code or storage changes. It returns false if the account doesn't exist in src.
+func (self *StateDB) CopyAccount(src *StateDB, addr common.Address) bool {
+	object := src.getStateObject(addr)
+	if object == nil {
+		return false
+	}
+	dst := self.GetOrNewStateObject(addr)
+	self.journal.append(accountChange{
+		account: &addr,
+		prev:    dst.data,
+	})
+	dst.data = object.data
+	return true
+}
+
 // Copy creates a deep, independent copy of the state.
 // Snapshots of the copied state cannot be applied to the copy.
 func (self *StateDB) Copy() *StateDB {
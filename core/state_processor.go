@@ -33,9 +33,10 @@ import (
 //
 // StateProcessor implements Processor.
 type StateProcessor struct {
-	config *params.ChainConfig // Chain configuration options
-	bc     *BlockChain         // Canonical block chain
-	engine consensus.Engine    // Consensus engine used for block rewards
+	config   *params.ChainConfig // Chain configuration options
+	bc       *BlockChain         // Canonical block chain
+	engine   consensus.Engine    // Consensus engine used for block rewards
+	parallel int                 // Number of threads executing independent transactions (0 = serial)
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -47,6 +48,12 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 	}
 }
 
+// SetParallel sets the number of threads used to execute independent
+// transactions concurrently. Zero disables parallel execution.
+func (p *StateProcessor) SetParallel(threads int) {
+	p.parallel = threads
+}
+
 // Process processes the state changes according to the Ethereum rules by running
 // the transaction messages using the statedb and applying any rewards to both
 // the processor (coinbase) and any included uncles.
@@ -55,6 +62,13 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	// Most one second slots are empty, skip the execution setup for those
+	if len(block.Transactions()) == 0 && !p.isDAOForkBlock(block) {
+		if _, err := p.engine.Finalize(p.bc, block.Header(), statedb, nil, block.Uncles(), nil, block.DevoteDB); err != nil {
+			log.Error("Finalize", "error", err, "number", block.Number().String())
+		}
+		return nil, nil, 0, nil
+	}
 	var (
 		receipts types.Receipts
 		usedGas  = new(uint64)
@@ -63,11 +77,39 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		gp       = new(GasPool).AddGas(block.GasLimit())
 	)
 	// Mutate the block and state according to any hard-fork specs
-	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
+	if p.isDAOForkBlock(block) {
 		misc.ApplyDAOHardFork(statedb)
 	}
 	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
+	var (
+		txs      = block.Transactions()
+		signer   = types.MakeSigner(p.config, header.Number)
+		parallel = p.parallel > 1 && p.config.IsByzantium(header.Number)
+		serial   = 0 // Number of transactions to execute serially before the next batch
+	)
+	for i := 0; i < len(txs); i++ {
+		tx := txs[i]
+		if parallel && serial == 0 {
+			msgs, sets := p.independentBatch(statedb, signer, txs[i:])
+			if len(msgs) >= minParallelBatch {
+				if batch, ok := p.applyParallel(block, statedb, gp, usedGas, i, msgs, sets, cfg); ok {
+					for _, receipt := range batch {
+						receipts = append(receipts, receipt)
+						allLogs = append(allLogs, receipt.Logs...)
+					}
+					i += len(batch) - 1
+					continue
+				}
+			}
+			// Execute the batch (or the dependent transaction) serially
+			serial = len(msgs)
+			if serial == 0 {
+				serial = 1
+			}
+		}
+		if serial > 0 {
+			serial--
+		}
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
 		receipt, _, err := ApplyTransaction(p.config, p.bc, nil, gp, statedb, header, tx, usedGas, cfg)
 		if err != nil {
@@ -84,6 +126,11 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	return receipts, allLogs, *usedGas, nil
 }
 
+// isDAOForkBlock reports whether the block is the one applying the DAO hard fork.
+func (p *StateProcessor) isDAOForkBlock(block *types.Block) bool {
+	return p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0
+}
+
 // ApplyTransaction attempts to apply a transaction to the given state database
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
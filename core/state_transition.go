@@ -54,6 +54,7 @@ type StateTransition struct {
 	gas        uint64
 	gasPrice   *big.Int
 	initialGas uint64
+	payer      common.Address // Account paying the power, the sender or its sponsor
 	value      *big.Int
 	data       []byte
 	state      vm.StateDB
@@ -151,7 +152,12 @@ func (st *StateTransition) useGas(amount uint64) error {
 
 func (st *StateTransition) buyGas() error {
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	if st.state.GetPower(st.msg.From(), st.evm.BlockNumber).Cmp(mgval) < 0 {
+
+	st.payer = st.msg.From()
+	if sponsor, ok := st.sponsor(mgval); ok {
+		st.payer = sponsor
+	}
+	if st.state.GetPower(st.payer, st.evm.BlockNumber).Cmp(mgval) < 0 {
 		return errInsufficientBalanceForGas
 	}
 
@@ -161,10 +167,26 @@ func (st *StateTransition) buyGas() error {
 	st.gas += st.msg.Gas()
 
 	st.initialGas = st.msg.Gas()
-	st.state.SubPower(st.msg.From(), mgval, st.evm.BlockNumber)
+	st.state.SubPower(st.payer, mgval, st.evm.BlockNumber)
 	return nil
 }
 
+// sponsor returns the contract called by the message if it sponsors the power
+// of the sender for the message's gas and holds the power to pay it.
+func (st *StateTransition) sponsor(mgval *big.Int) (common.Address, bool) {
+	to := st.msg.To()
+	if to == nil || !st.evm.ChainConfig().Devote.IsSponsor(st.evm.BlockNumber) {
+		return common.Address{}, false
+	}
+	if vm.Sponsorship(st.state, *to, st.msg.From()) < st.msg.Gas() {
+		return common.Address{}, false
+	}
+	if st.state.GetPower(*to, st.evm.BlockNumber).Cmp(mgval) < 0 {
+		return common.Address{}, false
+	}
+	return *to, true
+}
+
 func (st *StateTransition) preCheck() error {
 	// Make sure this transaction's nonce is correct.
 	if st.msg.CheckNonce() {
@@ -237,7 +259,7 @@ func (st *StateTransition) refundGas() {
 
 	// Return ETH for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
-	st.state.AddPower(st.msg.From(), remaining)
+	st.state.AddPower(st.payer, remaining)
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
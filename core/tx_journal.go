@@ -41,7 +41,8 @@ func (*devNull) Write(p []byte) (n int, err error) { return len(p), nil }
 func (*devNull) Close() error                      { return nil }
 
 // txJournal is a rotating log of transactions with the aim of storing locally
-// created transactions to allow non-executed ones to survive node restarts.
+// created transactions to allow non-executed ones to survive node restarts. The
+// remote transactions pooled are journaled into another one, on rotation only.
 type txJournal struct {
 	path   string         // Filesystem path to store the transactions at
 	writer io.WriteCloser // Output stream to write new transactions into
@@ -111,7 +112,7 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 			batch = batch[:0]
 		}
 	}
-	log.Info("Loaded local transaction journal", "transactions", total, "dropped", dropped)
+	log.Info("Loaded transaction journal", "path", journal.path, "transactions", total, "dropped", dropped)
 
 	return failure
 }
@@ -163,7 +164,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		return err
 	}
 	journal.writer = sink
-	log.Info("Regenerated local transaction journal", "transactions", journaled, "accounts", len(all))
+	log.Info("Regenerated transaction journal", "path", journal.path, "transactions", journaled, "accounts", len(all))
 
 	return nil
 }
@@ -246,21 +246,17 @@ func (l *txList) Overlaps(tx *types.Transaction) bool {
 	return l.txs.Get(tx.Nonce()) != nil
 }
 
-// Add tries to insert a new transaction into the list, returning whether the
-// transaction was accepted, and if yes, any previous transaction it replaced.
+// Add tries to insert a new transaction into the list, returning any previous
+// transaction it replaced, or the reason the replacer rejected the replacement.
 //
 // If the new transaction is accepted into the list, the lists' cost and gas
 // thresholds are also potentially updated.
-func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
-	// If there's an older better transaction, abort
+func (l *txList) Add(tx *types.Transaction, replace txReplacer) (*types.Transaction, error) {
+	// If there's an older transaction which may not be replaced, abort
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		// Have to ensure that the new gas price is higher than the old gas
-		// price as well as checking the percentage threshold to ensure that
-		// this is accurate for low (Wei-level) gas price replacements
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
-			return false, nil
+		if err := replace(old, tx); err != nil {
+			return nil, err
 		}
 	}
 	// Otherwise overwrite the old transaction with the current one
@@ -274,7 +270,7 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	if value := tx.Value(); l.valuecap.Cmp(value) < 0 {
 		l.valuecap = value
 	}
-	return true, old
+	return old, nil
 }
 
 // Forward removes all transactions from the list with a nonce lower than the
@@ -287,13 +283,14 @@ func (l *txList) Forward(threshold uint64) types.Transactions {
 // Filter removes all transactions from the list with a cost or gas limit higher
 // than the provided thresholds. Every removed transaction is returned for any
 // post-removal maintenance. Strict-mode invalidated transactions are also
-// returned.
+// returned. Transactions the sponsored function reports paid by a sponsor are
+// exempt from the cost limit.
 //
 // This method uses the cached costcap and gascap to quickly decide if there's even
 // a point in calculating all the costs or if the balance covers all. If the threshold
 // is lower than the costgas cap, the caps will be reset to a new high after removing
 // the newly invalidated transactions.
-func (l *txList) Filter(valueLimit, costLimit *big.Int, gasLimit uint64) (types.Transactions, types.Transactions) {
+func (l *txList) Filter(valueLimit, costLimit *big.Int, gasLimit uint64, sponsored func(*types.Transaction) bool) (types.Transactions, types.Transactions) {
 	// If all transactions are below the threshold, short circuit
 	if l.valuecap.Cmp(valueLimit) <= 0 && l.costcap.Cmp(costLimit) <= 0 && l.gascap <= gasLimit {
 		return nil, nil
@@ -303,7 +300,9 @@ func (l *txList) Filter(valueLimit, costLimit *big.Int, gasLimit uint64) (types.
 	l.valuecap = new(big.Int).Set(valueLimit)
 
 	// Filter out all the transactions above the account's funds
-	removed := l.txs.Filter(func(tx *types.Transaction) bool { return tx.Value().Cmp(valueLimit) > 0 || tx.Cost().Cmp(costLimit) > 0 || tx.Gas() > gasLimit })
+	removed := l.txs.Filter(func(tx *types.Transaction) bool {
+		return tx.Value().Cmp(valueLimit) > 0 || (tx.Cost().Cmp(costLimit) > 0 && !sponsored(tx)) || tx.Gas() > gasLimit
+	})
 
 	// If the list was strict, filter anything above the lowest nonce
 	var invalids types.Transactions
@@ -29,6 +29,7 @@ import (
 	"github.com/etherzero/go-etherzero/common/prque"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/metrics"
@@ -56,12 +57,28 @@ var (
 	// with a different one without the required price bump.
 	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
 
+	// ErrReplaceUnderpowered is returned if a transaction is attempted to be
+	// replaced with one committing too little additional power (gas * price).
+	ErrReplaceUnderpowered = errors.New("replacement transaction underpowered")
+
+	// ErrReplaceTooEarly is returned if a transaction is attempted to be replaced
+	// before it was pooled for the replacement delay.
+	ErrReplaceTooEarly = errors.New("replacement transaction too early")
+
 	// ErrInsufficientFunds is returned if the total cost of executing a transaction
 	// is higher than the balance of the user's account.
 	ErrInsufficientFunds = errors.New("insufficient funds for value")
 	ErrInsufficientMinFunds = errors.New("insufficient funds for 0.01 etz")
 	ErrInsufficientPower = errors.New("insufficient power for gas * price")
 
+	// ErrVoteRateLimit is returned if an account sent more voting transactions
+	// than permitted within the configured time window.
+	ErrVoteRateLimit = errors.New("voting transaction rate limit exceeded")
+
+	// ErrVoteInsufficientPower is returned if an account doesn't hold the minimum
+	// power required to send voting transactions.
+	ErrVoteInsufficientPower = errors.New("insufficient power for voting transaction")
+
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
@@ -101,6 +118,10 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Metrics for rejected voting transaction spam
+	voteRateLimitCounter = metrics.NewRegisteredCounter("txpool/vote/ratelimit", nil) // Rejected due to rate limiting
+	voteNopowerCounter   = metrics.NewRegisteredCounter("txpool/vote/nopower", nil)   // Rejected due to insufficient power
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -130,15 +151,25 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	RemoteJournal     string // Journal of remote transactions to survive node restarts
+	RemoteJournalSize uint64 // Maximum number of remote transactions journaled (0 = disabled)
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
+	ReplacementPolicy string        // Rule replacing an already existing transaction (price, power or time)
+	ReplacementDelay  time.Duration // Time a transaction must be pooled before replacement under the time policy
+
 	AccountSlots uint64 // Number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	VoteLimit    uint64        // Maximum number of voting transactions accepted per account within VoteWindow (0 = unlimited)
+	VoteWindow   time.Duration // Time window of the per account voting transaction rate limit
+	VoteMinPower uint64        // Minimum power an account needs to hold to send voting transactions
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -147,15 +178,24 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteJournal: "remotes.rlp",
+
 	PriceLimit: 1000000000,
 	PriceBump:  10,
 
+	ReplacementPolicy: ReplacePrice,
+	ReplacementDelay:  time.Minute,
+
 	AccountSlots: 16,
 	GlobalSlots:  4096,
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
 	Lifetime: 30 * time.Minute,
+
+	VoteLimit:    8,
+	VoteWindow:   time.Hour,
+	VoteMinPower: 90000 * 1000000000,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -174,6 +214,20 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	switch conf.ReplacementPolicy {
+	case ReplacePrice, ReplacePower, ReplaceTime:
+	default:
+		log.Warn("Sanitizing invalid txpool replacement policy", "provided", conf.ReplacementPolicy, "updated", DefaultTxPoolConfig.ReplacementPolicy)
+		conf.ReplacementPolicy = DefaultTxPoolConfig.ReplacementPolicy
+	}
+	if conf.ReplacementPolicy == ReplaceTime && conf.ReplacementDelay < time.Second {
+		log.Warn("Sanitizing invalid txpool replacement delay", "provided", conf.ReplacementDelay, "updated", DefaultTxPoolConfig.ReplacementDelay)
+		conf.ReplacementDelay = DefaultTxPoolConfig.ReplacementDelay
+	}
+	if conf.VoteLimit > 0 && conf.VoteWindow < time.Second {
+		log.Warn("Sanitizing invalid txpool vote window", "provided", conf.VoteWindow, "updated", DefaultTxPoolConfig.VoteWindow)
+		conf.VoteWindow = DefaultTxPoolConfig.VoteWindow
+	}
 	return conf
 }
 
@@ -200,8 +254,13 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals  *accountSet  // Set of local transaction to exempt from eviction rules
+	journal *txJournal   // Journal of local transaction to back up to disk
+	remotes *txJournal   // Journal of remote transactions to back up to disk, nil if disabled
+	votes   *voteLimiter // Rate limiter of remote voting transactions
+	replace txReplacer   // Replacement policy of transactions with the same nonce
+
+	replaying bool // Whether journaled or reorged transactions are re-added, exempt from the vote rate limit
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -232,6 +291,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+		votes:       newVoteLimiter(config.VoteLimit, config.VoteWindow),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
@@ -239,6 +299,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pool.locals.add(addr)
 	}
 	pool.priced = newTxPricedList(pool.all)
+	pool.replace = newTxReplacer(&config, pool.all.Arrival)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// If local transactions and journaling is enabled, load from disk
@@ -252,6 +313,15 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, load the remotes too, after the locals
+	// for these to take precedence
+	if config.RemoteJournal != "" && config.RemoteJournalSize > 0 {
+		pool.remotes = newTxJournal(config.RemoteJournal)
+
+		if err := pool.remotes.load(pool.replayRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -329,6 +399,7 @@ func (pool *TxPool) loop() {
 					}
 				}
 			}
+			pool.votes.expire(time.Now())
 			pool.mu.Unlock()
 
 		// Handle local transaction journal rotation
@@ -340,6 +411,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remotes != nil {
+				pool.mu.Lock()
+				if err := pool.remotes.rotate(pool.remote(int(pool.config.RemoteJournalSize))); err != nil {
+					log.Warn("Failed to rotate remote tx journal", "err", err)
+				}
+				pool.mu.Unlock()
+			}
 		}
 	}
 }
@@ -419,7 +497,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	senderCacher.recover(pool.signer, reinject)
+	pool.replaying = true
 	pool.addTxsLocked(reinject, false)
+	pool.replaying = false
 
 	// validate the pool of pending transactions, this will remove
 	// any transactions that have been included in the block or
@@ -449,6 +529,14 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.remotes != nil {
+		pool.mu.Lock()
+		if err := pool.remotes.rotate(pool.remote(int(pool.config.RemoteJournalSize))); err != nil {
+			log.Warn("Failed to rotate remote tx journal", "err", err)
+		}
+		pool.mu.Unlock()
+		pool.remotes.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -565,6 +653,54 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves up to limit transactions of the accounts not local, in nonce
+// order per account. The executable transactions are picked before the queued
+// ones, the cheapest of which are the first to go anyway.
+func (pool *TxPool) remote(limit int) map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr, list := range lists {
+			if limit <= 0 {
+				return txs
+			}
+			if pool.locals.contains(addr) {
+				continue
+			}
+			flat := list.Flatten()
+			if len(flat) > limit {
+				flat = flat[:limit]
+			}
+			txs[addr] = append(txs[addr], flat...)
+			limit -= len(flat)
+		}
+	}
+	return txs
+}
+
+// sponsored reports whether the power of the transaction will be paid by the
+// contract it calls, according to the sponsor registry at the current state.
+func (pool *TxPool) sponsored(from common.Address, tx *types.Transaction) bool {
+	to := tx.To()
+	if to == nil {
+		return false
+	}
+	number := pool.chain.CurrentBlock().Number()
+	if !pool.chainconfig.Devote.IsSponsor(new(big.Int).Add(number, common.Big1)) {
+		return false
+	}
+	if vm.Sponsorship(pool.currentState, *to, from) < tx.Gas() {
+		return false
+	}
+	return pool.currentState.GetPower(*to, number).Cmp(tx.Cost()) >= 0
+}
+
+// sponsoredBy returns a filter reporting the sponsored transactions of addr.
+func (pool *TxPool) sponsoredBy(addr common.Address) func(*types.Transaction) bool {
+	return func(tx *types.Transaction) bool {
+		return pool.sponsored(addr, tx)
+	}
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -601,11 +737,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		// return ErrInsufficientFunds
 		return errors.New(fmt.Sprintf("insufficient funds: %s %s", tx.Value().String(), from.Hex()))
 	}
-	if pool.currentState.GetBalance(from).Cmp(big.NewInt(1e+16)) < 0 {
-		return ErrInsufficientMinFunds
-	}
-	if pool.currentState.GetPower(from, pool.chain.CurrentBlock().Number()).Cmp(tx.Cost()) < 0 {
-		return ErrInsufficientPower
+	// Sponsored transactions are paid with the power of the called contract
+	if !pool.sponsored(from, tx) {
+		if pool.currentState.GetBalance(from).Cmp(big.NewInt(1e+16)) < 0 {
+			return ErrInsufficientMinFunds
+		}
+		if pool.currentState.GetPower(from, pool.chain.CurrentBlock().Number()).Cmp(tx.Cost()) < 0 {
+			return ErrInsufficientPower
+		}
 	}
 	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
 	if err != nil {
@@ -614,6 +753,17 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	// Protect against spamming the system contracts with power funded votes
+	if !local && IsVoteTx(pool.chainconfig, tx) {
+		if pool.currentState.GetPower(from, pool.chain.CurrentBlock().Number()).Cmp(new(big.Int).SetUint64(pool.config.VoteMinPower)) < 0 {
+			voteNopowerCounter.Inc(1)
+			return ErrVoteInsufficientPower
+		}
+		if !pool.replaying && !pool.votes.allow(from, time.Now()) {
+			voteRateLimitCounter.Inc(1)
+			return ErrVoteRateLimit
+		}
+	}
 	return nil
 }
 
@@ -657,11 +807,11 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction is replacing an already pending one, do directly
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
-		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
-		if !inserted {
+		// Nonce already pending, check if the replacement policy is met
+		old, err := list.Add(tx, pool.replace)
+		if err != nil {
 			pendingDiscardCounter.Inc(1)
-			return false, ErrReplaceUnderpriced
+			return false, err
 		}
 		// New transaction is better, replace old one
 		if old != nil {
@@ -672,6 +822,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
 		pool.journalTx(from, tx)
+		pool.chargeVote(from, tx, local)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
@@ -693,11 +844,22 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 	}
 	pool.journalTx(from, tx)
+	pool.chargeVote(from, tx, local)
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
 	return replace, nil
 }
 
+// chargeVote counts a remote voting transaction accepted into the pool against
+// the rate limit of its sender, unless it's only re-added.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) chargeVote(from common.Address, tx *types.Transaction, local bool) {
+	if !local && !pool.replaying && IsVoteTx(pool.chainconfig, tx) {
+		pool.votes.add(from, time.Now())
+	}
+}
+
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
 // Note, this method assumes the pool lock is held!
@@ -707,11 +869,11 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
-	if !inserted {
-		// An older transaction was better, discard this
+	old, err := pool.queue[from].Add(tx, pool.replace)
+	if err != nil {
+		// An older transaction may not be replaced, discard this
 		queuedDiscardCounter.Inc(1)
-		return false, ErrReplaceUnderpriced
+		return false, err
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
@@ -749,9 +911,9 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
-	if !inserted {
-		// An older transaction was better, discard this
+	old, err := list.Add(tx, pool.replace)
+	if err != nil {
+		// An older transaction may not be replaced, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed()
 
@@ -807,6 +969,18 @@ func (pool *TxPool) AddRemotes(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, false)
 }
 
+// replayRemotes re-adds the remote transactions of the journal, without counting
+// the voting ones against the rate limit again.
+func (pool *TxPool) replayRemotes(txs []*types.Transaction) []error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.replaying = true
+	defer func() { pool.replaying = false }()
+
+	return pool.addTxsLocked(txs, false)
+}
+
 // addTx enqueues a single transaction into the pool if it is valid.
 func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 	pool.mu.Lock()
@@ -955,7 +1129,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
-		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas)
+		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas, pool.sponsoredBy(addr))
 
 		for _, tx := range drops {
 			hash := tx.Hash()
@@ -1120,7 +1294,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
-		drops, invalids := list.Filter(pool.currentState.GetBalance(addr),pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas)
+		drops, invalids := list.Filter(pool.currentState.GetBalance(addr),pool.currentState.GetPower(addr, pool.chain.CurrentBlock().Number()), pool.currentMaxGas, pool.sponsoredBy(addr))
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
@@ -1222,14 +1396,16 @@ func (as *accountSet) flatten() []common.Address {
 // peeking into the pool in TxPool.Get without having to acquire the widely scoped
 // TxPool.mu mutex.
 type txLookup struct {
-	all  map[common.Hash]*types.Transaction
-	lock sync.RWMutex
+	all     map[common.Hash]*types.Transaction
+	arrived map[common.Hash]time.Time // Time each transaction was first seen at
+	lock    sync.RWMutex
 }
 
 // newTxLookup returns a new txLookup structure.
 func newTxLookup() *txLookup {
 	return &txLookup{
-		all: make(map[common.Hash]*types.Transaction),
+		all:     make(map[common.Hash]*types.Transaction),
+		arrived: make(map[common.Hash]time.Time),
 	}
 }
 
@@ -1266,7 +1442,20 @@ func (t *txLookup) Add(tx *types.Transaction) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	t.all[tx.Hash()] = tx
+	hash := tx.Hash()
+	if _, ok := t.all[hash]; !ok {
+		t.arrived[hash] = time.Now()
+	}
+	t.all[hash] = tx
+}
+
+// Arrival returns the time the transaction was added to the lookup at, or the
+// zero time if it isn't present.
+func (t *txLookup) Arrival(hash common.Hash) time.Time {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.arrived[hash]
 }
 
 // Remove removes a transaction from the lookup.
@@ -1275,4 +1464,5 @@ func (t *txLookup) Remove(hash common.Hash) {
 	defer t.lock.Unlock()
 
 	delete(t.all, hash)
+	delete(t.arrived, hash)
 }
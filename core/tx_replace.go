@@ -0,0 +1,93 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+)
+
+// Transaction replacement policies, deciding when a transaction may replace a
+// pooled one of the same sender and nonce. Transactions are paid with power on
+// etherzero, so bumping the gas price alone costs the sender nothing.
+const (
+	ReplacePrice = "price" // The gas price must be raised by PriceBump percent
+	ReplacePower = "power" // The power committed (gas * gas price) must be raised by PriceBump percent
+	ReplaceTime  = "time"  // The replaced transaction must have been pooled for ReplacementDelay
+)
+
+// txReplacer decides whether a transaction may replace the pooled one with the
+// same nonce, returning the reason if it may not.
+type txReplacer func(old, tx *types.Transaction) error
+
+// newTxReplacer creates the replacer of the configured policy. The arrival
+// function returns the time a pooled transaction was first seen at.
+func newTxReplacer(config *TxPoolConfig, arrival func(common.Hash) time.Time) txReplacer {
+	switch config.ReplacementPolicy {
+	case ReplacePower:
+		return powerReplacer(config.PriceBump)
+	case ReplaceTime:
+		return timeReplacer(config.ReplacementDelay, arrival)
+	default:
+		return priceReplacer(config.PriceBump)
+	}
+}
+
+// priceReplacer accepts replacements raising the gas price by the bump percent.
+func priceReplacer(bump uint64) txReplacer {
+	return func(old, tx *types.Transaction) error {
+		if !bumped(old.GasPrice(), tx.GasPrice(), bump) {
+			return ErrReplaceUnderpriced
+		}
+		return nil
+	}
+}
+
+// powerReplacer accepts replacements committing the bump percent more power,
+// so a higher gas price can't be offset by a lower gas limit.
+func powerReplacer(bump uint64) txReplacer {
+	return func(old, tx *types.Transaction) error {
+		have := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+		was := new(big.Int).Mul(old.GasPrice(), new(big.Int).SetUint64(old.Gas()))
+		if !bumped(was, have, bump) {
+			return ErrReplaceUnderpowered
+		}
+		return nil
+	}
+}
+
+// timeReplacer accepts replacements of transactions which were pooled for at
+// least the given delay, regardless of their price, so stuck transactions can be
+// replaced on a chain without fees while replacement spam stays rate limited.
+func timeReplacer(delay time.Duration, arrival func(common.Hash) time.Time) txReplacer {
+	return func(old, tx *types.Transaction) error {
+		if time.Since(arrival(old.Hash())) < delay {
+			return ErrReplaceTooEarly
+		}
+		return nil
+	}
+}
+
+// bumped reports whether have exceeds was by at least the bump percent. The
+// value must also be strictly higher, which matters for tiny values.
+func bumped(was, have *big.Int, bump uint64) bool {
+	threshold := new(big.Int).Div(new(big.Int).Mul(was, big.NewInt(100+int64(bump))), big.NewInt(100))
+	return was.Cmp(have) < 0 && threshold.Cmp(have) <= 0
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// Tests that each replacement policy accepts and rejects the replacements it's
+// meant to.
+func TestTransactionReplacers(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	old := pricedTransaction(0, 100000, big.NewInt(100), key)
+
+	tests := []struct {
+		policy string
+		gas    uint64
+		price  int64
+		err    error
+	}{
+		// The gas price must be bumped, whatever the gas
+		{ReplacePrice, 100000, 109, ErrReplaceUnderpriced},
+		{ReplacePrice, 50000, 110, nil},
+		// The power committed must be bumped, a lower gas can't offset the price
+		{ReplacePower, 50000, 110, ErrReplaceUnderpowered},
+		{ReplacePower, 100000, 109, ErrReplaceUnderpowered},
+		{ReplacePower, 50000, 220, nil},
+		{ReplacePower, 110000, 100, nil},
+	}
+	for i, tt := range tests {
+		replace := newTxReplacer(&TxPoolConfig{ReplacementPolicy: tt.policy, PriceBump: 10}, nil)
+		if err := replace(old, pricedTransaction(0, tt.gas, big.NewInt(tt.price), key)); err != tt.err {
+			t.Errorf("test %d: %s replacement error mismatch: have %v, want %v", i, tt.policy, err, tt.err)
+		}
+	}
+	// Under the time policy only the age of the replaced transaction matters
+	arrived := time.Now()
+	arrival := func(hash common.Hash) time.Time {
+		if hash != old.Hash() {
+			t.Fatalf("arrival of unexpected transaction %x requested", hash)
+		}
+		return arrived
+	}
+	replace := newTxReplacer(&TxPoolConfig{ReplacementPolicy: ReplaceTime, ReplacementDelay: time.Minute}, arrival)
+	if err := replace(old, pricedTransaction(0, 100000, big.NewInt(1000), key)); err != ErrReplaceTooEarly {
+		t.Errorf("early replacement error mismatch: have %v, want %v", err, ErrReplaceTooEarly)
+	}
+	arrived = arrived.Add(-time.Minute)
+	if err := replace(old, pricedTransaction(0, 100000, big.NewInt(1), key)); err != nil {
+		t.Errorf("failed to replace aged transaction: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// IsVoteTx reports whether the transaction is a voting transaction, i.e. a call
+// to any version of the masternode contract or to the governance contract. Those
+// are paid with power instead of balance, making them cheap to spam.
+func IsVoteTx(config *params.ChainConfig, tx *types.Transaction) bool {
+	to := tx.To()
+	return to != nil && (config.Devote.IsMasternodeContract(*to) || *to == config.Devote.GovernanceContractAddress())
+}
+
+// voteLimiter tracks the voting transactions recently accepted from each account
+// to rate limit them within a sliding time window.
+type voteLimiter struct {
+	limit  uint64
+	window time.Duration
+	seen   map[common.Address][]time.Time
+}
+
+func newVoteLimiter(limit uint64, window time.Duration) *voteLimiter {
+	return &voteLimiter{
+		limit:  limit,
+		window: window,
+		seen:   make(map[common.Address][]time.Time),
+	}
+}
+
+// allow reports whether another voting transaction from addr may be accepted.
+func (l *voteLimiter) allow(addr common.Address, now time.Time) bool {
+	if l.limit == 0 {
+		return true
+	}
+	return uint64(len(l.prune(addr, now))) < l.limit
+}
+
+// add records a voting transaction accepted from addr.
+func (l *voteLimiter) add(addr common.Address, now time.Time) {
+	if l.limit == 0 {
+		return
+	}
+	l.seen[addr] = append(l.prune(addr, now), now)
+}
+
+// prune drops the timestamps of addr which fell out of the window.
+func (l *voteLimiter) prune(addr common.Address, now time.Time) []time.Time {
+	times := l.seen[addr]
+	for len(times) > 0 && now.Sub(times[0]) >= l.window {
+		times = times[1:]
+	}
+	if len(times) == 0 {
+		delete(l.seen, addr)
+		return nil
+	}
+	l.seen[addr] = times
+	return times
+}
+
+// expire drops all accounts without voting transactions in the window.
+func (l *voteLimiter) expire(now time.Time) {
+	for addr := range l.seen {
+		l.prune(addr, now)
+	}
+}
@@ -18,13 +18,15 @@ package devotedb
 
 
 import (
-"fmt"
-"sync"
-
-"github.com/etherzero/go-etherzero/common"
-"github.com/etherzero/go-etherzero/ethdb"
-"github.com/etherzero/go-etherzero/trie"
-lru "github.com/hashicorp/golang-lru"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/metrics"
+	"github.com/etherzero/go-etherzero/trie"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 // Trie cache generation limit after which to evict trie nodes from memory.
@@ -166,6 +168,20 @@ type cachedTrie struct {
 	db *cachingDB
 }
 
+// TryGet retrieves a value from the trie, timing the lookup if metrics are on.
+func (m cachedTrie) TryGet(key []byte) ([]byte, error) {
+	if !metrics.Enabled {
+		return m.SecureTrie.TryGet(key)
+	}
+	start := time.Now()
+	value, err := m.SecureTrie.TryGet(key)
+	trieReadTimer.UpdateSince(start)
+	if err != nil {
+		trieReadFailMeter.Mark(1)
+	}
+	return value, err
+}
+
 func (m cachedTrie) Commit(onleaf trie.LeafCallback) (common.Hash, error) {
 	root, err := m.SecureTrie.Commit(onleaf)
 	if err == nil {
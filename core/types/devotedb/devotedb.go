@@ -21,10 +21,14 @@ package devotedb
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/crypto/sha3"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/params"
@@ -34,12 +38,28 @@ import (
 	"github.com/etherzero/go-etherzero/log"
 )
 
+// Key namespaces of the unified devote trie, holding both the witnesses and the
+// stats tries of the legacy layout.
+var (
+	witnessesPrefix = []byte("w")      // witnessesPrefix + cycle (uint64 big endian) -> witnesses
+	statsPrefix     = []byte("s")      // statsPrefix + cycle (uint64 big endian) + witness -> count
+	standbysPrefix  = []byte("b")      // standbysPrefix + cycle (uint64 big endian) -> standby witnesses, in both layouts
+	beaconPrefix    = []byte("m")      // beaconPrefix + cycle (uint64 big endian) -> randomness beacon mix, in both layouts
+	revealPrefix    = []byte("r")      // revealPrefix + witness -> last beacon reveal of the witness, in both layouts
+	mnSetPrefix     = []byte("n")      // mnSetPrefix + cycle (uint64 big endian) -> hash of the masternode set, in both layouts
+	registryKey     = []byte("g")      // registryKey -> masternode registry, in both layouts
+	legacyKey       = []byte("legacy") // legacyKey -> protocol of the tries replaced by the unified one
+)
+
 type DevoteDB struct {
 	db Database //etherzero db
 
 	statsTrie Trie //statsTrie cycle+witness +cnt
 	cycleTrie Trie //cycleTrie cyele key  ->  witnesses value
 
+	unified bool      // Whether both tries are the single, namespaced devote trie
+	legacy  *DevoteDB // Tries of the records written before the unified trie, read when missing
+
 	dCache *DevoteCache
 
 	cycle             uint64 //current cycle
@@ -52,28 +72,18 @@ type DevoteDB struct {
 func New(db Database, cycleRoot, statsRoot common.Hash) (*DevoteDB, error) {
 	csc, _ := lru.New(codeSizeCacheSize)
 
-	cycleTrie, cerr := db.OpenTrie(cycleRoot)
-	if cerr != nil {
-		return nil, cerr
-	}
-	statsTrie, serr := db.OpenTrie(statsRoot)
-	if serr != nil {
-		return nil, serr
-	}
-
-	d := &DevoteDB{
-		db:            db,
-		cycleTrie:     cycleTrie,
-		statsTrie:     statsTrie,
-		codeSizeCache: csc,
+	d, err := NewDevoteByProtocol(db, &DevoteProtocol{CycleHash: cycleRoot, StatsHash: statsRoot})
+	if err != nil {
+		return nil, err
 	}
-	cache := newCache(d)
-	d.setDevoteCache(cache)
+	d.codeSizeCache = csc
 	return d, nil
 }
 
 func NewDevoteByProtocol(db Database, protocol *DevoteProtocol) (*DevoteDB, error) {
-
+	if protocol.Unified() {
+		return newUnified(db, protocol.CycleHash)
+	}
 	cycleTrie, err := db.OpenTrie(protocol.CycleHash)
 	if err != nil {
 		return nil, err
@@ -92,6 +102,112 @@ func NewDevoteByProtocol(db Database, protocol *DevoteProtocol) (*DevoteDB, erro
 	return d, nil
 }
 
+// newUnified opens the unified devote trie, along with the legacy tries it was
+// forked from.
+func newUnified(db Database, root common.Hash) (*DevoteDB, error) {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	d := &DevoteDB{
+		cycleTrie: tr,
+		statsTrie: tr,
+		db:        db,
+		unified:   true,
+	}
+	if enc, _ := tr.TryGet(legacyKey); len(enc) > 0 {
+		protocol := new(DevoteProtocol)
+		if err := rlp.DecodeBytes(enc, protocol); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy devote protocol: %v", err)
+		}
+		if d.legacy, err = NewDevoteByProtocol(db, protocol); err != nil {
+			return nil, err
+		}
+	}
+	d.setDevoteCache(newCache(d))
+	return d, nil
+}
+
+// Unify moves the devote records into a single trie. The new trie starts out
+// holding the protocol of the current tries, which stay readable for the records
+// written before, so the transition needs no copying. The current tries must be
+// committed already.
+func (d *DevoteDB) Unify() error {
+	if d.unified {
+		return nil
+	}
+	legacy := &DevoteDB{
+		cycleTrie: d.cycleTrie,
+		statsTrie: d.statsTrie,
+		db:        d.db,
+	}
+	legacy.setDevoteCache(newCache(legacy))
+
+	enc, err := rlp.EncodeToBytes(legacy.Protocol())
+	if err != nil {
+		return err
+	}
+	tr, err := d.db.OpenTrie(common.Hash{})
+	if err != nil {
+		return err
+	}
+	if err := tr.TryUpdate(legacyKey, enc); err != nil {
+		return err
+	}
+	d.cycleTrie, d.statsTrie = tr, tr
+	d.unified, d.legacy = true, legacy
+	d.setDevoteCache(newCache(d))
+	return nil
+}
+
+// Unified reports whether the records live in the single devote trie.
+func (d *DevoteDB) Unified() bool {
+	return d.unified
+}
+
+// TrieRoots returns the roots of all the tries the records are read from, the
+// ones of the legacy tries included.
+func (d *DevoteDB) TrieRoots() []common.Hash {
+	if !d.unified {
+		return []common.Hash{d.cycleTrie.Hash(), d.statsTrie.Hash()}
+	}
+	roots := []common.Hash{d.cycleTrie.Hash()}
+	if d.legacy != nil {
+		roots = append(roots, d.legacy.TrieRoots()...)
+	}
+	return roots
+}
+
+// witnessesKey returns the key of the witnesses of a cycle.
+func (d *DevoteDB) witnessesKey(cycle uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	if d.unified {
+		return append(common.CopyBytes(witnessesPrefix), key...)
+	}
+	return key
+}
+
+// statsKey returns the key of a cycle+witness stats record.
+func (d *DevoteDB) statsKey(key []byte) []byte {
+	if d.unified {
+		return append(common.CopyBytes(statsPrefix), key...)
+	}
+	return key
+}
+
+// getStats retrieves a cycle+witness stats record, from the legacy tries if it
+// was written before the unified trie.
+func (d *DevoteDB) getStats(key []byte) []byte {
+	if cntBytes, _ := d.statsTrie.TryGet(d.statsKey(key)); cntBytes != nil {
+		return cntBytes
+	}
+	if d.legacy != nil {
+		return d.legacy.getStats(key)
+	}
+	return nil
+}
+
 func (db *DevoteDB) Database() Database {
 	return db.db
 }
@@ -100,6 +216,9 @@ func (db *DevoteDB) Root() (h common.Hash) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	if db.unified {
+		return db.cycleTrie.Hash()
+	}
 	hw := sha3.NewKeccak256()
 	rlp.Encode(hw, db.cycleTrie.Hash())
 	rlp.Encode(hw, db.statsTrie.Hash())
@@ -108,16 +227,23 @@ func (db *DevoteDB) Root() (h common.Hash) {
 }
 
 func (d *DevoteDB) Commit() (*DevoteProtocol, error) {
-	cycleRoot, err := d.cycleTrie.Commit(nil)
+	defer commitTimer.UpdateSince(time.Now())
+
+	if d.unified {
+		root, err := d.commitTrie(d.cycleTrie)
+		if err != nil {
+			return nil, err
+		}
+		return &DevoteProtocol{CycleHash: root}, nil
+	}
+	cycleRoot, err := d.commitTrie(d.cycleTrie)
 	if err != nil {
 		return nil, err
 	}
-	d.db.TrieDB().Commit(cycleRoot, false)
-	statsRoot, err := d.statsTrie.Commit(nil)
+	statsRoot, err := d.commitTrie(d.statsTrie)
 	if err != nil {
 		return nil, err
 	}
-	d.db.TrieDB().Commit(statsRoot, false)
 	a := &DevoteProtocol{
 		CycleHash: cycleRoot,
 		StatsHash: statsRoot,
@@ -125,6 +251,20 @@ func (d *DevoteDB) Commit() (*DevoteProtocol, error) {
 	return a, nil
 }
 
+// commitTrie commits a devote trie and flushes its nodes into the database.
+func (d *DevoteDB) commitTrie(tr Trie) (common.Hash, error) {
+	root, err := tr.Commit(nil)
+	if err != nil {
+		commitFailMeter.Mark(1)
+		return common.Hash{}, err
+	}
+	start := time.Now()
+	d.db.TrieDB().Commit(root, false)
+	commitFlushTimer.UpdateSince(start)
+
+	return root, nil
+}
+
 func (d *DevoteDB) Copy() *DevoteDB {
 	cycleTrie := d.cycleTrie
 	statsTrie := d.statsTrie
@@ -132,6 +272,8 @@ func (d *DevoteDB) Copy() *DevoteDB {
 	return &DevoteDB{
 		cycleTrie: cycleTrie,
 		statsTrie: statsTrie,
+		unified:   d.unified,
+		legacy:    d.legacy,
 	}
 }
 
@@ -142,6 +284,8 @@ func (d *DevoteDB) Snapshot() *DevoteDB {
 func (d *DevoteDB) RevertToSnapShot(snapshot *DevoteDB) {
 	d.cycleTrie = snapshot.cycleTrie
 	d.statsTrie = snapshot.statsTrie
+	d.unified = snapshot.unified
+	d.legacy = snapshot.legacy
 }
 
 func (d *DevoteDB) SetCycleTrie(trie Trie) {
@@ -157,7 +301,7 @@ func (d *DevoteDB) GetStatsNumber(key []byte) uint64 {
 	hash := common.Hash{}
 	hash.SetBytes(key)
 	if len(d.dCache.stats) < 1 {
-		if cntBytes, _ := d.statsTrie.TryGet(key); cntBytes != nil {
+		if cntBytes := d.getStats(key); cntBytes != nil {
 			count := binary.BigEndian.Uint64(cntBytes)
 			return count
 		}
@@ -173,13 +317,14 @@ func (d *DevoteDB) GetWitnesses(cycle uint64) ([]string, error) {
 	//		return list, nil
 	//	}
 	//}
-	newCycleBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(newCycleBytes, uint64(cycle))
 	// Load from DB in case it is missing.
-	witnessRLP, err := d.cycleTrie.TryGet(newCycleBytes)
+	witnessRLP, err := d.cycleTrie.TryGet(d.witnessesKey(cycle))
 	if err != nil {
 		return nil, err
 	}
+	if len(witnessRLP) == 0 && d.legacy != nil {
+		return d.legacy.GetWitnesses(cycle)
+	}
 
 	var witnesses []string
 	if err := rlp.DecodeBytes(witnessRLP, &witnesses); err != nil {
@@ -196,14 +341,196 @@ func (d *DevoteDB) SetWitnesses(cycle uint64, witnesses []string) error {
 	if d.dCache != nil {
 		d.dCache.SetWitnesses(cycle, witnesses)
 	}
-	newCycleBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(newCycleBytes, uint64(cycle))
 	witnessesRLP, err := rlp.EncodeToBytes(witnesses)
 	if err != nil {
 		return fmt.Errorf("failed to encode witnesses to rlp bytes: %s", err)
 	}
 
-	return d.cycleTrie.TryUpdate(newCycleBytes, witnessesRLP)
+	return d.cycleTrie.TryUpdate(d.witnessesKey(cycle), witnessesRLP)
+}
+
+// standbysKey returns the key of the standby witnesses of a cycle. Unlike the
+// witnesses, they are namespaced in the legacy cycle trie too.
+func standbysKey(cycle uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	return append(common.CopyBytes(standbysPrefix), key...)
+}
+
+// GetStandbys retrieves the standby witnesses elected for a cycle, which may
+// seal the slots missed by its witnesses. Cycles elected before the standby fork
+// have none.
+func (d *DevoteDB) GetStandbys(cycle uint64) ([]string, error) {
+	standbysRLP, err := d.cycleTrie.TryGet(standbysKey(cycle))
+	if err != nil {
+		return nil, err
+	}
+	if len(standbysRLP) == 0 {
+		if d.legacy != nil {
+			return d.legacy.GetStandbys(cycle)
+		}
+		return nil, nil
+	}
+	var standbys []string
+	if err := rlp.DecodeBytes(standbysRLP, &standbys); err != nil {
+		return nil, fmt.Errorf("failed to decode standbys: %s", err)
+	}
+	return standbys, nil
+}
+
+// SetStandbys records the standby witnesses elected for a cycle.
+func (d *DevoteDB) SetStandbys(cycle uint64, standbys []string) error {
+	standbysRLP, err := rlp.EncodeToBytes(standbys)
+	if err != nil {
+		return fmt.Errorf("failed to encode standbys to rlp bytes: %s", err)
+	}
+	return d.cycleTrie.TryUpdate(standbysKey(cycle), standbysRLP)
+}
+
+// BeaconReveal is the last contribution of a witness to the randomness beacon.
+type BeaconReveal struct {
+	Value     common.Hash // Onion layer revealed, the next one hashing to it
+	Cycle     uint64      // Cycle the layer was revealed in
+	Defaulted bool        // Whether the layer didn't follow the previous one of the onion
+}
+
+// GetBeacon retrieves the randomness beacon mixed from the reveals of a cycle,
+// the zero hash if nothing was revealed.
+func (d *DevoteDB) GetBeacon(cycle uint64) (common.Hash, error) {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+
+	mix, err := d.cycleTrie.TryGet(append(common.CopyBytes(beaconPrefix), key...))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(mix) == 0 && d.legacy != nil {
+		return d.legacy.GetBeacon(cycle)
+	}
+	return common.BytesToHash(mix), nil
+}
+
+// SetBeacon records the randomness beacon of a cycle.
+func (d *DevoteDB) SetBeacon(cycle uint64, mix common.Hash) error {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+
+	return d.cycleTrie.TryUpdate(append(common.CopyBytes(beaconPrefix), key...), mix.Bytes())
+}
+
+// GetBeaconReveal retrieves the last beacon reveal of a witness, nil if it never
+// revealed anything.
+func (d *DevoteDB) GetBeaconReveal(witness string) (*BeaconReveal, error) {
+	enc, err := d.cycleTrie.TryGet(append(common.CopyBytes(revealPrefix), []byte(witness)...))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		if d.legacy != nil {
+			return d.legacy.GetBeaconReveal(witness)
+		}
+		return nil, nil
+	}
+	reveal := new(BeaconReveal)
+	if err := rlp.DecodeBytes(enc, reveal); err != nil {
+		return nil, fmt.Errorf("failed to decode beacon reveal: %s", err)
+	}
+	return reveal, nil
+}
+
+// SetBeaconReveal records the last beacon reveal of a witness.
+func (d *DevoteDB) SetBeaconReveal(witness string, reveal *BeaconReveal) error {
+	enc, err := rlp.EncodeToBytes(reveal)
+	if err != nil {
+		return fmt.Errorf("failed to encode beacon reveal to rlp bytes: %s", err)
+	}
+	return d.cycleTrie.TryUpdate(append(common.CopyBytes(revealPrefix), []byte(witness)...), enc)
+}
+
+// MasternodeSetKey returns the key of the masternode set hash of a cycle, which
+// its proofs are verified against.
+func MasternodeSetKey(cycle uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	return append(common.CopyBytes(mnSetPrefix), key...)
+}
+
+// GetMasternodeSet retrieves the hash of the masternode set a cycle was elected
+// from, the zero hash if none was recorded.
+func (d *DevoteDB) GetMasternodeSet(cycle uint64) (common.Hash, error) {
+	enc, err := d.cycleTrie.TryGet(MasternodeSetKey(cycle))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(enc) == 0 && d.legacy != nil {
+		return d.legacy.GetMasternodeSet(cycle)
+	}
+	return common.BytesToHash(enc), nil
+}
+
+// SetMasternodeSet records the hash of the masternode set a cycle was elected
+// from.
+func (d *DevoteDB) SetMasternodeSet(cycle uint64, hash common.Hash) error {
+	return d.cycleTrie.TryUpdate(MasternodeSetKey(cycle), hash.Bytes())
+}
+
+// ProveMasternodeSet writes the merkle proof of the masternode set hash of a
+// cycle into proofDb, returning the root of the trie it proves against: the
+// cycle root of the protocol, or of the legacy one if recorded before the tries
+// were unified. The proof is keyed by the hash of MasternodeSetKey, as the trie
+// is a secure one.
+func (d *DevoteDB) ProveMasternodeSet(cycle uint64, proofDb ethdb.Putter) (common.Hash, error) {
+	key := MasternodeSetKey(cycle)
+	if enc, _ := d.cycleTrie.TryGet(key); len(enc) == 0 && d.legacy != nil {
+		return d.legacy.ProveMasternodeSet(cycle, proofDb)
+	}
+	if err := d.cycleTrie.Prove(crypto.Keccak256(key), 0, proofDb); err != nil {
+		return common.Hash{}, err
+	}
+	return d.cycleTrie.Hash(), nil
+}
+
+// RegisteredMasternode is a masternode of the registry kept in the devote trie.
+type RegisteredMasternode struct {
+	ID          string
+	OriginBlock uint64 // Block the masternode was registered at, zero for the genesis ones
+	LastPing    uint64 // Block of the last ping, zero if never pinged
+}
+
+// MasternodeRegistry is the registry of the masternodes kept in the devote trie,
+// following the events of the masternode contract it was seeded from.
+type MasternodeRegistry struct {
+	Contract common.Address
+	Nodes    []*RegisteredMasternode // Sorted by id
+}
+
+// GetMasternodeRegistry retrieves the masternode registry, nil if it was never
+// seeded.
+func (d *DevoteDB) GetMasternodeRegistry() (*MasternodeRegistry, error) {
+	enc, err := d.cycleTrie.TryGet(registryKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		if d.legacy != nil {
+			return d.legacy.GetMasternodeRegistry()
+		}
+		return nil, nil
+	}
+	registry := new(MasternodeRegistry)
+	if err := rlp.DecodeBytes(enc, registry); err != nil {
+		return nil, fmt.Errorf("failed to decode masternode registry: %s", err)
+	}
+	return registry, nil
+}
+
+// SetMasternodeRegistry records the masternode registry.
+func (d *DevoteDB) SetMasternodeRegistry(registry *MasternodeRegistry) error {
+	enc, err := rlp.EncodeToBytes(registry)
+	if err != nil {
+		return fmt.Errorf("failed to encode masternode registry to rlp bytes: %s", err)
+	}
+	return d.cycleTrie.TryUpdate(registryKey, enc)
 }
 
 func (d *DevoteDB) setDevoteCache(cache *DevoteCache) {
@@ -256,7 +583,7 @@ func (d *DevoteDB) Rolling(parentBlockTime, currentBlockTime uint64, witness str
 		binary.BigEndian.PutUint64(key, currentCycle)
 		// TODO
 		key = append(key, []byte(witness)...)
-		if cntBytes, _ := d.statsTrie.TryGet(key); cntBytes != nil {
+		if cntBytes := d.getStats(key); cntBytes != nil {
 			cnt = binary.BigEndian.Uint64(cntBytes) + 1
 		}
 	}
@@ -266,7 +593,7 @@ func (d *DevoteDB) Rolling(parentBlockTime, currentBlockTime uint64, witness str
 	binary.BigEndian.PutUint64(newCycleBytes, uint64(newCycle))
 	binary.BigEndian.PutUint64(newCntBytes, uint64(cnt))
 
-	d.statsTrie.TryUpdate(append(newCycleBytes, []byte(witness)...), newCntBytes)
+	d.statsTrie.TryUpdate(d.statsKey(append(newCycleBytes, []byte(witness)...)), newCntBytes)
 }
 
 // Exist reports whether the given Devote hash exists in the state.
@@ -276,6 +603,9 @@ func (d *DevoteDB) Exists() bool {
 }
 
 func (d *DevoteDB) Protocol() *DevoteProtocol {
+	if d.unified {
+		return &DevoteProtocol{CycleHash: d.cycleTrie.Hash()}
+	}
 	return &DevoteProtocol{
 		CycleHash: d.cycleTrie.Hash(),
 		StatsHash: d.statsTrie.Hash(),
@@ -290,17 +620,84 @@ func (d *DevoteDB) GetCycle() uint64 {
 	return d.cycle
 }
 
-type DevoteProtocol struct {
-	mu sync.Mutex
+//go:generate gencodec -type DevoteProtocol -out gen_protocol_json.go
 
+// DevoteProtocol is the set of devote trie roots embedded in every header. Its
+// RLP encoding is the list [CycleHash, StatsHash], in this exact order. Since the
+// unified trie fork CycleHash is the root of the single devote trie, StatsHash
+// being left zero, and the protocol is encoded as that single 32 byte root.
+type DevoteProtocol struct {
 	CycleHash common.Hash `json:"cyclehash"  gencodec:"required"`
 	StatsHash common.Hash `json:"statshash"  gencodec:"required"`
 }
 
-func (d *DevoteProtocol) Root() (h common.Hash) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
+// legacyProtocol is the RLP layout of the protocols predating the unified trie.
+type legacyProtocol struct {
+	CycleHash common.Hash
+	StatsHash common.Hash
+}
 
+// EncodeRLP implements rlp.Encoder, encoding unified protocols as their root.
+func (d *DevoteProtocol) EncodeRLP(w io.Writer) error {
+	if d == nil {
+		return rlp.Encode(w, []interface{}{})
+	}
+	if d.Unified() {
+		return rlp.Encode(w, d.CycleHash)
+	}
+	return rlp.Encode(w, legacyProtocol{d.CycleHash, d.StatsHash})
+}
+
+// DecodeRLP implements rlp.Decoder, accepting both the legacy root list and the
+// single root of the unified trie.
+func (d *DevoteProtocol) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		var dec legacyProtocol
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		d.CycleHash, d.StatsHash = dec.CycleHash, dec.StatsHash
+		return nil
+	}
+	var root common.Hash
+	if err := s.Decode(&root); err != nil {
+		return err
+	}
+	if root == (common.Hash{}) {
+		return errors.New("empty unified devote root")
+	}
+	d.CycleHash, d.StatsHash = root, common.Hash{}
+	return nil
+}
+
+// LegacyProtocol returns the protocol of the legacy tries, the ones the unified
+// trie was forked from for unified databases, nil if there are none.
+func (d *DevoteDB) LegacyProtocol() *DevoteProtocol {
+	if !d.unified {
+		return d.Protocol()
+	}
+	if d.legacy == nil {
+		return nil
+	}
+	return d.legacy.Protocol()
+}
+
+// Unified reports whether the protocol is the root of the unified devote trie.
+// Legacy protocols always carry a stats root, if only the empty one.
+func (d *DevoteProtocol) Unified() bool {
+	return d.StatsHash == (common.Hash{}) && d.CycleHash != (common.Hash{})
+}
+
+// Root returns the combined hash of the devote trie roots, the root of the
+// unified trie itself.
+func (d *DevoteProtocol) Root() (h common.Hash) {
+	if d.Unified() {
+		return d.CycleHash
+	}
 	hw := sha3.NewKeccak256()
 	rlp.Encode(hw, d.CycleHash)
 	rlp.Encode(hw, d.StatsHash)
@@ -0,0 +1,154 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build gofuzz
+
+package devotedb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// fuzzWitnesses is the pool of masternode ids the fuzzer picks witnesses from,
+// kept small so the operations keep hitting the same keys.
+var fuzzWitnesses = []string{
+	"0ecb8683bbbe0724", "58e19070b47ded79", "a8d7b1b9e6f2e401", "33e8a2f1d0c4b5a6",
+	"e1", "e1e1", "", "ffffffffffffffff",
+}
+
+// fuzzModel is the reference the devote tries are checked against.
+type fuzzModel struct {
+	witnesses map[uint64][]string // Witness list of every cycle set
+	stats     map[string]uint64   // Block count of every cycle and witness rolled
+}
+
+// Fuzz implements a go-fuzz fuzzer method applying a random sequence of devote
+// trie operations, cross-checking the tries against a plain model after every
+// step, across commits and reloads from the committed protocol, and checking
+// that the final roots only depend on the final content.
+func Fuzz(data []byte) int {
+	var (
+		diskdb = ethdb.NewMemDatabase()
+		db     = mustFuzzDB(diskdb, &DevoteProtocol{})
+		model  = &fuzzModel{witnesses: make(map[uint64][]string), stats: make(map[string]uint64)}
+	)
+	for len(data) >= 4 {
+		op, arg := data[0]%3, data[1:4]
+		data = data[4:]
+
+		switch op {
+		case 0:
+			// Set the witnesses of a cycle to a subset of the pool
+			cycle := uint64(arg[0] % 8)
+			var witnesses []string
+			for i, witness := range fuzzWitnesses {
+				if arg[1]&(1<<uint(i)) != 0 {
+					witnesses = append(witnesses, witness)
+				}
+			}
+			if err := db.SetWitnesses(cycle, witnesses); err != nil {
+				panic(fmt.Sprintf("failed to set witnesses: %v", err))
+			}
+			model.witnesses[cycle] = witnesses
+
+		case 1:
+			// Roll a block sealed by a pool witness, possibly opening a cycle
+			parent := uint64(arg[0]) * params.Epoch / 4
+			current := parent + uint64(arg[1]%4)*params.Epoch/4 + params.Period
+			witness := fuzzWitnesses[int(arg[2])%len(fuzzWitnesses)]
+
+			db.Rolling(parent, current, witness)
+
+			key := fuzzStatsKey(current/params.Epoch, witness)
+			if parent/params.Epoch == current/params.Epoch {
+				model.stats[key]++
+			} else {
+				model.stats[key] = 1
+			}
+
+		case 2:
+			// Commit the tries and reload them from the committed protocol
+			root := db.Protocol().Root()
+			protocol, err := db.Commit()
+			if err != nil {
+				panic(fmt.Sprintf("failed to commit: %v", err))
+			}
+			if protocol.Root() != root {
+				panic(fmt.Sprintf("commit root mismatch: have %x, want %x", protocol.Root(), root))
+			}
+			db = mustFuzzDB(diskdb, protocol)
+		}
+		model.check(db)
+	}
+	// The roots must not depend on the order the content was written in
+	fresh := mustFuzzDB(ethdb.NewMemDatabase(), &DevoteProtocol{})
+	for cycle, witnesses := range model.witnesses {
+		if err := fresh.SetWitnesses(cycle, witnesses); err != nil {
+			panic(fmt.Sprintf("failed to set witnesses: %v", err))
+		}
+	}
+	for key, count := range model.stats {
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, count)
+		if err := fresh.statsTrie.TryUpdate([]byte(key), value); err != nil {
+			panic(fmt.Sprintf("failed to set stats: %v", err))
+		}
+	}
+	if have, want := db.Protocol().Root(), fresh.Protocol().Root(); have != want {
+		panic(fmt.Sprintf("root mismatch: have %x, want %x", have, want))
+	}
+	return 0
+}
+
+// mustFuzzDB opens the devote tries of the protocol on top of the given
+// database, with a fresh trie cache.
+func mustFuzzDB(diskdb ethdb.Database, protocol *DevoteProtocol) *DevoteDB {
+	db, err := NewDevoteByProtocol(NewDatabase(diskdb), protocol)
+	if err != nil {
+		panic(fmt.Sprintf("failed to open devote tries: %v", err))
+	}
+	return db
+}
+
+// fuzzStatsKey returns the stats trie key of the witness in the given cycle.
+func fuzzStatsKey(cycle uint64, witness string) string {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	return string(append(key, witness...))
+}
+
+// check panics if the content of the tries diverges from the model.
+func (m *fuzzModel) check(db *DevoteDB) {
+	for cycle, want := range m.witnesses {
+		have, err := db.GetWitnesses(cycle)
+		if err != nil {
+			panic(fmt.Sprintf("failed to get witnesses of cycle %d: %v", cycle, err))
+		}
+		if len(have) != len(want) || (len(want) > 0 && !reflect.DeepEqual(have, want)) {
+			panic(fmt.Sprintf("witnesses mismatch of cycle %d: have %v, want %v", cycle, have, want))
+		}
+	}
+	for key, want := range m.stats {
+		if have := db.GetStatsNumber([]byte(key)); have != want {
+			panic(fmt.Sprintf("stats mismatch of %x: have %d, want %d", key, have, want))
+		}
+	}
+}
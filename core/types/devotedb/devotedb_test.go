@@ -0,0 +1,140 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devotedb
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+func statsKey(cycle uint64, witness string) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, cycle)
+	return append(key, []byte(witness)...)
+}
+
+// Tests that unifying the devote tries keeps the records written before readable
+// and that the unified trie round trips through its protocol.
+func TestUnify(t *testing.T) {
+	db := NewDatabase(ethdb.NewMemDatabase())
+
+	legacy, err := NewDevoteByProtocol(db, &DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	legacy.SetWitnesses(1, []string{"a", "b"})
+	legacy.Rolling(1*params.Epoch, 1*params.Epoch+1, "a")
+	protocol, err := legacy.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit legacy tries: %v", err)
+	}
+	if protocol.Unified() {
+		t.Fatalf("legacy protocol reported unified")
+	}
+	d, err := NewDevoteByProtocol(db, protocol)
+	if err != nil {
+		t.Fatalf("failed to reopen devote db: %v", err)
+	}
+	if err := d.Unify(); err != nil {
+		t.Fatalf("failed to unify devote tries: %v", err)
+	}
+	// Continue the cycle of the fork and open the next one
+	d.Rolling(1*params.Epoch+1, 1*params.Epoch+2, "a")
+	d.SetWitnesses(2, []string{"c"})
+	d.Rolling(1*params.Epoch+2, 2*params.Epoch, "c")
+
+	unified, err := d.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit unified trie: %v", err)
+	}
+	if !unified.Unified() {
+		t.Fatalf("unified protocol reported legacy: %+v", unified)
+	}
+	if root := d.Root(); root != unified.Root() {
+		t.Fatalf("root mismatch: have %x, want %x", root, unified.Root())
+	}
+	if roots := d.TrieRoots(); len(roots) != 3 || roots[1] != protocol.CycleHash || roots[2] != protocol.StatsHash {
+		t.Fatalf("trie roots mismatch: have %x", roots)
+	}
+	d, err = NewDevoteByProtocol(db, unified)
+	if err != nil {
+		t.Fatalf("failed to reopen unified trie: %v", err)
+	}
+	for cycle, want := range map[uint64][]string{1: {"a", "b"}, 2: {"c"}} {
+		witnesses, err := d.GetWitnesses(cycle)
+		if err != nil {
+			t.Fatalf("cycle %d: failed to read witnesses: %v", cycle, err)
+		}
+		if !reflect.DeepEqual(witnesses, want) {
+			t.Errorf("cycle %d: witnesses mismatch: have %v, want %v", cycle, witnesses, want)
+		}
+	}
+	tests := []struct {
+		cycle   uint64
+		witness string
+		want    uint64
+	}{
+		{1, "a", 2},
+		{1, "b", 0},
+		{2, "c", 1},
+	}
+	for _, tt := range tests {
+		if have := d.GetStatsNumber(statsKey(tt.cycle, tt.witness)); have != tt.want {
+			t.Errorf("cycle %d witness %s: stats mismatch: have %d, want %d", tt.cycle, tt.witness, have, tt.want)
+		}
+	}
+}
+
+// Tests that the standby witnesses are kept apart from the witnesses of the same
+// cycle, and stay readable once the tries are unified.
+func TestStandbys(t *testing.T) {
+	db := NewDatabase(ethdb.NewMemDatabase())
+
+	d, err := NewDevoteByProtocol(db, &DevoteProtocol{})
+	if err != nil {
+		t.Fatalf("failed to open devote db: %v", err)
+	}
+	if standbys, err := d.GetStandbys(1); err != nil || len(standbys) != 0 {
+		t.Fatalf("standbys before election: have %v/%v, want none", standbys, err)
+	}
+	d.SetWitnesses(1, []string{"a", "b"})
+	d.SetStandbys(1, []string{"c"})
+	if _, err := d.Commit(); err != nil {
+		t.Fatalf("failed to commit legacy tries: %v", err)
+	}
+	if err := d.Unify(); err != nil {
+		t.Fatalf("failed to unify devote tries: %v", err)
+	}
+	d.SetStandbys(2, []string{"d", "e"})
+
+	for cycle, want := range map[uint64][]string{1: {"c"}, 2: {"d", "e"}} {
+		standbys, err := d.GetStandbys(cycle)
+		if err != nil {
+			t.Fatalf("cycle %d: failed to read standbys: %v", cycle, err)
+		}
+		if !reflect.DeepEqual(standbys, want) {
+			t.Errorf("cycle %d: standbys mismatch: have %v, want %v", cycle, standbys, want)
+		}
+	}
+	if witnesses, _ := d.GetWitnesses(1); !reflect.DeepEqual(witnesses, []string{"a", "b"}) {
+		t.Errorf("witnesses mismatch: have %v, want [a b]", witnesses)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the metrics collected by the devote tries.
+
+package devotedb
+
+import (
+	"github.com/etherzero/go-etherzero/metrics"
+)
+
+var (
+	trieReadTimer     = metrics.NewRegisteredTimer("devote/trie/read", nil)
+	trieReadFailMeter = metrics.NewRegisteredMeter("devote/trie/read/fail", nil)
+
+	commitTimer      = metrics.NewRegisteredTimer("devote/commit", nil)       // Hashing and flushing the tries
+	commitFlushTimer = metrics.NewRegisteredTimer("devote/commit/flush", nil) // Flushing the trie nodes to disk only
+	commitFailMeter  = metrics.NewRegisteredMeter("devote/commit/fail", nil)
+)
@@ -0,0 +1,118 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devotedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+var testProtocol = &DevoteProtocol{
+	CycleHash: common.HexToHash("0x01"),
+	StatsHash: common.HexToHash("0x02"),
+}
+
+// Tests that the RLP encoding of the protocol is the canonical ordered list of
+// its roots and that malformed lists are rejected.
+func TestProtocolRLP(t *testing.T) {
+	enc, err := rlp.EncodeToBytes(testProtocol)
+	if err != nil {
+		t.Fatalf("failed to encode protocol: %v", err)
+	}
+	want, _ := rlp.EncodeToBytes([]interface{}{testProtocol.CycleHash, testProtocol.StatsHash})
+	if !bytes.Equal(enc, want) {
+		t.Fatalf("encoding mismatch: have %x, want %x", enc, want)
+	}
+	var dec DevoteProtocol
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode protocol: %v", err)
+	}
+	if dec.CycleHash != testProtocol.CycleHash || dec.StatsHash != testProtocol.StatsHash {
+		t.Fatalf("decoded protocol mismatch: have %+v, want %+v", dec, testProtocol)
+	}
+	short, _ := rlp.EncodeToBytes([]interface{}{testProtocol.CycleHash})
+	if err := rlp.DecodeBytes(short, &dec); err == nil {
+		t.Errorf("decoded protocol with missing root")
+	}
+	long, _ := rlp.EncodeToBytes([]interface{}{testProtocol.CycleHash, testProtocol.StatsHash, common.Hash{}})
+	if err := rlp.DecodeBytes(long, &dec); err == nil {
+		t.Errorf("decoded protocol with superfluous root")
+	}
+}
+
+// Tests that unified protocols are encoded as their single root and that both
+// layouts decode back to the same protocol.
+func TestUnifiedProtocolRLP(t *testing.T) {
+	unified := &DevoteProtocol{CycleHash: common.HexToHash("0x03")}
+
+	enc, err := rlp.EncodeToBytes(unified)
+	if err != nil {
+		t.Fatalf("failed to encode protocol: %v", err)
+	}
+	want, _ := rlp.EncodeToBytes(unified.CycleHash)
+	if !bytes.Equal(enc, want) {
+		t.Fatalf("encoding mismatch: have %x, want %x", enc, want)
+	}
+	var dec DevoteProtocol
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode protocol: %v", err)
+	}
+	if dec != *unified || !dec.Unified() {
+		t.Fatalf("decoded protocol mismatch: have %+v, want %+v", dec, unified)
+	}
+	if dec.Root() != unified.CycleHash {
+		t.Errorf("root mismatch: have %x, want %x", dec.Root(), unified.CycleHash)
+	}
+	empty, _ := rlp.EncodeToBytes(common.Hash{})
+	if err := rlp.DecodeBytes(empty, &dec); err == nil {
+		t.Errorf("decoded protocol with empty root")
+	}
+	// Legacy protocols keep their list layout, even with empty roots
+	enc, _ = rlp.EncodeToBytes(&DevoteProtocol{})
+	want, _ = rlp.EncodeToBytes([]interface{}{common.Hash{}, common.Hash{}})
+	if !bytes.Equal(enc, want) {
+		t.Errorf("empty legacy encoding mismatch: have %x, want %x", enc, want)
+	}
+}
+
+// Tests that the JSON encoding of the protocol round trips and that all roots
+// are required when decoding.
+func TestProtocolJSON(t *testing.T) {
+	enc, err := json.Marshal(testProtocol)
+	if err != nil {
+		t.Fatalf("failed to encode protocol: %v", err)
+	}
+	var dec DevoteProtocol
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode protocol: %v", err)
+	}
+	if dec.CycleHash != testProtocol.CycleHash || dec.StatsHash != testProtocol.StatsHash {
+		t.Fatalf("decoded protocol mismatch: have %+v, want %+v", dec, testProtocol)
+	}
+	for _, input := range []string{
+		`{"statshash":"0x0000000000000000000000000000000000000000000000000000000000000002"}`,
+		`{"cyclehash":"0x0000000000000000000000000000000000000000000000000000000000000001"}`,
+	} {
+		if err := json.Unmarshal([]byte(input), &dec); err == nil {
+			t.Errorf("decoded protocol with missing root: %s", input)
+		}
+	}
+}
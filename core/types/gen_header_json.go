@@ -9,6 +9,7 @@ import (
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
 )
 
 var _ = (*headerMarshaling)(nil)
@@ -16,22 +17,24 @@ var _ = (*headerMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash  common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    common.Address `json:"miner"            gencodec:"required"`
-		Root        common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big   `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big   `json:"number"           gencodec:"required"`
-		GasLimit    hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big   `json:"timestamp"        gencodec:"required"`
-		Extra       hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   common.Hash    `json:"mixHash"`
-		Nonce       BlockNonce     `json:"nonce"`
-		Hash        common.Hash    `json:"hash"`
+		ParentHash  common.Hash              `json:"parentHash"       gencodec:"required"`
+		UncleHash   common.Hash              `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase    common.Address           `json:"miner"            gencodec:"required"`
+		Root        common.Hash              `json:"stateRoot"        gencodec:"required"`
+		TxHash      common.Hash              `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash common.Hash              `json:"receiptsRoot"     gencodec:"required"`
+		Bloom       Bloom                    `json:"logsBloom"        gencodec:"required"`
+		Difficulty  *hexutil.Big             `json:"difficulty"       gencodec:"required"`
+		Number      *hexutil.Big             `json:"number"           gencodec:"required"`
+		GasLimit    hexutil.Uint64           `json:"gasLimit"         gencodec:"required"`
+		GasUsed     hexutil.Uint64           `json:"gasUsed"          gencodec:"required"`
+		Time        *hexutil.Big             `json:"timestamp"        gencodec:"required"`
+		Extra       hexutil.Bytes            `json:"extraData"        gencodec:"required"`
+		MixDigest   common.Hash              `json:"mixHash"`
+		Nonce       BlockNonce               `json:"nonce"`
+		Witness     string                   `json:"witness"          gencodec:"required"`
+		Protocol    *devotedb.DevoteProtocol `json:"protocol"          gencodec:"required"`
+		Hash        common.Hash              `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -49,6 +52,8 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.Extra = h.Extra
 	enc.MixDigest = h.MixDigest
 	enc.Nonce = h.Nonce
+	enc.Witness = h.Witness
+	enc.Protocol = h.Protocol
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
@@ -56,21 +61,23 @@ func (h Header) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash  *common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    *common.Address `json:"miner"            gencodec:"required"`
-		Root        *common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      *common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       *Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit    *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big    `json:"timestamp"        gencodec:"required"`
-		Extra       *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   *common.Hash    `json:"mixHash"`
-		Nonce       *BlockNonce     `json:"nonce"`
+		ParentHash  *common.Hash             `json:"parentHash"       gencodec:"required"`
+		UncleHash   *common.Hash             `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase    *common.Address          `json:"miner"            gencodec:"required"`
+		Root        *common.Hash             `json:"stateRoot"        gencodec:"required"`
+		TxHash      *common.Hash             `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash *common.Hash             `json:"receiptsRoot"     gencodec:"required"`
+		Bloom       *Bloom                   `json:"logsBloom"        gencodec:"required"`
+		Difficulty  *hexutil.Big             `json:"difficulty"       gencodec:"required"`
+		Number      *hexutil.Big             `json:"number"           gencodec:"required"`
+		GasLimit    *hexutil.Uint64          `json:"gasLimit"         gencodec:"required"`
+		GasUsed     *hexutil.Uint64          `json:"gasUsed"          gencodec:"required"`
+		Time        *hexutil.Big             `json:"timestamp"        gencodec:"required"`
+		Extra       *hexutil.Bytes           `json:"extraData"        gencodec:"required"`
+		MixDigest   *common.Hash             `json:"mixHash"`
+		Nonce       *BlockNonce              `json:"nonce"`
+		Witness     *string                  `json:"witness"          gencodec:"required"`
+		Protocol    *devotedb.DevoteProtocol `json:"protocol"          gencodec:"required"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -134,5 +141,13 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 	if dec.Nonce != nil {
 		h.Nonce = *dec.Nonce
 	}
+	if dec.Witness == nil {
+		return errors.New("missing required field 'witness' for Header")
+	}
+	h.Witness = *dec.Witness
+	if dec.Protocol == nil {
+		return errors.New("missing required field 'protocol' for Header")
+	}
+	h.Protocol = dec.Protocol
 	return nil
 }
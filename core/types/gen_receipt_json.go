@@ -23,6 +23,8 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 		TxHash            common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   common.Address `json:"contractAddress"`
 		GasUsed           hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		Payments          []*Payment     `json:"payments,omitempty"`
+		Witnesses         []string       `json:"witnesses,omitempty"`
 	}
 	var enc Receipt
 	enc.PostState = r.PostState
@@ -33,6 +35,8 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 	enc.TxHash = r.TxHash
 	enc.ContractAddress = r.ContractAddress
 	enc.GasUsed = hexutil.Uint64(r.GasUsed)
+	enc.Payments = r.Payments
+	enc.Witnesses = r.Witnesses
 	return json.Marshal(&enc)
 }
 
@@ -47,6 +51,8 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		TxHash            *common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   *common.Address `json:"contractAddress"`
 		GasUsed           *hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		Payments          []*Payment      `json:"payments,omitempty"`
+		Witnesses         []string        `json:"witnesses,omitempty"`
 	}
 	var dec Receipt
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -81,5 +87,11 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'gasUsed' for Receipt")
 	}
 	r.GasUsed = uint64(*dec.GasUsed)
+	if dec.Payments != nil {
+		r.Payments = dec.Payments
+	}
+	if dec.Witnesses != nil {
+		r.Witnesses = dec.Witnesses
+	}
 	return nil
 }
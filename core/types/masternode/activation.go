@@ -0,0 +1,189 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"sync"
+	"time"
+)
+
+// Activation states of the local masternode.
+const (
+	ActivationInitial          = iota // Not evaluated yet
+	ActivationPreEnabled              // Registered, waiting for its first ping to be mined
+	ActivationEnabled                 // Pinged within PingExpiry blocks
+	ActivationExpired                 // Ping or watchdog expired, or the first ping never got mined
+	ActivationNewStartRequired        // Not registered, a new registration is needed to start
+	ActivationUpdateRequired          // Most masternodes announce a newer protocol version
+)
+
+const (
+	// PreEnabledExpiry is how long a registered masternode may wait for its
+	// first ping to be mined before it's considered expired.
+	PreEnabledExpiry = 2 * MASTERNODE_PING_INTERVAL
+
+	// maxActivationHistory is the number of transitions kept for inspection.
+	maxActivationHistory = 16
+)
+
+// activationNames are the user facing names of the activation states.
+var activationNames = map[int]string{
+	ActivationInitial:          "initial",
+	ActivationPreEnabled:       "pre-enabled",
+	ActivationEnabled:          "enabled",
+	ActivationExpired:          "expired",
+	ActivationNewStartRequired: "new-start-required",
+	ActivationUpdateRequired:   "update-required",
+}
+
+// activationTransitions are the states each activation state may move to. A
+// registered masternode only gets back to pre-enabled through a new start.
+var activationTransitions = map[int][]int{
+	ActivationInitial:          {ActivationPreEnabled, ActivationEnabled, ActivationExpired, ActivationNewStartRequired, ActivationUpdateRequired},
+	ActivationPreEnabled:       {ActivationEnabled, ActivationExpired, ActivationNewStartRequired, ActivationUpdateRequired},
+	ActivationEnabled:          {ActivationExpired, ActivationNewStartRequired, ActivationUpdateRequired},
+	ActivationExpired:          {ActivationEnabled, ActivationNewStartRequired, ActivationUpdateRequired},
+	ActivationNewStartRequired: {ActivationPreEnabled, ActivationEnabled, ActivationExpired, ActivationUpdateRequired},
+	ActivationUpdateRequired:   {ActivationPreEnabled, ActivationEnabled, ActivationExpired, ActivationNewStartRequired},
+}
+
+// ActivationName returns the user facing name of an activation state.
+func ActivationName(state int) string {
+	if name, ok := activationNames[state]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ActivationInput is what the activation state of the local masternode is
+// derived from.
+type ActivationInput struct {
+	Registered bool // Whether the node key is registered in the contract at the head
+	State      int  // State in the contract at the head, watchdog included
+	Outdated   bool // Whether most masternodes announce a newer protocol version
+}
+
+// ActivationTransition is a change of the activation state of the masternode.
+type ActivationTransition struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// Activation is the state machine tracking the activation of the local
+// masternode. It's fed the registration of the node periodically and moves
+// along the allowed transitions, recording them.
+type Activation struct {
+	state   int
+	since   time.Time // Time the current state was entered at
+	stalled bool      // Whether expired as the first ping wasn't mined in time
+	history []ActivationTransition
+	lock    sync.RWMutex
+}
+
+// NewActivation creates the activation state machine in its initial state.
+func NewActivation() *Activation {
+	return &Activation{state: ActivationInitial, since: time.Now()}
+}
+
+// State returns the current activation state and the time it was entered at.
+func (a *Activation) State() (int, time.Time) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.state, a.since
+}
+
+// History returns the last transitions of the state machine, oldest first.
+func (a *Activation) History() []ActivationTransition {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return append([]ActivationTransition{}, a.history...)
+}
+
+// Update moves the state machine to the state derived from the input, returning
+// the transitions taken, if any.
+func (a *Activation) Update(in ActivationInput) []ActivationTransition {
+	return a.update(in, time.Now())
+}
+
+func (a *Activation) update(in ActivationInput, now time.Time) []ActivationTransition {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	to, reason := a.target(in, now)
+	if to == a.state {
+		return nil
+	}
+	var taken []ActivationTransition
+	if !activationAllowed(a.state, to) {
+		// The node quit and registered again between two updates
+		taken = append(taken, a.enter(ActivationNewStartRequired, "re-registered", now))
+	}
+	return append(taken, a.enter(to, reason, now))
+}
+
+// target returns the state derived from the input and the reason for it.
+func (a *Activation) target(in ActivationInput, now time.Time) (int, string) {
+	switch {
+	case !in.Registered:
+		return ActivationNewStartRequired, "not registered"
+	case in.Outdated:
+		return ActivationUpdateRequired, "newer protocol announced"
+	case in.State == MasternodeInit:
+		if a.stalled || (a.state == ActivationPreEnabled && now.Sub(a.since) > PreEnabledExpiry) {
+			return ActivationExpired, "first ping not mined"
+		}
+		return ActivationPreEnabled, "awaiting first ping"
+	case in.State == MasternodeEnable:
+		return ActivationEnabled, "pinged"
+	case in.State == MasternodeWatchdogExpired:
+		return ActivationExpired, "watchdog expired"
+	default:
+		return ActivationExpired, "ping expired"
+	}
+}
+
+// enter moves the state machine to the given state, recording the transition.
+func (a *Activation) enter(state int, reason string, now time.Time) ActivationTransition {
+	transition := ActivationTransition{
+		From:   ActivationName(a.state),
+		To:     ActivationName(state),
+		Reason: reason,
+		Time:   now,
+	}
+	a.stalled = state == ActivationExpired && a.state == ActivationPreEnabled
+	a.state, a.since = state, now
+
+	if a.history = append(a.history, transition); len(a.history) > maxActivationHistory {
+		a.history = a.history[len(a.history)-maxActivationHistory:]
+	}
+	return transition
+}
+
+// activationAllowed reports whether the state machine may move between the
+// given states.
+func activationAllowed(from, to int) bool {
+	for _, state := range activationTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
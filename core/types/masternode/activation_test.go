@@ -0,0 +1,69 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the activation state machine follows the registration of the
+// masternode, times out pre-enabled nodes and routes re-registrations through a
+// new start.
+func TestActivation(t *testing.T) {
+	a := NewActivation()
+	now := time.Now()
+
+	steps := []struct {
+		in    ActivationInput
+		after time.Duration
+		want  []string
+	}{
+		{ActivationInput{}, 0, []string{"new-start-required"}},
+		{ActivationInput{Registered: true, State: MasternodeInit}, 0, []string{"pre-enabled"}},
+		{ActivationInput{Registered: true, State: MasternodeInit}, PreEnabledExpiry / 2, nil},
+		{ActivationInput{Registered: true, State: MasternodeInit}, PreEnabledExpiry, []string{"expired"}},
+		{ActivationInput{Registered: true, State: MasternodeInit}, 0, nil},
+		{ActivationInput{Registered: true, State: MasternodeEnable}, 0, []string{"enabled"}},
+		{ActivationInput{Registered: true, State: MasternodeWatchdogExpired}, 0, []string{"expired"}},
+		{ActivationInput{Registered: true, State: MasternodeEnable}, 0, []string{"enabled"}},
+		{ActivationInput{Registered: true, State: MasternodeEnable, Outdated: true}, 0, []string{"update-required"}},
+		{ActivationInput{Registered: true, State: MasternodeEnable}, 0, []string{"enabled"}},
+		{ActivationInput{Registered: true, State: MasternodeInit}, 0, []string{"new-start-required", "pre-enabled"}},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		var have []string
+		for _, transition := range a.update(step.in, now) {
+			have = append(have, transition.To)
+		}
+		if len(have) != len(step.want) {
+			t.Fatalf("step %d: transitions mismatch: have %v, want %v", i, have, step.want)
+		}
+		for j := range have {
+			if have[j] != step.want[j] {
+				t.Fatalf("step %d: transitions mismatch: have %v, want %v", i, have, step.want)
+			}
+		}
+	}
+	if state, _ := a.State(); state != ActivationPreEnabled {
+		t.Errorf("final state mismatch: have %s, want %s", ActivationName(state), ActivationName(ActivationPreEnabled))
+	}
+	if history := a.History(); len(history) != 10 || history[0].From != "initial" {
+		t.Errorf("history mismatch: have %v", history)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+// Masternode sub-protocol versions, advertised in the etz64 handshake and in the
+// announcements of the masternodes.
+const (
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_1 = 1 // Signed announcements (mnb, mnget)
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_2 = 2 // Version advertised in the handshake
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_3 = 3 // Reachability checks
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_4 = 4 // DNS names in announced enodes
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_5 = 5 // Announcements bound to the network
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_6 = 6 // Multiple endpoints rotated among
+	MIN_MASTERNODE_PAYMENT_PROTO_VERSION_7 = 7 // Departure notices on shutdown
+
+	// ProtocolVersion is the masternode sub-protocol version of the local node.
+	ProtocolVersion = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_7
+
+	// MinProtocolVersion is the oldest version masternode messages are exchanged
+	// with. Peers and announcements of older versions are ignored.
+	MinProtocolVersion = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_2
+)
+
+// AnnouncementExpiry is how long after signing an announcement is accepted and
+// relayed. Masternodes re-announce themselves on every ping, well within it.
+const AnnouncementExpiry = 3 * MASTERNODE_PING_INTERVAL
+
+// MaxEndpoints is the most endpoints a masternode may announce to rotate among.
+const MaxEndpoints = 8
+
+var (
+	errInvalidAnnouncement = errors.New("invalid masternode announcement")
+	errExpiredAnnouncement = errors.New("expired masternode announcement")
+	errFutureAnnouncement  = errors.New("masternode announcement in the future")
+	errOutdatedProtocol    = errors.New("masternode announcement of outdated protocol")
+	errAnnouncementSigner  = errors.New("masternode announcement not signed by its node")
+	errCollateralMismatch  = errors.New("masternode announcement collateral mismatch")
+)
+
+// IsInvalidAnnouncement reports whether the error returned when verifying an
+// announcement or a departure means it was malformed or forged, rather than just
+// outdated or unverifiable against the local chain.
+func IsInvalidAnnouncement(err error) bool {
+	return err == errInvalidAnnouncement || err == errAnnouncementSigner || err == errCollateralMismatch || err == errInvalidDeparture
+}
+
+// IsStaleAnnouncement reports whether the error returned when verifying an
+// announcement or a departure means it expired, is ahead of the local clock or
+// was made by a node of an outdated protocol version.
+func IsStaleAnnouncement(err error) bool {
+	return err == errExpiredAnnouncement || err == errFutureAnnouncement || err == errOutdatedProtocol || err == errStaleDeparture
+}
+
+// Network identifies the chain masternode messages are signed for. Since version
+// 5 it's part of the signed hash, so the messages of a network, such as the
+// testnet, are rejected if replayed on another one.
+type Network struct {
+	ChainID *big.Int    // Chain ID of the network
+	Genesis common.Hash // Hash of the genesis block of the network
+}
+
+// fields returns the network identifiers prepended to the signed fields of the
+// messages bound to it.
+func (n Network) fields() []interface{} {
+	chainID := n.ChainID
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return []interface{}{chainID, n.Genesis}
+}
+
+// Announcement is the signed broadcast a masternode gossips about itself, so
+// peers can learn the masternode set and how to reach its members from the
+// network rather than only from the contract.
+type Announcement struct {
+	ENode     string         // Enode URL the masternode is reachable at, by DNS name since version 4
+	Account   common.Address // Account the collateral was deposited from
+	Block     *big.Int       // Block the collateral was deposited at
+	Protocol  uint32         // Masternode protocol version of the node
+	Sentinel  uint32         // Version of the sentinel watching the node, 0 if none
+	Time      uint64         // Unix time the announcement was signed at
+	Signature []byte         // Signature of the masternode node key
+
+	// Endpoints are all the enode URLs the masternode rotates among since
+	// version 6, ENode being the active one. Empty if it doesn't rotate.
+	Endpoints []string `rlp:"tail"`
+}
+
+// SignAnnouncement creates an announcement of the masternode running with the
+// given node key for the network, signed at the current time.
+func SignAnnouncement(key *ecdsa.PrivateKey, network Network, node *enode.Node, account common.Address, block *big.Int, sentinel uint32) (*Announcement, error) {
+	a := &Announcement{
+		ENode:    node.String(),
+		Account:  account,
+		Block:    new(big.Int).Set(block),
+		Protocol: ProtocolVersion,
+		Sentinel: sentinel,
+		Time:     uint64(time.Now().Unix()),
+	}
+	sig, err := crypto.Sign(a.SigHash(network).Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	a.Signature = sig
+	return a, nil
+}
+
+// SigHash returns the hash signed by the masternode node key on the network. The
+// announcements of nodes older than version 5 aren't bound to any network, the
+// ones older than version 6 don't sign endpoints.
+func (a *Announcement) SigHash(network Network) common.Hash {
+	fields := []interface{}{a.ENode, a.Account, a.Block, a.Protocol, a.Sentinel, a.Time}
+	if a.Protocol >= MIN_MASTERNODE_PAYMENT_PROTO_VERSION_5 {
+		fields = append(network.fields(), fields...)
+	}
+	if a.Protocol >= MIN_MASTERNODE_PAYMENT_PROTO_VERSION_6 {
+		fields = append(fields, a.Endpoints)
+	}
+	enc, _ := rlp.EncodeToBytes(fields)
+	return crypto.Keccak256Hash([]byte("etz-announce"), enc)
+}
+
+// Hash returns the hash identifying the announcement, signature included.
+func (a *Announcement) Hash() common.Hash {
+	enc, _ := rlp.EncodeToBytes(a)
+	return crypto.Keccak256Hash(enc)
+}
+
+// Host returns the DNS name the masternode is announced at, or an empty string
+// if it's announced at a raw IP address. Nodes older than version 4 can't parse
+// named announcements, so they must not be sent these.
+func (a *Announcement) Host() string {
+	_, host, _ := ParseENode(a.ENode)
+	return host
+}
+
+// Recover returns the masternode ID of the node which signed the announcement,
+// checking that it's the node of the announced enode and that the announcement
+// is fresh at the given unix time. Announcements signed for another network
+// recover another key, so they're rejected as not signed by their node.
+func (a *Announcement) Recover(network Network, now uint64) (string, error) {
+	if a.Time+uint64(AnnouncementExpiry/time.Second) < now {
+		return "", errExpiredAnnouncement
+	}
+	if a.Time > now+uint64(MASTERNODE_PING_INTERVAL/time.Second) {
+		return "", errFutureAnnouncement
+	}
+	if a.Protocol < MinProtocolVersion {
+		return "", errOutdatedProtocol
+	}
+	if len(a.Signature) != 65 || a.Block == nil {
+		return "", errInvalidAnnouncement
+	}
+	node, host, err := ParseENode(a.ENode)
+	if err != nil || (host != "" && a.Protocol < MIN_MASTERNODE_PAYMENT_PROTO_VERSION_4) {
+		return "", errInvalidAnnouncement
+	}
+	if err := a.checkEndpoints(node); err != nil {
+		return "", err
+	}
+	pubkey, err := crypto.SigToPub(a.SigHash(network).Bytes(), a.Signature)
+	if err != nil {
+		return "", errInvalidAnnouncement
+	}
+	if crypto.PubkeyToAddress(*pubkey) != crypto.PubkeyToAddress(*node.Pubkey()) {
+		return "", errAnnouncementSigner
+	}
+	return fmt.Sprintf("%x", crypto.FromECDSAPub(pubkey)[1:9]), nil
+}
+
+// checkEndpoints ensures the endpoints are only announced since version 6, that
+// there are at most MaxEndpoints of them, all of the announced node, and that
+// the active enode is one of them.
+func (a *Announcement) checkEndpoints(node *enode.Node) error {
+	if len(a.Endpoints) == 0 {
+		return nil
+	}
+	if a.Protocol < MIN_MASTERNODE_PAYMENT_PROTO_VERSION_6 || len(a.Endpoints) > MaxEndpoints {
+		return errInvalidAnnouncement
+	}
+	active := false
+	for _, url := range a.Endpoints {
+		endpoint, _, err := ParseENode(url)
+		if err != nil || endpoint.ID() != node.ID() {
+			return errInvalidAnnouncement
+		}
+		active = active || url == a.ENode
+	}
+	if !active {
+		return errInvalidAnnouncement
+	}
+	return nil
+}
+
+// Verify recovers the announcing masternode and checks its collateral proof
+// against the contract at the given block, returning the registered masternode.
+func (a *Announcement) Verify(contract Caller, network Network, blockNumber *big.Int, now uint64) (*Masternode, error) {
+	id, err := a.Recover(network, now)
+	if err != nil {
+		return nil, err
+	}
+	var key [8]byte
+	copy(key[:], common.FromHex(id))
+
+	node, err := GetMasternode(contract, key, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, errNotRegistered
+	}
+	if node.ID != id || node.Account != a.Account || node.OriginBlock.Cmp(a.Block) != 0 {
+		return nil, errCollateralMismatch
+	}
+	return node, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+var testNetwork = Network{ChainID: big.NewInt(90), Genesis: common.HexToHash("0x5c")}
+
+// Tests that an announcement survives an RLP round trip and recovers the ID of
+// its node only while fresh and unmodified.
+func TestAnnouncement(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212)
+
+	ann, err := SignAnnouncement(key, testNetwork, node, common.HexToAddress("0x01"), big.NewInt(42), 3)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(ann)
+	if err != nil {
+		t.Fatalf("failed to encode announcement: %v", err)
+	}
+	dec := new(Announcement)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("failed to decode announcement: %v", err)
+	}
+	if dec.Hash() != ann.Hash() {
+		t.Fatalf("hash mismatch after round trip: have %x, want %x", dec.Hash(), ann.Hash())
+	}
+	want := fmt.Sprintf("%x", crypto.FromECDSAPub(&key.PublicKey)[1:9])
+	id, err := dec.Recover(testNetwork, ann.Time)
+	if err != nil {
+		t.Fatalf("failed to recover announcement: %v", err)
+	}
+	if id != want {
+		t.Errorf("recovered id mismatch: have %s, want %s", id, want)
+	}
+	if _, err := dec.Recover(testNetwork, ann.Time+uint64(AnnouncementExpiry/time.Second)+1); err != errExpiredAnnouncement {
+		t.Errorf("expired announcement: have %v, want %v", err, errExpiredAnnouncement)
+	}
+	if _, err := dec.Recover(testNetwork, ann.Time-uint64(MASTERNODE_PING_INTERVAL/time.Second)-1); err != errFutureAnnouncement {
+		t.Errorf("future announcement: have %v, want %v", err, errFutureAnnouncement)
+	}
+	outdated := *dec
+	outdated.Protocol = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_1
+	if _, err := outdated.Recover(testNetwork, ann.Time); err != errOutdatedProtocol {
+		t.Errorf("outdated announcement: have %v, want %v", err, errOutdatedProtocol)
+	}
+	// Announcing another node, or tampering with the collateral, must be rejected
+	other, _ := crypto.GenerateKey()
+	forged := *dec
+	forged.ENode = enode.NewV4(&other.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212).String()
+	if id, _ := forged.Recover(testNetwork, ann.Time); id != "" {
+		t.Errorf("announcement accepted for foreign enode")
+	}
+	forged = *dec
+	forged.Block = big.NewInt(43)
+	if id, _ := forged.Recover(testNetwork, ann.Time); id == want {
+		t.Errorf("announcement accepted with tampered collateral")
+	}
+}
+
+// Tests that masternodes listening on IPv6 are announced at their full address.
+func TestAnnouncementIPv6(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("2001:db8::6"), 21212, 21212)
+
+	ann, err := SignAnnouncement(key, testNetwork, node, common.HexToAddress("0x01"), big.NewInt(42), 0)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	if _, err := ann.Recover(testNetwork, ann.Time); err != nil {
+		t.Fatalf("failed to recover announcement: %v", err)
+	}
+	dec, err := enode.ParseV4(ann.ENode)
+	if err != nil {
+		t.Fatalf("failed to parse announced enode: %v", err)
+	}
+	if !dec.IP().Equal(node.IP()) || dec.TCP() != node.TCP() {
+		t.Errorf("announced endpoint mismatch: have %v:%d, want %v:%d", dec.IP(), dec.TCP(), node.IP(), node.TCP())
+	}
+}
+
+// Tests that masternodes may be announced by DNS name since protocol version 4,
+// and that the name resolves to the dialed address.
+func TestAnnouncementHost(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212)
+
+	ann, err := SignAnnouncement(key, testNetwork, node, common.HexToAddress("0x01"), big.NewInt(42), 0)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	if host := ann.Host(); host != "" {
+		t.Errorf("host of announcement by IP: have %q, want none", host)
+	}
+	ann.ENode = NamedENode(node, "localhost")
+	ann.Signature, _ = crypto.Sign(ann.SigHash(testNetwork).Bytes(), key)
+
+	if host := ann.Host(); host != "localhost" {
+		t.Errorf("host mismatch: have %q, want %q", host, "localhost")
+	}
+	if _, err := ann.Recover(testNetwork, ann.Time); err != nil {
+		t.Fatalf("failed to recover named announcement: %v", err)
+	}
+	resolved, err := ResolveENode(ann.ENode)
+	if err != nil {
+		t.Fatalf("failed to resolve announced enode: %v", err)
+	}
+	if resolved.ID() != node.ID() || !resolved.IP().IsLoopback() || resolved.TCP() != node.TCP() {
+		t.Errorf("resolved enode mismatch: have %v", resolved)
+	}
+	// Older nodes can't parse names, so neither may their announcements carry one
+	ann.Protocol = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_3
+	ann.Signature, _ = crypto.Sign(ann.SigHash(testNetwork).Bytes(), key)
+	if _, err := ann.Recover(testNetwork, ann.Time); err != errInvalidAnnouncement {
+		t.Errorf("named announcement of version 3: have %v, want %v", err, errInvalidAnnouncement)
+	}
+	for _, host := range []string{"-bad.example.org", "bad..example.org", "bad_host.org"} {
+		if _, _, err := ParseENode(NamedENode(node, host)); err == nil {
+			t.Errorf("invalid host %q accepted", host)
+		}
+	}
+}
+
+// Tests that announcements are bound to the network they were signed for since
+// protocol version 5, and rejected if replayed on another one.
+func TestAnnouncementReplay(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212)
+
+	ann, err := SignAnnouncement(key, testNetwork, node, common.HexToAddress("0x01"), big.NewInt(42), 0)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	if _, err := ann.Recover(testNetwork, ann.Time); err != nil {
+		t.Fatalf("failed to recover announcement: %v", err)
+	}
+	networks := []Network{
+		{ChainID: big.NewInt(91), Genesis: testNetwork.Genesis},
+		{ChainID: testNetwork.ChainID, Genesis: common.HexToHash("0x5d")},
+	}
+	for i, network := range networks {
+		if _, err := ann.Recover(network, ann.Time); err != errAnnouncementSigner {
+			t.Errorf("network %d: replayed announcement: have %v, want %v", i, err, errAnnouncementSigner)
+		}
+	}
+	// Announcements of older nodes aren't bound to any network
+	ann.Protocol = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_4
+	ann.Signature, _ = crypto.Sign(ann.SigHash(Network{}).Bytes(), key)
+	for i, network := range append(networks, testNetwork) {
+		if _, err := ann.Recover(network, ann.Time); err != nil {
+			t.Errorf("network %d: failed to recover version 4 announcement: %v", i, err)
+		}
+	}
+}
+
+// Tests that the endpoints a masternode rotates among are signed, survive an RLP
+// round trip, and must all belong to the announced node, the active one too.
+func TestAnnouncementEndpoints(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212)
+
+	sign := func(endpoints ...string) *Announcement {
+		ann := &Announcement{
+			ENode:     endpoints[0],
+			Account:   common.HexToAddress("0x01"),
+			Block:     big.NewInt(42),
+			Protocol:  ProtocolVersion,
+			Time:      uint64(time.Now().Unix()),
+			Endpoints: endpoints,
+		}
+		ann.Signature, _ = crypto.Sign(ann.SigHash(testNetwork).Bytes(), key)
+		return ann
+	}
+	ann := sign(NamedENode(node, "10.3.58.7"), NamedENode(node, "mn.example.org"))
+
+	enc, err := rlp.EncodeToBytes(ann)
+	if err != nil {
+		t.Fatalf("failed to encode announcement: %v", err)
+	}
+	dec := new(Announcement)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("failed to decode announcement: %v", err)
+	}
+	if len(dec.Endpoints) != 2 || dec.Hash() != ann.Hash() {
+		t.Fatalf("endpoints lost in round trip: have %v, want %v", dec.Endpoints, ann.Endpoints)
+	}
+	if _, err := dec.Recover(testNetwork, ann.Time); err != nil {
+		t.Fatalf("failed to recover announcement: %v", err)
+	}
+	// Endpoints are signed
+	tampered := *dec
+	tampered.Endpoints = []string{dec.ENode}
+	if _, err := tampered.Recover(testNetwork, ann.Time); err != errAnnouncementSigner {
+		t.Errorf("tampered endpoints: have %v, want %v", err, errAnnouncementSigner)
+	}
+	// Endpoints of other nodes, or an active one not among them, are rejected
+	other, _ := crypto.GenerateKey()
+	foreign := sign(node.String(), enode.NewV4(&other.PublicKey, net.ParseIP("10.3.58.8"), 21212, 21212).String())
+	if _, err := foreign.Recover(testNetwork, foreign.Time); err != errInvalidAnnouncement {
+		t.Errorf("foreign endpoint: have %v, want %v", err, errInvalidAnnouncement)
+	}
+	inactive := sign(node.String(), NamedENode(node, "10.3.58.7"))
+	inactive.ENode = NamedENode(node, "10.3.58.9")
+	inactive.Signature, _ = crypto.Sign(inactive.SigHash(testNetwork).Bytes(), key)
+	if _, err := inactive.Recover(testNetwork, inactive.Time); err != errInvalidAnnouncement {
+		t.Errorf("inactive endpoint: have %v, want %v", err, errInvalidAnnouncement)
+	}
+	// Older nodes can't announce endpoints
+	outdated := sign(node.String(), NamedENode(node, "10.3.58.7"))
+	outdated.Protocol = MIN_MASTERNODE_PAYMENT_PROTO_VERSION_5
+	outdated.Signature, _ = crypto.Sign(outdated.SigHash(testNetwork).Bytes(), key)
+	if _, err := outdated.Recover(testNetwork, outdated.Time); err != errInvalidAnnouncement {
+		t.Errorf("version 5 endpoints: have %v, want %v", err, errInvalidAnnouncement)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+const (
+	// MaxBootstrapNodes is the maximum number of enodes in a bootstrap list.
+	MaxBootstrapNodes = 64
+
+	// bootstrapTXTPrefix starts the DNS TXT records holding bootstrap lists.
+	bootstrapTXTPrefix = "etz-bootstrap="
+)
+
+var (
+	errInvalidBootstrap = errors.New("invalid masternode bootstrap list")
+	errBootstrapSigner  = errors.New("masternode bootstrap list not signed by a genesis masternode")
+)
+
+// BootstrapList is a list of known-good masternode enodes, dialed to join the
+// masternode gossip while the masternode contract can't be read, such as during
+// the initial sync. Lists are published in DNS TXT records, signed by the node
+// key of a genesis masternode.
+type BootstrapList struct {
+	Seq       uint64   // Sequence number, a list supersedes the ones of lower numbers
+	ENodes    []string // Enode URLs of the masternodes, by IP address or DNS name
+	Signature []byte   // Signature of the node key of a genesis masternode
+}
+
+// SignBootstrapList creates a bootstrap list of the given enodes for the network,
+// signed by the node key of a genesis masternode.
+func SignBootstrapList(key *ecdsa.PrivateKey, network Network, seq uint64, enodes []string) (*BootstrapList, error) {
+	l := &BootstrapList{Seq: seq, ENodes: enodes}
+	sig, err := crypto.Sign(l.SigHash(network).Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	l.Signature = sig
+	return l, nil
+}
+
+// SigHash returns the hash signed by the genesis masternode on the network.
+func (l *BootstrapList) SigHash(network Network) common.Hash {
+	enc, _ := rlp.EncodeToBytes(append(network.fields(), l.Seq, l.ENodes))
+	return crypto.Keccak256Hash([]byte("etz-bootstrap"), enc)
+}
+
+// Verify checks that the list is well formed and signed for the network by one
+// of the given signers, the node keys of the genesis masternodes.
+func (l *BootstrapList) Verify(network Network, signers []common.Address) error {
+	if len(l.Signature) != 65 || len(l.ENodes) == 0 || len(l.ENodes) > MaxBootstrapNodes {
+		return errInvalidBootstrap
+	}
+	for _, url := range l.ENodes {
+		if _, _, err := ParseENode(url); err != nil {
+			return errInvalidBootstrap
+		}
+	}
+	pubkey, err := crypto.SigToPub(l.SigHash(network).Bytes(), l.Signature)
+	if err != nil {
+		return errInvalidBootstrap
+	}
+	signer := crypto.PubkeyToAddress(*pubkey)
+	for _, addr := range signers {
+		if addr == signer {
+			return nil
+		}
+	}
+	return errBootstrapSigner
+}
+
+// TXT encodes the list into the content of a DNS TXT record.
+func (l *BootstrapList) TXT() string {
+	enc, _ := rlp.EncodeToBytes(l)
+	return bootstrapTXTPrefix + base64.RawURLEncoding.EncodeToString(enc)
+}
+
+// ParseBootstrapTXT decodes a bootstrap list from the content of a DNS TXT
+// record, without verifying it.
+func ParseBootstrapTXT(txt string) (*BootstrapList, error) {
+	if !strings.HasPrefix(txt, bootstrapTXTPrefix) {
+		return nil, errInvalidBootstrap
+	}
+	enc, err := base64.RawURLEncoding.DecodeString(txt[len(bootstrapTXTPrefix):])
+	if err != nil {
+		return nil, errInvalidBootstrap
+	}
+	l := new(BootstrapList)
+	if err := rlp.DecodeBytes(enc, l); err != nil {
+		return nil, errInvalidBootstrap
+	}
+	return l, nil
+}
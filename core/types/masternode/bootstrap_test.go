@@ -0,0 +1,79 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// Tests that bootstrap lists survive the DNS TXT encoding and are only accepted
+// if signed for the network by one of the given signers.
+func TestBootstrapList(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	enodes := []string{
+		enode.NewV4(&other.PublicKey, net.ParseIP("10.3.58.6"), 21212, 21212).String(),
+		NamedENode(enode.NewV4(&key.PublicKey, net.ParseIP("10.3.58.7"), 21212, 21212), "mn.example.org"),
+	}
+	list, err := SignBootstrapList(key, testNetwork, 7, enodes)
+	if err != nil {
+		t.Fatalf("failed to sign bootstrap list: %v", err)
+	}
+	dec, err := ParseBootstrapTXT(list.TXT())
+	if err != nil {
+		t.Fatalf("failed to parse bootstrap list: %v", err)
+	}
+	if !reflect.DeepEqual(dec, list) {
+		t.Fatalf("bootstrap list mismatch after round trip: have %+v, want %+v", dec, list)
+	}
+	signers := []common.Address{crypto.PubkeyToAddress(other.PublicKey), crypto.PubkeyToAddress(key.PublicKey)}
+	if err := dec.Verify(testNetwork, signers); err != nil {
+		t.Fatalf("failed to verify bootstrap list: %v", err)
+	}
+	if err := dec.Verify(testNetwork, signers[:1]); err != errBootstrapSigner {
+		t.Errorf("foreign signer: have %v, want %v", err, errBootstrapSigner)
+	}
+	if err := dec.Verify(Network{ChainID: big.NewInt(91), Genesis: testNetwork.Genesis}, signers); err != errBootstrapSigner {
+		t.Errorf("replayed on another network: have %v, want %v", err, errBootstrapSigner)
+	}
+	tampered := *dec
+	tampered.Seq++
+	if err := tampered.Verify(testNetwork, signers); err != errBootstrapSigner {
+		t.Errorf("tampered sequence number: have %v, want %v", err, errBootstrapSigner)
+	}
+	invalid, _ := SignBootstrapList(key, testNetwork, 8, []string{"enode://bad"})
+	if err := invalid.Verify(testNetwork, signers); err != errInvalidBootstrap {
+		t.Errorf("invalid enode: have %v, want %v", err, errInvalidBootstrap)
+	}
+	empty, _ := SignBootstrapList(key, testNetwork, 8, nil)
+	if err := empty.Verify(testNetwork, signers); err != errInvalidBootstrap {
+		t.Errorf("empty list: have %v, want %v", err, errInvalidBootstrap)
+	}
+	for _, txt := range []string{"v=spf1 -all", bootstrapTXTPrefix + "!", bootstrapTXTPrefix + "AA"} {
+		if _, err := ParseBootstrapTXT(txt); err != errInvalidBootstrap {
+			t.Errorf("TXT record %q: have %v, want %v", txt, err, errInvalidBootstrap)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/accounts/abi"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// BudgetABI is the part of the governance contract interface read by superblocks.
+// approvedBudget returns the proposals of a cycle that passed the masternode
+// vote, as parallel lists of payees and amounts.
+const BudgetABI = `[{"constant":true,"inputs":[{"name":"cycle","type":"uint256"}],"name":"approvedBudget","outputs":[{"name":"payees","type":"address[]"},{"name":"amounts","type":"uint256[]"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+var (
+	budgetABI, _ = abi.JSON(strings.NewReader(BudgetABI))
+
+	errBudgetMismatch = errors.New("budget payees and amounts differ in length")
+)
+
+// Budget is a governance proposal approved for payment.
+type Budget struct {
+	Payee  common.Address `json:"payee"`
+	Amount *big.Int       `json:"amount"`
+}
+
+// GetBudget returns the budget list approved by the governance contract for the
+// given cycle, as seen at blockNumber. A governance account without code or
+// without the approvedBudget method approves no budget.
+func GetBudget(caller bind.ContractCaller, governance common.Address, cycle uint64, blockNumber *big.Int) ([]Budget, error) {
+	ret := new(struct {
+		Payees  []common.Address
+		Amounts []*big.Int
+	})
+	if ok, err := callGovernance(caller, budgetABI, governance, blockNumber, ret, "approvedBudget", new(big.Int).SetUint64(cycle)); err != nil || !ok {
+		return nil, err
+	}
+	if len(ret.Payees) != len(ret.Amounts) {
+		return nil, errBudgetMismatch
+	}
+	budgets := make([]Budget, len(ret.Payees))
+	for i, payee := range ret.Payees {
+		budgets[i] = Budget{Payee: payee, Amount: ret.Amounts[i]}
+	}
+	return budgets, nil
+}
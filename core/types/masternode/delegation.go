@@ -0,0 +1,118 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+const (
+	// DelegationLength is the size of an encoded delegation: the 8 byte expiry
+	// cycle followed by the 65 byte signature of the cold masternode key.
+	DelegationLength = 8 + 65
+
+	// MaxDelegationCycles is the number of cycles a delegation may be valid for
+	// ahead of the current one, 30 days of 10 minute cycles. As a signed delegation
+	// can't be revoked, it bounds the time a stolen hot key can seal blocks.
+	MaxDelegationCycles = 4320
+)
+
+var (
+	errInvalidDelegation = errors.New("invalid masternode delegation")
+	errExpiredDelegation = errors.New("expired masternode delegation")
+	errDelegationTooLong = errors.New("masternode delegation expires too far ahead")
+)
+
+// Delegation authorizes a hot signing key to seal blocks on behalf of the cold
+// key registered in the masternode contract. The cold key never has to be online
+// on the block producing host, but has to renew the delegation before it expires.
+type Delegation struct {
+	Delegate  common.Address // Address of the hot key allowed to sign blocks
+	Expiry    uint64         // Last cycle the delegation is valid for
+	Signature []byte         // Signature of the cold masternode key
+}
+
+// DelegationHash returns the hash signed by the cold key to authorize delegate
+// until the given cycle.
+func DelegationHash(delegate common.Address, expiry uint64) common.Hash {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, expiry)
+	return crypto.Keccak256Hash([]byte("etz-delegate"), delegate.Bytes(), enc)
+}
+
+// SignDelegation creates a delegation from the cold masternode key to delegate,
+// valid up to and including the expiry cycle, from MaxDelegationCycles before it.
+func SignDelegation(cold *ecdsa.PrivateKey, delegate common.Address, expiry uint64) (*Delegation, error) {
+	sig, err := crypto.Sign(DelegationHash(delegate, expiry).Bytes(), cold)
+	if err != nil {
+		return nil, err
+	}
+	return &Delegation{Delegate: delegate, Expiry: expiry, Signature: sig}, nil
+}
+
+// DecodeDelegation parses an encoded delegation for the given hot key address.
+func DecodeDelegation(delegate common.Address, enc []byte) (*Delegation, error) {
+	if len(enc) != DelegationLength {
+		return nil, errInvalidDelegation
+	}
+	return &Delegation{
+		Delegate:  delegate,
+		Expiry:    binary.BigEndian.Uint64(enc[:8]),
+		Signature: common.CopyBytes(enc[8:]),
+	}, nil
+}
+
+// Encode serializes the delegation into the format embedded in header extra-data.
+// The delegate itself is not included as it's implied by the block seal.
+func (d *Delegation) Encode() []byte {
+	enc := make([]byte, DelegationLength)
+	binary.BigEndian.PutUint64(enc[:8], d.Expiry)
+	copy(enc[8:], d.Signature)
+	return enc
+}
+
+// Recover returns the masternode ID of the cold key which signed the delegation,
+// checking that it's still valid in the given cycle.
+func (d *Delegation) Recover(cycle uint64) (string, error) {
+	pubkey, err := d.RecoverPubkey(cycle)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", pubkey[1:9]), nil
+}
+
+// RecoverPubkey returns the uncompressed public key of the cold key which signed
+// the delegation, checking that it's valid in the given cycle: not expired, nor
+// expiring more than MaxDelegationCycles later.
+func (d *Delegation) RecoverPubkey(cycle uint64) ([]byte, error) {
+	if cycle > d.Expiry {
+		return nil, errExpiredDelegation
+	}
+	if d.Expiry-cycle >= MaxDelegationCycles {
+		return nil, errDelegationTooLong
+	}
+	if len(d.Signature) != 65 {
+		return nil, errInvalidDelegation
+	}
+	return crypto.Ecrecover(DelegationHash(d.Delegate, d.Expiry).Bytes(), d.Signature)
+}
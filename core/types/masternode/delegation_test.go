@@ -0,0 +1,65 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// Tests that a delegation survives an encoding round trip and recovers the ID
+// of the cold key only within its validity window.
+func TestDelegation(t *testing.T) {
+	cold, _ := crypto.GenerateKey()
+	hot, _ := crypto.GenerateKey()
+	delegate := crypto.PubkeyToAddress(hot.PublicKey)
+
+	d, err := SignDelegation(cold, delegate, 100)
+	if err != nil {
+		t.Fatalf("failed to sign delegation: %v", err)
+	}
+	dec, err := DecodeDelegation(delegate, d.Encode())
+	if err != nil {
+		t.Fatalf("failed to decode delegation: %v", err)
+	}
+	want := fmt.Sprintf("%x", crypto.FromECDSAPub(&cold.PublicKey)[1:9])
+	id, err := dec.Recover(100)
+	if err != nil {
+		t.Fatalf("failed to recover delegation: %v", err)
+	}
+	if id != want {
+		t.Errorf("recovered id mismatch: have %s, want %s", id, want)
+	}
+	if _, err := dec.Recover(101); err != errExpiredDelegation {
+		t.Errorf("expired delegation: have %v, want %v", err, errExpiredDelegation)
+	}
+	// A delegation is only valid for a bounded number of cycles
+	d, _ = SignDelegation(cold, delegate, MaxDelegationCycles+10)
+	if _, err := d.Recover(11); err != nil {
+		t.Errorf("failed to recover delegation at its longest: %v", err)
+	}
+	if _, err := d.Recover(10); err != errDelegationTooLong {
+		t.Errorf("overlong delegation: have %v, want %v", err, errDelegationTooLong)
+	}
+	// A delegation presented by another hot key must not recover the cold ID
+	other, _ := DecodeDelegation(crypto.PubkeyToAddress(cold.PublicKey), d.Encode())
+	if id, _ := other.Recover(100); id == want {
+		t.Errorf("delegation accepted for foreign delegate")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+// DepartureExpiry is how long after signing a departure is accepted and relayed,
+// either way. It only matters to the hosts about to take over, right away.
+const DepartureExpiry = time.Minute
+
+var (
+	errInvalidDeparture = errors.New("invalid masternode departure")
+	errStaleDeparture   = errors.New("stale masternode departure")
+)
+
+// Departure is the signed notice a masternode host gossips when shutting down,
+// so that the standby hosts sharing its key take over its slots right away
+// instead of after missing some. Exchanged since version 7.
+type Departure struct {
+	Time      uint64 // Unix time the departure was signed at
+	Signature []byte // Signature of the masternode node key
+}
+
+// SignDeparture creates a departure of the masternode running with the given
+// node key for the network, signed at the current time.
+func SignDeparture(key *ecdsa.PrivateKey, network Network) (*Departure, error) {
+	d := &Departure{Time: uint64(time.Now().Unix())}
+
+	sig, err := crypto.Sign(d.SigHash(network).Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	d.Signature = sig
+	return d, nil
+}
+
+// SigHash returns the hash signed by the masternode node key on the network.
+func (d *Departure) SigHash(network Network) common.Hash {
+	enc, _ := rlp.EncodeToBytes(append(network.fields(), d.Time))
+	return crypto.Keccak256Hash([]byte("etz-depart"), enc)
+}
+
+// Hash returns the hash identifying the departure, signature included.
+func (d *Departure) Hash() common.Hash {
+	enc, _ := rlp.EncodeToBytes(d)
+	return crypto.Keccak256Hash(enc)
+}
+
+// Recover returns the ID of the masternode which signed the departure, checking
+// that it's fresh at the given unix time. Departures signed for another network
+// recover another key, so they're of an unknown masternode.
+func (d *Departure) Recover(network Network, now uint64) (string, error) {
+	expiry := uint64(DepartureExpiry / time.Second)
+	if d.Time+expiry < now || d.Time > now+expiry {
+		return "", errStaleDeparture
+	}
+	if len(d.Signature) != 65 {
+		return "", errInvalidDeparture
+	}
+	pubkey, err := crypto.SigToPub(d.SigHash(network).Bytes(), d.Signature)
+	if err != nil {
+		return "", errInvalidDeparture
+	}
+	return fmt.Sprintf("%x", crypto.FromECDSAPub(pubkey)[1:9]), nil
+}
+
+// Verify recovers the departing masternode and checks that it's registered in
+// the contract at the given block.
+func (d *Departure) Verify(contract Caller, network Network, blockNumber *big.Int, now uint64) (string, error) {
+	id, err := d.Recover(network, now)
+	if err != nil {
+		return "", err
+	}
+	var key [8]byte
+	copy(key[:], common.FromHex(id))
+
+	node, err := GetMasternode(contract, key, blockNumber)
+	if err != nil {
+		return "", err
+	}
+	if node == nil {
+		return "", errNotRegistered
+	}
+	return id, nil
+}
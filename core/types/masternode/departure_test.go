@@ -0,0 +1,71 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+// Tests that a departure survives an RLP round trip and recovers the ID of its
+// masternode only while fresh and on its network.
+func TestDeparture(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	d, err := SignDeparture(key, testNetwork)
+	if err != nil {
+		t.Fatalf("failed to sign departure: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(d)
+	if err != nil {
+		t.Fatalf("failed to encode departure: %v", err)
+	}
+	dec := new(Departure)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatalf("failed to decode departure: %v", err)
+	}
+	if dec.Hash() != d.Hash() {
+		t.Fatalf("hash mismatch after round trip: have %x, want %x", dec.Hash(), d.Hash())
+	}
+	want := fmt.Sprintf("%x", crypto.FromECDSAPub(&key.PublicKey)[1:9])
+	if id, err := dec.Recover(testNetwork, d.Time); err != nil || id != want {
+		t.Errorf("recovered id mismatch: have %s (%v), want %s", id, err, want)
+	}
+	expiry := uint64(DepartureExpiry / time.Second)
+	if _, err := dec.Recover(testNetwork, d.Time+expiry+1); err != errStaleDeparture {
+		t.Errorf("expired departure: have %v, want %v", err, errStaleDeparture)
+	}
+	if _, err := dec.Recover(testNetwork, d.Time-expiry-1); err != errStaleDeparture {
+		t.Errorf("future departure: have %v, want %v", err, errStaleDeparture)
+	}
+	// Replaying on another network departs another masternode
+	other := Network{ChainID: big.NewInt(88), Genesis: common.HexToHash("0x5c")}
+	if id, _ := dec.Recover(other, d.Time); id == want {
+		t.Errorf("departure accepted on another network")
+	}
+	truncated := *dec
+	truncated.Signature = truncated.Signature[:64]
+	if _, err := truncated.Recover(testNetwork, d.Time); err != errInvalidDeparture {
+		t.Errorf("truncated signature: have %v, want %v", err, errInvalidDeparture)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+var errInvalidHost = errors.New("invalid host name")
+
+// ParseENode parses an enode URL whose host may be a DNS name instead of an IP
+// address, so masternodes on dynamic addresses stay reachable. The node of a
+// named URL is returned without IP, along with the name to resolve it from.
+func ParseENode(rawurl string) (*enode.Node, string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Hostname() == "" || net.ParseIP(u.Hostname()) != nil {
+		node, err := enode.ParseV4(rawurl)
+		return node, "", err
+	}
+	host := u.Hostname()
+	if !validHost(host) {
+		return nil, "", errInvalidHost
+	}
+	u.Host = net.JoinHostPort("127.0.0.1", u.Port())
+	node, err := enode.ParseV4(u.String())
+	if err != nil {
+		return nil, "", err
+	}
+	return enode.NewV4(node.Pubkey(), nil, node.TCP(), node.UDP()), host, nil
+}
+
+// ResolveENode parses an enode URL like ParseENode, resolving the DNS name of a
+// named URL into the address to dial. IPv4 addresses are preferred.
+func ResolveENode(rawurl string) (*enode.Node, error) {
+	node, host, err := ParseENode(rawurl)
+	if err != nil || host == "" {
+		return node, err
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errInvalidHost
+	}
+	ip := ips[0]
+	for _, candidate := range ips {
+		if candidate.To4() != nil {
+			ip = candidate
+			break
+		}
+	}
+	return enode.NewV4(node.Pubkey(), ip, node.TCP(), node.UDP()), nil
+}
+
+// NamedENode returns the enode URL of the node with its IP replaced by the given
+// DNS name.
+func NamedENode(node *enode.Node, host string) string {
+	u, _ := url.Parse(node.String())
+	u.Host = net.JoinHostPort(host, strconv.Itoa(node.TCP()))
+	return u.String()
+}
+
+// validHost reports whether the name is a syntactically valid DNS host name.
+func validHost(host string) bool {
+	if len(host) > 253 {
+		return false
+	}
+	label := 0
+	for i := 0; i < len(host); i++ {
+		switch c := host[i]; {
+		case c == '.':
+			if label == 0 {
+				return false
+			}
+			label = 0
+		case c == '-' && label == 0:
+			return false
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			if label++; label > 63 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
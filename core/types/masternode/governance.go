@@ -0,0 +1,140 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/etherzero/go-etherzero"
+	"github.com/etherzero/go-etherzero/accounts/abi"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// GovernanceABI is the part of the governance contract interface read by the
+// consensus engine besides the budgets, see BudgetABI. treasury returns
+// the account receiving the treasury cut of the block reward and its share in
+// percent. maxWitnesses returns the number of witnesses elected per cycle, zero
+// leaving it to the chain config. vrfKey returns the VRF public key registered by
+// a masternode, empty if none.
+const GovernanceABI = `[{"constant":true,"inputs":[{"name":"id","type":"bytes8"}],"name":"vrfKey","outputs":[{"name":"","type":"bytes"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"maxWitnesses","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"treasury","outputs":[{"name":"account","type":"address"},{"name":"share","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+const (
+	// MaxTreasuryShare is the highest treasury cut of the block reward, in
+	// percent, honoured by the consensus engine.
+	MaxTreasuryShare = 20
+
+	// MaxWitnesses is the highest number of witnesses per cycle the governance
+	// contract may set.
+	MaxWitnesses = 101
+)
+
+var governanceABI, _ = abi.JSON(strings.NewReader(GovernanceABI))
+
+// Treasury is the governance controlled cut of the block reward.
+type Treasury struct {
+	Account common.Address `json:"account"`
+	Share   uint64         `json:"share"` // Percentage of the coinbase reward
+}
+
+// GetTreasury returns the treasury configured in the governance contract as seen
+// at blockNumber, or nil if there is none. A governance account without code or
+// without the treasury method has none either. Shares above MaxTreasuryShare are
+// capped.
+func GetTreasury(caller bind.ContractCaller, governance common.Address, blockNumber *big.Int) (*Treasury, error) {
+	ret := new(struct {
+		Account common.Address
+		Share   *big.Int
+	})
+	if ok, err := callGovernance(caller, governanceABI, governance, blockNumber, ret, "treasury"); err != nil || !ok {
+		return nil, err
+	}
+	if ret.Account == (common.Address{}) || ret.Share.Sign() <= 0 {
+		return nil, nil
+	}
+	treasury := &Treasury{Account: ret.Account, Share: MaxTreasuryShare}
+	if ret.Share.Cmp(big.NewInt(MaxTreasuryShare)) < 0 {
+		treasury.Share = ret.Share.Uint64()
+	}
+	return treasury, nil
+}
+
+// GetMaxWitnesses returns the number of witnesses per cycle set in the governance
+// contract as seen at blockNumber, or zero if it's left to the chain config.
+// Numbers above MaxWitnesses are capped.
+func GetMaxWitnesses(caller bind.ContractCaller, governance common.Address, blockNumber *big.Int) (uint64, error) {
+	if blockNumber == nil {
+		blockNumber = new(big.Int)
+	}
+	opts := new(bind.CallOpts)
+	opts.BlockNumber = blockNumber
+
+	ret := new(*big.Int)
+	contract := bind.NewBoundContract(governance, governanceABI, caller, nil, nil)
+	if err := contract.Call(opts, ret, "maxWitnesses"); err != nil {
+		return 0, err
+	}
+	if (*ret).Cmp(big.NewInt(MaxWitnesses)) > 0 {
+		return MaxWitnesses, nil
+	}
+	return (*ret).Uint64(), nil
+}
+
+// GetVRFKey returns the VRF public key registered in the governance contract by
+// the masternode id as seen at blockNumber, or nil if none was registered.
+func GetVRFKey(caller bind.ContractCaller, governance common.Address, id [8]byte, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber == nil {
+		blockNumber = new(big.Int)
+	}
+	opts := new(bind.CallOpts)
+	opts.BlockNumber = blockNumber
+
+	ret := new([]byte)
+	contract := bind.NewBoundContract(governance, governanceABI, caller, nil, nil)
+	if err := contract.Call(opts, ret, "vrfKey", id); err != nil {
+		return nil, err
+	}
+	if len(*ret) == 0 {
+		return nil, nil
+	}
+	return *ret, nil
+}
+
+// callGovernance calls a view method of the governance contract as seen at
+// blockNumber, unpacking its output into result. It reports false if the call
+// returned nothing, like calls to an account without code or to a contract
+// lacking the method do, so that the setting can be treated as absent instead
+// of failing every block reading it.
+func callGovernance(caller bind.ContractCaller, contractABI abi.ABI, governance common.Address, blockNumber *big.Int, result interface{}, method string, params ...interface{}) (bool, error) {
+	if blockNumber == nil {
+		blockNumber = new(big.Int)
+	}
+	input, err := contractABI.Pack(method, params...)
+	if err != nil {
+		return false, err
+	}
+	output, err := caller.CallContract(context.Background(), ethereum.CallMsg{To: &governance, Data: input}, blockNumber)
+	if err != nil {
+		return false, err
+	}
+	if len(output) == 0 {
+		return false, nil
+	}
+	return true, contractABI.Unpack(result, method, output)
+}
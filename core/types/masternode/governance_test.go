@@ -0,0 +1,115 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero"
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// governanceCaller is a governance contract answering every call with the same
+// output, or error.
+type governanceCaller struct {
+	output []byte
+	err    error
+}
+
+func (c *governanceCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *governanceCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.output, c.err
+}
+
+// Tests that the approved budgets are read from the governance contract, and
+// that an account without code or the method approves none instead of failing.
+func TestGetBudget(t *testing.T) {
+	payees := []common.Address{{0x01}, {0x02}}
+	amounts := []*big.Int{big.NewInt(100), big.NewInt(200)}
+	output, err := budgetABI.Methods["approvedBudget"].Outputs.Pack(payees, amounts)
+	if err != nil {
+		t.Fatalf("failed to pack budget: %v", err)
+	}
+	budgets, err := GetBudget(&governanceCaller{output: output}, common.Address{0xff}, 1, nil)
+	if err != nil {
+		t.Fatalf("failed to get budget: %v", err)
+	}
+	want := []Budget{{Payee: payees[0], Amount: amounts[0]}, {Payee: payees[1], Amount: amounts[1]}}
+	if !reflect.DeepEqual(budgets, want) {
+		t.Errorf("budget mismatch: have %v, want %v", budgets, want)
+	}
+	// No code or no method: no budget
+	if budgets, err := GetBudget(&governanceCaller{}, common.Address{0xff}, 1, nil); err != nil || budgets != nil {
+		t.Errorf("empty output: have %v, %v, want no budget", budgets, err)
+	}
+	// Failed calls are still reported
+	failure := errors.New("call failed")
+	if _, err := GetBudget(&governanceCaller{err: failure}, common.Address{0xff}, 1, nil); err != failure {
+		t.Errorf("failed call: have %v, want %v", err, failure)
+	}
+	// Malformed lists are rejected
+	output, _ = budgetABI.Methods["approvedBudget"].Outputs.Pack(payees, amounts[:1])
+	if _, err := GetBudget(&governanceCaller{output: output}, common.Address{0xff}, 1, nil); err != errBudgetMismatch {
+		t.Errorf("mismatched lists: have %v, want %v", err, errBudgetMismatch)
+	}
+}
+
+// Tests that the treasury is read from the governance contract with its share
+// capped, and that an account without code or the method has none.
+func TestGetTreasury(t *testing.T) {
+	account := common.Address{0x01}
+	tests := []struct {
+		account common.Address
+		share   int64
+		want    *Treasury
+	}{
+		{account, 10, &Treasury{Account: account, Share: 10}},
+		{account, MaxTreasuryShare, &Treasury{Account: account, Share: MaxTreasuryShare}},
+		{account, 50, &Treasury{Account: account, Share: MaxTreasuryShare}}, // capped
+		{account, 0, nil},           // no share, no treasury
+		{common.Address{}, 10, nil}, // no account, no treasury
+	}
+	for i, tt := range tests {
+		output, err := governanceABI.Methods["treasury"].Outputs.Pack(tt.account, big.NewInt(tt.share))
+		if err != nil {
+			t.Fatalf("test %d: failed to pack treasury: %v", i, err)
+		}
+		treasury, err := GetTreasury(&governanceCaller{output: output}, common.Address{0xff}, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to get treasury: %v", i, err)
+		}
+		if !reflect.DeepEqual(treasury, tt.want) {
+			t.Errorf("test %d: treasury mismatch: have %+v, want %+v", i, treasury, tt.want)
+		}
+	}
+	// No code or no method: no treasury
+	if treasury, err := GetTreasury(&governanceCaller{}, common.Address{0xff}, nil); err != nil || treasury != nil {
+		t.Errorf("empty output: have %v, %v, want no treasury", treasury, err)
+	}
+	// Failed calls are still reported
+	failure := errors.New("call failed")
+	if _, err := GetTreasury(&governanceCaller{err: failure}, common.Address{0xff}, nil); err != failure {
+		t.Errorf("failed call: have %v, want %v", err, failure)
+	}
+}
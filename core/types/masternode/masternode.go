@@ -26,6 +26,7 @@ import (
 	"crypto/ecdsa"
 	"github.com/etherzero/go-etherzero/accounts/abi/bind"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/log"
@@ -34,9 +35,16 @@ import (
 )
 
 const (
-	MasternodeInit = iota
+	MasternodeInit            = iota // Registered, but never pinged
+	MasternodeEnable                 // Pinged within the last PingExpiry blocks
+	MasternodeExpired                // Last ping older than PingExpiry blocks
+	MasternodeWatchdogExpired        // Enabled, but its host not confirmed healthy within WatchdogExpiry
 )
 
+// PingExpiry is the number of blocks after its last ping a masternode is no
+// longer considered online.
+const PingExpiry = 3600
+
 const (
 	MASTERNODE_PING_INTERVAL = 1200 * time.Second
 )
@@ -45,8 +53,36 @@ var (
 	errClosed            = errors.New("masternode set is closed")
 	errAlreadyRegistered = errors.New("masternode is already registered")
 	errNotRegistered     = errors.New("masternode is not registered")
+	errUnknownStatus     = errors.New("unknown masternode status")
 )
 
+// statusNames are the user facing names of the masternode states.
+var statusNames = map[int]string{
+	MasternodeInit:    "new",
+	MasternodeEnable:  "enabled",
+	MasternodeExpired: "expired",
+
+	MasternodeWatchdogExpired: "watchdog-expired",
+}
+
+// StatusName returns the user facing name of a masternode state.
+func StatusName(state int) string {
+	if name, ok := statusNames[state]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseStatus returns the masternode state with the given user facing name.
+func ParseStatus(name string) (int, error) {
+	for state, n := range statusNames {
+		if n == name {
+			return state, nil
+		}
+	}
+	return 0, errUnknownStatus
+}
+
 type Masternode struct {
 	ENode *enode.Node
 
@@ -82,11 +118,55 @@ func newMasternode(nodeId discv5.NodeID, account common.Address, block, blockOnl
 	}
 }
 
+// Info is the RPC representation of a registered masternode.
+type Info struct {
+	ID             string         `json:"id"`
+	Account        common.Address `json:"account"`
+	Status         string         `json:"status"`
+	OriginBlock    *hexutil.Big   `json:"originBlock"`    // Block the masternode was registered at
+	BlockOnlineAcc *hexutil.Big   `json:"blockOnlineAcc"` // Accumulated online blocks
+	BlockLastPing  *hexutil.Big   `json:"blockLastPing"`  // Block of the last ping, zero if never pinged
+}
+
+// Info returns the RPC representation of the masternode.
+func (n *Masternode) Info() *Info {
+	return &Info{
+		ID:             n.ID,
+		Account:        n.Account,
+		Status:         StatusName(n.State),
+		OriginBlock:    (*hexutil.Big)(n.OriginBlock),
+		BlockOnlineAcc: (*hexutil.Big)(n.BlockOnlineAcc),
+		BlockLastPing:  (*hexutil.Big)(n.BlockLastPing),
+	}
+}
+
 func (n *Masternode) String() string {
 	return fmt.Sprintf("Node: %s\n", n.NodeID.String())
 }
 
-func GetGovernanceAddress(contract *contract.Contract, blockNumber *big.Int) (common.Address, error) {
+// Caller is the read-only interface of the masternode contract used to follow
+// the registered masternodes. Every version of the contract must provide it,
+// adapting its ABI if needed.
+type Caller interface {
+	Has(opts *bind.CallOpts, id [8]byte) (bool, error)
+	LastId(opts *bind.CallOpts) ([8]byte, error)
+	GovernanceAddress(opts *bind.CallOpts) (common.Address, error)
+	GetInfo(opts *bind.CallOpts, id [8]byte) (struct {
+		Id1            [32]byte
+		Id2            [32]byte
+		PreId          [8]byte
+		NextId         [8]byte
+		BlockNumber    *big.Int
+		Account        common.Address
+		BlockOnlineAcc *big.Int
+		BlockLastPing  *big.Int
+	}, error)
+}
+
+// Caller is implemented by the binding of the genesis contract.
+var _ Caller = (*contract.Contract)(nil)
+
+func GetGovernanceAddress(contract Caller, blockNumber *big.Int) (common.Address, error) {
 	if blockNumber == nil {
 		blockNumber = new(big.Int)
 	}
@@ -96,7 +176,7 @@ func GetGovernanceAddress(contract *contract.Contract, blockNumber *big.Int) (co
 	return addr, err
 }
 
-func GetIdsByBlockNumber(contract *contract.Contract, blockNumber *big.Int) ([]string, error) {
+func GetIdsByBlockNumber(contract Caller, blockNumber *big.Int) ([]string, error) {
 	if blockNumber == nil {
 		blockNumber = new(big.Int)
 	}
@@ -119,7 +199,7 @@ func GetIdsByBlockNumber(contract *contract.Contract, blockNumber *big.Int) ([]s
 		}
 		lastId = ctx.pre
 		if ctx.Node.BlockLastPing.Cmp(common.Big0) > 0 {
-			if new(big.Int).Sub(blockNumber, ctx.Node.BlockLastPing).Cmp(big.NewInt(3600)) > 0 {
+			if new(big.Int).Sub(blockNumber, ctx.Node.BlockLastPing).Cmp(big.NewInt(PingExpiry)) > 0 {
 				continue
 			}
 		} else if ctx.Node.OriginBlock.Cmp(common.Big0) > 0 {
@@ -140,7 +220,7 @@ func GetIdsByBlockNumber(contract *contract.Contract, blockNumber *big.Int) ([]s
 			}
 			lastId = ctx.pre
 			if ctx.Node.BlockLastPing.Cmp(common.Big0) > 0 {
-				if new(big.Int).Sub(blockNumber, ctx.Node.BlockLastPing).Cmp(big.NewInt(3600)) <= 0 {
+				if new(big.Int).Sub(blockNumber, ctx.Node.BlockLastPing).Cmp(big.NewInt(PingExpiry)) <= 0 {
 					repeat := false
 					for _, n := range ids {
 						if n == ctx.Node.ID {
@@ -157,6 +237,67 @@ func GetIdsByBlockNumber(contract *contract.Contract, blockNumber *big.Int) ([]s
 	return ids, nil
 }
 
+// GetMasternodes returns all masternodes registered in the contract at the given
+// block, newest first, with their state evaluated at that block.
+func GetMasternodes(contract Caller, blockNumber *big.Int) ([]*Masternode, error) {
+	if blockNumber == nil {
+		blockNumber = new(big.Int)
+	}
+	opts := new(bind.CallOpts)
+	opts.BlockNumber = blockNumber
+
+	lastId, err := contract.LastId(opts)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Masternode
+	for lastId != ([8]byte{}) {
+		ctx, err := GetMasternodeContext(opts, contract, lastId)
+		if err != nil {
+			return nil, err
+		}
+		lastId = ctx.pre
+		if ctx.Node.ID == "" {
+			continue // Invalid node key, never reachable
+		}
+		ctx.Node.updateState(blockNumber)
+		nodes = append(nodes, ctx.Node)
+	}
+	return nodes, nil
+}
+
+// GetMasternode returns the masternode registered in the contract under the
+// given id at the given block, or nil if there is none.
+func GetMasternode(contract Caller, id [8]byte, blockNumber *big.Int) (*Masternode, error) {
+	if blockNumber == nil {
+		blockNumber = new(big.Int)
+	}
+	opts := new(bind.CallOpts)
+	opts.BlockNumber = blockNumber
+
+	ctx, err := GetMasternodeContext(opts, contract, id)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Node.ID == "" || ctx.Node.Account == (common.Address{}) {
+		return nil, nil
+	}
+	ctx.Node.updateState(blockNumber)
+	return ctx.Node, nil
+}
+
+// updateState evaluates the state of the masternode at the given block.
+func (n *Masternode) updateState(blockNumber *big.Int) {
+	switch {
+	case n.BlockLastPing.Sign() == 0:
+		n.State = MasternodeInit
+	case new(big.Int).Sub(blockNumber, n.BlockLastPing).Cmp(big.NewInt(PingExpiry)) > 0:
+		n.State = MasternodeExpired
+	default:
+		n.State = MasternodeEnable
+	}
+}
+
 func GetMasternodeID(ID discv5.NodeID) string {
 	return fmt.Sprintf("%x", ID[:8])
 }
@@ -167,9 +308,9 @@ type MasternodeContext struct {
 	next [8]byte
 }
 
-func GetMasternodeContext(opts *bind.CallOpts, contract *contract.Contract, id [8]byte) (*MasternodeContext, error) {
+func GetMasternodeContext(opts *bind.CallOpts, contract Caller, id [8]byte) (*MasternodeContext, error) {
 
-	data, err := contract.ContractCaller.GetInfo(opts, id)
+	data, err := contract.GetInfo(opts, id)
 	if err != nil {
 		return &MasternodeContext{}, err
 	}
@@ -29,3 +29,19 @@ func Test_rlphash(t *testing.T) {
 
 	fmt.Printf("%v", uint64(time.Now().Sub(createdTime)))
 }
+
+// Tests that masternode states round trip through their user facing names.
+func TestStatusNames(t *testing.T) {
+	for _, state := range []int{MasternodeInit, MasternodeEnable, MasternodeExpired, MasternodeWatchdogExpired} {
+		parsed, err := ParseStatus(StatusName(state))
+		if err != nil {
+			t.Fatalf("state %d: failed to parse name %q: %v", state, StatusName(state), err)
+		}
+		if parsed != state {
+			t.Errorf("state %d: parsed state mismatch: have %d", state, parsed)
+		}
+	}
+	if _, err := ParseStatus("online"); err != errUnknownStatus {
+		t.Errorf("unknown status error mismatch: have %v, want %v", err, errUnknownStatus)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// CalculateScore returns the score of the masternode with the given id in the
+// quorums anchored at the given block hash: the distance between the block hash
+// and the hash of the id. The lower the score, the higher the masternode ranks.
+func CalculateScore(blockHash common.Hash, id string) *big.Int {
+	hash := crypto.Keccak256([]byte(id))
+	for i := range hash {
+		hash[i] ^= blockHash[i]
+	}
+	return new(big.Int).SetBytes(hash)
+}
+
+// scoredNode is a masternode id ranked by its score.
+type scoredNode struct {
+	id    string
+	score *big.Int
+}
+
+// SelectQuorum returns the n masternodes among ids closest to the given block
+// hash, closest first. Ties are broken by id, so every node selects the same
+// quorum regardless of the order it knows the masternodes in. If there are no
+// more than n masternodes, all of them are returned, ranked.
+func SelectQuorum(blockHash common.Hash, ids []string, n int) []string {
+	nodes := make([]scoredNode, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, scoredNode{id: id, score: CalculateScore(blockHash, id)})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if cmp := nodes[i].score.Cmp(nodes[j].score); cmp != 0 {
+			return cmp < 0
+		}
+		return nodes[i].id < nodes[j].id
+	})
+	if n < 0 {
+		n = 0
+	}
+	if len(nodes) > n {
+		nodes = nodes[:n]
+	}
+	quorum := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		quorum = append(quorum, node.id)
+	}
+	return quorum
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/crypto"
+)
+
+// Tests that quorums are ranked by score and don't depend on the order the
+// masternodes are known in, as every node has to select the same quorum.
+func TestSelectQuorum(t *testing.T) {
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%016x", i)
+	}
+	hash := crypto.Keccak256Hash([]byte("block"))
+
+	quorum := SelectQuorum(hash, ids, 10)
+	if len(quorum) != 10 {
+		t.Fatalf("quorum size mismatch: have %d, want %d", len(quorum), 10)
+	}
+	for i := 1; i < len(quorum); i++ {
+		if CalculateScore(hash, quorum[i-1]).Cmp(CalculateScore(hash, quorum[i])) > 0 {
+			t.Fatalf("quorum not ranked by score at %d: %v", i, quorum)
+		}
+	}
+	// The lowest score of the quorum must beat all the masternodes left out
+	last := CalculateScore(hash, quorum[len(quorum)-1])
+	selected := make(map[string]bool)
+	for _, id := range quorum {
+		selected[id] = true
+	}
+	for _, id := range ids {
+		if !selected[id] && CalculateScore(hash, id).Cmp(last) < 0 {
+			t.Errorf("masternode %s left out with score below the quorum", id)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		shuffled := append([]string(nil), ids...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		if have := SelectQuorum(hash, shuffled, 10); !reflect.DeepEqual(have, quorum) {
+			t.Fatalf("quorum depends on input order: have %v, want %v", have, quorum)
+		}
+	}
+	if other := SelectQuorum(crypto.Keccak256Hash([]byte("other")), ids, 10); reflect.DeepEqual(other, quorum) {
+		t.Errorf("quorum doesn't depend on the block hash")
+	}
+	if have := SelectQuorum(hash, ids[:5], 10); len(have) != 5 {
+		t.Errorf("small quorum size mismatch: have %d, want %d", len(have), 5)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// WatchdogInterval is how often the built-in health checker confirms the
+	// host. External sentinels are expected to confirm at a similar pace.
+	WatchdogInterval = 5 * time.Minute
+
+	// WatchdogExpiry is how long after its last confirmation the watchdog of a
+	// masternode expires. Masternodes don't ping the contract nor announce
+	// themselves while expired, so they drop out of the payment queue once their
+	// last ping is older than PingExpiry blocks.
+	WatchdogExpiry = 2 * MASTERNODE_PING_INTERVAL
+)
+
+// Watchdog tracks the health confirmations of the host of a masternode, sent by
+// an external sentinel process or by the built-in health checker.
+type Watchdog struct {
+	last     time.Time // Time of the last confirmation
+	sentinel uint32    // Version of the sentinel which last confirmed, 0 if built-in
+	lock     sync.RWMutex
+}
+
+// NewWatchdog creates a watchdog armed at the current time, giving the sentinel
+// WatchdogExpiry to send its first confirmation.
+func NewWatchdog() *Watchdog {
+	return &Watchdog{last: time.Now()}
+}
+
+// Confirm records a health confirmation of the host by the given version of the
+// sentinel, 0 standing for the built-in health checker.
+func (w *Watchdog) Confirm(sentinel uint32) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.last, w.sentinel = time.Now(), sentinel
+}
+
+// Expired reports whether the host wasn't confirmed healthy within WatchdogExpiry.
+func (w *Watchdog) Expired() bool {
+	return w.expired(time.Now())
+}
+
+func (w *Watchdog) expired(now time.Time) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return now.Sub(w.last) > WatchdogExpiry
+}
+
+// Sentinel returns the version of the sentinel which last confirmed the host and
+// the time it did so.
+func (w *Watchdog) Sentinel() (uint32, time.Time) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.sentinel, w.last
+}
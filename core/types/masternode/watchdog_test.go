@@ -0,0 +1,45 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package masternode
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the watchdog expires if not confirmed in time, and that a sentinel
+// confirmation rearms it.
+func TestWatchdog(t *testing.T) {
+	w := NewWatchdog()
+	if w.Expired() {
+		t.Fatalf("fresh watchdog expired")
+	}
+	if !w.expired(time.Now().Add(WatchdogExpiry + time.Second)) {
+		t.Fatalf("unconfirmed watchdog not expired")
+	}
+	w.last = time.Now().Add(-WatchdogExpiry - time.Second)
+	if !w.Expired() {
+		t.Fatalf("stale watchdog not expired")
+	}
+	w.Confirm(3)
+	if w.Expired() {
+		t.Fatalf("confirmed watchdog expired")
+	}
+	if sentinel, _ := w.Sentinel(); sentinel != 3 {
+		t.Errorf("sentinel version mismatch: have %d, want %d", sentinel, 3)
+	}
+}
@@ -18,8 +18,10 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"unsafe"
 
 	"github.com/etherzero/go-etherzero/common"
@@ -55,6 +57,57 @@ type Receipt struct {
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
+
+	// System fields, only set on the system receipt of a block covering the
+	// operations applied by the consensus engine outside of any transaction
+	Payments  []*Payment `json:"payments,omitempty"`  // Coinbase and governance payments of the block
+	Witnesses []string   `json:"witnesses,omitempty"` // Witnesses elected, if the block opens a cycle
+}
+
+// Kinds of payments applied by the consensus engine.
+const (
+	PaymentMasternode = "masternode" // Block reward of the masternode paid by the block
+	PaymentTreasury   = "treasury"   // Treasury cut of the block reward
+	PaymentCommunity  = "community"  // Community share of the block reward, paid to the governance contract
+	PaymentBudget     = "budget"     // Governance budget paid out of the community fund by a superblock
+)
+
+// Payment is a balance transfer applied by the consensus engine outside of any
+// transaction. Rewards are minted, budgets are paid from the governance contract.
+type Payment struct {
+	Kind   string         `json:"kind"`
+	From   common.Address `json:"from"` // Zero if minted
+	To     common.Address `json:"to"`
+	Amount *big.Int       `json:"amount"`
+}
+
+// paymentJSON is the RPC encoding of a payment, with a hex encoded amount.
+type paymentJSON struct {
+	Kind   string         `json:"kind"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Amount *hexutil.Big   `json:"amount"`
+}
+
+// MarshalJSON marshals the payment with its amount hex encoded.
+func (p *Payment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&paymentJSON{p.Kind, p.From, p.To, (*hexutil.Big)(p.Amount)})
+}
+
+// UnmarshalJSON unmarshals a payment with a hex encoded amount.
+func (p *Payment) UnmarshalJSON(input []byte) error {
+	var dec paymentJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	p.Kind, p.From, p.To, p.Amount = dec.Kind, dec.From, dec.To, (*big.Int)(dec.Amount)
+	return nil
+}
+
+// NewSystemReceipt creates the system receipt of a block from the payments and
+// election applied by the consensus engine.
+func NewSystemReceipt(payments []*Payment, witnesses []string) *Receipt {
+	return &Receipt{Status: ReceiptStatusSuccessful, Payments: payments, Witnesses: witnesses}
 }
 
 type receiptMarshaling struct {
@@ -0,0 +1,146 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// The consensus info contract is a native, read only contract at
+// params.ConsensusInfoAddress exposing the devote consensus to contracts, so
+// governance and reward sharing contracts can check masternode facts without an
+// oracle. Masternodes are identified by their 8 byte id. Its Solidity interface is
+//
+//	function cycle() view returns (uint64);
+//	function witness() view returns (bytes8);
+//	function isWitness(bytes8 id) view returns (bool);
+//	function isMasternode(bytes8 id) view returns (bool);
+//
+// The witnesses are the ones recorded by the parent block, so in the first block
+// of a cycle they are still the ones of the previous cycle.
+var (
+	cycleMethod        = crypto.Keccak256([]byte("cycle()"))[:4]
+	witnessMethod      = crypto.Keccak256([]byte("witness()"))[:4]
+	isWitnessMethod    = crypto.Keccak256([]byte("isWitness(bytes8)"))[:4]
+	isMasternodeMethod = crypto.Keccak256([]byte("isMasternode(bytes8)"))[:4]
+
+	// hasMethod is the masternode contract method reporting a registered id
+	hasMethod = crypto.Keccak256([]byte("has(bytes8)"))[:4]
+)
+
+const (
+	consensusInfoGas = 200  // Gas of the cycle and witness queries
+	isWitnessGas     = 2000 // Gas of a lookup in the witnesses of the cycle
+)
+
+var (
+	errConsensusInfoMethod = errors.New("unknown consensus info method")
+	errConsensusInfoInput  = errors.New("invalid consensus info input")
+	errNoWitnesses         = errors.New("witnesses unavailable")
+)
+
+// isConsensusInfo reports whether addr is the consensus info contract and the
+// consensus info fork is active.
+func (evm *EVM) isConsensusInfo(addr common.Address) bool {
+	return addr == params.ConsensusInfoAddress && evm.ChainConfig().Devote.IsConsensusInfo(evm.BlockNumber)
+}
+
+// runConsensusInfo executes a call into the consensus info contract.
+func runConsensusInfo(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+	if contract.Value().Sign() != 0 {
+		return nil, errConsensusInfoInput
+	}
+	if len(input) < 4 {
+		return nil, errConsensusInfoMethod
+	}
+	method, args := input[:4], input[4:]
+
+	switch {
+	case bytes.Equal(method, cycleMethod):
+		if !contract.UseGas(consensusInfoGas) {
+			return nil, ErrOutOfGas
+		}
+		cycle := new(big.Int).Div(evm.Time, new(big.Int).SetUint64(params.Epoch))
+		return common.LeftPadBytes(cycle.Bytes(), 32), nil
+
+	case bytes.Equal(method, witnessMethod):
+		if !contract.UseGas(consensusInfoGas) {
+			return nil, ErrOutOfGas
+		}
+		return common.RightPadBytes(common.FromHex(evm.Witness), 32), nil
+
+	case bytes.Equal(method, isWitnessMethod):
+		if len(args) != 32 {
+			return nil, errConsensusInfoInput
+		}
+		if !contract.UseGas(isWitnessGas) {
+			return nil, ErrOutOfGas
+		}
+		if evm.Witnesses == nil {
+			return nil, errNoWitnesses
+		}
+		witnesses, err := evm.Witnesses()
+		if err != nil {
+			return nil, err
+		}
+		id := hex.EncodeToString(args[:8])
+		for _, witness := range witnesses {
+			if witness == id {
+				return abiBool(true), nil
+			}
+		}
+		return abiBool(false), nil
+
+	case bytes.Equal(method, isMasternodeMethod):
+		if len(args) != 32 {
+			return nil, errConsensusInfoInput
+		}
+		if !contract.UseGas(consensusInfoGas) {
+			return nil, ErrOutOfGas
+		}
+		// Ask the masternode contract in effect, paying its execution
+		registry := evm.ChainConfig().Devote.MasternodeContractAt(evm.BlockNumber).Address
+		query := append(append([]byte{}, hasMethod...), common.RightPadBytes(args[:8], 32)...)
+
+		ret, gas, err := evm.StaticCall(contract, registry, query, contract.Gas)
+		contract.Gas = gas
+		if err != nil {
+			return nil, err
+		}
+		if len(ret) != 32 {
+			return abiBool(false), nil
+		}
+		return abiBool(ret[31] != 0), nil
+	}
+	return nil, errConsensusInfoMethod
+}
+
+// abiBool encodes a boolean as an ABI word.
+func abiBool(b bool) []byte {
+	word := make([]byte, 32)
+	if b {
+		word[31] = 1
+	}
+	return word
+}
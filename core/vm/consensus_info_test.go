@@ -0,0 +1,73 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+func TestConsensusInfo(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+
+	// Stand in for the masternode contract, reporting every id as registered
+	statedb.SetCode(params.MasterndeContractAddress, common.FromHex("0x600160005260206000f3"))
+
+	config := *params.TestChainConfig
+	config.Devote = &params.DevoteConfig{ConsensusInfoBlock: big.NewInt(0)}
+
+	ctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int, *big.Int) {},
+		Witnesses:   func() ([]string, error) { return []string{"0102030405060708", "1112131415161718"}, nil },
+		BlockNumber: big.NewInt(1),
+		Time:        new(big.Int).SetUint64(3*params.Epoch + 1),
+		Witness:     "1112131415161718",
+	}
+	evm := NewEVM(ctx, statedb, &config, Config{})
+	caller := AccountRef(common.HexToAddress("0x1000"))
+
+	tests := []struct {
+		input []byte
+		want  []byte
+	}{
+		{cycleMethod, common.LeftPadBytes([]byte{3}, 32)},
+		{witnessMethod, common.RightPadBytes(common.FromHex("0x1112131415161718"), 32)},
+		{append(isWitnessMethod, common.RightPadBytes(common.FromHex("0x0102030405060708"), 32)...), abiBool(true)},
+		{append(isWitnessMethod, common.RightPadBytes(common.FromHex("0x2122232425262728"), 32)...), abiBool(false)},
+		{append(isMasternodeMethod, common.RightPadBytes(common.FromHex("0x2122232425262728"), 32)...), abiBool(true)},
+	}
+	for i, tt := range tests {
+		ret, _, err := evm.StaticCall(caller, params.ConsensusInfoAddress, tt.input, 100000)
+		if err != nil {
+			t.Errorf("test %d: call failed: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(ret, tt.want) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, ret, tt.want)
+		}
+	}
+	if _, _, err := evm.StaticCall(caller, params.ConsensusInfoAddress, []byte{0x01, 0x02, 0x03, 0x04}, 100000); err != errConsensusInfoMethod {
+		t.Errorf("unknown method error mismatch: have %v, want %v", err, errConsensusInfoMethod)
+	}
+}
@@ -38,11 +38,20 @@ type (
 	// GetHashFunc returns the nth block hash in the blockchain
 	// and is used by the BLOCKHASH EVM op code.
 	GetHashFunc func(uint64) common.Hash
+	// WitnessesFunc returns the witnesses of the current cycle and is used by
+	// the consensus info contract.
+	WitnessesFunc func() ([]string, error)
 )
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
 	if contract.CodeAddr != nil {
+		if evm.isSponsorRegistry(*contract.CodeAddr) {
+			return runSponsorRegistry(evm, contract, input, readOnly)
+		}
+		if evm.isConsensusInfo(*contract.CodeAddr) {
+			return runConsensusInfo(evm, contract, input)
+		}
 		precompiles := PrecompiledContractsHomestead
 		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
 			precompiles = PrecompiledContractsByzantium
@@ -77,6 +86,8 @@ type Context struct {
 	Transfer TransferFunc
 	// GetHash returns the hash corresponding to n
 	GetHash GetHashFunc
+	// Witnesses returns the witnesses of the current cycle
+	Witnesses WitnessesFunc
 
 	// Message information
 	Origin   common.Address // Provides information for ORIGIN
@@ -88,6 +99,7 @@ type Context struct {
 	BlockNumber *big.Int       // Provides information for NUMBER
 	Time        *big.Int       // Provides information for TIME
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
+	Witness     string         // Masternode id of the witness sealing the block
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -201,7 +213,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
 			precompiles = PrecompiledContractsByzantium
 		}
-		if precompiles[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
+		if precompiles[addr] == nil && !evm.isSponsorRegistry(addr) && !evm.isConsensusInfo(addr) && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
 				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
@@ -0,0 +1,136 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// The sponsor registry is a native contract at params.SponsorRegistryAddress
+// through which contracts sponsor the power of the transactions calling them:
+// a contract whitelists a caller (or every caller with the zero address) up to
+// a gas limit, and the power of such transactions is then taken from the
+// contract instead of the sender. Its Solidity interface is
+//
+//	function sponsor(address caller, uint64 gasLimit);
+//	function revoke(address caller);
+//	function sponsorship(address sponsor, address caller) view returns (uint64);
+var (
+	sponsorMethod     = crypto.Keccak256([]byte("sponsor(address,uint64)"))[:4]
+	revokeMethod      = crypto.Keccak256([]byte("revoke(address)"))[:4]
+	sponsorshipMethod = crypto.Keccak256([]byte("sponsorship(address,address)"))[:4]
+)
+
+var (
+	errSponsorMethod = errors.New("unknown sponsor registry method")
+	errSponsorInput  = errors.New("invalid sponsor registry input")
+	errSponsorCall   = errors.New("sponsor registry must be called directly without value")
+)
+
+// Sponsorship returns the gas limit up to which the sponsor contract pays the
+// power of transactions sent by caller, or 0 if it doesn't sponsor them.
+// A caller whitelisted individually takes precedence over the zero address
+// whitelisting everyone.
+func Sponsorship(db StateDB, sponsor, caller common.Address) uint64 {
+	if limit := sponsorLimit(db, sponsor, caller); limit > 0 {
+		return limit
+	}
+	return sponsorLimit(db, sponsor, common.Address{})
+}
+
+// sponsorKey is the registry storage slot of a sponsored caller.
+func sponsorKey(sponsor, caller common.Address) common.Hash {
+	return crypto.Keccak256Hash(sponsor.Bytes(), caller.Bytes())
+}
+
+func sponsorLimit(db StateDB, sponsor, caller common.Address) uint64 {
+	value := db.GetState(params.SponsorRegistryAddress, sponsorKey(sponsor, caller))
+	return binary.BigEndian.Uint64(value[common.HashLength-8:])
+}
+
+// isSponsorRegistry reports whether addr is the sponsor registry and the sponsor
+// fork is active.
+func (evm *EVM) isSponsorRegistry(addr common.Address) bool {
+	return addr == params.SponsorRegistryAddress && evm.ChainConfig().Devote.IsSponsor(evm.BlockNumber)
+}
+
+// runSponsorRegistry executes a call into the sponsor registry, the caller of
+// the registry being the sponsor.
+func runSponsorRegistry(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	if contract.Address() != params.SponsorRegistryAddress || contract.Value().Sign() != 0 {
+		return nil, errSponsorCall
+	}
+	if len(input) < 4 {
+		return nil, errSponsorMethod
+	}
+	method, args := input[:4], input[4:]
+
+	switch {
+	case bytes.Equal(method, sponsorshipMethod):
+		if len(args) != 64 {
+			return nil, errSponsorInput
+		}
+		if !contract.UseGas(2 * evm.ChainConfig().GasTable(evm.BlockNumber).SLoad) {
+			return nil, ErrOutOfGas
+		}
+		limit := Sponsorship(evm.StateDB, common.BytesToAddress(args[:32]), common.BytesToAddress(args[32:]))
+		return common.LeftPadBytes(new(big.Int).SetUint64(limit).Bytes(), 32), nil
+
+	case bytes.Equal(method, sponsorMethod):
+		if len(args) != 64 {
+			return nil, errSponsorInput
+		}
+		limit := new(big.Int).SetBytes(args[32:])
+		if !limit.IsUint64() {
+			return nil, errSponsorInput
+		}
+		return nil, setSponsorship(evm, contract, common.BytesToAddress(args[:32]), limit.Uint64(), readOnly)
+
+	case bytes.Equal(method, revokeMethod):
+		if len(args) != 32 {
+			return nil, errSponsorInput
+		}
+		return nil, setSponsorship(evm, contract, common.BytesToAddress(args[:32]), 0, readOnly)
+	}
+	return nil, errSponsorMethod
+}
+
+// setSponsorship stores the gas limit up to which the calling contract sponsors
+// the transactions of caller, a zero limit revoking the sponsorship.
+func setSponsorship(evm *EVM, contract *Contract, caller common.Address, limit uint64, readOnly bool) error {
+	if readOnly {
+		return errWriteProtection
+	}
+	if !contract.UseGas(params.SstoreSetGas) {
+		return ErrOutOfGas
+	}
+	// Keep the registry non-empty, or it would be deleted along with its storage
+	if evm.StateDB.GetNonce(params.SponsorRegistryAddress) == 0 {
+		evm.StateDB.SetNonce(params.SponsorRegistryAddress, 1)
+	}
+	var value common.Hash
+	binary.BigEndian.PutUint64(value[common.HashLength-8:], limit)
+	evm.StateDB.SetState(params.SponsorRegistryAddress, sponsorKey(contract.Caller(), caller), value)
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/state"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+func newSponsorEVM(t *testing.T, sponsorBlock *big.Int) *EVM {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	config := *params.TestChainConfig
+	config.Devote = &params.DevoteConfig{SponsorBlock: sponsorBlock}
+
+	ctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int, *big.Int) {},
+		BlockNumber: big.NewInt(1),
+	}
+	return NewEVM(ctx, statedb, &config, Config{})
+}
+
+func sponsorCall(method []byte, args ...[]byte) []byte {
+	input := append([]byte{}, method...)
+	for _, arg := range args {
+		input = append(input, common.LeftPadBytes(arg, 32)...)
+	}
+	return input
+}
+
+func TestSponsorRegistry(t *testing.T) {
+	var (
+		evm      = newSponsorEVM(t, big.NewInt(0))
+		contract = common.HexToAddress("0x1000")
+		alice    = common.HexToAddress("0x2000")
+		bob      = common.HexToAddress("0x3000")
+	)
+	// Whitelist everyone and alice with a higher limit
+	if _, _, err := evm.Call(AccountRef(contract), params.SponsorRegistryAddress, sponsorCall(sponsorMethod, common.Address{}.Bytes(), big.NewInt(21000).Bytes()), 100000, new(big.Int)); err != nil {
+		t.Fatalf("failed to sponsor everyone: %v", err)
+	}
+	if _, _, err := evm.Call(AccountRef(contract), params.SponsorRegistryAddress, sponsorCall(sponsorMethod, alice.Bytes(), big.NewInt(50000).Bytes()), 100000, new(big.Int)); err != nil {
+		t.Fatalf("failed to sponsor alice: %v", err)
+	}
+	if limit := Sponsorship(evm.StateDB, contract, alice); limit != 50000 {
+		t.Errorf("alice limit mismatch: have %d, want %d", limit, 50000)
+	}
+	if limit := Sponsorship(evm.StateDB, contract, bob); limit != 21000 {
+		t.Errorf("bob limit mismatch: have %d, want %d", limit, 21000)
+	}
+	if limit := Sponsorship(evm.StateDB, bob, alice); limit != 0 {
+		t.Errorf("unrelated sponsor limit mismatch: have %d, want 0", limit)
+	}
+	// Query the registry like a contract would
+	ret, _, err := evm.StaticCall(AccountRef(bob), params.SponsorRegistryAddress, sponsorCall(sponsorshipMethod, contract.Bytes(), alice.Bytes()), 100000)
+	if err != nil {
+		t.Fatalf("failed to query sponsorship: %v", err)
+	}
+	if limit := new(big.Int).SetBytes(ret); limit.Uint64() != 50000 {
+		t.Errorf("queried limit mismatch: have %v, want %d", limit, 50000)
+	}
+	// Registrations must not go through static calls
+	if _, _, err := evm.StaticCall(AccountRef(contract), params.SponsorRegistryAddress, sponsorCall(revokeMethod, alice.Bytes()), 100000); err != errWriteProtection {
+		t.Errorf("static revoke error mismatch: have %v, want %v", err, errWriteProtection)
+	}
+	// Revoking alice falls back to the everyone limit
+	if _, _, err := evm.Call(AccountRef(contract), params.SponsorRegistryAddress, sponsorCall(revokeMethod, alice.Bytes()), 100000, new(big.Int)); err != nil {
+		t.Fatalf("failed to revoke alice: %v", err)
+	}
+	if limit := Sponsorship(evm.StateDB, contract, alice); limit != 21000 {
+		t.Errorf("revoked alice limit mismatch: have %d, want %d", limit, 21000)
+	}
+	// The registry must survive empty account deletion
+	evm.StateDB.(*state.StateDB).Finalise(true)
+	if limit := Sponsorship(evm.StateDB, contract, bob); limit != 21000 {
+		t.Errorf("finalised bob limit mismatch: have %d, want %d", limit, 21000)
+	}
+}
+
+func TestSponsorRegistryBeforeFork(t *testing.T) {
+	var (
+		evm      = newSponsorEVM(t, big.NewInt(2))
+		contract = common.HexToAddress("0x1000")
+	)
+	if _, _, err := evm.Call(AccountRef(contract), params.SponsorRegistryAddress, sponsorCall(sponsorMethod, common.Address{}.Bytes(), big.NewInt(21000).Bytes()), 100000, new(big.Int)); err != nil {
+		t.Fatalf("failed to call registry address: %v", err)
+	}
+	if limit := Sponsorship(evm.StateDB, contract, common.HexToAddress("0x2000")); limit != 0 {
+		t.Errorf("limit mismatch before fork: have %d, want 0", limit)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/discover"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Kinds of alerts posted to the operator endpoint.
+const (
+	AlertMissedSlot        = "missed-slot"        // The local masternode didn't seal a slot it was elected for
+	AlertMasternodeExpired = "masternode-expired" // The local masternode stopped being enabled
+	AlertPaymentReceived   = "payment-received"   // The local masternode was paid by a block
+	AlertPeerCountLow      = "peer-count-low"     // The node fell below the minimum peer count
+	AlertClockDrift        = "clock-drift"        // The local clock drifted away from NTP time
+)
+
+const (
+	// alertQueueSize is the number of alerts pending delivery, past which new
+	// alerts are dropped.
+	alertQueueSize = 64
+
+	// alertAttempts is the number of times delivering an alert is attempted.
+	alertAttempts = 5
+
+	// alertBackoff is the delay before retrying a failed delivery, doubled
+	// after every further failure.
+	alertBackoff = time.Second
+
+	// alertTimeout is the timeout of a single delivery attempt.
+	alertTimeout = 10 * time.Second
+
+	// alertCheckInterval is the interval the masternode state, peer count and
+	// clock drift are checked at.
+	alertCheckInterval = time.Minute
+
+	// alertMaxDrift is the clock drift past which it's alerted of.
+	alertMaxDrift = time.Second
+)
+
+// Alert is an event of the node of interest to its operator, posted as JSON to
+// the alerting endpoint.
+type Alert struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Masternode string    `json:"masternode,omitempty"` // Local masternode ID, empty if not known yet
+
+	Slot   hexutil.Uint64  `json:"slot,omitempty"`   // Missed slot, set by missed-slot
+	Number *hexutil.Uint64 `json:"number,omitempty"` // Paying block, set by payment-received
+	Hash   *common.Hash    `json:"hash,omitempty"`   // Paying block, set by payment-received
+	Amount *hexutil.Big    `json:"amount,omitempty"` // Amount paid, set by payment-received
+	State  string          `json:"state,omitempty"`  // Masternode state, set by masternode-expired
+	Peers  int             `json:"peers,omitempty"`  // Peer count, set by peer-count-low
+	Drift  string          `json:"drift,omitempty"`  // Clock drift, set by clock-drift
+}
+
+// alerter watches the node for the events its operator should be told about and
+// posts them to the alerting endpoint, replacing scripts scraping the logs.
+// State changes are alerted of once, when entering the faulty state.
+type alerter struct {
+	eth      *Ethereum
+	engine   *devote.Devote // Devote engine, nil if not running devote
+	url      string
+	minPeers int
+	client   *http.Client
+	backoff  time.Duration // Delay before the first retry of a failed delivery
+
+	queue chan *Alert
+
+	slot    uint64         // Last slot checked for a miss
+	account common.Address // Account the local masternode is paid to
+	expired bool           // Whether the local masternode is known expired
+	lonely  bool           // Whether the node is known below the minimum peers
+	drifted bool           // Whether the local clock is known drifted
+
+	quit chan struct{}
+}
+
+func newAlerter(eth *Ethereum, url string, minPeers int) *alerter {
+	engine, _ := eth.engine.(*devote.Devote)
+	return &alerter{
+		eth:      eth,
+		engine:   engine,
+		url:      url,
+		minPeers: minPeers,
+		client:   &http.Client{Timeout: alertTimeout},
+		backoff:  alertBackoff,
+		queue:    make(chan *Alert, alertQueueSize),
+		quit:     make(chan struct{}),
+	}
+}
+
+func (a *alerter) start() {
+	go a.loop()
+	go a.deliver()
+}
+
+func (a *alerter) stop() {
+	close(a.quit)
+}
+
+func (a *alerter) loop() {
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := a.eth.blockchain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	slots := time.NewTicker(time.Duration(params.Period) * time.Second)
+	defer slots.Stop()
+	checks := time.NewTicker(alertCheckInterval)
+	defer checks.Stop()
+
+	log.Info("Alerting enabled", "url", a.url)
+	a.check()
+	for {
+		select {
+		case ev := <-heads:
+			a.checkPayment(ev.Block)
+		case now := <-slots.C:
+			a.checkSlot(uint64(now.Unix()))
+		case <-checks.C:
+			a.check()
+		case <-sub.Err():
+			return
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+// check evaluates the periodically checked conditions.
+func (a *alerter) check() {
+	a.checkMasternode()
+	a.checkPeers()
+	a.checkDrift()
+}
+
+// checkMasternode alerts of the local masternode expiring, and tracks the
+// account it's paid to.
+func (a *alerter) checkMasternode() {
+	mm := a.eth.masternodeManager
+	if mm.srvr == nil {
+		return
+	}
+	node, err := mm.nodeOf(mm.srvr.Self().X8())
+	if err != nil {
+		return
+	}
+	a.account = node.Account
+
+	state, err := mm.State()
+	if err != nil {
+		return
+	}
+	expired := state == masternode.MasternodeExpired || state == masternode.MasternodeWatchdogExpired
+	if expired && !a.expired {
+		a.post(&Alert{Type: AlertMasternodeExpired, State: masternode.StatusName(state)})
+	}
+	a.expired = expired
+}
+
+// checkPeers alerts of the node falling below the minimum peer count.
+func (a *alerter) checkPeers() {
+	srvr := a.eth.masternodeManager.srvr
+	if srvr == nil || a.minPeers == 0 {
+		return
+	}
+	peers := srvr.PeerCount()
+	lonely := peers < a.minPeers
+	if lonely && !a.lonely {
+		a.post(&Alert{Type: AlertPeerCountLow, Peers: peers})
+	}
+	a.lonely = lonely
+}
+
+// checkDrift alerts of the local clock drifting away from NTP time, as last
+// measured by the masternode manager.
+func (a *alerter) checkDrift() {
+	drift := time.Duration(discover.NanoDrift())
+	if drift < 0 {
+		drift = -drift
+	}
+	drifted := drift >= alertMaxDrift
+	if drifted && !a.drifted {
+		a.post(&Alert{Type: AlertClockDrift, Drift: drift.String()})
+	}
+	a.drifted = drifted
+}
+
+// checkSlot alerts of the local masternode missing the most recent slot which
+// should have been sealed by now.
+func (a *alerter) checkSlot(now uint64) {
+	if a.engine == nil || now < (standbyGraceSlots+1)*params.Period {
+		return
+	}
+	slot := devote.PrevSlot(now - standbyGraceSlots*params.Period)
+	if slot <= a.slot {
+		return
+	}
+	a.slot = slot
+
+	id := a.eth.masternodeManager.ID
+	if id == "" {
+		return
+	}
+	current := a.eth.blockchain.CurrentBlock().Header()
+	if witness, err := a.engine.WitnessAt(current, slot); err != nil || witness != id {
+		return
+	}
+	if a.eth.findSlot(current, slot) == nil {
+		a.post(&Alert{Type: AlertMissedSlot, Slot: hexutil.Uint64(slot)})
+	}
+}
+
+// checkPayment alerts of the masternode payment of a new head if it pays the
+// local masternode.
+func (a *alerter) checkPayment(block *types.Block) {
+	if a.account == (common.Address{}) {
+		return
+	}
+	receipt := rawdb.ReadSystemReceipt(a.eth.chainDb, block.Hash(), block.NumberU64())
+	if receipt == nil {
+		return
+	}
+	for _, payment := range receipt.Payments {
+		if payment.Kind != types.PaymentMasternode || payment.To != a.account {
+			continue
+		}
+		number, hash := hexutil.Uint64(block.NumberU64()), block.Hash()
+		a.post(&Alert{Type: AlertPaymentReceived, Number: &number, Hash: &hash, Amount: (*hexutil.Big)(new(big.Int).Set(payment.Amount))})
+	}
+}
+
+// post queues an alert for delivery, dropping it if the queue is full.
+func (a *alerter) post(alert *Alert) {
+	alert.Time = time.Now()
+	alert.Masternode = a.eth.masternodeManager.ID
+
+	log.Debug("Posting alert", "type", alert.Type)
+	select {
+	case a.queue <- alert:
+	default:
+		log.Warn("Alert queue full, dropping alert", "type", alert.Type)
+	}
+}
+
+// deliver posts the queued alerts to the endpoint one by one.
+func (a *alerter) deliver() {
+	for {
+		select {
+		case alert := <-a.queue:
+			a.send(alert)
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+// send posts an alert to the endpoint, retrying with an exponential backoff.
+func (a *alerter) send(alert *Alert) bool {
+	blob, err := json.Marshal(alert)
+	if err != nil {
+		return false
+	}
+	delay := a.backoff
+	for attempt := 1; ; attempt++ {
+		err := a.sendOnce(blob)
+		if err == nil {
+			return true
+		}
+		if attempt == alertAttempts {
+			log.Warn("Failed to deliver alert", "type", alert.Type, "attempts", attempt, "err", err)
+			return false
+		}
+		log.Debug("Failed to deliver alert, retrying", "type", alert.Type, "attempt", attempt, "delay", delay, "err", err)
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-a.quit:
+			return false
+		}
+	}
+}
+
+// sendOnce makes a single delivery attempt of an encoded alert.
+func (a *alerter) sendOnce(blob []byte) error {
+	res, err := a.client.Post(a.url, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("alert rejected: %s", res.Status)
+	}
+	return nil
+}
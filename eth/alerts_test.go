@@ -0,0 +1,67 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tests that alerts are retried with a backoff until the endpoint accepts them,
+// and given up on after the maximum number of attempts.
+func TestAlertDelivery(t *testing.T) {
+	var (
+		attempts int32
+		failures int32 = 2
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		alert := new(Alert)
+		if err := json.NewDecoder(r.Body).Decode(alert); err != nil || alert.Type != AlertMissedSlot || alert.Slot != 600 {
+			t.Errorf("alert mismatch: have %+v, err %v", alert, err)
+		}
+	}))
+	defer server.Close()
+
+	a := &alerter{
+		url:     server.URL,
+		client:  &http.Client{Timeout: time.Second},
+		backoff: time.Millisecond,
+		quit:    make(chan struct{}),
+	}
+	if !a.send(&Alert{Type: AlertMissedSlot, Slot: 600}) {
+		t.Fatalf("alert not delivered")
+	}
+	if have := atomic.LoadInt32(&attempts); have != 3 {
+		t.Errorf("attempt count mismatch: have %d, want 3", have)
+	}
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&failures, alertAttempts)
+	if a.send(&Alert{Type: AlertMissedSlot, Slot: 600}) {
+		t.Fatalf("alert delivered to failing endpoint")
+	}
+	if have := atomic.LoadInt32(&attempts); have != alertAttempts {
+		t.Errorf("attempt count mismatch: have %d, want %d", have, alertAttempts)
+	}
+}
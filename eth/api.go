@@ -26,13 +26,17 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/internal/ethapi"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rlp"
@@ -149,6 +153,262 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.e.miner.HashRate())
 }
 
+// PrivateMasternodeAPI provides private RPC methods to manage the masternode run
+// by this node. The collateral account may live on any wallet backend, so the
+// transactions can be confirmed on a Ledger or Trezor device.
+type PrivateMasternodeAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateMasternodeAPI creates a new RPC service which manages the local masternode.
+func NewPrivateMasternodeAPI(e *Ethereum) *PrivateMasternodeAPI {
+	return &PrivateMasternodeAPI{e: e}
+}
+
+// Register deposits the collateral from the given account and registers the
+// local node in the masternode contract.
+func (api *PrivateMasternodeAPI) Register(from common.Address) (common.Hash, error) {
+	tx, err := api.e.masternodeManager.Register(from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// Setup registers the local node as a masternode in one call, depositing the
+// collateral from the given account unlocked with the password, and returns the
+// id and enode of the masternode along with the transactions sent.
+func (api *PrivateMasternodeAPI) Setup(from common.Address, password string) (*MasternodeSetup, error) {
+	return api.e.masternodeManager.Setup(from, password)
+}
+
+// Quit unregisters the masternode owned by the given collateral account.
+func (api *PrivateMasternodeAPI) Quit(from common.Address) (common.Hash, error) {
+	tx, err := api.e.masternodeManager.Quit(from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SignDelegation authorizes a hot key to seal blocks on behalf of the local
+// masternode key until the given cycle, returning the encoded delegation to be
+// configured on the hot node.
+func (api *PrivateMasternodeAPI) SignDelegation(delegate common.Address, expiry uint64) (hexutil.Bytes, error) {
+	delegation, err := api.e.masternodeManager.SignDelegation(delegate, expiry)
+	if err != nil {
+		return nil, err
+	}
+	return delegation.Encode(), nil
+}
+
+// MasternodeStatus is the state of the masternode run by this node.
+type MasternodeStatus struct {
+	ID         string         `json:"id"`         // Masternode ID sealing blocks
+	Account    common.Address `json:"account"`    // Account of the node key
+	Masternode bool           `json:"masternode"` // Whether the ID is registered in the contract
+	Syncing    bool           `json:"syncing"`
+	Mining     bool           `json:"mining"`
+	Delegated  bool           `json:"delegated"`       // Whether sealing with a hot key on behalf of a cold key
+	Standby    bool           `json:"standby"`         // Whether running as a standby host
+	Fenced     bool           `json:"fenced"`          // Whether the standby host currently refrains from sealing
+	Observer   bool           `json:"observer"`        // Whether running in observer mode, never signing
+	State      string         `json:"state,omitempty"` // State of the registered node key, watchdog included
+	Sentinel   uint32         `json:"sentinel"`        // Version of the sentinel which last confirmed the host, 0 if built-in
+	Watchdog   time.Time      `json:"watchdog"`        // Time of the last health confirmation of the host
+
+	Activation  string                            `json:"activation"`  // Activation state of the masternode
+	Activated   time.Time                         `json:"activated"`   // Time the activation state was entered at
+	Transitions []masternode.ActivationTransition `json:"transitions"` // Last activation state changes, oldest first
+
+	Reachability string    `json:"reachability"`      // Outcome of the last dial back self-test
+	Reached      time.Time `json:"reached"`           // Time the last self-test completed
+	Warning      string    `json:"warning,omitempty"` // Problem the operator should look into
+}
+
+// Status returns the state of the masternode run by this node.
+func (api *PrivateMasternodeAPI) Status() (*MasternodeStatus, error) {
+	mm := api.e.masternodeManager
+	if mm.srvr == nil {
+		return nil, errMasternodeNotStarted
+	}
+	// Look up the state before locking, it's evaluated from the contract
+	state, err := mm.State()
+	if err != nil && err != errMasternodeNotRegistered {
+		return nil, err
+	}
+	sentinel, last := mm.watchdog.Sentinel()
+	reachable, reached := mm.Reachability()
+	activation, activated := mm.activation.State()
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	status := &MasternodeStatus{
+		ID:         mm.ID,
+		Account:    mm.NodeAccount,
+		Masternode: atomic.LoadUint32(&mm.IsMasternode) == 1,
+		Syncing:    atomic.LoadInt32(&mm.syncing) == 1,
+		Mining:     api.e.IsMining(),
+		Delegated:  mm.delegation != nil,
+		Standby:    api.e.standby != nil,
+		Fenced:     api.e.standbyFenced(),
+		Observer:   mm.observer,
+		Sentinel:   sentinel,
+		Watchdog:   last,
+
+		Activation:  masternode.ActivationName(activation),
+		Activated:   activated,
+		Transitions: mm.activation.History(),
+
+		Reachability: reachable,
+		Reached:      reached,
+	}
+	if reachable == reachabilityUnreachable {
+		status.Warning = "masternode unreachable behind NAT"
+	}
+	if err == nil {
+		status.State = masternode.StatusName(state)
+	}
+	return status, nil
+}
+
+// SentinelPing confirms the health of the masternode host on behalf of an
+// external sentinel process of the given version, rearming the watchdog.
+func (api *PrivateMasternodeAPI) SentinelPing(version uint32) {
+	api.e.masternodeManager.SentinelPing(version)
+}
+
+// Announcements returns the enode URLs of the masternodes announced on the
+// network, keyed by masternode ID.
+func (api *PrivateMasternodeAPI) Announcements() map[string]string {
+	nodes := make(map[string]string)
+	for _, ann := range api.e.masternodeManager.Announcements() {
+		if node, _, err := masternode.ParseENode(ann.ENode); err == nil {
+			x8 := node.X8()
+			nodes[fmt.Sprintf("%x", x8[:])] = ann.ENode
+		}
+	}
+	return nodes
+}
+
+// maxMasternodePage is the maximum number of masternodes returned by a single
+// list request.
+const maxMasternodePage = 1000
+
+// filterState converts the optional status filter of a list request into a
+// masternode state, -1 standing for all states.
+func filterState(filter *string) (int, error) {
+	if filter == nil || *filter == "" || *filter == "all" {
+		return -1, nil
+	}
+	return masternode.ParseStatus(*filter)
+}
+
+// List returns a page of the masternodes registered at the current head, newest
+// first, optionally filtered by status ("enabled", "new" or "expired"). If limit
+// is omitted, up to maxMasternodePage masternodes are returned.
+func (api *PrivateMasternodeAPI) List(offset, limit *uint64, filter *string) ([]*masternode.Info, error) {
+	state, err := filterState(filter)
+	if err != nil {
+		return nil, err
+	}
+	index, err := api.e.masternodeManager.masternodeIndex()
+	if err != nil {
+		return nil, err
+	}
+	nodes := index.filter(state)
+
+	begin, count := uint64(0), uint64(maxMasternodePage)
+	if offset != nil {
+		begin = *offset
+	}
+	if limit != nil && *limit < count {
+		count = *limit
+	}
+	if begin >= uint64(len(nodes)) {
+		return []*masternode.Info{}, nil
+	}
+	end := begin + count
+	if end > uint64(len(nodes)) {
+		end = uint64(len(nodes))
+	}
+	infos := make([]*masternode.Info, 0, end-begin)
+	for _, node := range nodes[begin:end] {
+		infos = append(infos, node.Info())
+	}
+	return infos, nil
+}
+
+// Count returns the number of masternodes registered at the current head,
+// optionally filtered by status.
+func (api *PrivateMasternodeAPI) Count(filter *string) (uint64, error) {
+	state, err := filterState(filter)
+	if err != nil {
+		return 0, err
+	}
+	index, err := api.e.masternodeManager.masternodeIndex()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(index.filter(state))), nil
+}
+
+// Counts returns the breakdown of the masternodes registered at the current head
+// by state, by announced protocol version and, if a GeoIP database is set, by
+// country and autonomous system.
+func (api *PrivateMasternodeAPI) Counts() (*MasternodeCounts, error) {
+	return api.e.masternodeManager.Counts()
+}
+
+// EstimateROI estimates the payment frequency and annualized return of a
+// masternode from the enabled masternodes and the block reward at the current
+// head.
+func (api *PrivateMasternodeAPI) EstimateROI() (*MasternodeROI, error) {
+	return api.e.masternodeManager.EstimateROI()
+}
+
+// Winner returns the masternode to be paid by the block at the given height,
+// which may be at most one past the current head.
+func (api *PrivateMasternodeAPI) Winner(height rpc.BlockNumber) (*masternode.Info, error) {
+	engine, ok := api.e.engine.(*devote.Devote)
+	if !ok {
+		return nil, errors.New("payment queue requires the devote engine")
+	}
+	head := api.e.blockchain.CurrentHeader()
+	number := uint64(height)
+	switch height {
+	case rpc.LatestBlockNumber:
+		number = head.Number.Uint64()
+	case rpc.PendingBlockNumber:
+		number = head.Number.Uint64() + 1
+	}
+	if number == 0 || number > head.Number.Uint64()+1 {
+		return nil, fmt.Errorf("no payee known for block #%d", number)
+	}
+	parent := api.e.blockchain.GetHeaderByNumber(number - 1)
+	if parent == nil {
+		return nil, fmt.Errorf("block #%d not found", number-1)
+	}
+	payee, err := engine.Payee(api.e.blockchain, parent)
+	if err != nil {
+		return nil, err
+	}
+	if payee == nil {
+		return nil, ethapi.ErrMasternodeUnknown
+	}
+	return payee.Info(), nil
+}
+
+// Withdraw transfers masternode rewards from the payout account to another address.
+func (api *PrivateMasternodeAPI) Withdraw(from common.Address, to common.Address, amount hexutil.Big) (common.Hash, error) {
+	tx, err := api.e.masternodeManager.Withdraw(from, to, (*big.Int)(&amount))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -183,6 +443,18 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// ResyncMasternodes drops the cached masternode set and re-reads it from the
+// contract, returning the differences to the cached view.
+func (api *PrivateAdminAPI) ResyncMasternodes() (*MasternodeResync, error) {
+	return api.eth.masternodeManager.Resync()
+}
+
+// MasternodePeers returns the registered masternodes, and the ones the node was
+// connected to before, along with their connection status.
+func (api *PrivateAdminAPI) MasternodePeers() []*MasternodePeerInfo {
+	return api.eth.masternodeManager.MasternodePeers()
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
@@ -302,9 +574,11 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
-	Hash  common.Hash            `json:"hash"`
-	Block map[string]interface{} `json:"block"`
-	RLP   string                 `json:"rlp"`
+	Hash   common.Hash            `json:"hash"`
+	Block  map[string]interface{} `json:"block"`
+	RLP    string                 `json:"rlp"`
+	Error  string                 `json:"error,omitempty"`
+	Reason string                 `json:"reason,omitempty"` // Short name of the devote consensus violation
 }
 
 // GetBadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
@@ -318,6 +592,10 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 		results[i] = &BadBlockArgs{
 			Hash: block.Hash(),
 		}
+		if err := api.eth.BlockChain().BadBlockError(block.Hash()); err != nil {
+			results[i].Error = err.Error()
+			results[i].Reason = devote.ErrorReason(err)
+		}
 		if rlpBytes, err := rlp.EncodeToBytes(block); err != nil {
 			results[i].RLP = err.Error() // Hacky, but hey, it works
 		} else {
@@ -330,6 +608,12 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// Forks returns the competing branches of the canonical chain the node observed
+// lately, along with the witnesses which sealed them.
+func (api *PrivateDebugAPI) Forks() []*Fork {
+	return api.eth.forks.Forks()
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -22,16 +22,20 @@ import (
 
 	"github.com/etherzero/go-etherzero/accounts"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/common/math"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/bloombits"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/eth/gasprice"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/internal/ethapi"
+	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rpc"
 	"fmt"
@@ -142,6 +146,10 @@ func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeReorgEvent(ch)
+}
+
 func (b *EthAPIBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainEvent(ch)
 }
@@ -231,55 +239,65 @@ func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.Ma
 
 // Masternodes return masternode info
 func (b *EthAPIBackend) Masternodes() []string {
-	list, _ := b.eth.masternodeManager.MasternodeList(b.eth.blockchain.CurrentBlock().Number())
-	return list
+	head := b.eth.blockchain.CurrentBlock()
+	list, err := b.eth.rpcCache.Do("eth_masternodes", head.Hash(), nil, func() (interface{}, error) {
+		return b.eth.masternodeManager.MasternodeList(head.Number())
+	})
+	if err != nil {
+		return nil
+	}
+	return list.([]string)
 }
 
 // GetInfo return related info in masternode contract
-func (b *EthAPIBackend) GetInfo(nodeid string) string {
+func (b *EthAPIBackend) GetInfo(nodeid string) (*masternode.Info, error) {
 	var id [8]byte
 	node, err := hex.DecodeString(strings.TrimPrefix(nodeid, "0x"))
-	if err != nil {
-		fmt.Printf("err %v\n", err)
-		return ""
-	} else if len(node) != len(id) {
-		return ""
+	if err != nil || len(node) != len(id) {
+		return nil, ethapi.ErrInvalidMasternodeID
 	}
 	copy(id[:], node)
-	fmt.Println("nodeid ", nodeid)
 
-	info, err := b.eth.masternodeManager.contract.GetInfo(nil, id)
+	number := b.eth.blockchain.CurrentBlock().Number()
+	caller, err := b.eth.masternodeManager.contracts.caller(number)
 	if err != nil {
-		fmt.Errorf("contract.Has error %v", err)
-		return ""
+		return nil, ethapi.MasternodeContractError(err)
 	}
-
-	return fmt.Sprintf("Id1: %v,Id2:%v,PreId:0x%v,NextId:0x%v,BlockNumber:%v,Account:%v,BlockOnlineAcc:%v,BloakLastPing:%v",
-		common.BytesToHash(info.Id1[:]).String(), common.BytesToHash(info.Id2[:]).String(), common.Bytes2Hex(info.PreId[:]), common.Bytes2Hex(info.NextId[:]), info.BlockNumber.String(), info.Account.String(),
-		info.BlockOnlineAcc.String(), info.BlockLastPing.String())
+	info, err := masternode.GetMasternode(caller, id, number)
+	if err != nil {
+		log.Warn("Failed to retrieve masternode info", "id", nodeid, "err", err)
+		return nil, ethapi.MasternodeContractError(err)
+	}
+	if info == nil {
+		return nil, ethapi.ErrMasternodeUnknown
+	}
+	return info.Info(), nil
 }
 
-// Data
-// Masternodes return masternode contract data
-func (b *EthAPIBackend) Data() (strPromotion string) {
-	if b.eth.masternodeManager.srvr.Self() == nil {
-		strPromotion = "wait for more 10 seconds to initial the geth"
-		return
+// Data returns the transaction data registering the local node in the
+// masternode contract.
+func (b *EthAPIBackend) Data() (hexutil.Bytes, error) {
+	srvr := b.eth.masternodeManager.srvr
+	if srvr == nil || srvr.Self() == nil {
+		return nil, ethapi.ErrMasternodeNotReady
 	}
-	xy := b.eth.masternodeManager.srvr.Self().XY()
+	xy := srvr.Self().XY()
 
 	var id [8]byte
 	copy(id[:], xy[0:8])
-	has, err := b.eth.masternodeManager.contract.Has(nil, id)
+	caller, err := b.eth.masternodeManager.contracts.caller(b.eth.blockchain.CurrentBlock().Number())
+	if err != nil {
+		return nil, ethapi.MasternodeContractError(err)
+	}
+	has, err := caller.Has(nil, id)
 	if err != nil {
-		fmt.Errorf("contract.Has error %v", err)
-		return
+		log.Warn("Failed to check masternode registration", "id", fmt.Sprintf("%x", id), "err", err)
+		return nil, ethapi.MasternodeContractError(err)
 	}
 	if has {
-		strPromotion = fmt.Sprintf("### It's already been a masternode!,don't send your masternode data any more!")
+		return nil, ethapi.ErrMasternodeRegistered
 	}
-	data := "0x2f926732" + common.Bytes2Hex(xy[:])
-	return fmt.Sprintf("%v your masternode data is %v", strPromotion, data)
+	return append(common.FromHex("0x2f926732"), xy[:]...), nil
 }
 
 // Masternodes return masternode contract data
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -42,6 +43,7 @@ import (
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/internal/ethapi"
+	"github.com/etherzero/go-etherzero/internal/rpccache"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/miner"
 	"github.com/etherzero/go-etherzero/node"
@@ -50,7 +52,6 @@ import (
 	"github.com/etherzero/go-etherzero/rlp"
 	"github.com/etherzero/go-etherzero/rpc"
 	"github.com/etherzero/go-etherzero/consensus/devote"
-	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
 	"time"
 )
 
@@ -61,6 +62,10 @@ type LesServer interface {
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
 }
 
+// rpcCacheSize is the number of expensive read RPC results cached for the
+// current head.
+const rpcCacheSize = 256
+
 // Ethereum implements the Ethereum full node service.
 type Ethereum struct {
 	config      *Config
@@ -94,6 +99,10 @@ type Ethereum struct {
 	networkID         uint64
 	netRPCService     *ethapi.PublicNetAPI
 	masternodeManager *MasternodeManager
+	standby           *standbyMonitor // Failover monitor if running as a standby masternode host
+	forks             *forkMonitor    // Monitor of the side chains competing with the canonical one
+	alerts            *alerter        // Poster of the operator alerts, nil if disabled
+	rpcCache          *rpccache.Cache // Results of the expensive read RPCs, purged on every new head
 	lock              sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 }
 
@@ -121,6 +130,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := rawdb.CheckSchemaVersion(chainDb); err != nil {
+		return nil, err
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
@@ -141,6 +153,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		witness:        config.Witness,
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+		rpcCache:       rpccache.New(rpcCacheSize),
 	}
 
 	log.Info("Initialising Ethereum protocol", "versions", ProtocolVersions, "network", config.NetworkId)
@@ -158,12 +171,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 			EWASMInterpreter:        config.EWASMInterpreter,
 			EVMInterpreter:          config.EVMInterpreter,
 		}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout, TriesInMemory: config.StateHistory}
 	)
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
 	if err != nil {
 		return nil, err
 	}
+	if config.ParallelExecution > 0 {
+		eth.blockchain.SetParallelExecution(config.ParallelExecution)
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -175,6 +191,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
@@ -184,18 +203,59 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	//devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(eth.chainDb), eth.blockchain.CurrentBlock().Header().Protocol)
 
 	contractBackend := NewContractBackend(eth)
-	contract, err := contract.NewContract(params.MasterndeContractAddress, contractBackend)
-	if eth.masternodeManager = NewMasternodeManager(eth, contract); err != nil {
+	eth.masternodeManager = NewMasternodeManager(eth, contractBackend)
+	eth.protocolManager.mm = eth.masternodeManager
+	eth.masternodeManager.SetObserver(config.DevoteObserver)
+	eth.masternodeManager.SetDelegation(config.MasternodeDelegation)
+	eth.masternodeManager.SetSentinel(config.MasternodeSentinel)
+	eth.masternodeManager.SetHost(config.MasternodeHost)
+	if err := eth.masternodeManager.SetEndpoints(config.MasternodeEndpoints, config.MasternodeRotation); err != nil {
 		return nil, err
 	}
-	eth.protocolManager.mm = eth.masternodeManager
+	eth.masternodeManager.SetBootstrap(config.MasternodeBootstrap)
+	if err := eth.masternodeManager.SetGeoIP(config.MasternodeGeoIP); err != nil {
+		log.Error("Failed to load GeoIP database, counting masternodes without it", "err", err)
+	}
+	if config.MasternodeRestake != (common.Address{}) {
+		reserve := new(big.Int).Mul(new(big.Int).SetUint64(config.MasternodeRestakeReserve), big.NewInt(params.Ether))
+		journal := ctx.ResolvePath("masternode-restake.rlp")
+		if err := eth.masternodeManager.SetRestake(config.MasternodeRestake, config.MasternodeRestakeTargets, config.MasternodeRestakeFunders, reserve, journal); err != nil {
+			return nil, err
+		}
+	}
 
 	if devote, ok := eth.engine.(*devote.Devote); ok {
 		devote.Masternodes(eth.masternodeManager.MasternodeList)
 		devote.GovernanceContract(eth.masternodeManager.GetGovernanceContractAddress)
+		devote.PaymentCandidates(eth.masternodeManager.Masternodes)
+		devote.SuperblockBudget(eth.masternodeManager.Budget)
+		devote.Treasury(eth.masternodeManager.Treasury)
+		devote.MaxWitnesses(eth.masternodeManager.MaxWitnesses)
+		devote.VRFKeys(eth.masternodeManager.VRFKey)
+		devote.RegistrySeed(eth.masternodeManager.RegisteredMasternodes)
+		devote.APICache(eth.rpcCache)
+		if config.DevoteObserver {
+			devote.Observe()
+			log.Info("Running in devote observer mode, never signing")
+		} else if config.MasternodeStandby > 0 {
+			eth.standby = newStandbyMonitor(eth, devote, config.MasternodeStandby)
+		}
+	}
+	eth.forks = newForkMonitor(eth.blockchain, config.ForkWebhook, config.ForkAlertDepth)
+	if config.AlertsURL != "" {
+		eth.alerts = newAlerter(eth, config.AlertsURL, config.AlertsMinPeers)
 	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.MinerExtraData))
+	eth.miner.SetSystemGas(config.MinerSystemGas)
+	eth.miner.SetSkipEmpty(config.DevoteSkipEmpty)
+	if config.MinerWatchdog {
+		if dir := ctx.ResolvePath("watchdog"); dir != "" {
+			eth.miner.SetWatchdog(dir)
+		} else {
+			log.Warn("Sealing deadline watchdog needs a datadir, disabled")
+		}
+	}
 
 	eth.APIBackend = &EthAPIBackend{eth, nil}
 	gpoParams := config.GPO
@@ -233,6 +293,15 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Data
 	if db, ok := db.(*ethdb.LDBDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
+	// Move the masternode data of old blocks into the freezer, if requested
+	if dir := ctx.ResolvePath(filepath.Join(name, "ancient")); config.FreezerCycles > 0 && dir != "" {
+		frozen, err := rawdb.NewDatabaseWithFreezer(db, dir, config.FreezerCycles)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		db = frozen
+	}
 	return db, nil
 }
 
@@ -301,6 +370,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPrivateMinerAPI(s),
 			Public:    false,
+		}, {
+			Namespace: "masternode",
+			Version:   "1.0",
+			Service:   NewPrivateMasternodeAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -452,6 +526,9 @@ func (s *Ethereum) StartMining(threads int) error {
 		}
 		th.SetThreads(threads)
 	}
+	if s.config.DevoteObserver {
+		return fmt.Errorf("mining %v", errObserverMode)
+	}
 	// If the miner was not running, initialize it
 	if !s.IsMining() {
 		// Propagate the initial price point to the transaction pool
@@ -475,6 +552,7 @@ func (s *Ethereum) StartMining(threads int) error {
 		// no need to verify
 		if devote, ok := s.engine.(*devote.Devote); ok {
 			devote.Authorize(witness, s.masternodeManager.SignHash)
+			devote.Delegate(s.masternodeManager.Delegation())
 		}
 		if clique, ok := s.engine.(*clique.Clique); ok {
 			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
@@ -511,6 +589,8 @@ func (s *Ethereum) StopMining() {
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
+func (s *Ethereum) MasternodeManager() *MasternodeManager { return s.masternodeManager }
+
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *core.TxPool               { return s.txPool }
@@ -551,6 +631,8 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	go s.startMasternode(srvr)
+	go s.purgeRPCCache()
+	s.forks.start()
 
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
@@ -563,17 +645,51 @@ func (s *Ethereum) startMasternode(srvr *p2p.Server) {
 	select {
 	case <-t.C:
 		s.masternodeManager.Start(srvr, s.EventMux())
+		if s.standby != nil {
+			s.standby.start()
+		}
+		if s.alerts != nil {
+			s.alerts.start()
+		}
 	}
 
 }
 
+// purgeRPCCache drops the cached RPC results on every new head, as most of them
+// are relative to the current head rather than to a fixed block.
+func (s *Ethereum) purgeRPCCache() {
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := s.blockchain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-heads:
+			s.rpcCache.Purge()
+		case <-sub.Err():
+			return
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
+	s.handover()
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.protocolManager.Stop()
+	s.masternodeManager.Stop()
+	if s.standby != nil {
+		s.standby.stop()
+	}
+	s.forks.stop()
+	if s.alerts != nil {
+		s.alerts.stop()
+	}
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
@@ -49,10 +49,17 @@ var DefaultConfig = Config{
 	TrieCleanCache: 256,
 	TrieDirtyCache: 256,
 	TrieTimeout:    60 * time.Minute,
+	StateHistory:   128,
 	MinerGasFloor:  8000000,
 	MinerGasCeil:   8000000,
 	MinerGasPrice:  big.NewInt(params.GWei),
 	MinerRecommit:  1 * time.Second,
+	MinerSystemGas: 1000000,
+	ForkAlertDepth: 3,
+	AlertsMinPeers: 3,
+
+	MasternodeRotation:       10 * time.Minute,
+	MasternodeRestakeReserve: 100,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -91,6 +98,10 @@ type Config struct {
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Whether to serve the devote proofs of any block to LES clients, instead of
+	// the ones of the last cycles only
+	LightDevoteArchive bool `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -98,6 +109,9 @@ type Config struct {
 	TrieCleanCache     int
 	TrieDirtyCache     int
 	TrieTimeout        time.Duration
+	StateHistory       uint64 // Number of recent block states kept when pruning (gcmode full)
+	ParallelExecution  int    // Number of threads executing independent transactions concurrently (0 = serial)
+	FreezerCycles      uint64 // Cycles after which masternode payments and devote snapshots are frozen (0 = disabled)
 
 	// Mining-related options
 	Etherbase      common.Address `toml:",omitempty"`
@@ -109,6 +123,35 @@ type Config struct {
 	MinerGasPrice  *big.Int
 	MinerRecommit  time.Duration
 	MinerNoverify  bool
+	MinerSystemGas uint64 // Block gas reserved for masternode system transactions
+	MinerWatchdog  bool   // Snapshot profiles into the datadir whenever the local witness misses its sealing deadline
+
+	// Devote options
+	DevoteSkipEmpty bool // Skip sealing empty blocks in the slots consensus allows to
+	DevoteObserver  bool // Validate and serve the chain without ever signing or acting as a masternode
+
+	// Fork monitor options
+	ForkWebhook    string `toml:",omitempty"` // Endpoint alerted of the forks longer than ForkAlertDepth
+	ForkAlertDepth uint64 // Blocks a side branch must exceed for its fork to be alerted of
+
+	// Alerting options
+	AlertsURL      string `toml:",omitempty"` // Operator endpoint the alerts are posted to as JSON (empty = disabled)
+	AlertsMinPeers int    // Peer count below which it's alerted of
+
+	// Masternode options
+	MasternodeDelegation []byte        `toml:",omitempty"` // Cold key delegation allowing the node key to seal blocks
+	MasternodeStandby    uint64        `toml:",omitempty"` // Missed slots of the primary host before a standby host takes over (0 = disabled)
+	MasternodeSentinel   bool          `toml:",omitempty"` // Expect health confirmations from an external sentinel instead of the built-in checker
+	MasternodeHost       string        `toml:",omitempty"` // DNS name the masternode is announced at instead of its IP address
+	MasternodeEndpoints  []string      `toml:",omitempty"` // Addresses forwarding to the masternode it rotates among, against targeted DoS
+	MasternodeRotation   time.Duration `toml:",omitempty"` // Interval between two rotations of the masternode endpoints
+	MasternodeBootstrap  string        `toml:",omitempty"` // DNS name of the signed bootstrap list of known-good masternodes
+	MasternodeGeoIP      string        `toml:",omitempty"` // ip2asn database breaking the masternode counts down by country and ASN
+
+	MasternodeRestake        common.Address   `toml:",omitempty"` // Payout account whose rewards are compounded into new masternodes
+	MasternodeRestakeTargets []string         `toml:",omitempty"` // Enodes of the node keys registered with the compounded rewards
+	MasternodeRestakeFunders []common.Address `toml:",omitempty"` // Fresh accounts registering the targets of the same index
+	MasternodeRestakeReserve uint64           `toml:",omitempty"` // Ether always left on the payout account when compounding
 
 	// Ethash options
 	Ethash ethash.Config
@@ -133,5 +176,6 @@ type Config struct {
 }
 
 type configMarshaling struct {
-	MinerExtraData hexutil.Bytes
+	MinerExtraData       hexutil.Bytes
+	MasternodeDelegation hexutil.Bytes
 }
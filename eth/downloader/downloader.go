@@ -27,6 +27,7 @@ import (
 
 	ethereum "github.com/etherzero/go-etherzero"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
@@ -111,7 +112,9 @@ type Downloader struct {
 	// Statistics
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
+	syncStatsChainTime   uint64 // Timestamp of the highest block known when syncing started
 	syncStatsState       stateSyncStats
+	syncStatsDevote      stateSyncStats // Devote protocol trie progress of the current sync
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	lightchain LightChain
@@ -247,27 +250,39 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 //
 // In addition, during the state download phase of fast synchronisation the number
 // of processed and the total number of known states are also returned. Otherwise
-// these are zero.
+// these are zero. The same goes for the devote protocol tries healed at the pivot.
+//
+// The devote cycles of the current and the latest known block are derived from
+// their timestamps.
 func (d *Downloader) Progress() ethereum.SyncProgress {
 	// Lock the current stats and return the progress
 	d.syncStatsLock.RLock()
 	defer d.syncStatsLock.RUnlock()
 
-	current := uint64(0)
+	var current *types.Header
 	switch d.mode {
 	case FullSync:
-		current = d.blockchain.CurrentBlock().NumberU64()
+		current = d.blockchain.CurrentBlock().Header()
 	case FastSync:
-		current = d.blockchain.CurrentFastBlock().NumberU64()
+		current = d.blockchain.CurrentFastBlock().Header()
 	case LightSync:
-		current = d.lightchain.CurrentHeader().Number.Uint64()
+		current = d.lightchain.CurrentHeader()
+	}
+	currentCycle := current.Time.Uint64() / params.Epoch
+	highestCycle := d.syncStatsChainTime / params.Epoch
+	if highestCycle < currentCycle {
+		highestCycle = currentCycle
 	}
 	return ethereum.SyncProgress{
-		StartingBlock: d.syncStatsChainOrigin,
-		CurrentBlock:  current,
-		HighestBlock:  d.syncStatsChainHeight,
-		PulledStates:  d.syncStatsState.processed,
-		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
+		StartingBlock:      d.syncStatsChainOrigin,
+		CurrentBlock:       current.Number.Uint64(),
+		HighestBlock:       d.syncStatsChainHeight,
+		PulledStates:       d.syncStatsState.processed,
+		KnownStates:        d.syncStatsState.processed + d.syncStatsState.pending,
+		CurrentCycle:       currentCycle,
+		HighestCycle:       highestCycle,
+		PulledDevoteStates: d.syncStatsDevote.processed,
+		KnownDevoteStates:  d.syncStatsDevote.processed + d.syncStatsDevote.pending,
 	}
 }
 
@@ -444,6 +459,8 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		d.syncStatsChainOrigin = origin
 	}
 	d.syncStatsChainHeight = height
+	d.syncStatsChainTime = latest.Time.Uint64()
+	d.syncStatsDevote = stateSyncStats{}
 	d.syncStatsLock.Unlock()
 
 	// Ensure our origin point is below any fast sync pivot point
@@ -1404,7 +1421,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						if n > 0 {
 							rollback = append(rollback, chunk[:n]...)
 						}
-						log.Debug("Invalid header encountered", "number", chunk[n].Number, "hash", chunk[n].Hash(), "err", err)
+						logInvalid("Invalid header encountered", chunk[n], err)
 						return errInvalidChain
 					}
 					// All verifications passed, store newly found uncertain headers
@@ -1489,7 +1506,7 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
 	}
 	if index, err := d.blockchain.InsertChain(blocks); err != nil {
-		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
+		logInvalid("Downloaded item processing failed", results[index].Header, err)
 		return errInvalidChain
 	}
 	return nil
@@ -1663,7 +1680,7 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 		receipts[i] = result.Receipts
 	}
 	if index, err := d.blockchain.InsertReceiptChain(blocks, receipts); err != nil {
-		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
+		logInvalid("Downloaded item processing failed", results[index].Header, err)
 		return errInvalidChain
 	}
 	return nil
@@ -1801,3 +1818,14 @@ func (d *Downloader) requestTTL() time.Duration {
 	}
 	return ttl
 }
+
+// logInvalid reports a header failing the import, which gets the delivering peer
+// dropped. Devote consensus violations are raised to warnings along with their
+// reason, anything else is logged at debug level.
+func logInvalid(msg string, header *types.Header, err error) {
+	if reason := devote.ErrorReason(err); reason != "" {
+		log.Warn(msg, "number", header.Number, "hash", header.Hash(), "witness", header.Witness, "reason", reason, "err", err)
+		return
+	}
+	log.Debug(msg, "number", header.Number, "hash", header.Hash(), "err", err)
+}
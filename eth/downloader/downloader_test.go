@@ -31,6 +31,7 @@ import (
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/trie"
 )
 
@@ -1166,11 +1167,52 @@ func checkProgress(t *testing.T, d *Downloader, stage string, want ethereum.Sync
 	p := d.Progress()
 	p.KnownStates, p.PulledStates = 0, 0
 	want.KnownStates, want.PulledStates = 0, 0
+	p.CurrentCycle, p.HighestCycle = 0, 0
+	want.CurrentCycle, want.HighestCycle = 0, 0
+	p.KnownDevoteStates, p.PulledDevoteStates = 0, 0
+	want.KnownDevoteStates, want.PulledDevoteStates = 0, 0
 	if p != want {
 		t.Fatalf("%s progress mismatch:\nhave %+v\nwant %+v", stage, p, want)
 	}
 }
 
+// Tests that the devote cycles reported in the progress follow the timestamps
+// of the current and the highest known block.
+func TestSyncProgressCycles(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+	chain := testChainBase.shorten(blockCacheItems - 15)
+	head := chain.headBlock().Time().Uint64() / params.Epoch
+
+	starting := make(chan struct{})
+	progress := make(chan struct{})
+	tester.downloader.syncInitHook = func(origin, latest uint64) {
+		starting <- struct{}{}
+		<-progress
+	}
+	tester.newPeer("peer", 63, chain)
+	pending := new(sync.WaitGroup)
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		if err := tester.sync("peer", nil, FullSync); err != nil {
+			panic(fmt.Sprintf("failed to synchronise blocks: %v", err))
+		}
+	}()
+	<-starting
+	if p := tester.downloader.Progress(); p.CurrentCycle != chain.genesis.Time().Uint64()/params.Epoch || p.HighestCycle != head {
+		t.Errorf("initial cycles mismatch: have %d/%d, want %d/%d", p.CurrentCycle, p.HighestCycle, chain.genesis.Time().Uint64()/params.Epoch, head)
+	}
+	progress <- struct{}{}
+	pending.Wait()
+
+	if p := tester.downloader.Progress(); p.CurrentCycle != head || p.HighestCycle != head {
+		t.Errorf("final cycles mismatch: have %d/%d, want %d/%d", p.CurrentCycle, p.HighestCycle, head, head)
+	}
+}
+
 // Tests that synchronisation progress (origin block number and highest block
 // number) is tracked and updated correctly in case of a fork (or manual head
 // revertal).
@@ -223,7 +223,8 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d      *Downloader // Downloader instance to access and manage current peerset
+	devote bool        // Whether the trie synced is devote protocol state
 
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
@@ -262,6 +263,7 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 func newDevoteSync(d *Downloader, root common.Hash) *stateSync {
 	return &stateSync{
 		d:       d,
+		devote:  true,
 		sched:   trie.NewSync(root, d.stateDB, nil),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -493,15 +495,23 @@ func (s *stateSync) updateStats(written, duplicate, unexpected int, duration tim
 	s.d.syncStatsLock.Lock()
 	defer s.d.syncStatsLock.Unlock()
 
-	s.d.syncStatsState.pending = uint64(s.sched.Pending())
-	s.d.syncStatsState.processed += uint64(written)
-	s.d.syncStatsState.duplicate += uint64(duplicate)
-	s.d.syncStatsState.unexpected += uint64(unexpected)
+	stats := &s.d.syncStatsState
+	if s.devote {
+		stats = &s.d.syncStatsDevote
+	}
+	stats.pending = uint64(s.sched.Pending())
+	stats.processed += uint64(written)
+	stats.duplicate += uint64(duplicate)
+	stats.unexpected += uint64(unexpected)
 
 	if written > 0 || duplicate > 0 || unexpected > 0 {
-		log.Info("Imported new state entries", "count", written, "elapsed", common.PrettyDuration(duration), "processed", s.d.syncStatsState.processed, "pending", s.d.syncStatsState.pending, "retry", len(s.tasks), "duplicate", s.d.syncStatsState.duplicate, "unexpected", s.d.syncStatsState.unexpected)
+		msg := "Imported new state entries"
+		if s.devote {
+			msg = "Imported new devote entries"
+		}
+		log.Info(msg, "count", written, "elapsed", common.PrettyDuration(duration), "processed", stats.processed, "pending", stats.pending, "retry", len(s.tasks), "duplicate", stats.duplicate, "unexpected", stats.unexpected)
 	}
-	if written > 0 {
-		rawdb.WriteFastTrieProgress(s.d.stateDB, s.d.syncStatsState.processed)
+	if written > 0 && !s.devote {
+		rawdb.WriteFastTrieProgress(s.d.stateDB, stats.processed)
 	}
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/prque"
 	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/log"
 )
@@ -661,7 +662,11 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 
 		default:
 			// Something went very wrong, drop the peer
-			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
+			if reason := devote.ErrorReason(err); reason != "" {
+				log.Warn("Dropping peer for invalid block", "peer", peer, "number", block.Number(), "hash", hash, "witness", block.Header().Witness, "reason", reason, "err", err)
+			} else {
+				log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
+			}
 			f.dropPeer(peer)
 			return
 		}
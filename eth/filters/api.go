@@ -28,6 +28,7 @@ import (
 	ethereum "github.com/etherzero/go-etherzero"
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/types"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
@@ -47,6 +48,8 @@ type filter struct {
 	crit     FilterCriteria
 	logs     []*types.Log
 	s        *Subscription // associated subscription in event system
+
+	masternode bool // whether the logs are returned as decoded masternode events
 }
 
 // PublicFilterAPI offers support to create and manage filters. This will allow external clients to retrieve various
@@ -233,6 +236,37 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// Reorg sends a notification each time the canonical chain is reorganised, with
+// the dropped and added segments along with the witnesses which sealed them.
+func (api *PublicFilterAPI) Reorg(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ReorgEvent)
+		reorgsSub := api.events.SubscribeReorgs(reorgs)
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, newReorg(ev))
+			case <-rpcSub.Err():
+				reorgsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -320,6 +354,36 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 	return logsSub.ID, nil
 }
 
+// NewMasternodeFilter creates a filter that returns the join, quit and ping
+// events of the masternode contract decoded into typed events, so clients can
+// follow the masternode lifecycle without the contract ABI. The addresses and
+// topics of the criteria are ignored, only its block range is used.
+//
+// The events are polled with eth_getFilterChanges.
+func (api *PublicFilterAPI) NewMasternodeFilter(crit FilterCriteria) (rpc.ID, error) {
+	id, err := api.NewFilter(masternodeCriteria(api.backend.ChainConfig(), crit))
+	if err != nil {
+		return id, err
+	}
+	api.filtersMu.Lock()
+	if f, found := api.filters[id]; found {
+		f.masternode = true
+	}
+	api.filtersMu.Unlock()
+
+	return id, nil
+}
+
+// GetMasternodeEvents returns the decoded masternode contract events within the
+// block range of the given criteria, ignoring its addresses and topics.
+func (api *PublicFilterAPI) GetMasternodeEvents(ctx context.Context, crit FilterCriteria) ([]*MasternodeEvent, error) {
+	logs, err := api.GetLogs(ctx, masternodeCriteria(api.backend.ChainConfig(), crit))
+	if err != nil {
+		return nil, err
+	}
+	return returnMasternodeEvents(logs), nil
+}
+
 // GetLogs returns logs matching the given argument that are stored within the state.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
@@ -408,7 +472,7 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 // last time it was called. This can be used for polling.
 //
 // For pending transaction and block filters the result is []common.Hash.
-// (pending)Log filters return []Log.
+// (pending)Log filters return []Log and masternode filters []MasternodeEvent.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getfilterchanges
 func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
@@ -431,6 +495,9 @@ func (api *PublicFilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 		case LogsSubscription:
 			logs := f.logs
 			f.logs = nil
+			if f.masternode {
+				return returnMasternodeEvents(logs), nil
+			}
 			return returnLogs(logs), nil
 		}
 	}
@@ -53,6 +53,8 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// ReorgSubscription queries the chain segments swapped by reorgs
+	ReorgSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -68,6 +70,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// reorgChanSize is the size of channel listening to ReorgEvent.
+	reorgChanSize = 10
 )
 
 var (
@@ -82,6 +86,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	hashes    chan []common.Hash
 	headers   chan *types.Header
+	reorgs    chan core.ReorgEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -99,6 +104,7 @@ type EventSystem struct {
 	logsSub       event.Subscription         // Subscription for new log event
 	rmLogsSub     event.Subscription         // Subscription for removed log event
 	chainSub      event.Subscription         // Subscription for new chain event
+	reorgSub      event.Subscription         // Subscription for chain reorg event
 	pendingLogSub *event.TypeMuxSubscription // Subscription for pending log event
 
 	// Channels
@@ -108,6 +114,7 @@ type EventSystem struct {
 	logsCh    chan []*types.Log          // Channel to receive new log event
 	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
 	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	reorgCh   chan core.ReorgEvent       // Channel to receive chain reorg event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -127,6 +134,7 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 		logsCh:    make(chan []*types.Log, logsChanSize),
 		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+		reorgCh:   make(chan core.ReorgEvent, reorgChanSize),
 	}
 
 	// Subscribe events
@@ -134,12 +142,13 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.reorgSub = m.backend.SubscribeReorgEvent(m.reorgCh)
 	// TODO(rjl493456442): use feed to subscribe pending log event
 	m.pendingLogSub = m.mux.Subscribe(core.PendingLogsEvent{})
 
 	// Make sure none of the subscriptions are empty
 	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil ||
-		m.pendingLogSub.Closed() {
+		m.reorgSub == nil || m.pendingLogSub.Closed() {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -175,6 +184,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.reorgs:
 			}
 		}
 
@@ -242,6 +252,7 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		reorgs:    make(chan core.ReorgEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -259,6 +270,7 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		reorgs:    make(chan core.ReorgEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -276,6 +288,7 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 		logs:      logs,
 		hashes:    make(chan []common.Hash),
 		headers:   make(chan *types.Header),
+		reorgs:    make(chan core.ReorgEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -292,6 +305,24 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		hashes:    make(chan []common.Hash),
 		headers:   headers,
+		reorgs:    make(chan core.ReorgEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeReorgs creates a subscription that writes the old and new segments of
+// the chain whenever it is reorganised.
+func (es *EventSystem) SubscribeReorgs(reorgs chan core.ReorgEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       ReorgSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		reorgs:    reorgs,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -308,6 +339,7 @@ func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscript
 		logs:      make(chan []*types.Log),
 		hashes:    hashes,
 		headers:   make(chan *types.Header),
+		reorgs:    make(chan core.ReorgEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -355,6 +387,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		for _, f := range filters[PendingTransactionsSubscription] {
 			f.hashes <- hashes
 		}
+	case core.ReorgEvent:
+		for _, f := range filters[ReorgSubscription] {
+			f.reorgs <- e
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			f.headers <- e.Block.Header()
@@ -453,6 +489,7 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.reorgSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -471,6 +508,8 @@ func (es *EventSystem) eventLoop() {
 			es.broadcast(index, ev)
 		case ev := <-es.chainCh:
 			es.broadcast(index, ev)
+		case ev := <-es.reorgCh:
+			es.broadcast(index, ev)
 		case ev, active := <-es.pendingLogSub.Chan():
 			if !active { // system stopped
 				return
@@ -506,6 +545,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.reorgSub.Err():
+			return
 		}
 	}
 }
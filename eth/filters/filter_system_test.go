@@ -46,12 +46,17 @@ type testBackend struct {
 	rmLogsFeed *event.Feed
 	logsFeed   *event.Feed
 	chainFeed  *event.Feed
+	reorgFeed  *event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
 	return b.db
 }
 
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
 func (b *testBackend) EventMux() *event.TypeMux {
 	return b.mux
 }
@@ -116,6 +121,10 @@ func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return b.logsFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+
 func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.chainFeed.Subscribe(ch)
 }
@@ -166,7 +175,7 @@ func TestBlockSubscription(t *testing.T) {
 		rmLogsFeed  = new(event.Feed)
 		logsFeed    = new(event.Feed)
 		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api         = NewPublicFilterAPI(backend, false)
 		genesis     = new(core.Genesis).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
@@ -212,6 +221,54 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestReorgSubscription tests if reorg subscriptions receive the swapped chain
+// segments, oldest first, along with the witnesses which sealed the new one.
+func TestReorgSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux       = new(event.TypeMux)
+		db        = ethdb.NewMemDatabase()
+		reorgFeed = new(event.Feed)
+		backend   = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), reorgFeed}
+		api       = NewPublicFilterAPI(backend, false)
+		header    = func(number int64, witness string) *types.Header {
+			return &types.Header{Number: big.NewInt(number), Witness: witness}
+		}
+		ev = core.ReorgEvent{
+			Common:   header(10, "a"),
+			OldChain: []*types.Header{header(12, "b"), header(11, "a")},
+			NewChain: []*types.Header{header(13, "c"), header(12, "d"), header(11, "c")},
+		}
+	)
+	reorgs := make(chan core.ReorgEvent)
+	sub := api.events.SubscribeReorgs(reorgs)
+	defer sub.Unsubscribe()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		reorgFeed.Send(ev)
+	}()
+	select {
+	case have := <-reorgs:
+		reorg := newReorg(have)
+		if reorg.Common.Number != 10 || reorg.Depth != 2 {
+			t.Errorf("common/depth mismatch: have %d/%d, want 10/2", reorg.Common.Number, reorg.Depth)
+		}
+		if len(reorg.Old) != 2 || reorg.Old[0].Number != 11 || reorg.Old[1].Witness != "b" {
+			t.Errorf("old segment mismatch: %+v", reorg.Old)
+		}
+		if len(reorg.New) != 3 || reorg.New[0].Number != 11 || reorg.New[2].Number != 13 {
+			t.Errorf("new segment mismatch: %+v", reorg.New)
+		}
+		if want := []string{"c", "d"}; !reflect.DeepEqual(reorg.Witnesses, want) {
+			t.Errorf("witnesses mismatch: have %v, want %v", reorg.Witnesses, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg not received")
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -223,7 +280,7 @@ func TestPendingTxFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		transactions = []*types.Transaction{
@@ -283,7 +340,7 @@ func TestLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		testCases = []struct {
@@ -332,7 +389,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 	)
 
@@ -359,7 +416,7 @@ func TestInvalidGetLogsRequest(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 		blockHash  = common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
 	)
@@ -389,7 +446,7 @@ func TestLogFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -508,7 +565,7 @@ func TestPendingLogsSubscription(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -0,0 +1,129 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etherzero/go-etherzero/accounts/abi"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// masternodeABI is the parsed ABI of the masternode contract, whose lifecycle
+// events are decoded by the masternode filters.
+var masternodeABI, _ = abi.JSON(strings.NewReader(contract.ContractABI))
+
+// masternodeEvents are the lifecycle events of the masternode contract followed
+// by the masternode filters.
+var masternodeEvents = []string{"join", "quit", "ping"}
+
+// MasternodeEvent is a decoded lifecycle event of the masternode contract.
+type MasternodeEvent struct {
+	Type           string          `json:"type"`                     // Event name: "join", "quit" or "ping"
+	ID             string          `json:"id"`                       // Masternode the event is about
+	Account        *common.Address `json:"account,omitempty"`        // Account of the masternode, set by join and quit
+	BlockOnlineAcc *hexutil.Big    `json:"blockOnlineAcc,omitempty"` // Accumulated online blocks, set by ping
+	BlockLastPing  *hexutil.Big    `json:"blockLastPing,omitempty"`  // Block of the previous ping, set by ping
+
+	Contract    common.Address `json:"contract"` // Version of the masternode contract emitting the event
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"` // Whether the event was reverted by a reorg
+}
+
+// masternodeCriteria narrows crit down to the lifecycle events of all the
+// versions of the masternode contract, ignoring the addresses and topics it may
+// hold already.
+func masternodeCriteria(config *params.ChainConfig, crit FilterCriteria) FilterCriteria {
+	var devote *params.DevoteConfig
+	if config != nil {
+		devote = config.Devote
+	}
+	crit.Addresses = devote.MasternodeContractAddresses()
+	topics := make([]common.Hash, 0, len(masternodeEvents))
+	for _, name := range masternodeEvents {
+		topics = append(topics, masternodeABI.Events[name].Id())
+	}
+	crit.Topics = [][]common.Hash{topics}
+	return crit
+}
+
+// decodeMasternodeLog decodes a lifecycle event of the masternode contract.
+func decodeMasternodeLog(l *types.Log) (*MasternodeEvent, error) {
+	if len(l.Topics) == 0 {
+		return nil, fmt.Errorf("anonymous log")
+	}
+	event := &MasternodeEvent{
+		Contract:    l.Address,
+		BlockNumber: hexutil.Uint64(l.BlockNumber),
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TxHash,
+		LogIndex:    hexutil.Uint(l.Index),
+		Removed:     l.Removed,
+	}
+	switch l.Topics[0] {
+	case masternodeABI.Events["join"].Id(), masternodeABI.Events["quit"].Id():
+		var membership contract.ContractJoin
+
+		event.Type = "join"
+		if l.Topics[0] == masternodeABI.Events["quit"].Id() {
+			event.Type = "quit"
+		}
+		if err := masternodeABI.Unpack(&membership, event.Type, l.Data); err != nil {
+			return nil, err
+		}
+		event.ID = fmt.Sprintf("%x", membership.Id)
+		event.Account = &membership.Addr
+
+	case masternodeABI.Events["ping"].Id():
+		var ping contract.ContractPing
+		if err := masternodeABI.Unpack(&ping, "ping", l.Data); err != nil {
+			return nil, err
+		}
+		event.Type = "ping"
+		event.ID = fmt.Sprintf("%x", ping.Id)
+		event.BlockOnlineAcc = (*hexutil.Big)(ping.BlockOnlineAcc)
+		event.BlockLastPing = (*hexutil.Big)(ping.BlockLastPing)
+
+	default:
+		return nil, fmt.Errorf("unknown event %x", l.Topics[0])
+	}
+	return event, nil
+}
+
+// returnMasternodeEvents decodes the masternode contract logs, skipping the
+// malformed ones.
+func returnMasternodeEvents(logs []*types.Log) []*MasternodeEvent {
+	events := make([]*MasternodeEvent, 0, len(logs))
+	for _, l := range logs {
+		event, err := decodeMasternodeLog(l)
+		if err != nil {
+			log.Debug("Skipping malformed masternode event", "block", l.BlockNumber, "tx", l.TxHash, "err", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
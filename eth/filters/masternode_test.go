@@ -0,0 +1,100 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the lifecycle events of the masternode contract are decoded into
+// typed events, and that the other logs are skipped.
+func TestMasternodeEvents(t *testing.T) {
+	var (
+		id      = [8]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+		account = common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	)
+	pack := func(name string, args ...interface{}) *types.Log {
+		data, err := masternodeABI.Events[name].Inputs.Pack(args...)
+		if err != nil {
+			t.Fatalf("failed to pack %s event: %v", name, err)
+		}
+		return &types.Log{
+			Address:     params.MasterndeContractAddress,
+			Topics:      []common.Hash{masternodeABI.Events[name].Id()},
+			Data:        data,
+			BlockNumber: 42,
+		}
+	}
+	logs := []*types.Log{
+		pack("join", id, account),
+		pack("ping", id, big.NewInt(100), big.NewInt(40)),
+		pack("newVote", account, account),
+		pack("quit", id, account),
+		{Address: params.MasterndeContractAddress},
+	}
+	events := returnMasternodeEvents(logs)
+	if len(events) != 3 {
+		t.Fatalf("event count mismatch: have %d, want 3", len(events))
+	}
+	for i, typ := range []string{"join", "ping", "quit"} {
+		if events[i].Type != typ {
+			t.Errorf("event %d: type mismatch: have %s, want %s", i, events[i].Type, typ)
+		}
+		if events[i].ID != "0102030405060708" {
+			t.Errorf("event %d: id mismatch: have %s, want 0102030405060708", i, events[i].ID)
+		}
+		if uint64(events[i].BlockNumber) != 42 {
+			t.Errorf("event %d: block mismatch: have %d, want 42", i, events[i].BlockNumber)
+		}
+	}
+	if events[0].Account == nil || *events[0].Account != account {
+		t.Errorf("join account mismatch: have %v, want %x", events[0].Account, account)
+	}
+	if events[1].BlockOnlineAcc.ToInt().Uint64() != 100 || events[1].BlockLastPing.ToInt().Uint64() != 40 {
+		t.Errorf("ping blocks mismatch: have %v/%v, want 100/40", events[1].BlockOnlineAcc, events[1].BlockLastPing)
+	}
+	if events[1].Account != nil {
+		t.Errorf("ping account set: %x", *events[1].Account)
+	}
+}
+
+// Tests that the masternode criteria follow every version of the contract and
+// keep the requested block range.
+func TestMasternodeCriteria(t *testing.T) {
+	upgrade := common.HexToAddress("0x000000000000000000000000000000000000000c")
+	config := &params.ChainConfig{Devote: &params.DevoteConfig{
+		MasternodeContracts: []params.MasternodeContract{{Block: big.NewInt(10), Address: upgrade, Version: 2}},
+	}}
+	crit := masternodeCriteria(config, FilterCriteria{
+		FromBlock: big.NewInt(5),
+		Addresses: []common.Address{common.HexToAddress("0x01")},
+	})
+	if len(crit.Addresses) != 2 || crit.Addresses[0] != params.MasterndeContractAddress || crit.Addresses[1] != upgrade {
+		t.Errorf("addresses mismatch: have %x", crit.Addresses)
+	}
+	if len(crit.Topics) != 1 || len(crit.Topics[0]) != len(masternodeEvents) {
+		t.Errorf("topics mismatch: have %x", crit.Topics)
+	}
+	if crit.FromBlock == nil || crit.FromBlock.Int64() != 5 {
+		t.Errorf("block range lost: have %v", crit.FromBlock)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+)
+
+// ReorgBlock is a block swapped in or out of the canonical chain by a reorg.
+type ReorgBlock struct {
+	Number  hexutil.Uint64 `json:"number"`
+	Hash    common.Hash    `json:"hash"`
+	Witness string         `json:"witness"` // Masternode which sealed the block
+}
+
+// Reorg is a reorganisation of the canonical chain, as notified to the reorg
+// subscribers. The segments are ordered by ascending block number.
+type Reorg struct {
+	Common    ReorgBlock   `json:"common"` // Last block shared by both segments
+	Depth     hexutil.Uint `json:"depth"`  // Number of canonical blocks dropped
+	Old       []ReorgBlock `json:"old"`
+	New       []ReorgBlock `json:"new"`
+	Witnesses []string     `json:"witnesses"` // Witnesses which sealed the new segment, deduplicated
+}
+
+// newReorgBlock converts a header into its reorg notification entry.
+func newReorgBlock(header *types.Header) ReorgBlock {
+	return ReorgBlock{
+		Number:  hexutil.Uint64(header.Number.Uint64()),
+		Hash:    header.Hash(),
+		Witness: header.Witness,
+	}
+}
+
+// newReorg converts a chain reorg event into its notification, reversing the
+// segments the chain posts newest first.
+func newReorg(ev core.ReorgEvent) *Reorg {
+	reorg := &Reorg{
+		Common:    newReorgBlock(ev.Common),
+		Depth:     hexutil.Uint(len(ev.OldChain)),
+		Old:       make([]ReorgBlock, 0, len(ev.OldChain)),
+		New:       make([]ReorgBlock, 0, len(ev.NewChain)),
+		Witnesses: []string{},
+	}
+	for i := len(ev.OldChain) - 1; i >= 0; i-- {
+		reorg.Old = append(reorg.Old, newReorgBlock(ev.OldChain[i]))
+	}
+	seen := make(map[string]bool)
+	for i := len(ev.NewChain) - 1; i >= 0; i-- {
+		block := newReorgBlock(ev.NewChain[i])
+		reorg.New = append(reorg.New, block)
+
+		if !seen[block.Witness] {
+			seen[block.Witness] = true
+			reorg.Witnesses = append(reorg.Witnesses, block.Witness)
+		}
+	}
+	return reorg
+}
@@ -0,0 +1,225 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+const (
+	// maxForks is the number of most recent forks tracked by the monitor.
+	maxForks = 64
+
+	// maxForkWalk is the number of side blocks walked back looking for the point
+	// a side block forks off the canonical chain.
+	maxForkWalk = 1024
+
+	// forkWebhookTimeout is the timeout of the fork alerts posted to the webhook.
+	forkWebhookTimeout = 5 * time.Second
+)
+
+// Fork is a competing branch of the canonical chain observed by the node, with
+// the witnesses which sealed it.
+type Fork struct {
+	Number    hexutil.Uint64            `json:"number"`    // Canonical block the branch forks off
+	Hash      common.Hash               `json:"hash"`      // Canonical block the branch forks off
+	Length    hexutil.Uint64            `json:"length"`    // Longest side branch past the fork point
+	Blocks    hexutil.Uint64            `json:"blocks"`    // Side blocks observed in total
+	Witnesses map[string]hexutil.Uint64 `json:"witnesses"` // Side blocks sealed by each witness
+	FirstSeen time.Time                 `json:"firstSeen"`
+	LastSeen  time.Time                 `json:"lastSeen"`
+
+	hashes  map[common.Hash]struct{} // Side blocks already accounted for
+	alerted bool                     // Whether the webhook was alerted of the fork
+}
+
+// forkMonitor tracks the side chains imported by the blockchain and attributes
+// them to the witnesses sealing them, alerting a webhook of the deep ones.
+type forkMonitor struct {
+	chain   *core.BlockChain
+	webhook string // Endpoint alerted of the deep forks, empty if disabled
+	depth   uint64 // Length past which a fork is alerted of
+	client  *http.Client
+
+	forks map[common.Hash]*Fork // Forks tracked, keyed by fork point
+	lock  sync.RWMutex
+
+	quit chan struct{}
+}
+
+func newForkMonitor(chain *core.BlockChain, webhook string, depth uint64) *forkMonitor {
+	return &forkMonitor{
+		chain:   chain,
+		webhook: webhook,
+		depth:   depth,
+		client:  &http.Client{Timeout: forkWebhookTimeout},
+		forks:   make(map[common.Hash]*Fork),
+		quit:    make(chan struct{}),
+	}
+}
+
+func (m *forkMonitor) start() {
+	go m.loop()
+}
+
+func (m *forkMonitor) stop() {
+	close(m.quit)
+}
+
+func (m *forkMonitor) loop() {
+	sides := make(chan core.ChainSideEvent, 64)
+	sub := m.chain.SubscribeChainSideEvent(sides)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-sides:
+			if fork := m.track(ev.Block.Header()); fork != nil {
+				go m.alert(fork)
+			}
+		case <-sub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// track accounts a side block to the fork it belongs to, returning a copy of the
+// fork if it just grew past the alerting depth.
+func (m *forkMonitor) track(header *types.Header) *Fork {
+	point := m.forkPoint(header)
+	if point == nil {
+		log.Debug("Side block fork point unknown", "number", header.Number, "hash", header.Hash())
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	fork, ok := m.forks[point.Hash()]
+	if !ok {
+		fork = &Fork{
+			Number:    hexutil.Uint64(point.Number.Uint64()),
+			Hash:      point.Hash(),
+			Witnesses: make(map[string]hexutil.Uint64),
+			FirstSeen: now,
+			hashes:    make(map[common.Hash]struct{}),
+		}
+		m.forks[fork.Hash] = fork
+		m.prune()
+	}
+	if _, ok := fork.hashes[header.Hash()]; ok {
+		return nil
+	}
+	fork.hashes[header.Hash()] = struct{}{}
+	fork.Blocks++
+	fork.Witnesses[header.Witness]++
+	fork.LastSeen = now
+	if length := header.Number.Uint64() - point.Number.Uint64(); length > uint64(fork.Length) {
+		fork.Length = hexutil.Uint64(length)
+	}
+	log.Debug("Side block observed", "number", header.Number, "hash", header.Hash(), "witness", header.Witness,
+		"forkpoint", point.Number, "length", fork.Length)
+
+	if m.webhook == "" || fork.alerted || uint64(fork.Length) <= m.depth {
+		return nil
+	}
+	fork.alerted = true
+	return fork.copy()
+}
+
+// forkPoint walks a side block back to the canonical block it forks off.
+func (m *forkMonitor) forkPoint(header *types.Header) *types.Header {
+	for i := 0; i < maxForkWalk && header.Number.Sign() > 0; i++ {
+		header = m.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if header == nil {
+			return nil
+		}
+		if canonical := m.chain.GetHeaderByNumber(header.Number.Uint64()); canonical != nil && canonical.Hash() == header.Hash() {
+			return header
+		}
+	}
+	return nil
+}
+
+// prune drops the oldest forks beyond the tracked ones.
+func (m *forkMonitor) prune() {
+	for len(m.forks) > maxForks {
+		var oldest *Fork
+		for _, fork := range m.forks {
+			if oldest == nil || fork.LastSeen.Before(oldest.LastSeen) {
+				oldest = fork
+			}
+		}
+		delete(m.forks, oldest.Hash)
+	}
+}
+
+// alert posts a deep fork to the webhook.
+func (m *forkMonitor) alert(fork *Fork) {
+	log.Warn("Deep fork observed", "number", fork.Number, "hash", fork.Hash, "length", fork.Length, "witnesses", len(fork.Witnesses))
+
+	blob, err := json.Marshal(fork)
+	if err != nil {
+		return
+	}
+	res, err := m.client.Post(m.webhook, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		log.Warn("Failed to alert fork webhook", "err", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		log.Warn("Fork webhook rejected alert", "status", res.Status)
+	}
+}
+
+// Forks returns the tracked forks, the most recent first.
+func (m *forkMonitor) Forks() []*Fork {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	forks := make([]*Fork, 0, len(m.forks))
+	for _, fork := range m.forks {
+		forks = append(forks, fork.copy())
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i].LastSeen.After(forks[j].LastSeen) })
+	return forks
+}
+
+// copy returns a copy of the fork safe to hand out of the monitor lock.
+func (f *Fork) copy() *Fork {
+	cpy := *f
+	cpy.Witnesses = make(map[string]hexutil.Uint64, len(f.Witnesses))
+	for witness, blocks := range f.Witnesses {
+		cpy.Witnesses[witness] = blocks
+	}
+	cpy.hashes = nil
+	return &cpy
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/ethash"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that side blocks are grouped by the canonical block they fork off and
+// attributed to their witnesses, and that deep forks are posted to the webhook.
+func TestForkMonitor(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		config  = &params.ChainConfig{}
+		gspec   = &core.Genesis{Config: config}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, err := core.NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create new blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	canonical, _ := core.GenerateChain(config, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
+	if _, err := blockchain.InsertChain(canonical); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	side, _ := core.GenerateChain(config, canonical[4], ethash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{1})
+	})
+	if _, err := blockchain.InsertChain(side); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+
+	alerts := make(chan *Fork, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fork := new(Fork)
+		if err := json.NewDecoder(r.Body).Decode(fork); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		alerts <- fork
+	}))
+	defer webhook.Close()
+
+	monitor := newForkMonitor(blockchain, webhook.URL, 3)
+	witnesses := []string{"a", "b", "a", "c"}
+	headers := make([]*types.Header, len(side))
+	for i, block := range side {
+		header := types.CopyHeader(block.Header())
+		header.Witness = witnesses[i]
+		headers[i] = header
+
+		if fork := monitor.track(header); fork != nil {
+			if i != 3 {
+				t.Errorf("fork alerted at depth %d", i+1)
+			}
+			go monitor.alert(fork)
+		}
+	}
+	// Tracking the same block twice must not count it again
+	monitor.track(headers[0])
+
+	forks := monitor.Forks()
+	if len(forks) != 1 {
+		t.Fatalf("fork count mismatch: have %d, want 1", len(forks))
+	}
+	fork := forks[0]
+	if fork.Hash != canonical[4].Hash() || fork.Length != 4 || fork.Blocks != 4 {
+		t.Errorf("fork mismatch: have %x/%d/%d, want %x/4/4", fork.Hash, fork.Length, fork.Blocks, canonical[4].Hash())
+	}
+	if fork.Witnesses["a"] != 2 || fork.Witnesses["b"] != 1 || fork.Witnesses["c"] != 1 {
+		t.Errorf("witnesses mismatch: %v", fork.Witnesses)
+	}
+	select {
+	case alert := <-alerts:
+		if alert.Hash != fork.Hash || alert.Length != 4 {
+			t.Errorf("alert mismatch: have %x/%d, want %x/4", alert.Hash, alert.Length, fork.Hash)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("deep fork not alerted")
+	}
+}
@@ -19,33 +19,57 @@ var _ = (*configMarshaling)(nil)
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		NoPruning               bool
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
-		DatabaseCache           int
-		TrieCleanCache          int
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		Etherbase               common.Address `toml:",omitempty"`
-		MinerNotify             []string       `toml:",omitempty"`
-		MinerExtraData          hexutil.Bytes  `toml:",omitempty"`
-		MinerGasFloor           uint64
-		MinerGasCeil            uint64
-		MinerGasPrice           *big.Int
-		MinerRecommit           time.Duration
-		MinerNoverify           bool
-		Ethash                  ethash.Config
-		TxPool                  core.TxPoolConfig
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		EWASMInterpreter        string
-		EVMInterpreter          string
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                uint64
+		SyncMode                 downloader.SyncMode
+		NoPruning                bool
+		LightServ                int  `toml:",omitempty"`
+		LightPeers               int  `toml:",omitempty"`
+		LightDevoteArchive       bool `toml:",omitempty"`
+		SkipBcVersionCheck       bool `toml:"-"`
+		DatabaseHandles          int  `toml:"-"`
+		DatabaseCache            int
+		TrieCleanCache           int
+		TrieDirtyCache           int
+		TrieTimeout              time.Duration
+		StateHistory             uint64
+		ParallelExecution        int
+		FreezerCycles            uint64
+		Etherbase                common.Address `toml:",omitempty"`
+		MinerNotify              []string       `toml:",omitempty"`
+		MinerExtraData           hexutil.Bytes  `toml:",omitempty"`
+		MinerGasFloor            uint64
+		MinerGasCeil             uint64
+		MinerGasPrice            *big.Int
+		MinerRecommit            time.Duration
+		MinerNoverify            bool
+		MinerSystemGas           uint64
+		MinerWatchdog            bool
+		DevoteSkipEmpty          bool
+		DevoteObserver           bool
+		ForkWebhook              string `toml:",omitempty"`
+		ForkAlertDepth           uint64
+		AlertsURL                string `toml:",omitempty"`
+		AlertsMinPeers           int
+		MasternodeDelegation     hexutil.Bytes    `toml:",omitempty"`
+		MasternodeStandby        uint64           `toml:",omitempty"`
+		MasternodeSentinel       bool             `toml:",omitempty"`
+		MasternodeHost           string           `toml:",omitempty"`
+		MasternodeEndpoints      []string         `toml:",omitempty"`
+		MasternodeRotation       time.Duration    `toml:",omitempty"`
+		MasternodeBootstrap      string           `toml:",omitempty"`
+		MasternodeGeoIP          string           `toml:",omitempty"`
+		MasternodeRestake        common.Address   `toml:",omitempty"`
+		MasternodeRestakeTargets []string         `toml:",omitempty"`
+		MasternodeRestakeFunders []common.Address `toml:",omitempty"`
+		MasternodeRestakeReserve uint64           `toml:",omitempty"`
+		Ethash                   ethash.Config
+		TxPool                   core.TxPoolConfig
+		GPO                      gasprice.Config
+		EnablePreimageRecording  bool
+		DocRoot                  string `toml:"-"`
+		EWASMInterpreter         string
+		EVMInterpreter           string
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -54,12 +78,16 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.LightDevoteArchive = c.LightDevoteArchive
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
+	enc.StateHistory = c.StateHistory
+	enc.ParallelExecution = c.ParallelExecution
+	enc.FreezerCycles = c.FreezerCycles
 	enc.Etherbase = c.Etherbase
 	enc.MinerNotify = c.MinerNotify
 	enc.MinerExtraData = c.MinerExtraData
@@ -68,6 +96,26 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerGasPrice = c.MinerGasPrice
 	enc.MinerRecommit = c.MinerRecommit
 	enc.MinerNoverify = c.MinerNoverify
+	enc.MinerSystemGas = c.MinerSystemGas
+	enc.MinerWatchdog = c.MinerWatchdog
+	enc.DevoteSkipEmpty = c.DevoteSkipEmpty
+	enc.DevoteObserver = c.DevoteObserver
+	enc.ForkWebhook = c.ForkWebhook
+	enc.ForkAlertDepth = c.ForkAlertDepth
+	enc.AlertsURL = c.AlertsURL
+	enc.AlertsMinPeers = c.AlertsMinPeers
+	enc.MasternodeDelegation = c.MasternodeDelegation
+	enc.MasternodeStandby = c.MasternodeStandby
+	enc.MasternodeSentinel = c.MasternodeSentinel
+	enc.MasternodeHost = c.MasternodeHost
+	enc.MasternodeEndpoints = c.MasternodeEndpoints
+	enc.MasternodeRotation = c.MasternodeRotation
+	enc.MasternodeBootstrap = c.MasternodeBootstrap
+	enc.MasternodeGeoIP = c.MasternodeGeoIP
+	enc.MasternodeRestake = c.MasternodeRestake
+	enc.MasternodeRestakeTargets = c.MasternodeRestakeTargets
+	enc.MasternodeRestakeFunders = c.MasternodeRestakeFunders
+	enc.MasternodeRestakeReserve = c.MasternodeRestakeReserve
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
@@ -81,33 +129,57 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		NoPruning               *bool
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
-		DatabaseCache           *int
-		TrieCleanCache          *int
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		Etherbase               *common.Address `toml:",omitempty"`
-		MinerNotify             []string        `toml:",omitempty"`
-		MinerExtraData          *hexutil.Bytes  `toml:",omitempty"`
-		MinerGasFloor           *uint64
-		MinerGasCeil            *uint64
-		MinerGasPrice           *big.Int
-		MinerRecommit           *time.Duration
-		MinerNoverify           *bool
-		Ethash                  *ethash.Config
-		TxPool                  *core.TxPoolConfig
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		EWASMInterpreter        *string
-		EVMInterpreter          *string
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                *uint64
+		SyncMode                 *downloader.SyncMode
+		NoPruning                *bool
+		LightServ                *int  `toml:",omitempty"`
+		LightPeers               *int  `toml:",omitempty"`
+		LightDevoteArchive       *bool `toml:",omitempty"`
+		SkipBcVersionCheck       *bool `toml:"-"`
+		DatabaseHandles          *int  `toml:"-"`
+		DatabaseCache            *int
+		TrieCleanCache           *int
+		TrieDirtyCache           *int
+		TrieTimeout              *time.Duration
+		StateHistory             *uint64
+		ParallelExecution        *int
+		FreezerCycles            *uint64
+		Etherbase                *common.Address `toml:",omitempty"`
+		MinerNotify              []string        `toml:",omitempty"`
+		MinerExtraData           *hexutil.Bytes  `toml:",omitempty"`
+		MinerGasFloor            *uint64
+		MinerGasCeil             *uint64
+		MinerGasPrice            *big.Int
+		MinerRecommit            *time.Duration
+		MinerNoverify            *bool
+		MinerSystemGas           *uint64
+		MinerWatchdog            *bool
+		DevoteSkipEmpty          *bool
+		DevoteObserver           *bool
+		ForkWebhook              *string `toml:",omitempty"`
+		ForkAlertDepth           *uint64
+		AlertsURL                *string `toml:",omitempty"`
+		AlertsMinPeers           *int
+		MasternodeDelegation     hexutil.Bytes    `toml:",omitempty"`
+		MasternodeStandby        *uint64          `toml:",omitempty"`
+		MasternodeSentinel       *bool            `toml:",omitempty"`
+		MasternodeHost           *string          `toml:",omitempty"`
+		MasternodeEndpoints      []string         `toml:",omitempty"`
+		MasternodeRotation       *time.Duration   `toml:",omitempty"`
+		MasternodeBootstrap      *string          `toml:",omitempty"`
+		MasternodeGeoIP          *string          `toml:",omitempty"`
+		MasternodeRestake        *common.Address  `toml:",omitempty"`
+		MasternodeRestakeTargets []string         `toml:",omitempty"`
+		MasternodeRestakeFunders []common.Address `toml:",omitempty"`
+		MasternodeRestakeReserve *uint64          `toml:",omitempty"`
+		Ethash                   *ethash.Config
+		TxPool                   *core.TxPoolConfig
+		GPO                      *gasprice.Config
+		EnablePreimageRecording  *bool
+		DocRoot                  *string `toml:"-"`
+		EWASMInterpreter         *string
+		EVMInterpreter           *string
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -131,6 +203,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.LightPeers != nil {
 		c.LightPeers = *dec.LightPeers
 	}
+	if dec.LightDevoteArchive != nil {
+		c.LightDevoteArchive = *dec.LightDevoteArchive
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -149,6 +224,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieTimeout != nil {
 		c.TrieTimeout = *dec.TrieTimeout
 	}
+	if dec.StateHistory != nil {
+		c.StateHistory = *dec.StateHistory
+	}
+	if dec.ParallelExecution != nil {
+		c.ParallelExecution = *dec.ParallelExecution
+	}
+	if dec.FreezerCycles != nil {
+		c.FreezerCycles = *dec.FreezerCycles
+	}
 	if dec.Etherbase != nil {
 		c.Etherbase = *dec.Etherbase
 	}
@@ -173,6 +257,66 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.MinerNoverify != nil {
 		c.MinerNoverify = *dec.MinerNoverify
 	}
+	if dec.MinerSystemGas != nil {
+		c.MinerSystemGas = *dec.MinerSystemGas
+	}
+	if dec.MinerWatchdog != nil {
+		c.MinerWatchdog = *dec.MinerWatchdog
+	}
+	if dec.DevoteSkipEmpty != nil {
+		c.DevoteSkipEmpty = *dec.DevoteSkipEmpty
+	}
+	if dec.DevoteObserver != nil {
+		c.DevoteObserver = *dec.DevoteObserver
+	}
+	if dec.ForkWebhook != nil {
+		c.ForkWebhook = *dec.ForkWebhook
+	}
+	if dec.ForkAlertDepth != nil {
+		c.ForkAlertDepth = *dec.ForkAlertDepth
+	}
+	if dec.AlertsURL != nil {
+		c.AlertsURL = *dec.AlertsURL
+	}
+	if dec.AlertsMinPeers != nil {
+		c.AlertsMinPeers = *dec.AlertsMinPeers
+	}
+	if dec.MasternodeDelegation != nil {
+		c.MasternodeDelegation = dec.MasternodeDelegation
+	}
+	if dec.MasternodeStandby != nil {
+		c.MasternodeStandby = *dec.MasternodeStandby
+	}
+	if dec.MasternodeSentinel != nil {
+		c.MasternodeSentinel = *dec.MasternodeSentinel
+	}
+	if dec.MasternodeHost != nil {
+		c.MasternodeHost = *dec.MasternodeHost
+	}
+	if dec.MasternodeEndpoints != nil {
+		c.MasternodeEndpoints = dec.MasternodeEndpoints
+	}
+	if dec.MasternodeRotation != nil {
+		c.MasternodeRotation = *dec.MasternodeRotation
+	}
+	if dec.MasternodeBootstrap != nil {
+		c.MasternodeBootstrap = *dec.MasternodeBootstrap
+	}
+	if dec.MasternodeGeoIP != nil {
+		c.MasternodeGeoIP = *dec.MasternodeGeoIP
+	}
+	if dec.MasternodeRestake != nil {
+		c.MasternodeRestake = *dec.MasternodeRestake
+	}
+	if dec.MasternodeRestakeTargets != nil {
+		c.MasternodeRestakeTargets = dec.MasternodeRestakeTargets
+	}
+	if dec.MasternodeRestakeFunders != nil {
+		c.MasternodeRestakeFunders = dec.MasternodeRestakeFunders
+	}
+	if dec.MasternodeRestakeReserve != nil {
+		c.MasternodeRestakeReserve = *dec.MasternodeRestakeReserve
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
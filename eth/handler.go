@@ -31,6 +31,7 @@ import (
 	"github.com/etherzero/go-etherzero/consensus/misc"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/eth/fetcher"
 	"github.com/etherzero/go-etherzero/ethdb"
@@ -80,6 +81,7 @@ type ProtocolManager struct {
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
+	banned     *banList // Peers refused for misbehaving in the masternode gossip
 
 	mm *MasternodeManager
 
@@ -112,6 +114,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		blockchain:  blockchain,
 		chainconfig: config,
 		peers:       newPeerSet(),
+		banned:      newBanList(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
 		txsyncCh:    make(chan *txsync),
@@ -258,6 +261,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
 		return p2p.DiscTooManyPeers
 	}
+	if pm.banned.banned(p.ID()) {
+		return errResp(ErrBadReputation, "peer banned")
+	}
 	p.Log().Debug("Ethereum peer connected", "name", p.Name())
 
 	// Execute the Ethereum handshake
@@ -290,6 +296,14 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	// after this will be sent via broadcasts.
 	pm.syncTransactions(p)
 
+	// Learn the masternode set from masternode capable peers, announcements
+	// appearing after this will be relayed as they arrive.
+	if p.masternodeCapable() && pm.mm != nil {
+		if err := p.RequestAnnouncements(); err != nil {
+			return err
+		}
+	}
+
 	// If we're DAO hard-fork aware, validate any remote peer with regard to the hard-fork
 	if daoBlock := pm.chainconfig.DAOForkBlock; daoBlock != nil {
 		// Request the peer's DAO fork header for extra-data validation
@@ -687,12 +701,138 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txpool.AddRemotes(txs)
 
+	case p.version >= etz64 && msg.Code == GetMasternodeAnnouncesMsg:
+		// Masternode announcements requested, send over the known ones, no more
+		// than the peer can remember
+		if pm.mm == nil || !p.masternodeCapable() {
+			break
+		}
+		anns := pm.mm.Announcements()
+		if len(anns) > maxKnownAnns {
+			anns = anns[:maxKnownAnns]
+		}
+		if len(anns) > 0 {
+			return p.SendAnnouncements(anns)
+		}
+
+	case p.version >= etz64 && msg.Code == MasternodeAnnounceMsg:
+		// Masternode announcements arrived, verify and relay the fresh ones
+		var anns []*masternode.Announcement
+		if err := msg.Decode(&anns); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(anns) > maxAnnouncementsPerMsg {
+			return errResp(ErrMsgTooLarge, "%d announcements > %d", len(anns), maxAnnouncementsPerMsg)
+		}
+		// Ignore the announcements of peers running outdated masternode versions
+		if pm.mm == nil || !p.masternodeCapable() {
+			break
+		}
+		var fresh []*masternode.Announcement
+		for i, ann := range anns {
+			if ann == nil {
+				return errResp(ErrDecode, "announcement %d is nil", i)
+			}
+			// Only penalize the peer for repeating itself, not for relaying back
+			// what we sent it
+			hash := ann.Hash()
+			known := p.knownMnbs.Contains(hash)
+			if p.ReceivedAnnouncement(hash) {
+				if err := pm.penalize(p, offenceDuplicate); err != nil {
+					return err
+				}
+				continue
+			}
+			if known {
+				continue
+			}
+
+			// Announcements may be ahead of our head, only penalize the relaying
+			// peer for the ones it should have dropped itself
+			added, err := pm.mm.AddAnnouncement(ann)
+			if err != nil {
+				p.Log().Debug("Rejected masternode announcement", "enode", ann.ENode, "err", err)
+				switch {
+				case masternode.IsInvalidAnnouncement(err):
+					err = pm.penalize(p, offenceInvalid)
+				case masternode.IsStaleAnnouncement(err):
+					err = pm.penalize(p, offenceStale)
+				default:
+					err = nil
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if added {
+				fresh = append(fresh, ann)
+			}
+		}
+		pm.BroadcastAnnouncements(fresh)
+
+	case p.version >= etz64 && msg.Code == MasternodeDepartMsg:
+		// A masternode host is shutting down, verify and relay the notice
+		var d masternode.Departure
+		if err := msg.Decode(&d); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if pm.mm == nil || !p.masternodeCapable() {
+			break
+		}
+		p.MarkAnnouncement(d.Hash())
+
+		added, err := pm.mm.AddDeparture(&d)
+		if err != nil {
+			p.Log().Debug("Rejected masternode departure", "err", err)
+			switch {
+			case masternode.IsInvalidAnnouncement(err):
+				return pm.penalize(p, offenceInvalid)
+			case masternode.IsStaleAnnouncement(err):
+				return pm.penalize(p, offenceStale)
+			}
+			break
+		}
+		if added {
+			pm.BroadcastDeparture(&d)
+		}
+
+	case p.version >= etz64 && msg.Code == ReachabilityCheckMsg:
+		// A peer asks to be dialed back, serve it in the background
+		var req reachabilityCheck
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if p.allowDialBack() {
+			go pm.dialBack(p, &req)
+		}
+
+	case p.version >= etz64 && msg.Code == ReachabilityResultMsg:
+		// A peer dialed us back, record the outcome of the self-test
+		var res reachabilityResult
+		if err := msg.Decode(&res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if pm.mm != nil {
+			pm.mm.reachabilityResult(p.id, &res)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
 	return nil
 }
 
+// penalize records an offence in the gossip of the peer, banning it and
+// returning an error to disconnect it if its reputation dropped too low.
+func (pm *ProtocolManager) penalize(p *peer, o offence) error {
+	if !p.reputation.penalize(o) {
+		return nil
+	}
+	pm.banned.ban(p.ID())
+	return errResp(ErrBadReputation, "penalty score %d", p.reputation.summary().Score)
+}
+
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -752,6 +892,36 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	}
 }
 
+// BroadcastAnnouncements will propagate a batch of masternode announcements to
+// all masternode capable peers which are not known to already have the given announcement.
+func (pm *ProtocolManager) BroadcastAnnouncements(anns []*masternode.Announcement) {
+	var annset = make(map[*peer][]*masternode.Announcement)
+
+	for _, ann := range anns {
+		peers := pm.peers.PeersWithoutAnnouncement(ann.Hash())
+		for _, peer := range peers {
+			annset[peer] = append(annset[peer], ann)
+		}
+		log.Trace("Broadcast masternode announcement", "enode", ann.ENode, "recipients", len(peers))
+	}
+	for peer, anns := range annset {
+		peer.AsyncSendAnnouncements(anns)
+	}
+}
+
+// BroadcastDeparture relays a masternode departure to the peers which don't know
+// about it yet, returning the number of peers it was sent to.
+func (pm *ProtocolManager) BroadcastDeparture(d *masternode.Departure) int {
+	peers := pm.peers.PeersWithoutAnnouncement(d.Hash())
+	for _, peer := range peers {
+		if err := peer.SendDeparture(d); err != nil {
+			peer.Log().Debug("Failed to send masternode departure", "err", err)
+		}
+	}
+	log.Trace("Broadcast masternode departure", "recipients", len(peers))
+	return len(peers)
+}
+
 // Mined broadcast loop
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	// automatically stops if unsubscribe
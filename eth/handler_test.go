@@ -29,12 +29,14 @@ import (
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/p2p/enode"
 	"github.com/etherzero/go-etherzero/params"
 )
 
@@ -611,3 +613,85 @@ outer:
 		t.Errorf("block broadcast to %d peers, expected %d", receivedCount, broadcastExpected)
 	}
 }
+
+// Tests that masternode announcement lists are split into messages the remote
+// side accepts, and that peers sending longer lists are dropped.
+func TestAnnouncementBatches(t *testing.T) {
+	anns := make([]*masternode.Announcement, 2*maxAnnouncementsPerMsg+1)
+	for i := range anns {
+		anns[i] = &masternode.Announcement{ENode: fmt.Sprintf("enode-%d", i), Block: big.NewInt(int64(i)), Protocol: masternode.ProtocolVersion}
+	}
+	// Long lists are sent in batches
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	p := newPeer(etz64, p2p.NewPeer(enode.ID{}, "peer", nil), net)
+	p.mnVersion = masternode.ProtocolVersion
+
+	errc := make(chan error, 1)
+	go func() { errc <- p.SendAnnouncements(anns) }()
+
+	var received int
+	for _, want := range []int{maxAnnouncementsPerMsg, maxAnnouncementsPerMsg, 1} {
+		msg, err := app.ReadMsg()
+		if err != nil {
+			t.Fatalf("failed to read announcements: %v", err)
+		}
+		var batch []*masternode.Announcement
+		if err := msg.Decode(&batch); err != nil {
+			t.Fatalf("failed to decode announcements: %v", err)
+		}
+		if len(batch) != want {
+			t.Fatalf("batch size mismatch: have %d, want %d", len(batch), want)
+		}
+		received += len(batch)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to send announcements: %v", err)
+	}
+	if received != len(anns) {
+		t.Fatalf("announcement count mismatch: have %d, want %d", received, len(anns))
+	}
+	// Oversized lists get the peer dropped
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	peer, dropped := newTestPeer("peer", etz64, pm, true)
+	defer peer.close()
+
+	if err := p2p.Send(peer.app, MasternodeAnnounceMsg, anns[:maxAnnouncementsPerMsg+1]); err != nil {
+		t.Fatalf("failed to send announcements: %v", err)
+	}
+	select {
+	case err := <-dropped:
+		if err == nil {
+			t.Fatalf("peer dropped without error")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("peer sending oversized announcement list not dropped")
+	}
+}
+
+// Tests that only the announcements a peer sent before count as duplicates, not
+// those it relays back after we sent them to it.
+func TestDuplicateAnnouncements(t *testing.T) {
+	_, net := p2p.MsgPipe()
+	p := newPeer(etz64, p2p.NewPeer(enode.ID{}, "peer", nil), net)
+
+	sent, received := common.Hash{0x01}, common.Hash{0x02}
+	p.MarkAnnouncement(sent)
+	if p.ReceivedAnnouncement(sent) {
+		t.Errorf("relayed announcement counted as duplicate")
+	}
+	if p.ReceivedAnnouncement(received) {
+		t.Errorf("first announcement counted as duplicate")
+	}
+	for _, hash := range []common.Hash{sent, received} {
+		if !p.ReceivedAnnouncement(hash) {
+			t.Errorf("repeated announcement %x not counted as duplicate", hash)
+		}
+		if !p.knownMnbs.Contains(hash) {
+			t.Errorf("received announcement %x not marked as known", hash)
+		}
+	}
+}
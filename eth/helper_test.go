@@ -31,6 +31,7 @@ import (
 	"github.com/etherzero/go-etherzero/consensus/ethash"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/eth/downloader"
@@ -186,6 +187,9 @@ func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesi
 		CurrentBlock:    head,
 		GenesisBlock:    genesis,
 	}
+	if p.version >= etz64 {
+		msg.Masternode = []uint32{masternode.ProtocolVersion}
+	}
 	if err := p2p.ExpectMsg(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
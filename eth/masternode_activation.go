@@ -0,0 +1,93 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+// activationInterval is the interval between two evaluations of the activation
+// state of the local masternode, on top of the ones triggered by contract events.
+const activationInterval = 30 * time.Second
+
+// checkActivation evaluates the registration of the local masternode at the
+// current head, under the id of the cold key if sealing on its behalf, and moves
+// its activation state machine accordingly. Entering a registered state starts
+// announcing and advertising the masternode, entering new-start-required stops
+// it. Observers never activate.
+func (self *MasternodeManager) checkActivation() {
+	self.mu.RLock()
+	var id [8]byte
+	copy(id[:], common.FromHex(self.ID))
+	observer := self.observer
+	self.mu.RUnlock()
+
+	if observer {
+		return
+	}
+
+	state, err := self.stateOf(id)
+	if err != nil && err != errMasternodeNotRegistered {
+		log.Debug("Failed to evaluate masternode activation", "err", err)
+		return
+	}
+	in := masternode.ActivationInput{
+		Registered: err == nil,
+		State:      state,
+		Outdated:   self.protocolOutdated(),
+	}
+	transitions := self.activation.Update(in)
+	for _, transition := range transitions {
+		logger := log.Debug
+		if self.srvr.IsMasternode || in.Registered {
+			logger = log.Info
+		}
+		logger("Masternode activation state changed", "from", transition.From, "to", transition.To, "reason", transition.Reason)
+	}
+	if len(transitions) == 0 {
+		return
+	}
+	current, _ := self.activation.State()
+	switch {
+	case current == masternode.ActivationNewStartRequired:
+		if atomic.CompareAndSwapUint32(&self.IsMasternode, 1, 0) {
+			self.advertise(false)
+		}
+	case atomic.CompareAndSwapUint32(&self.IsMasternode, 0, 1):
+		self.announce()
+		self.advertise(true)
+	}
+}
+
+// protocolOutdated reports whether most announced masternodes run a newer
+// masternode protocol version than the local node.
+func (self *MasternodeManager) protocolOutdated() bool {
+	anns := self.Announcements()
+
+	newer := 0
+	for _, ann := range anns {
+		if ann.Protocol > masternode.ProtocolVersion {
+			newer++
+		}
+	}
+	return newer*2 > len(anns)
+}
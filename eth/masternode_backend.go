@@ -18,6 +18,7 @@ package eth
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"sync"
@@ -26,6 +27,8 @@ import (
 	"errors"
 	"context"
 
+	"github.com/etherzero/go-etherzero/accounts"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
 	"github.com/etherzero/go-etherzero/core/types"
@@ -35,21 +38,30 @@ import (
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/p2p/discover"
+	"github.com/etherzero/go-etherzero/p2p/enode"
 	"crypto/ecdsa"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/eth/downloader"
+	"github.com/hashicorp/golang-lru"
 )
 
 var (
 	statsReportInterval  = 10 * time.Second // Time interval to report vote pool stats
 	ErrUnknownMasternode = errors.New("unknown masternode")
+
+	errMasternodeNotStarted    = errors.New("masternode manager not started")
+	errObserverMode            = errors.New("disabled in observer mode")
+	errMasternodeNotRegistered = errors.New("masternode not registered")
+	errNoMasternodeOwned       = errors.New("account owns no masternode")
+	errQuitFromNodeAccount     = errors.New("masternode node account would ping instead of quitting")
 )
 
 type MasternodeManager struct {
 	// channels for fetcher, syncer, txsyncLoop
 	IsMasternode uint32
 	srvr         *p2p.Server
-	contract     *contract.Contract
+	contracts    *contractRegistry // Versions of the masternode contract
+	backend      bind.ContractCaller
 
 	mux *event.TypeMux
 	eth *Ethereum
@@ -60,18 +72,197 @@ type MasternodeManager struct {
 	ID          string
 	NodeAccount common.Address
 	PrivateKey  *ecdsa.PrivateKey
+
+	// delegation, if set, lets the node key act as the hot signing key of the
+	// cold masternode key registered in the contract. Note the contract still
+	// expects liveness pings from the account of the cold key.
+	delegation *masternode.Delegation
+
+	// watchdog expires unless the host is confirmed healthy periodically, by the
+	// built-in health checker or, if sentinel is set, by an external process.
+	watchdog *masternode.Watchdog
+	sentinel bool
+
+	// activation tracks the activation state of the local masternode, deciding
+	// whether it announces itself and pings the contract.
+	activation *masternode.Activation
+
+	// observer, if set, keeps the node from ever acting as a masternode: it
+	// doesn't sign, ping, announce or advertise, even if registered.
+	observer bool
+
+	// host, if set, is the DNS name the local masternode is announced at.
+	host string
+
+	// endpoints, if set, are the addresses the local masternode rotates among
+	// every rotation, endpoint being the active one.
+	endpoints []string
+	endpoint  int
+	rotation  time.Duration
+
+	// bootstrap, if set, is the DNS name the signed bootstrap list of known-good
+	// masternodes is looked up at.
+	bootstrap string
+
+	// geoip, if set, breaks the masternode counts down by country and ASN.
+	geoip *geoIPTable
+
+	// restaker, if set, compounds the rewards of a payout account into new
+	// masternode registrations.
+	restaker *masternodeRestaker
+
+	index     *masternodeIndex // Masternodes registered at the last requested head
+	indexLock sync.Mutex
+
+	network       masternode.Network                  // Network the announcements are signed for
+	announcements map[string]*masternode.Announcement // Latest verified announcement of every masternode
+	departures    *lru.Cache                          // Hashes of the departures recently relayed
+	annLock       sync.RWMutex
+
+	topicStop chan struct{} // Stops the masternode topic registration, nil if not advertising
+	topicLock sync.Mutex
+
+	peers     map[string]*masternodePeer // Masternodes kept connected to, keyed by masternode id
+	reach     reachability               // Outcome of the reachability self-tests
+	peersLock sync.Mutex
+
+	resolved    map[string]*enode.Node // Last resolutions of the masternodes announced by DNS name
+	resolveCh   chan struct{}          // Signals a new masternode announced by DNS name
+	resolveLock sync.RWMutex
+
+	quit chan struct{}
 }
 
-func NewMasternodeManager(eth *Ethereum, contract *contract.Contract) *MasternodeManager {
+func NewMasternodeManager(eth *Ethereum, backend bind.ContractBackend) *MasternodeManager {
 
 	// Create the masternode manager with its initial settings
+	departures, _ := lru.New(maxDepartures)
 	manager := &MasternodeManager{
-		eth:      eth,
-		contract: contract,
+		eth:       eth,
+		contracts: newContractRegistry(eth.chainConfig.Devote, backend),
+		backend:   backend,
+		watchdog:  masternode.NewWatchdog(),
+
+		activation: masternode.NewActivation(),
+
+		network:       masternode.Network{ChainID: eth.chainConfig.ChainID, Genesis: eth.blockchain.Genesis().Hash()},
+		announcements: make(map[string]*masternode.Announcement),
+		departures:    departures,
+		peers:         make(map[string]*masternodePeer),
+		resolved:      make(map[string]*enode.Node),
+		resolveCh:     make(chan struct{}, 1),
+		quit:          make(chan struct{}),
 	}
 	return manager
 }
 
+// SetDelegation configures the delegation issued by the cold masternode key to
+// the local node key. It's verified against the local key once started.
+func (self *MasternodeManager) SetDelegation(enc []byte) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(enc) == 0 {
+		self.delegation = nil
+		return
+	}
+	// The delegate is only known after start, fill it in there
+	self.delegation, _ = masternode.DecodeDelegation(common.Address{}, enc)
+	if self.delegation == nil {
+		log.Error("Invalid masternode delegation, sealing with the node key", "len", len(enc))
+	}
+}
+
+// SetSentinel configures whether the health of the host is confirmed by an
+// external sentinel process instead of the built-in health checker.
+func (self *MasternodeManager) SetSentinel(external bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.sentinel = external
+}
+
+// SetObserver configures whether the node runs in observer mode, following the
+// masternodes without ever acting as one.
+func (self *MasternodeManager) SetObserver(observer bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.observer = observer
+}
+
+// Observer reports whether the node runs in observer mode.
+func (self *MasternodeManager) Observer() bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.observer
+}
+
+// SentinelPing records a health confirmation of the host sent by the given
+// version of an external sentinel.
+func (self *MasternodeManager) SentinelPing(version uint32) {
+	self.watchdog.Confirm(version)
+}
+
+// checkHealth is the built-in health checker, confirming the host to the
+// watchdog while it's connected and following the chain.
+func (self *MasternodeManager) checkHealth() {
+	self.mu.RLock()
+	external := self.sentinel
+	self.mu.RUnlock()
+
+	if external {
+		return
+	}
+	if atomic.LoadInt32(&self.syncing) == 1 || self.eth.protocolManager.peers.Len() == 0 {
+		log.Warn("Masternode host unhealthy", "syncing", atomic.LoadInt32(&self.syncing) == 1, "peers", self.eth.protocolManager.peers.Len())
+		return
+	}
+	self.watchdog.Confirm(0)
+}
+
+// State returns the state of the local masternode at the current head, which is
+// its state in the contract unless enabled with an expired watchdog. It returns
+// errMasternodeNotRegistered if the node key isn't registered.
+func (self *MasternodeManager) State() (int, error) {
+	if self.srvr == nil {
+		return 0, errMasternodeNotStarted
+	}
+	return self.stateOf(self.srvr.Self().X8())
+}
+
+// stateOf returns the state of the masternode with the given id at the current
+// head, reporting an expired watchdog of the local host like State.
+func (self *MasternodeManager) stateOf(id [8]byte) (int, error) {
+	node, err := self.nodeOf(id)
+	if err != nil {
+		return 0, err
+	}
+	if node.State == masternode.MasternodeEnable && self.watchdog.Expired() {
+		return masternode.MasternodeWatchdogExpired, nil
+	}
+	return node.State, nil
+}
+
+// nodeOf returns the masternode with the given id registered at the current
+// head, or errMasternodeNotRegistered if there is none.
+func (self *MasternodeManager) nodeOf(id [8]byte) (*masternode.Masternode, error) {
+	number := self.eth.blockchain.CurrentBlock().Number()
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return nil, err
+	}
+	node, err := masternode.GetMasternode(caller, id, number)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, errMasternodeNotRegistered
+	}
+	return node, nil
+}
+
 func (self *MasternodeManager) Clear() {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -83,43 +274,68 @@ func (self *MasternodeManager) Start(srvr *p2p.Server, mux *event.TypeMux) {
 	self.mux = mux
 	log.Trace("MasternodeManqager start ")
 	x8 := srvr.Self().X8()
+	self.mu.Lock()
 	self.ID = fmt.Sprintf("%x", x8[:])
 	self.NodeAccount = crypto.PubkeyToAddress(srvr.Config.PrivateKey.PublicKey)
 	self.PrivateKey = srvr.Config.PrivateKey
+	if self.delegation != nil {
+		self.delegation.Delegate = self.NodeAccount
+		cycle := self.eth.blockchain.CurrentHeader().Time.Uint64() / params.Epoch
+		if id, err := self.delegation.Recover(cycle); err != nil {
+			log.Error("Masternode delegation rejected, sealing with the node key", "err", err)
+			self.delegation = nil
+		} else {
+			log.Info("Sealing on behalf of cold masternode key", "id", id, "delegate", self.NodeAccount, "expiry", self.delegation.Expiry)
+			self.ID = id
+		}
+	}
+	self.mu.Unlock()
 
 	go self.masternodeLoop()
 	go self.checkSyncing()
+	go self.reconnectMasternodes()
+	go self.resolveMasternodes()
+	go self.rotateEndpoints()
+	go self.bootstrapMasternodes()
+	if self.restaker != nil {
+		go self.restaker.loop()
+	}
+	if srvr.DiscV5 != nil {
+		go self.discoverMasternodes()
+	}
 }
 
 func (self *MasternodeManager) Stop() {
-
+	self.advertise(false)
+	if self.restaker != nil {
+		close(self.restaker.quit)
+	}
+	close(self.quit)
 }
 
 func (mm *MasternodeManager) masternodeLoop() {
-	xy := mm.srvr.Self().XY()
-	has, err := mm.contract.Has(nil, mm.srvr.Self().X8())
+	var id8 [8]byte
+	copy(id8[:], common.FromHex(mm.ID))
+	current, err := mm.contracts.contract(mm.eth.blockchain.CurrentBlock().Number())
 	if err != nil {
-		log.Error("contract.Has", "error", err)
+		log.Error("Masternode contract unavailable", "err", err)
+		return
 	}
-	if has {
+	mm.checkActivation()
+	if atomic.LoadUint32(&mm.IsMasternode) == 1 {
 		fmt.Println("### It's already been a masternode! ")
-		atomic.StoreUint32(&mm.IsMasternode, 1)
-	} else {
-		atomic.StoreUint32(&mm.IsMasternode, 0)
-		if mm.srvr.IsMasternode {
-			data := "0x2f926732" + common.Bytes2Hex(xy[:])
-			fmt.Printf("### Masternode Transaction Data: %s\n", data)
-		}
+	} else if mm.srvr.IsMasternode {
+		log.Info("Masternode not registered, deposit its collateral with masternode.register", "id", mm.ID)
 	}
 
 	joinCh := make(chan *contract.ContractJoin, 32)
 	quitCh := make(chan *contract.ContractQuit, 32)
-	joinSub, err1 := mm.contract.WatchJoin(nil, joinCh)
+	joinSub, err1 := current.WatchJoin(nil, joinCh)
 	if err1 != nil {
 		// TODO: exit
 		return
 	}
-	quitSub, err2 := mm.contract.WatchQuit(nil, quitCh)
+	quitSub, err2 := current.WatchQuit(nil, quitCh)
 	if err2 != nil {
 		// TODO: exit
 		return
@@ -133,17 +349,27 @@ func (mm *MasternodeManager) masternodeLoop() {
 	report := time.NewTicker(statsReportInterval)
 	defer report.Stop()
 
+	health := time.NewTicker(masternode.WatchdogInterval)
+	defer health.Stop()
+	mm.checkHealth()
+
+	reach := time.NewTimer(time.Minute)
+	defer reach.Stop()
+
+	activation := time.NewTicker(activationInterval)
+	defer activation.Stop()
+
 	for {
 		select {
 		case join := <-joinCh:
-			if bytes.Equal(join.Id[:], xy[:]) {
-				atomic.StoreUint32(&mm.IsMasternode, 1)
+			if bytes.Equal(join.Id[:], id8[:]) {
 				fmt.Println("### Become a masternode! ")
+				mm.checkActivation()
 			}
 		case quit := <-quitCh:
-			if bytes.Equal(quit.Id[:], xy[0:8]) {
-				atomic.StoreUint32(&mm.IsMasternode, 0)
+			if bytes.Equal(quit.Id[:], id8[:]) {
 				fmt.Println("### Remove a masternode! ")
+				mm.checkActivation()
 			}
 		case err := <-joinSub.Err():
 			joinSub.Unsubscribe()
@@ -152,6 +378,16 @@ func (mm *MasternodeManager) masternodeLoop() {
 			quitSub.Unsubscribe()
 			fmt.Println("eventQuit err", err.Error())
 
+		case <-health.C:
+			mm.checkHealth()
+
+		case <-activation.C:
+			mm.checkActivation()
+
+		case <-reach.C:
+			reach.Reset(reachabilityInterval)
+			mm.checkReachability()
+
 		case <-ntp.C:
 			ntp.Reset(10 * time.Minute)
 			go discover.CheckClockDrift()
@@ -160,11 +396,23 @@ func (mm *MasternodeManager) masternodeLoop() {
 			if atomic.LoadUint32(&mm.IsMasternode) == 0 {
 				break
 			}
+			if mm.watchdog.Expired() {
+				// Let the masternode expire in the contract, dropping it from payments
+				sentinel, last := mm.watchdog.Sentinel()
+				log.Warn("Masternode watchdog expired, skipping ping", "sentinel", sentinel, "last", last)
+				break
+			}
+			mm.announce()
+
 			logTime := time.Now().Format("2006-01-02 15:04:05")
 			if atomic.LoadInt32(&mm.syncing) == 1 {
 				fmt.Println(logTime, " syncing...")
 				break
 			}
+			if mm.eth.standbyFenced() {
+				// The primary host pings with the same key, avoid nonce clashes
+				break
+			}
 			address := mm.NodeAccount
 			stateDB, _ := mm.eth.blockchain.State()
 			if stateDB.GetBalance(address).Cmp(big.NewInt(1e+16)) < 0 {
@@ -184,13 +432,13 @@ func (mm *MasternodeManager) masternodeLoop() {
 			}
 			tx := types.NewTransaction(
 				mm.eth.txPool.State().GetNonce(address),
-				params.MasterndeContractAddress,
+				mm.eth.chainConfig.Devote.MasternodeContractAt(mm.eth.blockchain.CurrentBlock().Number()).Address,
 				big.NewInt(0),
 				90000,
 				gasPrice,
 				nil,
 			)
-			signed, err := types.SignTx(tx, types.NewEIP155Signer(mm.eth.blockchain.Config().ChainID), mm.PrivateKey)
+			signed, err := mm.signTx(tx)
 			if err != nil {
 				fmt.Println(logTime, "SignTx error:", err)
 				break
@@ -215,10 +463,167 @@ func (self *MasternodeManager) SignHash(id string, hash []byte) ([]byte, error)
 	if id != self.ID {
 		return nil, ErrUnknownMasternode
 	}
-	// Sign the hash using plain ECDSA operations
+	return self.signHash(hash)
+}
+
+// Delegation returns the delegation the local node key seals blocks with, if any.
+func (self *MasternodeManager) Delegation() *masternode.Delegation {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.delegation
+}
+
+// SignDelegation authorizes the given hot key to seal blocks on behalf of the
+// local masternode key until the expiry cycle, less than MaxDelegationCycles
+// ahead of the current head. It's meant to be run on the (offline) host of the
+// cold key.
+func (self *MasternodeManager) SignDelegation(delegate common.Address, expiry uint64) (*masternode.Delegation, error) {
+	cycle := self.eth.blockchain.CurrentHeader().Time.Uint64() / params.Epoch
+	if expiry < cycle || expiry-cycle >= masternode.MaxDelegationCycles {
+		return nil, fmt.Errorf("delegation expiry %d outside of cycles %d-%d", expiry, cycle, cycle+masternode.MaxDelegationCycles-1)
+	}
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	sig, err := self.signHash(masternode.DelegationHash(delegate, expiry).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &masternode.Delegation{Delegate: delegate, Expiry: expiry, Signature: sig}, nil
+}
+
+// signHash signs the hash with the local node key.
+func (self *MasternodeManager) signHash(hash []byte) ([]byte, error) {
+	if self.observer {
+		return nil, errObserverMode
+	}
+	if self.PrivateKey == nil {
+		return nil, errMasternodeNotStarted
+	}
+	// Sign through the keystore if the masternode key is kept encrypted there,
+	// unlocked by the node at startup, otherwise fall back to plain ECDSA
+	account := accounts.Account{Address: self.NodeAccount}
+	if wallet, err := self.eth.accountManager.Find(account); err == nil {
+		return wallet.SignHash(account, hash)
+	}
 	return crypto.Sign(hash, self.PrivateKey)
 }
 
+// signTx signs a masternode transaction with the node account, routing through
+// the keystore if the masternode key is kept encrypted there.
+func (self *MasternodeManager) signTx(tx *types.Transaction) (*types.Transaction, error) {
+	if self.Observer() {
+		return nil, errObserverMode
+	}
+	chainID := self.eth.blockchain.Config().ChainID
+	account := accounts.Account{Address: self.NodeAccount}
+	if wallet, err := self.eth.accountManager.Find(account); err == nil {
+		return wallet.SignTx(account, tx, chainID)
+	}
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), self.PrivateKey)
+}
+
+// announce signs the announcement of the local masternode, as registered in the
+// contract at the current head, and broadcasts it to the network.
+func (self *MasternodeManager) announce() {
+	if self.watchdog.Expired() {
+		return
+	}
+	number := self.eth.blockchain.CurrentBlock().Number()
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		log.Warn("Masternode contract unavailable", "err", err)
+		return
+	}
+	// Announcements are signed by the registered key itself, which isn't on
+	// this host when sealing on behalf of a cold key
+	node, err := masternode.GetMasternode(caller, self.srvr.Self().X8(), number)
+	if err != nil || node == nil {
+		log.Debug("Local node not registered, skipping announcement", "err", err)
+		return
+	}
+	ann := &masternode.Announcement{
+		ENode:    self.localENode(),
+		Account:  node.Account,
+		Block:    node.OriginBlock,
+		Protocol: masternode.ProtocolVersion,
+		Time:     uint64(time.Now().Unix()),
+	}
+	if endpoints, active := self.localEndpoints(); len(endpoints) > 0 {
+		ann.ENode, ann.Endpoints = active, endpoints // Same snapshot, whatever rotated since
+	}
+	ann.Sentinel, _ = self.watchdog.Sentinel()
+	self.mu.RLock()
+	ann.Signature, err = self.signHash(ann.SigHash(self.network).Bytes())
+	self.mu.RUnlock()
+	if err != nil {
+		log.Warn("Failed to sign masternode announcement", "err", err)
+		return
+	}
+	if _, err := self.AddAnnouncement(ann); err != nil {
+		log.Warn("Local masternode announcement rejected", "err", err)
+		return
+	}
+	self.eth.protocolManager.BroadcastAnnouncements([]*masternode.Announcement{ann})
+}
+
+// AddAnnouncement verifies a masternode announcement against the contract at
+// the current head and stores it if it's newer than the known one, reporting
+// whether it was stored and should be relayed.
+func (self *MasternodeManager) AddAnnouncement(ann *masternode.Announcement) (bool, error) {
+	number := self.eth.blockchain.CurrentBlock().Number()
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return false, err
+	}
+	node, err := ann.Verify(caller, self.network, number, uint64(time.Now().Unix()))
+	if err != nil {
+		return false, err
+	}
+	self.annLock.Lock()
+	defer self.annLock.Unlock()
+
+	known, ok := self.announcements[node.ID]
+	if ok && known.Time >= ann.Time {
+		return false, nil
+	}
+	self.announcements[node.ID] = ann
+
+	// Forget the resolution of an endpoint rotated away from
+	if ok && known.ENode != ann.ENode {
+		self.resolveLock.Lock()
+		delete(self.resolved, node.ID)
+		self.resolveLock.Unlock()
+	}
+	if ann.Host() != "" && self.resolvedNode(node.ID) == nil {
+		select {
+		case self.resolveCh <- struct{}{}:
+		default:
+		}
+	}
+	return true, nil
+}
+
+// Announcements returns the latest announcement of every masternode which hasn't
+// expired yet, dropping the expired ones.
+func (self *MasternodeManager) Announcements() []*masternode.Announcement {
+	self.annLock.Lock()
+	defer self.annLock.Unlock()
+
+	deadline := uint64(time.Now().Add(-masternode.AnnouncementExpiry).Unix())
+
+	anns := make([]*masternode.Announcement, 0, len(self.announcements))
+	for id, ann := range self.announcements {
+		if ann.Time < deadline {
+			delete(self.announcements, id)
+			continue
+		}
+		anns = append(anns, ann)
+	}
+	return anns
+}
+
 func (self *MasternodeManager) checkSyncing() {
 	events := self.mux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
 	for ev := range events.Chan() {
@@ -233,10 +638,171 @@ func (self *MasternodeManager) checkSyncing() {
 
 
 func (self *MasternodeManager) MasternodeList(number *big.Int) ([]string, error) {
-	return masternode.GetIdsByBlockNumber(self.contract, number)
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return nil, err
+	}
+	return masternode.GetIdsByBlockNumber(caller, number)
 }
 
 
+// Masternodes returns the masternodes registered at the given block, with their
+// state evaluated at that block.
+func (self *MasternodeManager) Masternodes(number *big.Int) ([]*masternode.Masternode, error) {
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return nil, err
+	}
+	return masternode.GetMasternodes(caller, number)
+}
+
+// RegisteredMasternodes returns the masternodes registered in the masternode
+// contract in effect at the given header, as of its parent, for the devote
+// registry to be seeded from.
+func (self *MasternodeManager) RegisteredMasternodes(header *types.Header) ([]*masternode.Masternode, error) {
+	caller, err := self.contracts.caller(header.Number)
+	if err != nil {
+		return nil, err
+	}
+	return masternode.GetMasternodes(caller, new(big.Int).Sub(header.Number, common.Big1))
+}
+
 func (self *MasternodeManager) GetGovernanceContractAddress(number *big.Int) (common.Address, error) {
-	return masternode.GetGovernanceAddress(self.contract, number)
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return masternode.GetGovernanceAddress(caller, number)
+}
+
+// Budget returns the budgets approved by the governance contract for the given
+// cycle, as seen at the given block.
+func (self *MasternodeManager) Budget(governance common.Address, cycle uint64, number *big.Int) ([]masternode.Budget, error) {
+	return masternode.GetBudget(self.backend, governance, cycle, number)
+}
+
+// Treasury returns the treasury configured in the governance contract as seen
+// at the given block.
+func (self *MasternodeManager) Treasury(governance common.Address, number *big.Int) (*masternode.Treasury, error) {
+	return masternode.GetTreasury(self.backend, governance, number)
+}
+
+// MaxWitnesses returns the number of witnesses per cycle set in the governance
+// contract as seen at the given block, zero if it's left to the chain config.
+func (self *MasternodeManager) MaxWitnesses(governance common.Address, number *big.Int) (uint64, error) {
+	return masternode.GetMaxWitnesses(self.backend, governance, number)
+}
+
+// VRFKey returns the VRF public key registered in the governance contract by the
+// masternode as seen at the given block, nil if none was registered.
+func (self *MasternodeManager) VRFKey(governance common.Address, id string, number *big.Int) ([]byte, error) {
+	raw, err := hex.DecodeString(id)
+	if err != nil || len(raw) != 8 {
+		return nil, fmt.Errorf("invalid masternode id %q", id)
+	}
+	var nodeid [8]byte
+	copy(nodeid[:], raw)
+
+	return masternode.GetVRFKey(self.backend, governance, nodeid, number)
+}
+
+// collateralTransactor creates the transaction signer for the collateral/payout
+// account, which may live in any wallet backend known to the account manager,
+// including Ledger and Trezor hardware wallets.
+func (self *MasternodeManager) collateralTransactor(from common.Address) (*bind.TransactOpts, error) {
+	account := accounts.Account{Address: from}
+	wallet, err := self.eth.accountManager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewWalletTransactor(wallet, account, self.eth.blockchain.Config().ChainID), nil
+}
+
+// Register sends the registration transaction of the local node, depositing the
+// collateral from the given account.
+func (self *MasternodeManager) Register(from common.Address) (*types.Transaction, error) {
+	if self.srvr == nil {
+		return nil, errMasternodeNotStarted
+	}
+	if self.Observer() {
+		return nil, errObserverMode
+	}
+	current, err := self.contracts.contract(self.eth.blockchain.CurrentBlock().Number())
+	if err != nil {
+		return nil, err
+	}
+	opts, err := self.collateralTransactor(from)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Value, err = current.EtzPerNode(nil); err != nil {
+		return nil, err
+	}
+	var id1, id2 [32]byte
+	xy := self.srvr.Self().XY()
+	copy(id1[:], xy[:32])
+	copy(id2[:], xy[32:])
+	return current.Register(opts, id1, id2)
+}
+
+// Quit sends the transaction unregistering the masternode owned by the given
+// collateral account, refunding the collateral to it. The contract treats the
+// same empty transaction sent by the node address of a masternode as a ping, so
+// the account must not be one.
+func (self *MasternodeManager) Quit(from common.Address) (*types.Transaction, error) {
+	number := self.eth.blockchain.CurrentBlock().Number()
+	current, err := self.contracts.contract(number)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQuit(current, from, number); err != nil {
+		return nil, err
+	}
+	opts, err := self.collateralTransactor(from)
+	if err != nil {
+		return nil, err
+	}
+	raw := &contract.ContractRaw{Contract: current}
+	return raw.Transfer(opts)
+}
+
+// checkQuit ensures that an empty transaction sent by the given account to the
+// masternode contract at the given block unregisters the masternode it owns.
+func checkQuit(current *contract.Contract, from common.Address, number *big.Int) error {
+	nodes, err := masternode.GetMasternodes(current, number)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if crypto.PubkeyToAddress(*node.ENode.Pubkey()) == from {
+			return errQuitFromNodeAccount
+		}
+	}
+	id, err := current.GetId(&bind.CallOpts{BlockNumber: number}, from)
+	if err != nil {
+		return err
+	}
+	if id == ([8]byte{}) {
+		return errNoMasternodeOwned
+	}
+	return nil
+}
+
+// Withdraw transfers accumulated rewards from the payout account to the given
+// destination.
+func (self *MasternodeManager) Withdraw(from, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	opts, err := self.collateralTransactor(from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := self.eth.APIBackend.gpo.SuggestPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	tx := types.NewTransaction(self.eth.txPool.State().GetNonce(from), to, amount, params.TxGas, gasPrice, nil)
+	signed, err := opts.Signer(types.NewEIP155Signer(self.eth.blockchain.Config().ChainID), from, tx)
+	if err != nil {
+		return nil, err
+	}
+	return signed, self.eth.txPool.AddLocal(signed)
 }
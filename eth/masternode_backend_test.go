@@ -0,0 +1,119 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/accounts"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind/backends"
+	"github.com/etherzero/go-etherzero/accounts/keystore"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/node"
+	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that a node restarted with its masternode key encrypted in the keystore
+// seals without the key being unlocked explicitly.
+func TestLockedMasternodeKeySeals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "masternodekey-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	keyfile := filepath.Join(dir, "keystore", "masternodekey")
+	if err := keystore.StoreMasternodeKey(keyfile, key, "secret", keystore.LightScryptN, keystore.LightScryptP); err != nil {
+		t.Fatalf("failed to store masternode key: %v", err)
+	}
+	stack, err := node.New(&node.Config{
+		DataDir:               dir,
+		UseLightweightKDF:     true,
+		MasternodeKeyPassword: "secret",
+		P2P:                   p2p.Config{ListenAddr: "127.0.0.1:0", NoDiscovery: true, MaxPeers: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	wallet, err := stack.AccountManager().Find(accounts.Account{Address: address})
+	if err != nil {
+		t.Fatalf("masternode key not in keystore: %v", err)
+	}
+	if status, _ := wallet.Status(); status != "Locked" {
+		t.Fatalf("masternode key status before start: have %q, want %q", status, "Locked")
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	manager := &MasternodeManager{
+		eth:         &Ethereum{accountManager: stack.AccountManager()},
+		ID:          "0a00000000000000",
+		NodeAccount: address,
+		PrivateKey:  stack.Server().PrivateKey,
+	}
+	hash := crypto.Keccak256([]byte("seal"))
+	sig, err := manager.SignHash(manager.ID, hash)
+	if err != nil {
+		t.Fatalf("failed to seal with the masternode key: %v", err)
+	}
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != address {
+		t.Fatalf("seal signer mismatch: have %v, want %x (err %v)", pubkey, address, err)
+	}
+}
+
+// Tests that only the owner of a masternode may quit it, as the empty quit
+// transaction sent by a node address pings its masternode instead.
+func TestCheckQuit(t *testing.T) {
+	funds := new(big.Int).Mul(big.NewInt(100000), big.NewInt(params.Ether))
+
+	owner, _ := crypto.GenerateKey()
+	node, _ := crypto.GenerateKey()
+	backend, err := backends.NewMasternodeBackend(owner, core.GenesisAlloc{crypto.PubkeyToAddress(owner.PublicKey): {Balance: funds}}, 10000000)
+	if err != nil {
+		t.Fatalf("failed to deploy masternode contract: %v", err)
+	}
+	if _, err := backend.Register(owner, node); err != nil {
+		t.Fatalf("failed to register masternode: %v", err)
+	}
+	number := backend.Blockchain().CurrentBlock().Number()
+
+	tests := []struct {
+		from common.Address
+		err  error
+	}{
+		{crypto.PubkeyToAddress(owner.PublicKey), nil},
+		{crypto.PubkeyToAddress(node.PublicKey), errQuitFromNodeAccount},
+		{common.Address{0x01}, errNoMasternodeOwned},
+	}
+	for i, tt := range tests {
+		if err := checkQuit(backend.Contract(), tt.from, number); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+const (
+	masternodeBootstrapCycle   = 30 * time.Second // Interval to check whether the bootstrap masternodes are needed
+	masternodeBootstrapRefresh = time.Hour        // Interval between two lookups of the bootstrap list in DNS
+)
+
+// SetBootstrap configures the DNS name the signed masternode bootstrap list is
+// looked up at, an empty name using only the built-in list.
+func (self *MasternodeManager) SetBootstrap(name string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.bootstrap = name
+}
+
+// bootstrapDefaults returns the built-in bootstrap list of the network with the
+// given genesis, and the node keys of its genesis masternodes, the only ones
+// allowed to sign the lists superseding it.
+func bootstrapDefaults(genesis common.Hash) ([]string, []common.Address) {
+	var enodes, masternodes []string
+	switch genesis {
+	case params.MainnetGenesisHash:
+		enodes, masternodes = params.MainnetMasternodeBootstrap, params.MainnetMasternodes
+	case params.TestnetGenesisHash:
+		enodes, masternodes = params.TestnetMasternodeBootstrap, params.TestnetMasternodes
+	}
+	signers := make([]common.Address, 0, len(masternodes))
+	for _, url := range masternodes {
+		if node, err := enode.ParseV4(url); err == nil {
+			signers = append(signers, crypto.PubkeyToAddress(*node.Pubkey()))
+		}
+	}
+	return enodes, signers
+}
+
+// needBootstrap reports whether the masternodes to connect to can't be taken
+// from the contract, because the chain is syncing or the contract unreadable.
+func (self *MasternodeManager) needBootstrap() bool {
+	if atomic.LoadInt32(&self.syncing) == 1 {
+		return true
+	}
+	_, err := self.masternodeIndex()
+	return err != nil
+}
+
+// bootstrapMasternodes dials the bootstrap masternodes while the masternode
+// contract can't be read, so that a new node joins the masternode gossip before
+// finishing its initial sync, and releases them once it can. The built-in list
+// is superseded by the signed ones looked up in DNS, if configured.
+func (self *MasternodeManager) bootstrapMasternodes() {
+	enodes, signers := bootstrapDefaults(self.network.Genesis)
+	var (
+		seq     uint64 // Sequence number of the list in use, 0 if built-in
+		dialed  = make(map[enode.ID]*enode.Node)
+		check   = time.NewTicker(masternodeBootstrapCycle)
+		refresh = time.NewTimer(0)
+	)
+	defer check.Stop()
+	defer refresh.Stop()
+
+	release := func() {
+		self.peersLock.Lock()
+		defer self.peersLock.Unlock()
+
+		for id, node := range dialed {
+			// Keep the ones the masternode peer tracking took over
+			if _, ok := self.peers[masternodeNodeID(node)]; !ok {
+				self.srvr.RemovePeer(node)
+			}
+			delete(dialed, id)
+		}
+	}
+	for {
+		select {
+		case <-refresh.C:
+			if list := self.lookupBootstrap(signers, seq); list != nil {
+				log.Info("Updated masternode bootstrap list", "seq", list.Seq, "nodes", len(list.ENodes))
+				seq, enodes = list.Seq, list.ENodes
+				release()
+			}
+			refresh.Reset(masternodeBootstrapRefresh)
+			if self.needBootstrap() {
+				self.dialBootstrap(enodes, dialed)
+			}
+
+		case <-check.C:
+			if self.needBootstrap() {
+				self.dialBootstrap(enodes, dialed)
+			} else if len(dialed) > 0 {
+				log.Debug("Releasing masternode bootstrap nodes", "count", len(dialed))
+				release()
+			}
+
+		case <-self.quit:
+			release()
+			return
+		}
+	}
+}
+
+// dialBootstrap dials the bootstrap masternodes not dialed yet, resolving the
+// ones listed by DNS name.
+func (self *MasternodeManager) dialBootstrap(enodes []string, dialed map[enode.ID]*enode.Node) {
+	for _, url := range enodes {
+		node, err := masternode.ResolveENode(url)
+		if err != nil {
+			log.Debug("Failed to resolve bootstrap masternode", "enode", url, "err", err)
+			continue
+		}
+		if node.ID() == self.srvr.Self().ID() {
+			continue
+		}
+		if _, ok := dialed[node.ID()]; ok {
+			continue
+		}
+		log.Debug("Dialing bootstrap masternode", "enode", node)
+		dialed[node.ID()] = node
+		self.srvr.AddPeer(node)
+	}
+}
+
+// lookupBootstrap looks up the signed bootstrap lists in the TXT records of the
+// configured DNS name, returning the newest valid one if it supersedes the list
+// of the given sequence number.
+func (self *MasternodeManager) lookupBootstrap(signers []common.Address, seq uint64) *masternode.BootstrapList {
+	self.mu.RLock()
+	name := self.bootstrap
+	self.mu.RUnlock()
+
+	if name == "" {
+		return nil
+	}
+	records, err := net.LookupTXT(name)
+	if err != nil {
+		log.Debug("Failed to look up masternode bootstrap list", "name", name, "err", err)
+		return nil
+	}
+	var newest *masternode.BootstrapList
+	for _, txt := range records {
+		list, err := masternode.ParseBootstrapTXT(txt)
+		if err != nil {
+			continue // Other TXT records of the name
+		}
+		if err := list.Verify(self.network, signers); err != nil {
+			log.Warn("Rejected masternode bootstrap list", "name", name, "seq", list.Seq, "err", err)
+			continue
+		}
+		if list.Seq > seq && (newest == nil || list.Seq > newest.Seq) {
+			newest = list
+		}
+	}
+	return newest
+}
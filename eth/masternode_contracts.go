@@ -0,0 +1,104 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// errContractTransact is returned if the masternode contract in effect doesn't
+// share the ABI of the genesis contract, which the transaction and event paths
+// of the manager are bound to.
+var errContractTransact = errors.New("masternode contract version doesn't support transactions")
+
+// masternodeBindings maps the ABI versions of the masternode contract to the
+// constructors of their read-only bindings.
+var masternodeBindings = map[uint64]func(common.Address, bind.ContractBackend) (masternode.Caller, error){
+	1: func(address common.Address, backend bind.ContractBackend) (masternode.Caller, error) {
+		binding, err := contract.NewContract(address, backend)
+		if err != nil {
+			return nil, err
+		}
+		return binding, nil
+	},
+}
+
+// contractRegistry tracks the versions of the masternode contract activated at
+// the upgrade blocks of the chain config, binding each of them on first use.
+// Reads are always served by the contract in effect at the requested block, so
+// a node following an upgrade keeps validating the blocks before it.
+type contractRegistry struct {
+	config  *params.DevoteConfig
+	backend bind.ContractBackend
+
+	bindings map[common.Address]masternode.Caller
+	lock     sync.Mutex
+}
+
+func newContractRegistry(config *params.DevoteConfig, backend bind.ContractBackend) *contractRegistry {
+	return &contractRegistry{
+		config:   config,
+		backend:  backend,
+		bindings: make(map[common.Address]masternode.Caller),
+	}
+}
+
+// caller returns the binding of the masternode contract in effect at the given
+// block.
+func (r *contractRegistry) caller(number *big.Int) (masternode.Caller, error) {
+	version := r.config.MasternodeContractAt(number)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if binding, ok := r.bindings[version.Address]; ok {
+		return binding, nil
+	}
+	newBinding, ok := masternodeBindings[version.Version]
+	if !ok {
+		return nil, fmt.Errorf("unknown masternode contract version %d", version.Version)
+	}
+	binding, err := newBinding(version.Address, r.backend)
+	if err != nil {
+		return nil, err
+	}
+	r.bindings[version.Address] = binding
+	return binding, nil
+}
+
+// contract returns the full binding of the masternode contract in effect at the
+// given block, for sending transactions and watching events.
+func (r *contractRegistry) contract(number *big.Int) (*contract.Contract, error) {
+	caller, err := r.caller(number)
+	if err != nil {
+		return nil, err
+	}
+	binding, ok := caller.(*contract.Contract)
+	if !ok {
+		return nil, errContractTransact
+	}
+	return binding, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/discv5"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+const (
+	masternodePeers          = 8                // Number of discovered masternodes to keep connected
+	masternodeFastDiscPeriod = 5 * time.Second  // Topic search period while looking for more masternodes
+	masternodeSlowDiscPeriod = 60 * time.Second // Topic search period once enough masternodes are connected
+	masternodeDialCheck      = 30 * time.Second // Interval to release discovered masternodes which disconnected
+)
+
+// masternodeTopic returns the discovery v5 topic the masternodes of the network
+// with the given genesis advertise themselves under.
+func masternodeTopic(genesisHash common.Hash) discv5.Topic {
+	return discv5.Topic("etz-masternode@" + common.Bytes2Hex(genesisHash.Bytes()[0:8]))
+}
+
+// advertise starts or stops registering the local node under the masternode
+// topic, so that nodes needing masternode peers can find it directly.
+func (self *MasternodeManager) advertise(active bool) {
+	if self.srvr == nil || self.srvr.DiscV5 == nil {
+		return
+	}
+	self.topicLock.Lock()
+	defer self.topicLock.Unlock()
+
+	if active == (self.topicStop != nil) {
+		return
+	}
+	if !active {
+		close(self.topicStop)
+		self.topicStop = nil
+		return
+	}
+	stop := make(chan struct{})
+	self.topicStop = stop
+
+	topic := masternodeTopic(self.eth.blockchain.Genesis().Hash())
+	go func() {
+		logger := log.New("topic", topic)
+		logger.Info("Starting masternode topic registration")
+		defer logger.Info("Terminated masternode topic registration")
+
+		self.srvr.DiscV5.RegisterTopic(topic, stop)
+	}()
+}
+
+// discoverMasternodes searches the masternode topic and connects to the found
+// masternodes, until masternodePeers of them are connected.
+func (self *MasternodeManager) discoverMasternodes() {
+	var (
+		topic     = masternodeTopic(self.eth.blockchain.Genesis().Hash())
+		setPeriod = make(chan time.Duration, 1)
+		found     = make(chan *discv5.Node, 100)
+		lookups   = make(chan bool, 100)
+		dialed    = make(map[enode.ID]*enode.Node)
+		check     = time.NewTicker(masternodeDialCheck)
+	)
+	defer check.Stop()
+
+	setPeriod <- masternodeFastDiscPeriod
+	go self.srvr.DiscV5.SearchTopic(topic, setPeriod, found, lookups)
+
+	for {
+		select {
+		case n := <-found:
+			if len(dialed) >= masternodePeers {
+				break
+			}
+			pubkey, err := n.ID.Pubkey()
+			if err != nil {
+				break
+			}
+			node := enode.NewV4(pubkey, n.IP, int(n.TCP), int(n.UDP))
+			if node.ID() == self.srvr.Self().ID() {
+				break
+			}
+			if _, ok := dialed[node.ID()]; ok {
+				break
+			}
+			log.Debug("Discovered masternode", "enode", node)
+			dialed[node.ID()] = node
+			self.srvr.AddPeer(node)
+
+			if len(dialed) == masternodePeers {
+				select {
+				case setPeriod <- masternodeSlowDiscPeriod:
+				default:
+				}
+			}
+
+		case <-lookups:
+
+		case <-check.C:
+			// Release the masternodes which went away, making room for others
+			for id, node := range dialed {
+				if self.eth.protocolManager.peers.Peer(fmt.Sprintf("%x", id.Bytes()[:8])) == nil {
+					self.srvr.RemovePeer(node)
+					delete(dialed, id)
+				}
+			}
+			if len(dialed) < masternodePeers {
+				select {
+				case setPeriod <- masternodeFastDiscPeriod:
+				default:
+				}
+			}
+
+		case <-self.quit:
+			close(setPeriod)
+			for _, node := range dialed {
+				self.srvr.RemovePeer(node)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// masternodeResolveInterval is the interval between two resolutions of the DNS
+// names masternodes are announced at.
+const masternodeResolveInterval = 5 * time.Minute
+
+// SetHost configures the DNS name the local masternode is announced at instead
+// of its IP address, an empty name announcing the IP address.
+func (self *MasternodeManager) SetHost(host string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.host = host
+}
+
+// localENode returns the enode URL the local masternode is announced at, the
+// active endpoint if it rotates among several.
+func (self *MasternodeManager) localENode() string {
+	if _, active := self.localEndpoints(); active != "" {
+		return active
+	}
+	self.mu.RLock()
+	host := self.host
+	self.mu.RUnlock()
+
+	local := self.srvr.Self()
+	if host == "" {
+		return local.String()
+	}
+	named := masternode.NamedENode(local, host)
+	if _, _, err := masternode.ParseENode(named); err != nil {
+		log.Warn("Invalid masternode host, announcing IP address", "host", host, "err", err)
+		return local.String()
+	}
+	return named
+}
+
+// resolvedNode returns the enode a masternode announced by DNS name last
+// resolved to, nil if it isn't announced by name or wasn't resolved yet.
+func (self *MasternodeManager) resolvedNode(id string) *enode.Node {
+	self.resolveLock.RLock()
+	defer self.resolveLock.RUnlock()
+
+	return self.resolved[id]
+}
+
+// resolveMasternodes periodically resolves the DNS names of the masternodes
+// announced by name, so the ones on dynamic IPs are redialed at their current
+// address. Newly announced names are resolved right away.
+func (self *MasternodeManager) resolveMasternodes() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			self.resolveNames(true)
+			timer.Reset(masternodeResolveInterval)
+
+		case <-self.resolveCh:
+			self.resolveNames(false)
+
+		case <-self.quit:
+			return
+		}
+	}
+}
+
+// resolveNames resolves the DNS names of the announced masternodes, all of them
+// or only the ones not resolved yet, and drops the resolutions of masternodes
+// no longer announced by name.
+func (self *MasternodeManager) resolveNames(all bool) {
+	named := make(map[string]string)
+	for _, ann := range self.Announcements() {
+		if node, host, err := masternode.ParseENode(ann.ENode); err == nil && host != "" {
+			named[masternodeNodeID(node)] = ann.ENode
+		}
+	}
+	self.mu.RLock()
+	local := self.ID
+	self.mu.RUnlock()
+
+	resolved := make(map[string]*enode.Node)
+	for id, url := range named {
+		old := self.resolvedNode(id)
+		if id == local || (!all && old != nil) {
+			resolved[id] = old
+			continue
+		}
+		node, err := masternode.ResolveENode(url)
+		if err != nil {
+			log.Debug("Failed to resolve masternode", "id", id, "enode", url, "err", err)
+			resolved[id] = old // Keep the last known address
+			continue
+		}
+		if old != nil && (!old.IP().Equal(node.IP()) || old.TCP() != node.TCP()) {
+			log.Info("Masternode address changed", "id", id, "old", old.IP(), "new", node.IP())
+		}
+		resolved[id] = node
+	}
+	self.resolveLock.Lock()
+	self.resolved = resolved
+	self.resolveLock.Unlock()
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SetGeoIP loads the ip2asn database the masternode counts are broken down by
+// country and autonomous system with, an empty path disabling the breakdown.
+func (self *MasternodeManager) SetGeoIP(path string) error {
+	var table *geoIPTable
+	if path != "" {
+		var err error
+		if table, err = loadGeoIP(path); err != nil {
+			return err
+		}
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.geoip = table
+	return nil
+}
+
+// geoIPRange is an address range announced by a single autonomous system.
+type geoIPRange struct {
+	start, end net.IP // Inclusive bounds, in 16 byte form
+	country    string
+	asn        string
+}
+
+// geoIPTable maps IP addresses to their country and autonomous system, loaded
+// from an ip2asn database (tab separated range start, range end, AS number,
+// country code and AS description).
+type geoIPTable struct {
+	ranges []geoIPRange // Sorted by range start
+}
+
+// loadGeoIP reads an ip2asn database from the given file.
+func loadGeoIP(path string) (*geoIPTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table := new(geoIPTable)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("%s:%d: invalid address range", path, line)
+		}
+		if fields[2] == "0" {
+			continue // Not routed
+		}
+		table.ranges = append(table.ranges, geoIPRange{
+			start:   start.To16(),
+			end:     end.To16(),
+			country: fields[3],
+			asn:     "AS" + fields[2],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(table.ranges, func(i, j int) bool {
+		return bytes.Compare(table.ranges[i].start, table.ranges[j].start) < 0
+	})
+	return table, nil
+}
+
+// lookup returns the country and autonomous system of the address, or empty
+// strings if it isn't in any known range.
+func (t *geoIPTable) lookup(ip net.IP) (string, string) {
+	ip = ip.To16()
+	if ip == nil {
+		return "", ""
+	}
+	i := sort.Search(len(t.ranges), func(i int) bool {
+		return bytes.Compare(t.ranges[i].start, ip) > 0
+	})
+	if i == 0 || bytes.Compare(t.ranges[i-1].end, ip) < 0 {
+		return "", ""
+	}
+	return t.ranges[i-1].country, t.ranges[i-1].asn
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+// maxDepartures is the number of departure hashes remembered not to relay the
+// same departure twice.
+const maxDepartures = 256
+
+// handover hands block production over gracefully when a sealing masternode host
+// shuts down: the block being sealed is finished if still within its slot, and
+// the departure is gossiped for the standby hosts to take over right away.
+func (s *Ethereum) handover() {
+	if !s.IsMining() || s.standbyFenced() {
+		return
+	}
+	if engine, ok := s.engine.(*devote.Devote); !ok || engine.Observer() {
+		return
+	}
+	log.Info("Handing block production over")
+	s.miner.Finish()
+	s.StopMining()
+
+	s.masternodeManager.depart()
+}
+
+// depart signs the departure of the local masternode and sends it to the peers.
+func (self *MasternodeManager) depart() {
+	number := self.eth.blockchain.CurrentBlock().Number()
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		log.Warn("Masternode contract unavailable", "err", err)
+		return
+	}
+	node, err := masternode.GetMasternode(caller, self.srvr.Self().X8(), number)
+	if err != nil || node == nil {
+		log.Debug("Local node not registered, skipping departure", "err", err)
+		return
+	}
+	d := &masternode.Departure{Time: uint64(time.Now().Unix())}
+
+	self.mu.RLock()
+	d.Signature, err = self.signHash(d.SigHash(self.network).Bytes())
+	self.mu.RUnlock()
+	if err != nil {
+		log.Warn("Failed to sign masternode departure", "err", err)
+		return
+	}
+	self.departures.Add(d.Hash(), struct{}{})
+	sent := self.eth.protocolManager.BroadcastDeparture(d)
+	log.Info("Announced masternode departure", "id", self.ID, "peers", sent)
+}
+
+// AddDeparture verifies the departure of a masternode against the contract at
+// the current head, reporting whether it wasn't seen yet and should be relayed.
+// A departure of the local masternode makes a standby host take over.
+func (self *MasternodeManager) AddDeparture(d *masternode.Departure) (bool, error) {
+	number := self.eth.blockchain.CurrentBlock().Number()
+	caller, err := self.contracts.caller(number)
+	if err != nil {
+		return false, err
+	}
+	id, err := d.Verify(caller, self.network, number, uint64(time.Now().Unix()))
+	if err != nil {
+		return false, err
+	}
+	if seen, _ := self.departures.ContainsOrAdd(d.Hash(), struct{}{}); seen {
+		return false, nil
+	}
+	log.Debug("Masternode departing", "id", id)
+
+	self.mu.RLock()
+	local := self.ID
+	self.mu.RUnlock()
+	if id == local && self.eth.standby != nil {
+		self.eth.standby.departed()
+	}
+	return true, nil
+}
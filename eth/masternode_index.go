@@ -0,0 +1,290 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+)
+
+// masternodeIndex is the set of masternodes registered at a given block,
+// indexed by their state. Walking the contract's linked list costs a call per
+// masternode, so the index is built once per block and shared by all requests.
+type masternodeIndex struct {
+	hash    common.Hash // Block the index was built at
+	number  uint64
+	nodes   []*masternode.Masternode
+	byState map[int][]*masternode.Masternode
+}
+
+func newMasternodeIndex(hash common.Hash, number uint64, nodes []*masternode.Masternode) *masternodeIndex {
+	index := &masternodeIndex{
+		hash:    hash,
+		number:  number,
+		nodes:   nodes,
+		byState: make(map[int][]*masternode.Masternode),
+	}
+	for _, node := range nodes {
+		index.byState[node.State] = append(index.byState[node.State], node)
+	}
+	return index
+}
+
+// filter returns the masternodes in the given state, or all of them if state
+// is negative.
+func (index *masternodeIndex) filter(state int) []*masternode.Masternode {
+	if state < 0 {
+		return index.nodes
+	}
+	return index.byState[state]
+}
+
+// masternodeIndex returns the index of the masternodes registered at the current
+// head, rebuilding it if the chain moved on since it was last requested.
+func (self *MasternodeManager) masternodeIndex() (*masternodeIndex, error) {
+	head := self.eth.blockchain.CurrentBlock()
+
+	self.indexLock.Lock()
+	defer self.indexLock.Unlock()
+
+	if self.index != nil && self.index.hash == head.Hash() {
+		return self.index, nil
+	}
+	nodes, err := self.Masternodes(head.Number())
+	if err != nil {
+		return nil, err
+	}
+	self.index = newMasternodeIndex(head.Hash(), head.NumberU64(), nodes)
+	return self.index, nil
+}
+
+// Count returns the number of masternodes in the given state registered at the
+// current head, or all of them if state is negative.
+func (self *MasternodeManager) Count(state int) (int, error) {
+	index, err := self.masternodeIndex()
+	if err != nil {
+		return 0, err
+	}
+	return len(index.filter(state)), nil
+}
+
+// MasternodeCounts is the breakdown of the masternodes registered at a block.
+type MasternodeCounts struct {
+	Number     hexutil.Uint64 `json:"number"`              // Block the masternodes were counted at
+	Total      int            `json:"total"`               // Masternodes registered at the block
+	ByState    map[string]int `json:"byState"`             // Masternodes by state in the contract
+	ByProtocol map[string]int `json:"byProtocol"`          // Masternodes by announced protocol version
+	ByCountry  map[string]int `json:"byCountry,omitempty"` // Masternodes by country, if a GeoIP database is set
+	ByASN      map[string]int `json:"byASN,omitempty"`     // Masternodes by autonomous system, if a GeoIP database is set
+}
+
+// Counts returns the breakdown of the masternodes registered at the current head
+// by state and by the protocol version they announce. Masternodes which weren't
+// announced are counted as "unknown". If a GeoIP database is configured, they're
+// also broken down by the country and autonomous system of their address.
+func (self *MasternodeManager) Counts() (*MasternodeCounts, error) {
+	index, err := self.masternodeIndex()
+	if err != nil {
+		return nil, err
+	}
+	self.mu.RLock()
+	geoip := self.geoip
+	self.mu.RUnlock()
+
+	// Collect the protocol versions and addresses of the announced masternodes
+	protocols := make(map[string]uint32)
+	addresses := make(map[string]net.IP)
+	for _, ann := range self.Announcements() {
+		node, host, err := masternode.ParseENode(ann.ENode)
+		if err != nil {
+			continue
+		}
+		id := masternodeNodeID(node)
+		protocols[id] = ann.Protocol
+		if host != "" {
+			if node = self.resolvedNode(id); node == nil {
+				continue
+			}
+		}
+		addresses[id] = node.IP()
+	}
+	counts := &MasternodeCounts{
+		Number:     hexutil.Uint64(index.number),
+		Total:      len(index.nodes),
+		ByState:    make(map[string]int),
+		ByProtocol: make(map[string]int),
+	}
+	if geoip != nil {
+		counts.ByCountry, counts.ByASN = make(map[string]int), make(map[string]int)
+	}
+	for _, node := range index.nodes {
+		counts.ByState[masternode.StatusName(node.State)]++
+
+		if protocol, ok := protocols[node.ID]; ok {
+			counts.ByProtocol[strconv.FormatUint(uint64(protocol), 10)]++
+		} else {
+			counts.ByProtocol["unknown"]++
+		}
+		if geoip == nil {
+			continue
+		}
+		country, asn := "", ""
+		if ip, ok := addresses[node.ID]; ok {
+			country, asn = geoip.lookup(ip)
+		}
+		if country == "" {
+			country = "unknown"
+		}
+		if asn == "" {
+			asn = "unknown"
+		}
+		counts.ByCountry[country]++
+		counts.ByASN[asn]++
+	}
+	return counts, nil
+}
+
+// LocalState returns the state of the local masternode at the current head, or
+// false if it isn't registered.
+func (self *MasternodeManager) LocalState() (int, bool) {
+	self.mu.RLock()
+	id := self.ID
+	self.mu.RUnlock()
+
+	if id == "" {
+		return 0, false
+	}
+	index, err := self.masternodeIndex()
+	if err != nil {
+		return 0, false
+	}
+	for _, node := range index.nodes {
+		if node.ID == id {
+			return node.State, true
+		}
+	}
+	return 0, false
+}
+
+// MasternodeResync is the difference between the cached masternode set and the
+// one re-read from the contract by a resync.
+type MasternodeResync struct {
+	Number  hexutil.Uint64 `json:"number"`  // Block the contract was read at
+	Total   int            `json:"total"`   // Masternodes registered after the resync
+	Added   []string       `json:"added"`   // Masternodes missing from the cached set
+	Removed []string       `json:"removed"` // Masternodes no longer registered
+	Changed []string       `json:"changed"` // Masternodes whose state or enode changed
+}
+
+// diffMasternodes reports how the registered masternodes changed from the cached
+// ones, sorting the ids of every change. Without a cached set, as before the
+// first index is built, there is nothing to compare against and no change is
+// reported.
+func diffMasternodes(cached map[string]*masternode.Masternode, nodes []*masternode.Masternode) *MasternodeResync {
+	result := &MasternodeResync{
+		Total:   len(nodes),
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	if cached == nil {
+		return result
+	}
+	current := make(map[string]*masternode.Masternode)
+	for _, node := range nodes {
+		current[node.ID] = node
+
+		old, ok := cached[node.ID]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, node.ID)
+		case old.State != node.State || !bytes.Equal(old.NodeID[:], node.NodeID[:]):
+			result.Changed = append(result.Changed, node.ID)
+		}
+	}
+	for id := range cached {
+		if _, ok := current[id]; !ok {
+			result.Removed = append(result.Removed, id)
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Changed)
+	return result
+}
+
+// Resync drops the cached masternode set, re-reads the linked list of the
+// contract at the current head and reports how the set changed since the last
+// index, if any. The local registration flag is refreshed, and if the local
+// node is a masternode, the connections to registered masternodes are rebuilt
+// from their enodes.
+func (self *MasternodeManager) Resync() (*MasternodeResync, error) {
+	head := self.eth.blockchain.CurrentBlock()
+
+	self.indexLock.Lock()
+	defer self.indexLock.Unlock()
+
+	var cached map[string]*masternode.Masternode
+	if self.index != nil {
+		cached = make(map[string]*masternode.Masternode)
+		for _, node := range self.index.nodes {
+			cached[node.ID] = node
+		}
+	}
+	self.index = nil
+
+	nodes, err := self.Masternodes(head.Number())
+	if err != nil {
+		return nil, err
+	}
+	self.index = newMasternodeIndex(head.Hash(), head.NumberU64(), nodes)
+
+	result := diffMasternodes(cached, nodes)
+	result.Number = hexutil.Uint64(head.NumberU64())
+
+	// Refresh the local activation, which is otherwise only evaluated periodically
+	self.mu.RLock()
+	id := self.ID
+	self.mu.RUnlock()
+
+	if self.srvr != nil {
+		self.checkActivation()
+	}
+	if self.srvr != nil && atomic.LoadUint32(&self.IsMasternode) == 1 {
+		for _, removed := range result.Removed {
+			if node := cached[removed]; node.ENode != nil {
+				self.srvr.RemovePeer(node.ENode)
+			}
+		}
+		for _, node := range nodes {
+			if node.ID != id && node.ENode != nil {
+				self.srvr.AddPeer(node.ENode)
+			}
+		}
+	}
+	log.Info("Resynced masternodes from contract", "number", head.Number(), "total", result.Total,
+		"added", len(result.Added), "removed", len(result.Removed), "changed", len(result.Changed))
+	return result, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+)
+
+// Tests that a resync reports the sorted changes against the cached masternodes,
+// and none without a cached set to compare against.
+func TestDiffMasternodes(t *testing.T) {
+	node := func(id string, state int) *masternode.Masternode {
+		return &masternode.Masternode{ID: id, State: state}
+	}
+	nodes := []*masternode.Masternode{
+		node("e", masternode.MasternodeEnable),
+		node("b", masternode.MasternodeEnable),
+		node("d", masternode.MasternodeExpired),
+		node("a", masternode.MasternodeEnable),
+	}
+	if diff := diffMasternodes(nil, nodes); diff.Total != 4 || len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+		t.Errorf("changes reported without a cached set: %+v", diff)
+	}
+	cached := map[string]*masternode.Masternode{
+		"a": node("a", masternode.MasternodeEnable),
+		"c": node("c", masternode.MasternodeEnable),
+		"d": node("d", masternode.MasternodeEnable),
+		"f": node("f", masternode.MasternodeEnable),
+	}
+	diff := diffMasternodes(cached, nodes)
+	if want := []string{"b", "e"}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("added mismatch: have %v, want %v", diff.Added, want)
+	}
+	if want := []string{"c", "f"}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("removed mismatch: have %v, want %v", diff.Removed, want)
+	}
+	if want := []string{"d"}; !reflect.DeepEqual(diff.Changed, want) {
+		t.Errorf("changed mismatch: have %v, want %v", diff.Changed, want)
+	}
+}
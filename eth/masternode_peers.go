@@ -0,0 +1,244 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+const (
+	masternodeReconnectCycle = 5 * time.Second  // Interval to check the connections to the masternodes
+	masternodeDialTimeout    = 15 * time.Second // Time a dial is given to connect before backing off
+	masternodeMinBackoff     = 5 * time.Second  // Delay before redialing a masternode the first time
+	masternodeMaxBackoff     = 10 * time.Minute // Maximum delay between two dials of a masternode
+)
+
+// masternodePeer tracks the connection to a single masternode.
+type masternodePeer struct {
+	node      *enode.Node
+	connected bool
+	seen      bool          // Whether the masternode was ever connected, persisting it
+	dialed    time.Time     // Time the pending dial started, zero if none
+	backoff   time.Duration // Delay before the next dial after a failure
+	next      time.Time     // Earliest time of the next dial
+}
+
+// MasternodePeerInfo is the connection status of a masternode, as reported by
+// admin_masternodePeers.
+type MasternodePeerInfo struct {
+	ID         string `json:"id"`         // Masternode id
+	Enode      string `json:"enode"`      // Enode URL the masternode is dialed at
+	Registered bool   `json:"registered"` // Whether the masternode is registered at the current head
+	Connected  bool   `json:"connected"`  // Whether the masternode is currently connected
+	Backoff    string `json:"backoff"`    // Current redial delay, empty while connected
+}
+
+// masternodeNodeID returns the masternode id of a node, the first 8 bytes of the
+// X coordinate of its public key.
+func masternodeNodeID(node *enode.Node) string {
+	return fmt.Sprintf("%x", crypto.FromECDSAPub(node.Pubkey())[1:9])
+}
+
+// connectedTo reports whether the node is connected as an eth peer.
+func (self *MasternodeManager) connectedTo(node *enode.Node) bool {
+	return self.eth.protocolManager.peers.Peer(fmt.Sprintf("%x", node.ID().Bytes()[:8])) != nil
+}
+
+// sameEndpoint reports whether the two nodes are dialed at the same address.
+func sameEndpoint(a, b *enode.Node) bool {
+	return a.IP().Equal(b.IP()) && a.TCP() == b.TCP()
+}
+
+// registeredMasternodes returns the enodes of the masternodes registered at the
+// current head, keyed by masternode id, the local one excluded.
+func (self *MasternodeManager) registeredMasternodes() map[string]*enode.Node {
+	registered := make(map[string]*enode.Node)
+
+	index, err := self.masternodeIndex()
+	if err != nil {
+		return registered
+	}
+	self.mu.RLock()
+	local := self.ID
+	self.mu.RUnlock()
+
+	for _, node := range index.nodes {
+		if node.ID == local || node.ENode == nil {
+			continue
+		}
+		// The contract only knows the key, prefer the endpoint a rotating node
+		// is active at, then the address resolved by name
+		if active := self.activeEndpoint(node.ID); active != nil {
+			registered[node.ID] = active
+		} else if resolved := self.resolvedNode(node.ID); resolved != nil {
+			registered[node.ID] = resolved
+		} else {
+			registered[node.ID] = node.ENode
+		}
+	}
+	return registered
+}
+
+// reconnectMasternodes keeps the local masternode connected to the registered
+// masternodes and to the ones it was connected to before, redialing them with
+// exponential backoff. Masternodes connected to are persisted, so they can be
+// dialed right after a restart, even before the chain is synced.
+func (self *MasternodeManager) reconnectMasternodes() {
+	self.peersLock.Lock()
+	for _, url := range rawdb.ReadMasternodePeers(self.eth.chainDb) {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			log.Warn("Dropping invalid masternode peer", "enode", url, "err", err)
+			continue
+		}
+		self.peers[masternodeNodeID(node)] = &masternodePeer{node: node, seen: true, backoff: masternodeMinBackoff}
+	}
+	self.peersLock.Unlock()
+
+	ticker := time.NewTicker(masternodeReconnectCycle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if atomic.LoadUint32(&self.IsMasternode) == 1 {
+				self.checkMasternodePeers(time.Now())
+			}
+		case <-self.quit:
+			return
+		}
+	}
+}
+
+// checkMasternodePeers updates the connection status of the masternodes, dials
+// the disconnected ones which are due and persists the connected ones.
+func (self *MasternodeManager) checkMasternodePeers(now time.Time) {
+	registered := self.registeredMasternodes()
+
+	self.peersLock.Lock()
+	defer self.peersLock.Unlock()
+
+	changed := false
+	for id, node := range registered {
+		peer, ok := self.peers[id]
+		if ok && peer.node.ID() == node.ID() {
+			if node.IP() == nil || sameEndpoint(peer.node, node) {
+				continue
+			}
+			// Address re-resolved, dial the new one once disconnected
+			if !peer.connected {
+				self.srvr.RemovePeer(peer.node)
+				peer.dialed, peer.next = time.Time{}, now
+			}
+			peer.node = node
+			changed = changed || peer.seen
+			continue
+		}
+		if ok {
+			self.srvr.RemovePeer(peer.node) // Enode changed in the contract
+		}
+		self.peers[id] = &masternodePeer{node: node, backoff: masternodeMinBackoff}
+	}
+	for id, peer := range self.peers {
+		_, isRegistered := registered[id]
+		connected := self.connectedTo(peer.node)
+
+		switch {
+		case connected:
+			if !peer.connected {
+				log.Debug("Connected to masternode", "id", id)
+				peer.connected, peer.dialed, peer.backoff = true, time.Time{}, masternodeMinBackoff
+				if !peer.seen {
+					peer.seen, changed = true, true
+				}
+			}
+
+		case peer.connected:
+			// Stop the static redials of the server, we redial with backoff
+			log.Debug("Disconnected from masternode", "id", id)
+			self.srvr.RemovePeer(peer.node)
+			peer.connected, peer.next = false, now.Add(peer.backoff)
+
+		case !peer.dialed.IsZero():
+			if now.Sub(peer.dialed) < masternodeDialTimeout {
+				break
+			}
+			self.srvr.RemovePeer(peer.node)
+			peer.dialed, peer.next = time.Time{}, now.Add(peer.backoff)
+			if peer.backoff *= 2; peer.backoff > masternodeMaxBackoff {
+				peer.backoff = masternodeMaxBackoff
+			}
+			// Forget masternodes no longer registered once the chain is synced
+			if !isRegistered && atomic.LoadInt32(&self.syncing) == 0 && len(registered) > 0 {
+				delete(self.peers, id)
+				changed = changed || peer.seen
+			}
+
+		case !now.Before(peer.next):
+			peer.dialed = now
+			self.srvr.AddPeer(peer.node)
+		}
+	}
+	if changed {
+		var enodes []string
+		for _, peer := range self.peers {
+			if peer.seen {
+				enodes = append(enodes, peer.node.String())
+			}
+		}
+		sort.Strings(enodes)
+		rawdb.WriteMasternodePeers(self.eth.chainDb, enodes)
+	}
+}
+
+// MasternodePeers returns the connection status of the registered masternodes
+// and of the ones connected to before.
+func (self *MasternodeManager) MasternodePeers() []*MasternodePeerInfo {
+	registered := self.registeredMasternodes()
+
+	self.peersLock.Lock()
+	defer self.peersLock.Unlock()
+
+	infos := make([]*MasternodePeerInfo, 0, len(registered))
+	for id, node := range registered {
+		info := &MasternodePeerInfo{ID: id, Enode: node.String(), Registered: true, Connected: self.connectedTo(node)}
+		if peer, ok := self.peers[id]; ok && peer.node.ID() == node.ID() && !info.Connected {
+			info.Backoff = peer.backoff.String()
+		}
+		infos = append(infos, info)
+	}
+	for id, peer := range self.peers {
+		if _, ok := registered[id]; ok {
+			continue
+		}
+		info := &MasternodePeerInfo{ID: id, Enode: peer.node.String(), Connected: self.connectedTo(peer.node)}
+		if !info.Connected {
+			info.Backoff = peer.backoff.String()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
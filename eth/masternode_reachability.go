@@ -0,0 +1,141 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p"
+)
+
+const (
+	reachabilityInterval    = 10 * time.Minute // Interval between two reachability self-tests
+	reachabilityPeers       = 3                // Number of peers asked to dial back per self-test
+	reachabilityDialTimeout = 5 * time.Second  // Time a peer waits for the dial back to connect
+	reachabilityServeLimit  = time.Minute      // Minimum time between two dial backs served to a peer
+)
+
+// Reachability states of the local masternode, as reported by masternode_status.
+const (
+	reachabilityUnknown     = "unknown"
+	reachabilityReachable   = "reachable"
+	reachabilityUnreachable = "unreachable"
+)
+
+// reachability is the outcome of the reachability self-tests of the masternode.
+type reachability struct {
+	pending map[uint64]string // Dial back requests in flight, mapped to the peer asked
+	state   string
+	checked time.Time // Time of the last dial back result
+	lock    sync.Mutex
+}
+
+// checkReachability asks a few masternode peers to dial back the advertised
+// listening port of the local node, so a masternode stuck behind a NAT without
+// port mapping is noticed.
+func (self *MasternodeManager) checkReachability() {
+	if atomic.LoadUint32(&self.IsMasternode) == 0 && !self.srvr.IsMasternode {
+		return
+	}
+	port := self.srvr.Self().TCP()
+	if port == 0 {
+		return
+	}
+	var candidates []*peer
+	for _, p := range self.eth.protocolManager.peers.Peers() {
+		if p.masternodeCapable() && p.mnVersion >= masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_3 {
+			candidates = append(candidates, p)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	if len(candidates) > reachabilityPeers {
+		candidates = candidates[:reachabilityPeers]
+	}
+	self.reach.lock.Lock()
+	defer self.reach.lock.Unlock()
+
+	self.reach.pending = make(map[uint64]string)
+	for _, p := range candidates {
+		id := rand.Uint64()
+		if err := p2p.Send(p.rw, ReachabilityCheckMsg, &reachabilityCheck{ID: id, Port: uint16(port)}); err != nil {
+			continue
+		}
+		self.reach.pending[id] = p.id
+	}
+}
+
+// reachabilityResult records the outcome of a dial back requested from a peer.
+// A single successful dial back proves the node reachable, while it's only
+// considered unreachable if none of the asked peers succeeded.
+func (self *MasternodeManager) reachabilityResult(peer string, res *reachabilityResult) {
+	self.reach.lock.Lock()
+	defer self.reach.lock.Unlock()
+
+	if asked, ok := self.reach.pending[res.ID]; !ok || asked != peer {
+		return
+	}
+	delete(self.reach.pending, res.ID)
+
+	switch {
+	case res.Reachable:
+		self.reach.state = reachabilityReachable
+		self.reach.pending = nil
+	case len(self.reach.pending) == 0:
+		if self.reach.state != reachabilityUnreachable {
+			log.Warn("Masternode unreachable behind NAT, peers failed to dial back", "port", self.srvr.Self().TCP())
+		}
+		self.reach.state = reachabilityUnreachable
+	default:
+		return // Wait for the other peers
+	}
+	self.reach.checked = time.Now()
+}
+
+// Reachability returns the outcome of the last reachability self-test and the
+// time it completed at.
+func (self *MasternodeManager) Reachability() (string, time.Time) {
+	self.reach.lock.Lock()
+	defer self.reach.lock.Unlock()
+
+	if self.reach.state == "" {
+		return reachabilityUnknown, time.Time{}
+	}
+	return self.reach.state, self.reach.checked
+}
+
+// dialBack serves a reachability check of a peer, dialing the requested port at
+// the address the peer connected from and reporting the outcome. Only the peer's
+// own address is dialed, so the check can't be abused to probe other hosts.
+func (pm *ProtocolManager) dialBack(p *peer, req *reachabilityCheck) {
+	addr, ok := p.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return
+	}
+	target := &net.TCPAddr{IP: addr.IP, Port: int(req.Port)}
+	conn, err := net.DialTimeout("tcp", target.String(), reachabilityDialTimeout)
+	if err == nil {
+		conn.Close()
+	}
+	p.Log().Trace("Served reachability check", "addr", target, "reachable", err == nil)
+	p2p.Send(p.rw, ReachabilityResultMsg, &reachabilityResult{ID: req.ID, Reachable: err == nil})
+}
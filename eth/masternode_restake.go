@@ -0,0 +1,335 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/etherzero/go-etherzero/rlp"
+)
+
+const (
+	restakeCooldown   = 24 * time.Hour // Minimum time between two collateral transfers
+	restakeCheckEvery = 100            // Number of blocks between two balance checks
+)
+
+// restakeEntry is a transaction sent by the restaker, as kept in its journal.
+type restakeEntry struct {
+	Time     uint64         // Unix time the transaction was sent at
+	Target   string         // Enode URL of the node funded or registered
+	Funder   common.Address // Funding account registering the target
+	Register bool           // Whether the transaction registers the target, or funds it
+	Tx       common.Hash    // Transaction sent
+	Balance  *big.Int       // Balance of the sending account before the transaction
+}
+
+// masternodeRestaker compounds the masternode rewards paid to a payout account.
+//
+// The masternode contract lets every account own a single masternode, and pays
+// the rewards to the account owning it, so the payout account can't register
+// another one itself. Instead every target node key is paired with a fresh
+// funding account: whenever the rewards cover the collateral on top of a
+// reserve, it's forwarded to the funding account of the next target, which
+// then registers the target and receives its rewards. The payout and funding
+// accounts must be unlocked, the restaker never handles their passwords.
+// Transactions are journaled to disk and at most one transfer is sent per
+// restakeCooldown.
+type masternodeRestaker struct {
+	mm      *MasternodeManager
+	account common.Address
+	targets []*enode.Node
+	funders []common.Address // Funding account registering the target of the same index
+	reserve *big.Int         // Balance always left on the payout account
+	journal string           // Path of the journal of sent transactions
+
+	backend    bind.ContractTransactor                          // Backend the collateral transfers are sent through
+	contract   func() (*contract.Contract, error)               // Masternode contract in effect at the head
+	balance    func(common.Address) *big.Int                    // Pending balance of an account
+	transactor func(common.Address) (*bind.TransactOpts, error) // Signer of the transactions of an account
+
+	last time.Time         // Time of the last collateral transfer sent
+	done map[enode.ID]bool // Targets already registered, or given up on
+
+	quit chan struct{}
+}
+
+// newMasternodeRestaker creates the restaker of the given payout account,
+// loading its journal. Each target is registered by the funding account of the
+// same index.
+func newMasternodeRestaker(account common.Address, targets []string, funders []common.Address, reserve *big.Int, journal string) (*masternodeRestaker, error) {
+	if len(funders) != len(targets) {
+		return nil, errors.New("every restake target needs a funding account")
+	}
+	r := &masternodeRestaker{
+		account: account,
+		funders: funders,
+		reserve: reserve,
+		journal: journal,
+		done:    make(map[enode.ID]bool),
+		quit:    make(chan struct{}),
+	}
+	seen := map[common.Address]bool{account: true}
+	for _, funder := range funders {
+		if seen[funder] {
+			return nil, errors.New("restake funding accounts must be distinct from each other and the payout account")
+		}
+		seen[funder] = true
+	}
+	for _, url := range targets {
+		node, err := enode.ParseV4(url)
+		if err != nil {
+			return nil, err
+		}
+		r.targets = append(r.targets, node)
+	}
+	entries, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.Register {
+			if t := time.Unix(int64(entry.Time), 0); t.After(r.last) {
+				r.last = t
+			}
+			continue
+		}
+		if node, err := enode.ParseV4(entry.Target); err == nil {
+			r.done[node.ID()] = true
+		}
+	}
+	return r, nil
+}
+
+// load reads the transactions sent so far from the journal.
+func (r *masternodeRestaker) load() ([]*restakeEntry, error) {
+	file, err := os.Open(r.journal)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		stream  = rlp.NewStream(file, 0)
+		entries []*restakeEntry
+	)
+	for {
+		entry := new(restakeEntry)
+		if err := stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				log.Warn("Truncated masternode restake journal", "err", err)
+			}
+			return entries, nil
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// record appends a sent transaction to the journal.
+func (r *masternodeRestaker) record(entry *restakeEntry) error {
+	file, err := os.OpenFile(r.journal, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return rlp.Encode(file, entry)
+}
+
+// loop checks the payout account every restakeCheckEvery blocks, unless the
+// node is syncing.
+func (r *masternodeRestaker) loop() {
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := r.mm.eth.blockchain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-heads:
+			if head.Block.NumberU64()%restakeCheckEvery == 0 && atomic.LoadInt32(&r.mm.syncing) == 0 {
+				r.check()
+			}
+		case <-sub.Err():
+			return
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// check advances the compounding of the next target: it registers the target
+// if its funding account holds the collateral, or else forwards the collateral
+// to it if the rewards on the payout account cover it and the safety limits
+// allow it.
+func (r *masternodeRestaker) check() {
+	current, err := r.contract()
+	if err != nil {
+		return
+	}
+	// Pick the first target which isn't registered yet
+	index := -1
+	for i, node := range r.targets {
+		if r.done[node.ID()] {
+			continue
+		}
+		if registered, err := current.Has(nil, node.X8()); err != nil {
+			return
+		} else if registered {
+			r.done[node.ID()] = true
+			continue
+		}
+		index = i
+		break
+	}
+	if index < 0 {
+		return
+	}
+	target, funder := r.targets[index], r.funders[index]
+
+	// A funding account owning a masternode already can't register another one
+	if id, err := current.GetId(nil, funder); err != nil {
+		return
+	} else if id != ([8]byte{}) {
+		log.Error("Masternode restake funding account owns a masternode, skipping target", "account", funder, "target", target.ID())
+		r.done[target.ID()] = true
+		return
+	}
+	collateral, err := current.EtzPerNode(nil)
+	if err != nil {
+		return
+	}
+	funds := r.balance(funder)
+	if funds.Cmp(collateral) >= 0 {
+		r.register(current, target, funder, collateral, funds)
+		return
+	}
+	if time.Since(r.last) < restakeCooldown {
+		return
+	}
+	missing := new(big.Int).Sub(collateral, funds)
+	balance := r.balance(r.account)
+	if balance.Cmp(new(big.Int).Add(missing, r.reserve)) < 0 {
+		return
+	}
+	r.fund(target, funder, missing, balance)
+}
+
+// fund forwards the missing collateral of the target from the payout account to
+// its funding account.
+func (r *masternodeRestaker) fund(target *enode.Node, funder common.Address, amount, balance *big.Int) {
+	opts, err := r.transactor(r.account)
+	if err != nil {
+		log.Warn("Masternode restake account unavailable", "account", r.account, "err", err)
+		return
+	}
+	ctx := context.Background()
+	nonce, err := r.backend.PendingNonceAt(ctx, r.account)
+	if err != nil {
+		return
+	}
+	gasPrice, err := r.backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return
+	}
+	tx, err := opts.Signer(types.HomesteadSigner{}, r.account, types.NewTransaction(nonce, funder, amount, params.TxGas, gasPrice, nil))
+	if err != nil {
+		log.Warn("Failed to sign masternode restake transfer", "account", r.account, "err", err)
+		return
+	}
+	if err := r.backend.SendTransaction(ctx, tx); err != nil {
+		log.Warn("Failed to forward masternode rewards", "funder", funder, "err", err)
+		return
+	}
+	r.last = time.Now()
+
+	entry := &restakeEntry{Time: uint64(r.last.Unix()), Target: target.String(), Funder: funder, Tx: tx.Hash(), Balance: balance}
+	if err := r.record(entry); err != nil {
+		log.Error("Failed to journal masternode restake", "err", err)
+	}
+	log.Info("Forwarded masternode rewards", "account", r.account, "funder", funder, "amount", amount, "tx", tx.Hash())
+}
+
+// register registers the target from its funding account, depositing the
+// collateral.
+func (r *masternodeRestaker) register(current *contract.Contract, target *enode.Node, funder common.Address, collateral, balance *big.Int) {
+	opts, err := r.transactor(funder)
+	if err != nil {
+		log.Warn("Masternode restake funding account unavailable", "account", funder, "err", err)
+		return
+	}
+	opts.Value = collateral
+
+	var id1, id2 [32]byte
+	xy := target.XY()
+	copy(id1[:], xy[:32])
+	copy(id2[:], xy[32:])
+
+	tx, err := current.Register(opts, id1, id2)
+	if err != nil {
+		log.Warn("Failed to register restaked masternode", "target", target.ID(), "err", err)
+		return
+	}
+	r.done[target.ID()] = true
+
+	entry := &restakeEntry{Time: uint64(time.Now().Unix()), Target: target.String(), Funder: funder, Register: true, Tx: tx.Hash(), Balance: balance}
+	if err := r.record(entry); err != nil {
+		log.Error("Failed to journal masternode restake", "err", err)
+	}
+	log.Info("Restaked masternode rewards", "funder", funder, "target", target.ID(), "tx", tx.Hash())
+}
+
+// SetRestake configures the compounding of the rewards paid to the given payout
+// account into registrations of the target node keys by the funding accounts of
+// the same index, keeping the reserve (in wei) on the payout account. The
+// transactions sent are journaled at the given path.
+func (self *MasternodeManager) SetRestake(account common.Address, targets []string, funders []common.Address, reserve *big.Int, journal string) error {
+	if self.Observer() {
+		return errObserverMode
+	}
+	restaker, err := newMasternodeRestaker(account, targets, funders, reserve, journal)
+	if err != nil {
+		return err
+	}
+	restaker.mm = self
+	restaker.backend = self.contracts.backend
+	restaker.contract = func() (*contract.Contract, error) {
+		return self.contracts.contract(self.eth.blockchain.CurrentBlock().Number())
+	}
+	restaker.balance = func(account common.Address) *big.Int {
+		return self.eth.txPool.State().GetBalance(account)
+	}
+	restaker.transactor = self.collateralTransactor
+
+	self.restaker = restaker
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind/backends"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/contracts/masternode/contract"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the rewards of a payout account owning a masternode are compounded
+// through fresh funding accounts, as the masternode contract refuses a second
+// registration from the payout account itself.
+func TestMasternodeRestake(t *testing.T) {
+	funds := new(big.Int).Mul(big.NewInt(100000), big.NewInt(params.Ether))
+
+	payout, _ := crypto.GenerateKey()
+	owner, _ := crypto.GenerateKey()
+	fresh, _ := crypto.GenerateKey()
+	keys := map[common.Address]*ecdsa.PrivateKey{}
+	for _, key := range []*ecdsa.PrivateKey{payout, owner, fresh} {
+		keys[crypto.PubkeyToAddress(key.PublicKey)] = key
+	}
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(payout.PublicKey): {Balance: funds},
+		crypto.PubkeyToAddress(owner.PublicKey):  {Balance: funds},
+	}
+	backend, err := backends.NewMasternodeBackend(payout, alloc, 10000000)
+	if err != nil {
+		t.Fatalf("failed to deploy masternode contract: %v", err)
+	}
+	// Both the payout account and the owner own a masternode already
+	for _, key := range []*ecdsa.PrivateKey{payout, owner} {
+		node, _ := crypto.GenerateKey()
+		if _, err := backend.Register(key, node); err != nil {
+			t.Fatalf("failed to register masternode: %v", err)
+		}
+	}
+	first, _ := crypto.GenerateKey()
+	second, _ := crypto.GenerateKey()
+	targets := []*enode.Node{
+		enode.NewV4(&first.PublicKey, net.ParseIP("127.0.0.1"), 21212, 21212),
+		enode.NewV4(&second.PublicKey, net.ParseIP("127.0.0.1"), 21213, 21213),
+	}
+	// The payout account can't register the target itself
+	opts := bind.NewKeyedTransactor(payout)
+	opts.Value, _ = backend.Contract().EtzPerNode(nil)
+	var id1, id2 [32]byte
+	xy := targets[0].XY()
+	copy(id1[:], xy[:32])
+	copy(id2[:], xy[32:])
+	if _, err := backend.Contract().Register(opts, id1, id2); err == nil {
+		t.Fatalf("second registration of the payout account accepted")
+	}
+
+	dir, err := ioutil.TempDir("", "restake-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "masternode-restake.rlp")
+
+	newRestaker := func() *masternodeRestaker {
+		r, err := newMasternodeRestaker(crypto.PubkeyToAddress(payout.PublicKey),
+			[]string{targets[0].String(), targets[1].String()},
+			[]common.Address{crypto.PubkeyToAddress(fresh.PublicKey), crypto.PubkeyToAddress(owner.PublicKey)},
+			new(big.Int), journal)
+		if err != nil {
+			t.Fatalf("failed to create restaker: %v", err)
+		}
+		r.backend = backend
+		r.contract = func() (*contract.Contract, error) { return backend.Contract(), nil }
+		r.balance = func(account common.Address) *big.Int {
+			balance, _ := backend.BalanceAt(context.Background(), account, nil)
+			return balance
+		}
+		r.transactor = func(account common.Address) (*bind.TransactOpts, error) {
+			key, ok := keys[account]
+			if !ok {
+				return nil, fmt.Errorf("unknown account %x", account)
+			}
+			return bind.NewKeyedTransactor(key), nil
+		}
+		return r
+	}
+	r := newRestaker()
+
+	// The collateral is forwarded to the funding account, which registers the
+	// target on the next check
+	r.check()
+	backend.Commit()
+	if balance := r.balance(crypto.PubkeyToAddress(fresh.PublicKey)); balance.Cmp(opts.Value) != 0 {
+		t.Fatalf("funding account balance mismatch: have %v, want %v", balance, opts.Value)
+	}
+	r.check()
+	backend.Commit()
+
+	if registered, err := backend.Contract().Has(nil, targets[0].X8()); err != nil || !registered {
+		t.Fatalf("target not registered: %v, err %v", registered, err)
+	}
+	info, err := backend.Contract().GetInfo(nil, targets[0].X8())
+	if err != nil {
+		t.Fatalf("failed to get target info: %v", err)
+	}
+	if info.Account != crypto.PubkeyToAddress(fresh.PublicKey) {
+		t.Fatalf("target owner mismatch: have %x, want %x", info.Account, crypto.PubkeyToAddress(fresh.PublicKey))
+	}
+	// The funding account of the second target owns a masternode, it's skipped
+	// without sending anything
+	before := r.balance(crypto.PubkeyToAddress(payout.PublicKey))
+	r.last = r.last.Add(-restakeCooldown)
+	r.check()
+	backend.Commit()
+	if after := r.balance(crypto.PubkeyToAddress(payout.PublicKey)); after.Cmp(before) != 0 {
+		t.Fatalf("payout balance changed for unusable funding account: have %v, want %v", after, before)
+	}
+	if !r.done[targets[1].ID()] {
+		t.Fatalf("target with unusable funding account not given up on")
+	}
+	// The journal keeps the registration and the cooldown across restarts
+	restarted := newRestaker()
+	if !restarted.done[targets[0].ID()] {
+		t.Fatalf("journaled registration lost on restart")
+	}
+	if restarted.last.IsZero() {
+		t.Fatalf("journaled transfer lost on restart")
+	}
+}
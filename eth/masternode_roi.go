@@ -0,0 +1,123 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// secondsPerYear is the length of the year the returns are annualized over.
+const secondsPerYear = 365 * 24 * 3600
+
+// errNoEnabledMasternodes is returned if the returns can't be estimated since
+// no masternode is enabled to be paid.
+var errNoEnabledMasternodes = errors.New("no enabled masternodes")
+
+// MasternodeROI is the estimated return of a masternode, assuming the enabled
+// masternodes are paid in turn by the payment queue at the current reward.
+type MasternodeROI struct {
+	Number          hexutil.Uint64 `json:"number"`          // Block the estimate is based on
+	Enabled         int            `json:"enabled"`         // Enabled masternodes sharing the payments
+	Collateral      *hexutil.Big   `json:"collateral"`      // Collateral locked by a masternode
+	Reward          *hexutil.Big   `json:"reward"`          // Masternode reward of a block, net of the treasury cut
+	PaymentInterval hexutil.Uint64 `json:"paymentInterval"` // Seconds between two payments of a masternode
+	CyclePayments   float64        `json:"cyclePayments"`   // Expected payments of a masternode per cycle
+	AnnualPayments  float64        `json:"annualPayments"`  // Expected payments of a masternode per year
+	AnnualReturn    *hexutil.Big   `json:"annualReturn"`    // Expected rewards of a masternode per year
+	ROI             float64        `json:"roi"`             // Annual return as a percentage of the collateral
+}
+
+// EstimateROI estimates the payment frequency and annualized return of a
+// masternode from the enabled masternodes, the block reward and the collateral
+// at the current head. Halvings of the reward within the year are ignored.
+func (self *MasternodeManager) EstimateROI() (*MasternodeROI, error) {
+	index, err := self.masternodeIndex()
+	if err != nil {
+		return nil, err
+	}
+	enabled := len(index.filter(masternode.MasternodeEnable))
+	if enabled == 0 {
+		return nil, errNoEnabledMasternodes
+	}
+	number := new(big.Int).SetUint64(index.number)
+
+	current, err := self.contracts.contract(number)
+	if err != nil {
+		return nil, err
+	}
+	collateral, err := current.EtzPerNode(nil)
+	if err != nil {
+		return nil, err
+	}
+	config := self.eth.chainConfig.Devote
+	next := new(big.Int).Add(number, common.Big1)
+
+	reward, _, err := config.BlockReward(next)
+	if err != nil {
+		return nil, err
+	}
+	if config.IsTreasury(next) {
+		governance, err := self.GetGovernanceContractAddress(number)
+		if err != nil {
+			return nil, err
+		}
+		treasury, err := self.Treasury(governance, number)
+		if err != nil {
+			return nil, err
+		}
+		if treasury != nil {
+			cut := new(big.Int).Mul(reward, new(big.Int).SetUint64(treasury.Share))
+			reward.Sub(reward, cut.Div(cut, big.NewInt(100)))
+		}
+	}
+	period := params.Period
+	if config != nil && config.Period > 0 {
+		period = config.Period
+	}
+	return estimateROI(index.number, enabled, collateral, reward, period), nil
+}
+
+// estimateROI computes the returns of a masternode paid reward every enabled
+// blocks, blocks being sealed every period seconds.
+func estimateROI(number uint64, enabled int, collateral, reward *big.Int, period uint64) *MasternodeROI {
+	interval := uint64(enabled) * period
+
+	roi := &MasternodeROI{
+		Number:          hexutil.Uint64(number),
+		Enabled:         enabled,
+		Collateral:      (*hexutil.Big)(collateral),
+		Reward:          (*hexutil.Big)(reward),
+		PaymentInterval: hexutil.Uint64(interval),
+		CyclePayments:   float64(params.Epoch) / float64(interval),
+		AnnualPayments:  float64(secondsPerYear) / float64(interval),
+	}
+	annual := new(big.Int).Mul(reward, big.NewInt(secondsPerYear))
+	annual.Div(annual, new(big.Int).SetUint64(interval))
+	roi.AnnualReturn = (*hexutil.Big)(annual)
+
+	if collateral.Sign() > 0 {
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(annual), new(big.Float).SetInt(collateral)).Float64()
+		roi.ROI = ratio * 100
+	}
+	return roi
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+// minEndpointRotation is the shortest interval the local masternode may rotate
+// its endpoints at, for the announcements to reach the network in between.
+const minEndpointRotation = time.Minute
+
+// SetEndpoints configures the addresses, IPs or DNS names, the local masternode
+// rotates among every interval, all forwarding to its listening port. Only the
+// active one is dialed by the other masternodes, so it can be moved away from
+// an address under attack. Fewer than two addresses disable the rotation.
+func (self *MasternodeManager) SetEndpoints(hosts []string, interval time.Duration) error {
+	if len(hosts) > masternode.MaxEndpoints {
+		return fmt.Errorf("too many masternode endpoints: %d > %d", len(hosts), masternode.MaxEndpoints)
+	}
+	if len(hosts) > 1 && interval < minEndpointRotation {
+		return fmt.Errorf("masternode endpoint rotation too frequent: %v < %v", interval, minEndpointRotation)
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(hosts) < 2 {
+		self.endpoints, self.rotation = nil, 0
+		return nil
+	}
+	self.endpoints, self.rotation, self.endpoint = hosts, interval, 0
+	return nil
+}
+
+// localEndpoints returns the enode URLs of all the endpoints the local masternode
+// rotates among along with the active one, or nil if it doesn't rotate.
+func (self *MasternodeManager) localEndpoints() ([]string, string) {
+	self.mu.RLock()
+	hosts, active := self.endpoints, self.endpoint
+	self.mu.RUnlock()
+
+	if len(hosts) == 0 {
+		return nil, ""
+	}
+	local := self.srvr.Self()
+
+	endpoints := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		url := masternode.NamedENode(local, host)
+		if _, _, err := masternode.ParseENode(url); err != nil {
+			log.Warn("Invalid masternode endpoint, skipping", "host", host, "err", err)
+			continue
+		}
+		endpoints = append(endpoints, url)
+	}
+	if len(endpoints) == 0 {
+		return nil, ""
+	}
+	return endpoints, endpoints[active%len(endpoints)]
+}
+
+// rotateEndpoints periodically moves the local masternode to its next endpoint
+// and announces it right away, for the relays to redial it there.
+func (self *MasternodeManager) rotateEndpoints() {
+	self.mu.RLock()
+	interval := self.rotation
+	self.mu.RUnlock()
+
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			self.mu.Lock()
+			self.endpoint = (self.endpoint + 1) % len(self.endpoints)
+			host := self.endpoints[self.endpoint]
+			self.mu.Unlock()
+
+			log.Info("Rotating masternode endpoint", "host", host)
+			self.announce()
+
+		case <-self.quit:
+			return
+		}
+	}
+}
+
+// activeEndpoint returns the endpoint a rotating masternode last announced as
+// active, resolved if named, or nil if it doesn't rotate or wasn't resolved yet.
+func (self *MasternodeManager) activeEndpoint(id string) *enode.Node {
+	self.annLock.RLock()
+	ann, ok := self.announcements[id]
+	self.annLock.RUnlock()
+
+	if !ok || len(ann.Endpoints) == 0 {
+		return nil
+	}
+	node, host, err := masternode.ParseENode(ann.ENode)
+	if err != nil {
+		return nil
+	}
+	if host != "" {
+		return self.resolvedNode(id)
+	}
+	return node
+}
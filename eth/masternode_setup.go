@@ -0,0 +1,144 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero/accounts"
+	"github.com/etherzero/go-etherzero/accounts/abi/bind"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// masternodePingFunds is the balance the node account needs to send its pings
+// to the contract, topped up by the setup.
+var masternodePingFunds = big.NewInt(1e+16)
+
+var (
+	errMasternodeRegistered   = errors.New("masternode already registered")
+	errInsufficientCollateral = errors.New("insufficient funds for collateral")
+)
+
+// MasternodeSetup is the outcome of a one-shot masternode setup.
+type MasternodeSetup struct {
+	ID           string         `json:"id"`                // Masternode ID of the node key
+	ENode        string         `json:"enode"`             // Enode URL the masternode is announced at
+	Account      common.Address `json:"account"`           // Node account pinging the contract
+	Registration common.Hash    `json:"registration"`      // Transaction depositing the collateral
+	Funding      *common.Hash   `json:"funding,omitempty"` // Transaction funding the pings, if needed
+}
+
+// Setup registers the local node as a masternode in one go: the collateral is
+// deposited from the given account, unlocked with the password for these
+// transactions only, and the node account is funded for its pings if needed.
+// The masternode announces itself once the registration is mined.
+func (self *MasternodeManager) Setup(from common.Address, password string) (*MasternodeSetup, error) {
+	if self.srvr == nil {
+		return nil, errMasternodeNotStarted
+	}
+	if self.Observer() {
+		return nil, errObserverMode
+	}
+	if _, err := self.State(); err != errMasternodeNotRegistered {
+		if err == nil {
+			err = errMasternodeRegistered
+		}
+		return nil, err
+	}
+	head := self.eth.blockchain.CurrentBlock()
+	current, err := self.contracts.contract(head.Number())
+	if err != nil {
+		return nil, err
+	}
+	collateral, err := current.EtzPerNode(nil)
+	if err != nil {
+		return nil, err
+	}
+	account := accounts.Account{Address: from}
+	wallet, err := self.eth.accountManager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	chainID := self.eth.blockchain.Config().ChainID
+	signer := func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != from {
+			return nil, errors.New("not authorized to sign this account")
+		}
+		return wallet.SignTxWithPassphrase(account, password, tx, chainID)
+	}
+	self.mu.RLock()
+	id, nodeAccount := self.ID, self.NodeAccount
+	self.mu.RUnlock()
+
+	state := self.eth.txPool.State()
+	needed := new(big.Int).Set(collateral)
+	funding := state.GetBalance(nodeAccount).Cmp(masternodePingFunds) < 0
+	if funding {
+		needed.Add(needed, masternodePingFunds)
+	}
+	if state.GetBalance(from).Cmp(needed) < 0 {
+		return nil, errInsufficientCollateral
+	}
+	// Deposit the collateral, registering the node key
+	nonce := state.GetNonce(from)
+	opts := &bind.TransactOpts{
+		From:   from,
+		Nonce:  new(big.Int).SetUint64(nonce),
+		Signer: signer,
+		Value:  collateral,
+	}
+	var id1, id2 [32]byte
+	xy := self.srvr.Self().XY()
+	copy(id1[:], xy[:32])
+	copy(id2[:], xy[32:])
+
+	tx, err := current.Register(opts, id1, id2)
+	if err != nil {
+		return nil, err
+	}
+	result := &MasternodeSetup{
+		ID:           id,
+		ENode:        self.localENode(),
+		Account:      nodeAccount,
+		Registration: tx.Hash(),
+	}
+	log.Info("Sent masternode registration", "id", id, "collateral", from, "tx", tx.Hash())
+
+	// Fund the pings of the node account, the registration is already out
+	if funding {
+		gasPrice, err := self.eth.APIBackend.gpo.SuggestPrice(context.Background())
+		if err != nil {
+			return result, err
+		}
+		tx := types.NewTransaction(nonce+1, nodeAccount, masternodePingFunds, params.TxGas, gasPrice, nil)
+		signed, err := signer(types.NewEIP155Signer(chainID), from, tx)
+		if err != nil {
+			return result, err
+		}
+		if err := self.eth.txPool.AddLocal(signed); err != nil {
+			return result, err
+		}
+		hash := signed.Hash()
+		result.Funding = &hash
+	}
+	return result, nil
+}
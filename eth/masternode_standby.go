@@ -0,0 +1,199 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	// standbyGraceSlots is the number of slots to wait for a block to arrive
+	// before considering the slot missed by the primary host.
+	standbyGraceSlots = 2
+
+	// standbySealedBlocks is the number of locally sealed block hashes kept to
+	// tell blocks of the primary host apart from our own.
+	standbySealedBlocks = 1024
+)
+
+// standbyMonitor runs on a backup host sharing the masternode key of a primary
+// host. It keeps the engine fenced off while the primary seals its slots, takes
+// over block production once the primary misses a configured number of
+// consecutive slots and steps down again as soon as the primary reappears.
+type standbyMonitor struct {
+	eth    *Ethereum
+	engine *devote.Devote
+	slots  uint64 // Number of consecutive missed slots triggering a takeover
+
+	active uint32     // Whether this host took over block production
+	missed uint64     // Consecutive slots missed by the primary
+	last   uint64     // Last slot evaluated
+	sealed *lru.Cache // Hashes of blocks sealed by this host
+
+	depart chan struct{} // Signals the departure of the primary host
+	quit   chan struct{}
+}
+
+func newStandbyMonitor(eth *Ethereum, engine *devote.Devote, slots uint64) *standbyMonitor {
+	sealed, _ := lru.New(standbySealedBlocks)
+	if slots == 0 {
+		slots = 1
+	}
+	// Stay fenced until the primary is known to be unresponsive
+	engine.Fence()
+	return &standbyMonitor{
+		eth:    eth,
+		engine: engine,
+		slots:  slots,
+		sealed: sealed,
+		depart: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Active reports whether the standby host took over block production.
+func (m *standbyMonitor) Active() bool {
+	return atomic.LoadUint32(&m.active) == 1
+}
+
+func (m *standbyMonitor) start() {
+	go m.loop()
+}
+
+func (m *standbyMonitor) stop() {
+	close(m.quit)
+}
+
+func (m *standbyMonitor) loop() {
+	mined := m.eth.EventMux().Subscribe(core.NewMinedBlockEvent{})
+	defer mined.Unsubscribe()
+
+	ticker := time.NewTicker(time.Duration(params.Period) * time.Second)
+	defer ticker.Stop()
+
+	log.Info("Masternode standby monitor started", "slots", m.slots)
+	for {
+		select {
+		case ev, ok := <-mined.Chan():
+			if !ok {
+				return
+			}
+			if block, ok := ev.Data.(core.NewMinedBlockEvent); ok {
+				m.sealed.Add(block.Block.Hash(), struct{}{})
+			}
+		case now := <-ticker.C:
+			m.check(uint64(now.Unix()))
+		case <-m.depart:
+			if !m.Active() {
+				log.Warn("Primary masternode host departing, taking over")
+				m.takeover()
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// departed notifies the monitor that the primary host announced its shutdown.
+func (m *standbyMonitor) departed() {
+	select {
+	case m.depart <- struct{}{}:
+	default:
+	}
+}
+
+// check evaluates the most recent slot which should have been sealed by now.
+func (m *standbyMonitor) check(now uint64) {
+	if now < (standbyGraceSlots+1)*params.Period {
+		return
+	}
+	slot := devote.PrevSlot(now - standbyGraceSlots*params.Period)
+	if slot <= m.last {
+		return
+	}
+	m.last = slot
+
+	id := m.eth.masternodeManager.ID
+	current := m.eth.blockchain.CurrentBlock().Header()
+	witness, err := m.engine.WitnessAt(current, slot)
+	if err != nil || witness != id {
+		return
+	}
+	header := m.eth.findSlot(current, slot)
+	switch {
+	case header == nil:
+		m.missed++
+		log.Warn("Masternode slot missed", "slot", slot, "missed", m.missed, "threshold", m.slots)
+		if !m.Active() && m.missed >= m.slots {
+			m.takeover()
+		}
+	case header.Witness == id:
+		m.missed = 0
+		if m.Active() && !m.sealed.Contains(header.Hash()) {
+			m.stepDown(header)
+		}
+	}
+}
+
+// findSlot looks up the canonical header sealed in the given slot, if any.
+func (s *Ethereum) findSlot(head *types.Header, slot uint64) *types.Header {
+	for header := head; header != nil && header.Time.Uint64() >= slot; {
+		if header.Time.Uint64() == slot {
+			return header
+		}
+		if header.Number.Sign() == 0 {
+			break
+		}
+		header = s.blockchain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return nil
+}
+
+// takeover unfences the engine and starts block production on this host.
+func (m *standbyMonitor) takeover() {
+	log.Warn("Primary masternode host unresponsive, taking over", "missed", m.missed)
+	atomic.StoreUint32(&m.active, 1)
+	m.engine.Unfence()
+	if !m.eth.IsMining() {
+		if err := m.eth.StartMining(1); err != nil {
+			log.Error("Failed to take over block production", "err", err)
+		}
+	}
+}
+
+// stepDown fences the engine again after observing a block of the masternode
+// which was not sealed by this host, i.e. the primary host is back.
+func (m *standbyMonitor) stepDown(header *types.Header) {
+	log.Warn("Primary masternode host is back, standing down", "number", header.Number, "hash", header.Hash())
+	m.engine.Fence()
+	atomic.StoreUint32(&m.active, 0)
+	m.missed = 0
+}
+
+// standbyFenced reports whether the local host must refrain from acting with the
+// masternode key, because it's a standby host and the primary is alive.
+func (s *Ethereum) standbyFenced() bool {
+	return s.standby != nil && !s.standby.Active()
+}
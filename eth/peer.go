@@ -26,6 +26,7 @@ import (
 	mapset "github.com/deckarep/golang-set"
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/p2p"
 	"github.com/etherzero/go-etherzero/rlp"
 )
@@ -39,6 +40,7 @@ var (
 const (
 	maxKnownTxs    = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownBlocks = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
+	maxKnownAnns   = 4096  // Maximum masternode announcement hashes to keep in the known list (prevent DOS)
 
 	// maxQueuedTxs is the maximum number of transaction lists to queue up before
 	// dropping broadcasts. This is a sensitive number as a transaction list might
@@ -55,15 +57,27 @@ const (
 	// above some healthy uncle limit, so use that.
 	maxQueuedAnns = 4
 
+	// maxQueuedMnbs is the maximum number of masternode announcement lists to
+	// queue up before dropping broadcasts. Announcements are resent periodically,
+	// so a dropped one is only delayed.
+	maxQueuedMnbs = 32
+
+	// maxAnnouncementsPerMsg is the maximum number of masternode announcements a
+	// single message may carry. Longer lists are split by the sender, peers
+	// exceeding it are dropped.
+	maxAnnouncementsPerMsg = 256
+
 	handshakeTimeout = 5 * time.Second
 )
 
 // PeerInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about a connected peer.
 type PeerInfo struct {
-	Version    int      `json:"version"`    // Ethereum protocol version negotiated
-	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
-	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+	Version    int             `json:"version"`    // Ethereum protocol version negotiated
+	Masternode uint32          `json:"masternode"` // Masternode sub-protocol version advertised, 0 if none
+	Difficulty *big.Int        `json:"difficulty"` // Total difficulty of the peer's blockchain
+	Head       string          `json:"head"`       // SHA3 hash of the peer's best owned block
+	Reputation *ReputationInfo `json:"reputation"` // Misbehaviour in the masternode gossip of the peer
 }
 
 // propEvent is a block propagation, waiting for its turn in the broadcast queue.
@@ -78,19 +92,26 @@ type peer struct {
 	*p2p.Peer
 	rw p2p.MsgReadWriter
 
-	version  int         // Protocol version negotiated
-	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
+	version   int         // Protocol version negotiated
+	mnVersion uint32      // Masternode sub-protocol version advertised in the handshake
+	forkDrop  *time.Timer // Timed connection dropper if forks aren't validated in time
 
 	head common.Hash
 	td   *big.Int
 	lock sync.RWMutex
 
-	knownTxs    mapset.Set                // Set of transaction hashes known to be known by this peer
-	knownBlocks mapset.Set                // Set of block hashes known to be known by this peer
-	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
-	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
-	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
-	term        chan struct{}             // Termination channel to stop the broadcaster
+	reputation reputation // Penalty score of the peer's masternode gossip
+	dialedBack time.Time  // Time the last reachability check of the peer was served
+
+	knownTxs    mapset.Set                      // Set of transaction hashes known to be known by this peer
+	knownBlocks mapset.Set                      // Set of block hashes known to be known by this peer
+	knownMnbs   mapset.Set                      // Set of masternode announcement hashes known to be known by this peer
+	recvMnbs    mapset.Set                      // Set of masternode announcement hashes received from this peer
+	queuedTxs   chan []*types.Transaction       // Queue of transactions to broadcast to the peer
+	queuedProps chan *propEvent                 // Queue of blocks to broadcast to the peer
+	queuedAnns  chan *types.Block               // Queue of blocks to announce to the peer
+	queuedMnbs  chan []*masternode.Announcement // Queue of masternode announcements to broadcast to the peer
+	term        chan struct{}                   // Termination channel to stop the broadcaster
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -101,9 +122,12 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
 		knownTxs:    mapset.NewSet(),
 		knownBlocks: mapset.NewSet(),
+		knownMnbs:   mapset.NewSet(),
+		recvMnbs:    mapset.NewSet(),
 		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
 		queuedProps: make(chan *propEvent, maxQueuedProps),
 		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
+		queuedMnbs:  make(chan []*masternode.Announcement, maxQueuedMnbs),
 		term:        make(chan struct{}),
 	}
 }
@@ -132,6 +156,12 @@ func (p *peer) broadcast() {
 			}
 			p.Log().Trace("Announced block", "number", block.Number(), "hash", block.Hash())
 
+		case anns := <-p.queuedMnbs:
+			if err := p.SendAnnouncements(anns); err != nil {
+				return
+			}
+			p.Log().Trace("Broadcast masternode announcements", "count", len(anns))
+
 		case <-p.term:
 			return
 		}
@@ -149,8 +179,10 @@ func (p *peer) Info() *PeerInfo {
 
 	return &PeerInfo{
 		Version:    p.version,
+		Masternode: p.mnVersion,
 		Difficulty: td,
 		Head:       hash.Hex(),
+		Reputation: p.reputation.summary(),
 	}
 }
 
@@ -215,6 +247,98 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// MarkAnnouncement marks a masternode announcement as known for the peer,
+// ensuring that it will never be propagated to this particular peer.
+func (p *peer) MarkAnnouncement(hash common.Hash) {
+	// If we reached the memory allowance, drop a previously known announcement hash
+	for p.knownMnbs.Cardinality() >= maxKnownAnns {
+		p.knownMnbs.Pop()
+	}
+	p.knownMnbs.Add(hash)
+}
+
+// ReceivedAnnouncement records a masternode announcement received from the peer,
+// marking it as known, and reports whether the peer had sent it before. Those
+// known because we sent them to the peer don't count, as it may relay them back
+// before receiving them.
+func (p *peer) ReceivedAnnouncement(hash common.Hash) bool {
+	p.MarkAnnouncement(hash)
+	if p.recvMnbs.Contains(hash) {
+		return true
+	}
+	// If we reached the memory allowance, drop a previously received announcement hash
+	for p.recvMnbs.Cardinality() >= maxKnownAnns {
+		p.recvMnbs.Pop()
+	}
+	p.recvMnbs.Add(hash)
+	return false
+}
+
+// SendAnnouncements sends masternode announcements to the peer, split into
+// messages of at most maxAnnouncementsPerMsg, and includes their hashes in its
+// announcement hash set for future reference. Announcements
+// by DNS name, bound to the network or signing endpoints are withheld from peers
+// too old to parse or verify them, which would penalize us for relaying them.
+func (p *peer) SendAnnouncements(anns []*masternode.Announcement) error {
+	list := make([]*masternode.Announcement, 0, len(anns))
+	for _, ann := range anns {
+		p.MarkAnnouncement(ann.Hash())
+		if p.mnVersion < masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_4 && ann.Host() != "" {
+			continue
+		}
+		if p.mnVersion < masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_5 && ann.Protocol >= masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_5 {
+			continue
+		}
+		if p.mnVersion < masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_6 && ann.Protocol >= masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_6 {
+			continue
+		}
+		list = append(list, ann)
+	}
+	for len(list) > 0 {
+		batch := list
+		if len(batch) > maxAnnouncementsPerMsg {
+			batch = batch[:maxAnnouncementsPerMsg]
+		}
+		if err := p2p.Send(p.rw, MasternodeAnnounceMsg, batch); err != nil {
+			return err
+		}
+		list = list[len(batch):]
+	}
+	return nil
+}
+
+// AsyncSendAnnouncements queues a list of masternode announcements for
+// propagation to a remote peer. If the peer's broadcast queue is full, the event
+// is silently dropped.
+func (p *peer) AsyncSendAnnouncements(anns []*masternode.Announcement) {
+	select {
+	case p.queuedMnbs <- anns:
+		for _, ann := range anns {
+			p.MarkAnnouncement(ann.Hash())
+		}
+	default:
+		p.Log().Debug("Dropping masternode announcement propagation", "count", len(anns))
+	}
+}
+
+// SendDeparture sends a masternode departure to the peer, unless it's too old to
+// know about departures, and includes its hash in the announcement hash set of
+// the peer for future reference.
+func (p *peer) SendDeparture(d *masternode.Departure) error {
+	p.MarkAnnouncement(d.Hash())
+	if p.mnVersion < masternode.MIN_MASTERNODE_PAYMENT_PROTO_VERSION_7 {
+		return nil
+	}
+	return p2p.Send(p.rw, MasternodeDepartMsg, d)
+}
+
+// RequestAnnouncements asks the peer for the masternode announcements it knows
+// about (mnget).
+func (p *peer) RequestAnnouncements() error {
+	p.Log().Debug("Fetching masternode announcements")
+	return p2p.Send(p.rw, GetMasternodeAnnouncesMsg, struct{}{})
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -335,13 +459,17 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 	var status statusData // safe to read after two values have been received from errc
 
 	go func() {
-		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
+		status := &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       network,
 			TD:              td,
 			CurrentBlock:    head,
 			GenesisBlock:    genesis,
-		})
+		}
+		if p.version >= etz64 {
+			status.Masternode = []uint32{masternode.ProtocolVersion}
+		}
+		errc <- p2p.Send(p.rw, StatusMsg, status)
 	}()
 	go func() {
 		errc <- p.readStatus(network, &status, genesis)
@@ -359,9 +487,31 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		}
 	}
 	p.td, p.head = status.TD, status.CurrentBlock
+	if p.version >= etz64 && len(status.Masternode) > 0 {
+		p.mnVersion = status.Masternode[0]
+	}
 	return nil
 }
 
+// allowDialBack reports whether a reachability check of the peer may be served,
+// limiting them to one per reachabilityServeLimit.
+func (p *peer) allowDialBack() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if time.Since(p.dialedBack) < reachabilityServeLimit {
+		return false
+	}
+	p.dialedBack = time.Now()
+	return true
+}
+
+// masternodeCapable returns whether masternode messages are exchanged with the
+// peer, requiring it to advertise a recent enough masternode sub-protocol.
+func (p *peer) masternodeCapable() bool {
+	return p.version >= etz64 && p.mnVersion >= masternode.MinProtocolVersion
+}
+
 func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
@@ -462,6 +612,18 @@ func (ps *peerSet) Len() int {
 	return len(ps.peers)
 }
 
+// Peers retrieves a list of all the registered peers.
+func (ps *peerSet) Peers() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
 // PeersWithoutBlock retrieves a list of peers that do not have a given block in
 // their set of known hashes.
 func (ps *peerSet) PeersWithoutBlock(hash common.Hash) []*peer {
@@ -492,6 +654,21 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// PeersWithoutAnnouncement retrieves a list of masternode capable peers that do
+// not have a given masternode announcement in their set of known hashes.
+func (ps *peerSet) PeersWithoutAnnouncement(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.masternodeCapable() && !p.knownMnbs.Contains(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
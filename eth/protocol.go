@@ -63,6 +63,13 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to etz/64
+	MasternodeAnnounceMsg     = 0x11 // Signed masternode announcements (mnb)
+	GetMasternodeAnnouncesMsg = 0x12 // Request for all known announcements (mnget)
+	ReachabilityCheckMsg      = 0x13 // Request to dial back the listening port of the sender
+	ReachabilityResultMsg     = 0x14 // Outcome of a dial back
+	MasternodeDepartMsg       = 0x15 // Signed notice of a masternode host shutting down
 )
 
 type errCode int
@@ -77,6 +84,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrBadReputation
 )
 
 func (e errCode) String() string {
@@ -94,6 +102,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrBadReputation:           "Reputation too low",
 }
 
 type txPool interface {
@@ -116,6 +125,10 @@ type statusData struct {
 	TD              *big.Int
 	CurrentBlock    common.Hash
 	GenesisBlock    common.Hash
+
+	// Masternode holds the masternode sub-protocol version of etz64 peers. It's
+	// a tail so peers predating it, sending none, are still understood.
+	Masternode []uint32 `rlp:"tail"`
 }
 
 // newBlockHashesData is the network packet for the block announcements.
@@ -182,3 +195,16 @@ type blockBody struct {
 
 // blockBodiesData is the network packet for block content distribution.
 type blockBodiesData []*blockBody
+
+// reachabilityCheck is the network packet asking a peer to dial back the given
+// port at the address the sender connected from.
+type reachabilityCheck struct {
+	ID   uint64 // Request id, echoed in the result
+	Port uint16 // Listening TCP port the sender advertises
+}
+
+// reachabilityResult is the network packet reporting the outcome of a dial back.
+type reachabilityResult struct {
+	ID        uint64
+	Reachable bool
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/p2p/enode"
+)
+
+const (
+	penaltyInvalid   = 20 // Penalty for gossip with an invalid signature or forged collateral
+	penaltyStale     = 2  // Penalty for gossip that expired or comes from an outdated node
+	penaltyDuplicate = 1  // Penalty for gossip the peer already sent
+
+	reputationThreshold = 100              // Penalty score at which a peer is disconnected and banned
+	reputationDecay     = 10 * time.Second // Time after which a penalty point is forgiven
+	peerBanDuration     = 30 * time.Minute // Time a peer is refused after being banned
+)
+
+// offence is a kind of misbehaviour in the gossip of a peer.
+type offence int
+
+const (
+	offenceInvalid offence = iota
+	offenceStale
+	offenceDuplicate
+)
+
+// ReputationInfo is the summary of the misbehaviour of a peer, reported in the
+// admin_peers output.
+type ReputationInfo struct {
+	Score     uint64 `json:"score"`     // Current penalty score, decaying over time
+	Invalid   uint64 `json:"invalid"`   // Messages with invalid signatures or collateral
+	Stale     uint64 `json:"stale"`     // Messages which expired or came from outdated nodes
+	Duplicate uint64 `json:"duplicate"` // Messages the peer already sent
+}
+
+// reputation tracks the penalty score of a single peer.
+type reputation struct {
+	info    ReputationInfo
+	updated time.Time // Time the score was last decayed at
+	lock    sync.Mutex
+}
+
+// penalize records an offence of the peer, returning whether its score reached
+// the threshold for disconnecting it.
+func (r *reputation) penalize(o offence) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.decay(time.Now())
+	switch o {
+	case offenceInvalid:
+		r.info.Invalid++
+		r.info.Score += penaltyInvalid
+	case offenceStale:
+		r.info.Stale++
+		r.info.Score += penaltyStale
+	case offenceDuplicate:
+		r.info.Duplicate++
+		r.info.Score += penaltyDuplicate
+	}
+	return r.info.Score >= reputationThreshold
+}
+
+// decay forgives the penalty points accrued since the last update.
+func (r *reputation) decay(now time.Time) {
+	if r.updated.IsZero() {
+		r.updated = now
+		return
+	}
+	forgiven := uint64(now.Sub(r.updated) / reputationDecay)
+	if forgiven == 0 {
+		return
+	}
+	if forgiven > r.info.Score {
+		forgiven = r.info.Score
+	}
+	r.info.Score -= forgiven
+	r.updated = r.updated.Add(time.Duration(forgiven) * reputationDecay)
+	if r.info.Score == 0 {
+		r.updated = now
+	}
+}
+
+// summary returns the current reputation of the peer.
+func (r *reputation) summary() *ReputationInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.decay(time.Now())
+	info := r.info
+	return &info
+}
+
+// banList is the set of peers refused for misbehaving, each until its ban
+// expires.
+type banList struct {
+	peers map[enode.ID]time.Time
+	lock  sync.Mutex
+}
+
+func newBanList() *banList {
+	return &banList{peers: make(map[enode.ID]time.Time)}
+}
+
+// ban refuses the peer for peerBanDuration.
+func (b *banList) ban(id enode.ID) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.peers[id] = time.Now().Add(peerBanDuration)
+}
+
+// banned reports whether the peer is currently refused, dropping expired bans.
+func (b *banList) banned(id enode.ID) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	for peer, expiry := range b.peers {
+		if now.After(expiry) {
+			delete(b.peers, peer)
+		}
+	}
+	_, ok := b.peers[id]
+	return ok
+}
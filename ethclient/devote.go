@@ -0,0 +1,101 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/etherzero/go-etherzero"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+)
+
+// Masternodes and devote consensus
+
+// MasternodeList returns up to limit masternodes registered in the masternode
+// contract at the current head, skipping the first offset ones. If status is not
+// empty, only masternodes in that state ("enabled", "new" or "expired") are
+// returned. The masternode namespace is private, so the client has to be
+// connected over IPC or an endpoint exposing it.
+func (ec *Client) MasternodeList(ctx context.Context, offset, limit uint64, status string) ([]*masternode.Info, error) {
+	var list []*masternode.Info
+	err := ec.c.CallContext(ctx, &list, "masternode_list", offset, limit, status)
+	return list, err
+}
+
+// MasternodeCount returns the number of masternodes registered at the current
+// head, optionally only those in the given state.
+func (ec *Client) MasternodeCount(ctx context.Context, status string) (uint64, error) {
+	var count uint64
+	err := ec.c.CallContext(ctx, &count, "masternode_count", status)
+	return count, err
+}
+
+// MasternodeWinner returns the masternode paid by the block at the given height.
+// If number is nil, the payee of the latest known block is returned.
+func (ec *Client) MasternodeWinner(ctx context.Context, number *big.Int) (*masternode.Info, error) {
+	var winner *masternode.Info
+	if err := ec.c.CallContext(ctx, &winner, "masternode_winner", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if winner == nil {
+		return nil, ethereum.NotFound
+	}
+	return winner, nil
+}
+
+// Witnesses returns the witnesses scheduled to seal blocks in the cycle of the
+// given block. If number is nil, the latest known block is used.
+func (ec *Client) Witnesses(ctx context.Context, number *big.Int) ([]string, error) {
+	var witnesses []string
+	err := ec.c.CallContext(ctx, &witnesses, "devote_getSigners", toBlockNumArg(number))
+	return witnesses, err
+}
+
+// WitnessesByCycle returns the witnesses elected for the given cycle.
+func (ec *Client) WitnessesByCycle(ctx context.Context, cycle uint64) ([]string, error) {
+	var witnesses []string
+	err := ec.c.CallContext(ctx, &witnesses, "devote_getSignersByEpoch", cycle)
+	return witnesses, err
+}
+
+// WitnessStats returns the witnesses of the cycles between begin and end (both
+// inclusive), ranked by their produced versus expected blocks.
+func (ec *Client) WitnessStats(ctx context.Context, begin, end uint64) ([]*devote.WitnessStats, error) {
+	var stats []*devote.WitnessStats
+	err := ec.c.CallContext(ctx, &stats, "devote_witnessStats", begin, end)
+	return stats, err
+}
+
+// ConfirmedBlockNumber returns the number of the latest irreversible block.
+func (ec *Client) ConfirmedBlockNumber(ctx context.Context) (*big.Int, error) {
+	number := new(big.Int)
+	if err := ec.c.CallContext(ctx, number, "devote_getConfirmedBlockNumber"); err != nil {
+		return nil, err
+	}
+	return number, nil
+}
+
+// BlockWitness returns the address of the key which sealed the given block. If
+// number is nil, the latest known block is used.
+func (ec *Client) BlockWitness(ctx context.Context, number *big.Int) (common.Address, error) {
+	var sealer common.Address
+	err := ec.c.CallContext(ctx, &sealer, "eth_getBlockWitness", toBlockNumArg(number))
+	return sealer, err
+}
@@ -280,6 +280,11 @@ type rpcProgress struct {
 	HighestBlock  hexutil.Uint64
 	PulledStates  hexutil.Uint64
 	KnownStates   hexutil.Uint64
+
+	CurrentCycle       hexutil.Uint64
+	HighestCycle       hexutil.Uint64
+	PulledDevoteStates hexutil.Uint64
+	KnownDevoteStates  hexutil.Uint64
 }
 
 // SyncProgress retrieves the current progress of the sync algorithm. If there's
@@ -299,11 +304,15 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 		return nil, err
 	}
 	return &ethereum.SyncProgress{
-		StartingBlock: uint64(progress.StartingBlock),
-		CurrentBlock:  uint64(progress.CurrentBlock),
-		HighestBlock:  uint64(progress.HighestBlock),
-		PulledStates:  uint64(progress.PulledStates),
-		KnownStates:   uint64(progress.KnownStates),
+		StartingBlock:      uint64(progress.StartingBlock),
+		CurrentBlock:       uint64(progress.CurrentBlock),
+		HighestBlock:       uint64(progress.HighestBlock),
+		PulledStates:       uint64(progress.PulledStates),
+		KnownStates:        uint64(progress.KnownStates),
+		CurrentCycle:       uint64(progress.CurrentCycle),
+		HighestCycle:       uint64(progress.HighestCycle),
+		PulledDevoteStates: uint64(progress.PulledDevoteStates),
+		KnownDevoteStates:  uint64(progress.KnownDevoteStates),
 	}, nil
 }
 
@@ -33,13 +33,16 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/mclock"
 	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/eth"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/les"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/p2p"
+	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rpc"
 	"golang.org/x/net/websocket"
 )
@@ -663,13 +666,62 @@ func (s *Service) reportPending(conn *websocket.Conn) error {
 
 // nodeStats is the information to report about the local node.
 type nodeStats struct {
-	Active   bool `json:"active"`
-	Syncing  bool `json:"syncing"`
-	Mining   bool `json:"mining"`
-	Hashrate int  `json:"hashrate"`
-	Peers    int  `json:"peers"`
-	GasPrice int  `json:"gasPrice"`
-	Uptime   int  `json:"uptime"`
+	Active     bool             `json:"active"`
+	Syncing    bool             `json:"syncing"`
+	Mining     bool             `json:"mining"`
+	Hashrate   int              `json:"hashrate"`
+	Peers      int              `json:"peers"`
+	GasPrice   int              `json:"gasPrice"`
+	Uptime     int              `json:"uptime"`
+	Masternode *masternodeStats `json:"masternode,omitempty"`
+}
+
+// masternodeStats is the information to report about the consensus health as
+// seen by the local node.
+type masternodeStats struct {
+	Enabled     int    `json:"enabled"`     // Enabled masternodes at the current head
+	State       string `json:"state"`       // Status of the local masternode, empty if not registered
+	Witness     string `json:"witness"`     // Witness scheduled for the current slot
+	Missed      uint64 `json:"missed"`      // Slots missed by all witnesses in the current cycle
+	LocalMissed uint64 `json:"localMissed"` // Slots missed by the local masternode in the current cycle
+}
+
+// assembleMasternodeStats gathers the masternode metrics of a full node running
+// the devote engine, or returns nil otherwise.
+func (s *Service) assembleMasternodeStats() *masternodeStats {
+	if s.eth == nil {
+		return nil
+	}
+	engine, ok := s.eth.Engine().(*devote.Devote)
+	if !ok {
+		return nil
+	}
+	var (
+		manager = s.eth.MasternodeManager()
+		head    = s.eth.BlockChain().CurrentHeader()
+		stats   = new(masternodeStats)
+	)
+	if enabled, err := manager.Count(masternode.MasternodeEnable); err == nil {
+		stats.Enabled = enabled
+	}
+	if state, ok := manager.LocalState(); ok {
+		stats.State = masternode.StatusName(state)
+	}
+	if witness, err := engine.WitnessAt(head, devote.PrevSlot(uint64(time.Now().Unix())+1)); err == nil {
+		stats.Witness = witness
+	}
+	local, _ := s.eth.Witness()
+
+	cycle := head.Time.Uint64() / params.Epoch
+	if witnesses, err := engine.WitnessStats(head, cycle, cycle); err == nil {
+		for _, witness := range witnesses {
+			stats.Missed += witness.Missed
+			if witness.Witness == local {
+				stats.LocalMissed = witness.Missed
+			}
+		}
+	}
+	return stats
 }
 
 // reportPending retrieves various stats about the node at the networking and
@@ -701,13 +753,14 @@ func (s *Service) reportStats(conn *websocket.Conn) error {
 	stats := map[string]interface{}{
 		"id": s.node,
 		"stats": &nodeStats{
-			Active:   true,
-			Mining:   mining,
-			Hashrate: hashrate,
-			Peers:    s.server.PeerCount(),
-			GasPrice: gasprice,
-			Syncing:  syncing,
-			Uptime:   100,
+			Active:     true,
+			Mining:     mining,
+			Hashrate:   hashrate,
+			Peers:      s.server.PeerCount(),
+			GasPrice:   gasprice,
+			Syncing:    syncing,
+			Uptime:     100,
+			Masternode: s.assembleMasternodeStats(),
 		},
 	}
 	report := map[string][]interface{}{
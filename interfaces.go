@@ -103,6 +103,11 @@ type SyncProgress struct {
 	HighestBlock  uint64 // Highest alleged block number in the chain
 	PulledStates  uint64 // Number of state trie entries already downloaded
 	KnownStates   uint64 // Total number of state trie entries known about
+
+	CurrentCycle       uint64 // Devote cycle of the current block
+	HighestCycle       uint64 // Devote cycle of the highest alleged block
+	PulledDevoteStates uint64 // Number of devote trie entries already downloaded
+	KnownDevoteStates  uint64 // Total number of devote trie entries known about
 }
 
 // ChainSyncReader wraps access to the node's current sync status. If there's no
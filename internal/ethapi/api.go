@@ -31,10 +31,12 @@ import (
 	"github.com/etherzero/go-etherzero/common"
 	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/common/math"
+	"github.com/etherzero/go-etherzero/consensus/devote"
 	"github.com/etherzero/go-etherzero/consensus/ethash"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/crypto"
 	"github.com/etherzero/go-etherzero/log"
@@ -114,6 +116,11 @@ func (b *PublicEthereumAPI) JoinMasternode(nodeid string) bool {
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// - currentCycle:  devote cycle of the block this node is currently importing
+// - highestCycle:  devote cycle of the highest block header this node has received
+// - remainingCycles: number of devote cycles left until the highest block
+// - pulledDevoteStates: number of devote protocol entries healed until now
+// - knownDevoteStates:  number of known devote protocol entries that still need to be pulled
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	progress := s.b.Downloader().Progress()
 
@@ -128,6 +135,12 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
 		"pulledStates":  hexutil.Uint64(progress.PulledStates),
 		"knownStates":   hexutil.Uint64(progress.KnownStates),
+
+		"currentCycle":       hexutil.Uint64(progress.CurrentCycle),
+		"highestCycle":       hexutil.Uint64(progress.HighestCycle),
+		"remainingCycles":    hexutil.Uint64(progress.HighestCycle - progress.CurrentCycle),
+		"pulledDevoteStates": hexutil.Uint64(progress.PulledDevoteStates),
+		"knownDevoteStates":  hexutil.Uint64(progress.KnownDevoteStates),
 	}, nil
 }
 
@@ -263,13 +276,9 @@ func (s *PrivateAccountAPI) ListAccounts() []common.Address {
 	return addresses
 }
 
-// Masternodes will return a list master nodes messages.
-func (s *PrivateAccountAPI) List() []string {
-	return s.b.Masternodes()
-}
-
-// Masternodes will return a list master nodes messages.
-func (s *PrivateAccountAPI) Data() string {
+// Data returns the transaction data registering the local node in the
+// masternode contract.
+func (s *PrivateAccountAPI) Data() (hexutil.Bytes, error) {
 	return s.b.Data()
 }
 
@@ -278,7 +287,7 @@ func (s *PrivateAccountAPI) Ns() int64 {
 }
 
 // GetInfo return related info in masternode contract
-func (s *PrivateAccountAPI) GetInfo(nodeid string) string {
+func (s *PrivateAccountAPI) GetInfo(nodeid string) (*masternode.Info, error) {
 	return s.b.GetInfo(nodeid)
 }
 
@@ -582,6 +591,35 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Add
 	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
 }
 
+// Sponsorship is the power sponsorship of a contract for one of its callers.
+type Sponsorship struct {
+	Contract  common.Address `json:"contract"`
+	Caller    common.Address `json:"caller"`
+	Sponsored bool           `json:"sponsored"` // Whether the contract pays the power of the caller's transactions
+	GasLimit  hexutil.Uint64 `json:"gasLimit"`  // Highest gas limit of a sponsored transaction
+	Power     *hexutil.Big   `json:"power"`     // Power of the contract left to sponsor transactions with
+}
+
+// GetSponsorship returns whether the contract sponsors the power of transactions
+// sent by the caller to it, according to the sponsor registry in the state of
+// the given block number.
+func (s *PublicBlockChainAPI) GetSponsorship(ctx context.Context, contract common.Address, caller common.Address, blockNr rpc.BlockNumber) (*Sponsorship, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	result := &Sponsorship{
+		Contract: contract,
+		Caller:   caller,
+		Power:    (*hexutil.Big)(state.GetPower(contract, header.Number)),
+	}
+	if s.b.ChainConfig().Devote.IsSponsor(header.Number) {
+		result.GasLimit = hexutil.Uint64(vm.Sponsorship(state, contract, caller))
+		result.Sponsored = result.GasLimit > 0
+	}
+	return result, state.Error()
+}
+
 // Result structs for GetProof
 type AccountResult struct {
 	Address      common.Address  `json:"address"`
@@ -648,6 +686,46 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	}, state.Error()
 }
 
+// GetHeaderByNumber returns the requested canonical block header, including the
+// witness and the devote trie roots.
+func (s *PublicBlockChainAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	header, err := s.b.HeaderByNumber(ctx, number)
+	if header != nil && err == nil {
+		response := s.rpcMarshalHeader(header)
+		if number == rpc.PendingBlockNumber {
+			// Pending header need to nil out a few fields
+			for _, field := range []string{"hash", "nonce", "miner"} {
+				response[field] = nil
+			}
+		}
+		return response, nil
+	}
+	return nil, err
+}
+
+// GetHeaderByHash returns the requested header by hash.
+func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, blockHash common.Hash) (map[string]interface{}, error) {
+	block, err := s.b.GetBlock(ctx, blockHash)
+	if block != nil {
+		return s.rpcMarshalHeader(block.Header()), nil
+	}
+	return nil, err
+}
+
+// GetBlockWitness returns the address of the key which sealed the requested
+// block, recovered from the signature in its extra-data. For blocks sealed by
+// a hot key this is the delegate, not the cold masternode key.
+func (s *PublicBlockChainAPI) GetBlockWitness(ctx context.Context, blockNr rpc.BlockNumber) (common.Address, error) {
+	if blockNr == rpc.PendingBlockNumber {
+		return common.Address{}, errors.New("pending block is not sealed")
+	}
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if header == nil || err != nil {
+		return common.Address{}, err
+	}
+	return devote.Sealer(header)
+}
+
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -936,14 +1014,12 @@ func FormatLogs(logs []vm.StructLog) []StructLogRes {
 	return formatted
 }
 
-// RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
-// returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
-// transaction hashes.
-func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
-	head := b.Header() // copies the header once
+// RPCMarshalHeader converts the given header to the RPC output, including the
+// witness and the devote trie roots.
+func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	fields := map[string]interface{}{
 		"number":           (*hexutil.Big)(head.Number),
-		"hash":             b.Hash(),
+		"hash":             head.Hash(),
 		"parentHash":       head.ParentHash,
 		"nonce":            head.Nonce,
 		"mixHash":          head.MixDigest,
@@ -953,13 +1029,28 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 		"miner":            head.Coinbase,
 		"difficulty":       (*hexutil.Big)(head.Difficulty),
 		"extraData":        hexutil.Bytes(head.Extra),
-		"size":             hexutil.Uint64(b.Size()),
 		"gasLimit":         hexutil.Uint64(head.GasLimit),
 		"gasUsed":          hexutil.Uint64(head.GasUsed),
 		"timestamp":        (*hexutil.Big)(head.Time),
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
+		"witness":          head.Witness,
+		"protocol":         head.Protocol,
+	}
+	if head.Protocol != nil {
+		fields["cycleHash"] = head.Protocol.CycleHash
+		fields["statsHash"] = head.Protocol.StatsHash
+		fields["devoteRoot"] = head.Protocol.Root()
 	}
+	return fields
+}
+
+// RPCMarshalBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
+// returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
+// transaction hashes.
+func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
+	fields := RPCMarshalHeader(b.Header()) // copies the header once
+	fields["size"] = hexutil.Uint64(b.Size())
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -999,9 +1090,29 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		return nil, err
 	}
 	fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
+	fields["miner"] = s.author(b.Header())
 	return fields, err
 }
 
+// rpcMarshalHeader uses the generalized output filler, then adds the author of
+// the block as its miner.
+func (s *PublicBlockChainAPI) rpcMarshalHeader(header *types.Header) map[string]interface{} {
+	fields := RPCMarshalHeader(header)
+	fields["miner"] = s.author(header)
+	return fields
+}
+
+// author returns the account credited with the block. From the author fork on
+// that's the masternode recovered from the seal, otherwise the coinbase.
+func (s *PublicBlockChainAPI) author(header *types.Header) common.Address {
+	if s.b.ChainConfig().Devote.IsAuthor(header.Number) {
+		if author, err := devote.SealAuthor(header); err == nil {
+			return author
+		}
+	}
+	return header.Coinbase
+}
+
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
 	BlockHash        common.Hash     `json:"blockHash"`
@@ -1185,6 +1296,33 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	return rlp.EncodeToBytes(tx)
 }
 
+// GetSystemReceipt returns the system receipt of the given block, listing the
+// payments and the election applied by the consensus engine outside of any
+// transaction. Blocks imported by fast sync have none.
+func (s *PublicTransactionPoolAPI) GetSystemReceipt(ctx context.Context, blockHash common.Hash) (map[string]interface{}, error) {
+	number := rawdb.ReadHeaderNumber(s.b.ChainDb(), blockHash)
+	if number == nil {
+		return nil, nil
+	}
+	receipt := rawdb.ReadSystemReceipt(s.b.ChainDb(), blockHash, *number)
+	if receipt == nil {
+		return nil, nil
+	}
+	fields := map[string]interface{}{
+		"blockHash":   blockHash,
+		"blockNumber": hexutil.Uint64(*number),
+		"payments":    receipt.Payments,
+		"witnesses":   receipt.Witnesses,
+	}
+	if receipt.Payments == nil {
+		fields["payments"] = []*types.Payment{}
+	}
+	if receipt.Witnesses == nil {
+		fields["witnesses"] = []string{}
+	}
+	return fields, nil
+}
+
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash)
@@ -1324,7 +1462,7 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 // submitTransaction is a helper function that submits tx to txPool and logs a message.
 func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
 	if err := b.SendTx(ctx, tx); err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, txPoolError(err)
 	}
 	if tx.To() == nil {
 		signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
@@ -1387,6 +1525,57 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	return submitTransaction(ctx, s.b, tx)
 }
 
+// maxRawTransactionBatch is the maximum number of transactions accepted by a
+// single SendRawTransactions call.
+const maxRawTransactionBatch = 1024
+
+// RawTransactionStatus is the outcome of submitting a transaction of a batch.
+type RawTransactionStatus struct {
+	Hash     common.Hash `json:"hash"`
+	Accepted bool        `json:"accepted"`
+	Queued   bool        `json:"queued,omitempty"` // Accepted, but not executable until the nonce gap is filled
+	Error    string      `json:"error,omitempty"`  // Reason the transaction was rejected
+}
+
+// SendRawTransactions adds a batch of signed transactions to the transaction
+// pool in one call, in the given order. A rejected transaction doesn't abort the
+// batch, the status of every transaction is reported instead.
+func (s *PublicTransactionPoolAPI) SendRawTransactions(ctx context.Context, encodedTxs []hexutil.Bytes) ([]*RawTransactionStatus, error) {
+	if len(encodedTxs) > maxRawTransactionBatch {
+		return nil, fmt.Errorf("batch of %d transactions exceeds limit of %d", len(encodedTxs), maxRawTransactionBatch)
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), s.b.CurrentBlock().Number())
+
+	statuses := make([]*RawTransactionStatus, len(encodedTxs))
+	for i, encodedTx := range encodedTxs {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+			statuses[i] = &RawTransactionStatus{Error: err.Error()}
+			continue
+		}
+		status := &RawTransactionStatus{Hash: tx.Hash()}
+		statuses[i] = status
+
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			status.Error = err.Error()
+			continue
+		}
+		// Look up the next executable nonce first, to tell queued transactions apart
+		nonce, err := s.b.GetPoolNonce(ctx, from)
+		if err != nil {
+			status.Error = err.Error()
+			continue
+		}
+		if _, err := submitTransaction(ctx, s.b, tx); err != nil {
+			status.Error = err.Error()
+			continue
+		}
+		status.Accepted, status.Queued = true, tx.Nonce() > nonce
+	}
+	return statuses, nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -1507,7 +1696,7 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 				return common.Hash{}, err
 			}
 			if err = s.b.SendTx(ctx, signedTx); err != nil {
-				return common.Hash{}, err
+				return common.Hash{}, txPoolError(err)
 			}
 			return signedTx.Hash(), nil
 		}
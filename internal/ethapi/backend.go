@@ -23,9 +23,11 @@ import (
 
 	"github.com/etherzero/go-etherzero/accounts"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/ethdb"
@@ -46,12 +48,12 @@ type Backend interface {
 	AccountManager() *accounts.Manager
 
 	// masternode control api
-	Masternodes() []string        // masternodes info
-	Data() string                 // return masternode contract nodes data
-	GetInfo(nodeid string) string // return related info in masternode contract
-	StartMasternode() bool        // start the masternode,hash ,srvr means two different parameters
-	StopMasternode() bool         // stop the masternode,hash ,srvr means two different parameters
-	Ns() int64                    // nanoseconds
+	Masternodes() []string                           // masternodes info
+	Data() (hexutil.Bytes, error)                    // return the registration data of the local node
+	GetInfo(nodeid string) (*masternode.Info, error) // return related info in masternode contract
+	StartMasternode() bool                           // start the masternode,hash ,srvr means two different parameters
+	StopMasternode() bool                            // stop the masternode,hash ,srvr means two different parameters
+	Ns() int64                                       // nanoseconds
 
 	// BlockChain API
 	SetHead(number uint64)
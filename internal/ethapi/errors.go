@@ -0,0 +1,74 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/core"
+)
+
+// MasternodeError is an error of the masternode control API. It implements the
+// rpc.Error interface, so its code is surfaced as the JSON-RPC error code.
+type MasternodeError struct {
+	Code    int
+	Message string
+}
+
+func (e *MasternodeError) Error() string  { return e.Message }
+func (e *MasternodeError) ErrorCode() int { return e.Code }
+
+// Errors returned by the masternode control API backends.
+var (
+	ErrInvalidMasternodeID   = &MasternodeError{-32602, "invalid masternode id"}
+	ErrMasternodeNotReady    = &MasternodeError{-32010, "masternode server not started yet"}
+	ErrMasternodeUnknown     = &MasternodeError{-32011, "masternode not registered"}
+	ErrMasternodeRegistered  = &MasternodeError{-32012, "node already registered as masternode"}
+	ErrMasternodeUnsupported = &MasternodeError{-32013, "masternodes not supported by light clients"}
+)
+
+// MasternodeContractError wraps a failed call into the masternode contract.
+func MasternodeContractError(err error) error {
+	return &MasternodeError{-32014, fmt.Sprintf("masternode contract call failed: %v", err)}
+}
+
+// TxPoolError is a transaction rejected by the pool, carrying a JSON-RPC error
+// code so clients can tell the replacement rules apart.
+type TxPoolError struct {
+	Code    int
+	Message string
+}
+
+func (e *TxPoolError) Error() string  { return e.Message }
+func (e *TxPoolError) ErrorCode() int { return e.Code }
+
+// txPoolErrorCodes are the JSON-RPC error codes of the rejected replacements,
+// one per transaction replacement policy.
+var txPoolErrorCodes = map[error]int{
+	core.ErrReplaceUnderpriced:  -32020,
+	core.ErrReplaceUnderpowered: -32021,
+	core.ErrReplaceTooEarly:     -32022,
+}
+
+// txPoolError attaches the JSON-RPC error code to a transaction pool error, if
+// it has one.
+func txPoolError(err error) error {
+	if code, ok := txPoolErrorCodes[err]; ok {
+		return &TxPoolError{code, err.Error()}
+	}
+	return err
+}
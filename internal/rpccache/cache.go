@@ -0,0 +1,77 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rpccache caches the results of expensive read-only RPC calls, such as
+// the full masternode list or the witness schedule, which explorers poll on
+// every block.
+package rpccache
+
+import (
+	"fmt"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+var (
+	hitMeter  = metrics.NewRegisteredMeter("rpc/cache/hit", nil)  // Calls answered from the cache
+	missMeter = metrics.NewRegisteredMeter("rpc/cache/miss", nil) // Calls computed and cached
+)
+
+// Cache holds the results of RPC calls by method, block hash and arguments.
+// Since the results may depend on the chain head as well, the owner purges the
+// cache on every new head. A nil cache caches nothing.
+//
+// The results are shared by all the callers, they must not be modified.
+type Cache struct {
+	entries *lru.Cache
+}
+
+// New creates a cache holding up to size results.
+func New(size int) *Cache {
+	entries, _ := lru.New(size)
+	return &Cache{entries: entries}
+}
+
+// Do returns the result of the call of method at the given block with args,
+// running fn to compute it if it's not cached yet. Failed calls aren't cached.
+// The arguments are keyed by value, pointers must be dereferenced.
+func (c *Cache) Do(method string, block common.Hash, args []interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return fn()
+	}
+	key := fmt.Sprintf("%s/%x/%v", method, block, args)
+	if result, ok := c.entries.Get(key); ok {
+		hitMeter.Mark(1)
+		return result, nil
+	}
+	missMeter.Mark(1)
+
+	result, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	c.entries.Add(key, result)
+	return result, nil
+}
+
+// Purge drops all the cached results.
+func (c *Cache) Purge() {
+	if c != nil {
+		c.entries.Purge()
+	}
+}
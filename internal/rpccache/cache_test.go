@@ -0,0 +1,82 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpccache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+)
+
+// Tests that results are cached by method, block and arguments until purged,
+// and that failures are never cached.
+func TestCache(t *testing.T) {
+	cache := New(16)
+
+	calls := 0
+	call := func(method string, block common.Hash, args ...interface{}) interface{} {
+		result, err := cache.Do(method, block, args, func() (interface{}, error) {
+			calls++
+			return calls, nil
+		})
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		return result
+	}
+	if have := call("devote_getSigners", common.Hash{1}); have != 1 {
+		t.Fatalf("first call result mismatch: have %v, want 1", have)
+	}
+	if have := call("devote_getSigners", common.Hash{1}); have != 1 {
+		t.Fatalf("cached call result mismatch: have %v, want 1", have)
+	}
+	tests := []struct {
+		method string
+		block  common.Hash
+		args   []interface{}
+	}{
+		{"devote_getSignersByEpoch", common.Hash{1}, nil},
+		{"devote_getSigners", common.Hash{2}, nil},
+		{"devote_getSigners", common.Hash{1}, []interface{}{uint64(7)}},
+	}
+	for i, tt := range tests {
+		if have := call(tt.method, tt.block, tt.args...); have != i+2 {
+			t.Errorf("test %d: result mismatch: have %v, want %d", i, have, i+2)
+		}
+	}
+	cache.Purge()
+	if have := call("devote_getSigners", common.Hash{1}); have != 5 {
+		t.Fatalf("call after purge result mismatch: have %v, want 5", have)
+	}
+	failure := errors.New("failure")
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Do("masternode_list", common.Hash{1}, nil, func() (interface{}, error) {
+			calls++
+			return nil, failure
+		}); err != failure {
+			t.Fatalf("error mismatch: have %v, want %v", err, failure)
+		}
+	}
+	if calls != 7 {
+		t.Fatalf("failed call cached")
+	}
+	var disabled *Cache
+	if have, _ := disabled.Do("masternode_list", common.Hash{1}, nil, func() (interface{}, error) { return 42, nil }); have != 42 {
+		t.Fatalf("disabled cache result mismatch: have %v, want 42", have)
+	}
+}
@@ -32,6 +32,7 @@ var Modules = map[string]string{
 	"swarmfs":    SWARMFS_JS,
 	"txpool":     TxPool_JS,
 	"devote":     Devote_JS,
+	"masternode": Masternode_JS,
 }
 
 const Chequebook_JS = `
@@ -169,6 +170,11 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'resyncMasternodes',
+			call: 'admin_resyncMasternodes',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'importChain',
 			call: 'admin_importChain',
@@ -205,6 +211,10 @@ web3._extend({
 			name: 'nodeInfo',
 			getter: 'admin_nodeInfo'
 		}),
+		new web3._extend.Property({
+			name: 'masternodePeers',
+			getter: 'admin_masternodePeers'
+		}),
 		new web3._extend.Property({
 			name: 'peers',
 			getter: 'admin_peers'
@@ -414,6 +424,11 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'forks',
+			call: 'debug_forks',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -445,6 +460,16 @@ web3._extend({
 			call: 'eth_chainId',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'getSystemReceipt',
+			call: 'eth_getSystemReceipt',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sendRawTransactions',
+			call: 'eth_sendRawTransactions',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sign',
 			call: 'eth_sign',
@@ -488,6 +513,36 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getSponsorship',
+			call: 'eth_getSponsorship',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getHeader',
+			call: function(args) {
+				return (web3._extend.utils.isString(args[0]) && args[0].indexOf('0x') === 0) ? 'eth_getHeaderByHash' : 'eth_getHeaderByNumber';
+			},
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockWitness',
+			call: 'eth_getBlockWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'newMasternodeFilter',
+			call: 'eth_newMasternodeFilter',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getMasternodeEvents',
+			call: 'eth_getMasternodeEvents',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -704,11 +759,38 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getWitnesses',
+			call: 'devote_getSigners',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSignersAtHash',
+			call: 'devote_getSignersAtHash',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getSignersByEpoch',
 			call: 'devote_getSignersByEpoch',
 			params: 1,
-			inputFormatter: [web3._extend.formatters.uint64]
+			inputFormatter: [web3._extend.utils.toDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getStandbys',
+			call: 'devote_getStandbys',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getMasternodesAtCycle',
+			call: 'devote_getMasternodesAtCycle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getVRFKey',
+			call: 'devote_getVRFKey',
+			params: 0
 		}),
 		new web3._extend.Method({
 			name: 'getConfirmedBlockNumber',
@@ -716,12 +798,153 @@ web3._extend({
 			params: 0,
 			outputFormatter: web3._extend.utils.toBigNumber
 		}),
+		new web3._extend.Method({
+			name: 'getRoots',
+			call: 'devote_getRoots',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getSnapshot',
 			call: 'devote_getSnapshot',
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getSnapshotAtHash',
+			call: 'devote_getSnapshotAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlockPayee',
+			call: function(args) {
+				return (web3._extend.utils.isString(args[0]) && args[0].indexOf('0x') === 0) ? 'devote_getBlockPayeeAtHash' : 'devote_getBlockPayee';
+			},
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'witnessStats',
+			call: 'devote_witnessStats',
+			params: 2,
+			inputFormatter: [web3._extend.utils.toDecimal, web3._extend.utils.toDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'devote_propose',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discard',
+			call: 'devote_discard',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'proposals',
+			getter: 'devote_proposals'
+		}),
+	]
+});
+`
+
+const Masternode_JS = `
+web3._extend({
+	property: 'masternode',
+	methods: [
+		new web3._extend.Method({
+			name: 'status',
+			call: 'masternode_status',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'list',
+			call: 'masternode_list',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'count',
+			call: 'masternode_count',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'counts',
+			call: 'masternode_counts',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'estimateROI',
+			call: 'masternode_estimateROI',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'winner',
+			call: 'masternode_winner',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'data',
+			call: 'masternode_data',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getInfo',
+			call: 'masternode_getInfo',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'startMasternode',
+			call: 'masternode_startMasternode',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'stopMasternode',
+			call: 'masternode_stopMasternode',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'register',
+			call: 'masternode_register',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setup',
+			call: 'masternode_setup',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'quit',
+			call: 'masternode_quit',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'signDelegation',
+			call: 'masternode_signDelegation',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.toDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'withdraw',
+			call: 'masternode_withdraw',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'sentinelPing',
+			call: 'masternode_sentinelPing',
+			params: 1,
+			inputFormatter: [web3._extend.utils.toDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'announcements',
+			call: 'masternode_announcements',
+			params: 0
+		}),
 	]
 });
-`
\ No newline at end of file
+`
@@ -22,17 +22,20 @@ import (
 
 	"github.com/etherzero/go-etherzero/accounts"
 	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/common/hexutil"
 	"github.com/etherzero/go-etherzero/common/math"
 	"github.com/etherzero/go-etherzero/core"
 	"github.com/etherzero/go-etherzero/core/bloombits"
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
 	"github.com/etherzero/go-etherzero/core/vm"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/eth/gasprice"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
+	"github.com/etherzero/go-etherzero/internal/ethapi"
 	"github.com/etherzero/go-etherzero/light"
 	"github.com/etherzero/go-etherzero/params"
 	"github.com/etherzero/go-etherzero/rpc"
@@ -163,6 +166,10 @@ func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *LesApiBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.blockchain.SubscribeReorgEvent(ch)
+}
+
 func (b *LesApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -209,8 +216,8 @@ func (b *LesApiBackend) Masternodes() []string {
 }
 
 // Data return masternode contract data
-func (b *LesApiBackend) Data() string {
-	return ""
+func (b *LesApiBackend) Data() (hexutil.Bytes, error) {
+	return nil, ethapi.ErrMasternodeUnsupported
 }
 
 
@@ -219,13 +226,13 @@ func (b *LesApiBackend) Ns() int64 {
 }
 
 // GetInfo return related info in masternode contract
-func (b *LesApiBackend) GetInfo(nodeid string) string {
-	return ""
+func (b *LesApiBackend) GetInfo(nodeid string) (*masternode.Info, error) {
+	return nil, ethapi.ErrMasternodeUnsupported
 }
 
 // GetEnode return related Enodeinfo in enodeinfo contract
-func (b *LesApiBackend) GetEnode(nodeid string) string {
-	return ""
+func (b *LesApiBackend) GetEnode(nodeid string) (string, error) {
+	return "", ethapi.ErrMasternodeUnsupported
 }
 
 
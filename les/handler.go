@@ -33,6 +33,7 @@ import (
 	"github.com/etherzero/go-etherzero/core/rawdb"
 	"github.com/etherzero/go-etherzero/core/state"
 	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
 	"github.com/etherzero/go-etherzero/eth/downloader"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
@@ -60,6 +61,11 @@ const (
 	MaxTxSend                = 64  // Amount of transactions to be send per request
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
 
+	// devoteRecentBlocks is the number of blocks below its head whose devote
+	// proofs a server serves unless in devote archive mode. A cycle spans at most
+	// params.Epoch blocks, so this covers at least the two latest cycles.
+	devoteRecentBlocks = 2 * params.Epoch
+
 	disableClientRemovePeer = false
 )
 
@@ -324,7 +330,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetDevoteProofsMsg}
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
@@ -819,6 +825,53 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
 		return p.SendProofsV2(req.ReqID, bv, nodes.NodeList())
 
+	case GetDevoteProofsMsg:
+		p.Log().Trace("Received devote proofs request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID uint64
+			Reqs  []DevoteProofReq
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// Gather devote trie proofs until the fetch or network limits is reached
+		reqCnt := len(req.Reqs)
+		if reject(uint64(reqCnt), MaxProofsFetch) {
+			return errResp(ErrRequestRejected, "")
+		}
+		var (
+			archive = pm.server.config.LightDevoteArchive
+			head    = pm.blockchain.CurrentHeader().Number.Uint64()
+			db      = devotedb.NewDatabase(pm.chainDb)
+			nodes   = light.NewNodeSet()
+		)
+		for _, req := range req.Reqs {
+			// Look up the devote protocol of the requested block, if still served
+			header := pm.blockchain.GetHeaderByHash(req.BHash)
+			if header == nil || header.Protocol == nil {
+				continue
+			}
+			if !archive && header.Number.Uint64()+devoteRecentBlocks < head {
+				continue
+			}
+			root := header.Protocol.CycleHash
+			if req.Stats {
+				root = header.Protocol.StatsHash
+			}
+			trie, err := db.OpenTrie(root)
+			if err != nil {
+				continue
+			}
+			trie.Prove(req.Key, req.FromLevel, nodes)
+			if nodes.DataSize() >= softResponseLimit {
+				break
+			}
+		}
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		return p.SendDevoteProofs(req.ReqID, bv, nodes.NodeList())
+
 	case ProofsV1Msg:
 		if pm.odr == nil {
 			return errResp(ErrUnexpectedResponse, "")
@@ -861,6 +914,27 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			Obj:     resp.Data,
 		}
 
+	case DevoteProofsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received devote proofs response")
+		// A batch of devote merkle proofs arrived to one of our previous requests
+		var resp struct {
+			ReqID, BV uint64
+			Data      light.NodeList
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgDevoteProofs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
 	case GetHeaderProofsMsg:
 		p.Log().Trace("Received headers proof request")
 		// Decode the retrieval message
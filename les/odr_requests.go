@@ -64,6 +64,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*TrieRequest)(r)
 	case *light.CodeRequest:
 		return (*CodeRequest)(r)
+	case *light.DevoteTrieRequest:
+		return (*DevoteTrieRequest)(r)
 	case *light.ChtRequest:
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
@@ -256,6 +258,65 @@ func (r *TrieRequest) Validate(db ethdb.Database, msg *Msg) error {
 	}
 }
 
+// DevoteProofReq requests the merkle proof of a key in the cycle or the stats
+// devote trie of a block.
+type DevoteProofReq struct {
+	BHash     common.Hash
+	Stats     bool
+	Key       []byte
+	FromLevel uint
+}
+
+// ODR request type for devote trie entries, see LesOdrRequest interface
+type DevoteTrieRequest light.DevoteTrieRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *DevoteTrieRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetDevoteProofsMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request.
+// Proofs of old blocks are only requested from devote archive servers.
+func (r *DevoteTrieRequest) CanSend(peer *peer) bool {
+	return peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber, true) && peer.ServesDevoteProof(r.Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *DevoteTrieRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting devote trie proof", "root", r.Id.Root, "key", r.Key)
+	req := DevoteProofReq{
+		BHash: r.Id.BlockHash,
+		Stats: r.Id.Stats,
+		Key:   r.Key,
+	}
+	return peer.RequestDevoteProofs(reqID, r.GetCost(peer), []DevoteProofReq{req})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *DevoteTrieRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating devote trie proof", "root", r.Id.Root, "key", r.Key)
+
+	if msg.MsgType != MsgDevoteProofs {
+		return errInvalidMessageType
+	}
+	proofs := msg.Obj.(light.NodeList)
+	// Verify the proof and store if checks out
+	nodeSet := proofs.NodeSet()
+	reads := &readTraceDB{db: nodeSet}
+	if _, _, err := trie.VerifyProof(r.Id.Root, r.Key, reads); err != nil {
+		return fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	// check if all nodes have been read by VerifyProof
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	r.Proof = nodeSet
+	return nil
+}
+
 type CodeReq struct {
 	BHash  common.Hash
 	AccKey []byte
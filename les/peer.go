@@ -58,6 +58,8 @@ type peer struct {
 
 	announceType, requestAnnounceType uint64
 
+	devoteArchive bool // Whether the server serves the devote proofs of any block
+
 	id string
 
 	headInfo *announceData
@@ -217,6 +219,12 @@ func (p *peer) SendProofsV2(reqID, bv uint64, proofs light.NodeList) error {
 	return sendResponse(p.rw, ProofsV2Msg, reqID, bv, proofs)
 }
 
+// SendDevoteProofs sends a batch of devote trie merkle proofs, corresponding to
+// the ones requested.
+func (p *peer) SendDevoteProofs(reqID, bv uint64, proofs light.NodeList) error {
+	return sendResponse(p.rw, DevoteProofsMsg, reqID, bv, proofs)
+}
+
 // SendHeaderProofs sends a batch of legacy LES/1 header proofs, corresponding to the ones requested.
 func (p *peer) SendHeaderProofs(reqID, bv uint64, proofs []ChtResp) error {
 	return sendResponse(p.rw, HeaderProofsMsg, reqID, bv, proofs)
@@ -279,6 +287,25 @@ func (p *peer) RequestProofs(reqID, cost uint64, reqs []ProofReq) error {
 	}
 }
 
+// RequestDevoteProofs fetches a batch of devote trie merkle proofs from a remote node.
+func (p *peer) RequestDevoteProofs(reqID, cost uint64, reqs []DevoteProofReq) error {
+	p.Log().Debug("Fetching batch of devote proofs", "count", len(reqs))
+	return sendRequest(p.rw, GetDevoteProofsMsg, reqID, cost, reqs)
+}
+
+// ServesDevoteProof tells if the server still serves the devote proofs of the
+// given block: devote archive servers do for any block, the others for the
+// recent ones only.
+func (p *peer) ServesDevoteProof(number uint64) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.version < lpv2 {
+		return false
+	}
+	return p.devoteArchive || number+devoteRecentBlocks >= p.headInfo.Number
+}
+
 // RequestHelperTrieProofs fetches a batch of HelperTrie merkle proofs from a remote node.
 func (p *peer) RequestHelperTrieProofs(reqID, cost uint64, data interface{}) error {
 	switch p.version {
@@ -405,6 +432,9 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		send = send.add("serveChainSince", uint64(0))
 		send = send.add("serveStateSince", uint64(0))
 		send = send.add("txRelay", nil)
+		if server.config.LightDevoteArchive {
+			send = send.add("serveDevoteArchive", nil)
+		}
 		send = send.add("flowControl/BL", server.defParams.BufLimit)
 		send = send.add("flowControl/MRR", server.defParams.MinRecharge)
 		list := server.fcCostStats.getCurrentList()
@@ -471,6 +501,7 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		if recv.get("txRelay", nil) != nil {
 			return errResp(ErrUselessPeer, "peer cannot relay transactions")
 		}
+		p.devoteArchive = recv.get("serveDevoteArchive", nil) == nil
 		params := &flowcontrol.ServerParams{}
 		if err := recv.get("flowControl/BL", &params.BufLimit); err != nil {
 			return err
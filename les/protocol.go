@@ -46,7 +46,7 @@ var (
 )
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 22}
+var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 24}
 
 const (
 	NetworkId          = 1
@@ -79,6 +79,8 @@ const (
 	SendTxV2Msg            = 0x13
 	GetTxStatusMsg         = 0x14
 	TxStatusMsg            = 0x15
+	GetDevoteProofsMsg     = 0x16
+	DevoteProofsMsg        = 0x17
 )
 
 type errCode int
@@ -533,3 +533,9 @@ func (self *LightChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 func (self *LightChain) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return self.scope.Track(new(event.Feed).Subscribe(ch))
 }
+
+// SubscribeReorgEvent implements the interface of filters.Backend
+// LightChain does not send core.ReorgEvent, so return an empty subscription.
+func (self *LightChain) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return self.scope.Track(new(event.Feed).Subscribe(ch))
+}
@@ -95,6 +95,47 @@ func (req *TrieRequest) StoreResult(db ethdb.Database) {
 	req.Proof.Store(db)
 }
 
+// DevoteTrieID identifies the cycle or the stats devote trie of a block.
+type DevoteTrieID struct {
+	BlockHash, Root common.Hash
+	BlockNumber     uint64
+	Stats           bool
+}
+
+// DevoteCycleTrieID returns a DevoteTrieID for the cycle trie of a block, the
+// unified devote trie since the tries were merged.
+func DevoteCycleTrieID(header *types.Header) *DevoteTrieID {
+	return &DevoteTrieID{
+		BlockHash:   header.Hash(),
+		BlockNumber: header.Number.Uint64(),
+		Root:        header.Protocol.CycleHash,
+	}
+}
+
+// DevoteStatsTrieID returns a DevoteTrieID for the stats trie of a block.
+func DevoteStatsTrieID(header *types.Header) *DevoteTrieID {
+	return &DevoteTrieID{
+		BlockHash:   header.Hash(),
+		BlockNumber: header.Number.Uint64(),
+		Root:        header.Protocol.StatsHash,
+		Stats:       true,
+	}
+}
+
+// DevoteTrieRequest is the ODR request type for devote trie entries. Proofs of
+// blocks older than a few cycles are only served by devote archive servers.
+type DevoteTrieRequest struct {
+	OdrRequest
+	Id    *DevoteTrieID
+	Key   []byte
+	Proof *NodeSet
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *DevoteTrieRequest) StoreResult(db ethdb.Database) {
+	req.Proof.Store(db)
+}
+
 // CodeRequest is the ODR request type for retrieving contract code
 type CodeRequest struct {
 	OdrRequest
@@ -162,7 +162,33 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetSystemGas sets the block gas reserved for masternode system transactions,
+// which user transactions are not allowed to consume.
+func (self *Miner) SetSystemGas(gas uint64) {
+	self.worker.setSystemGas(gas)
+}
+
 // Pending returns the currently pending block and associated state.
+// SetSkipEmpty sets whether the local witness skips sealing blocks without
+// transactions in the slots the consensus rules allow it to.
+func (self *Miner) SetSkipEmpty(skip bool) {
+	self.worker.setSkipEmpty(skip)
+}
+
+// SetWatchdog enables snapshotting the goroutine and CPU profiles into dir
+// whenever the local witness misses its sealing deadline, or disables it if
+// dir is empty.
+func (self *Miner) SetWatchdog(dir string) {
+	self.worker.setWatchdog(dir)
+}
+
+// Finish stops the local witness from sealing new blocks and waits for the one
+// being sealed, if any, to be written to the chain within its slot. Mining must
+// be stopped or restarted afterwards.
+func (self *Miner) Finish() {
+	self.worker.finish()
+}
+
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
 }
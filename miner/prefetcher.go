@@ -0,0 +1,166 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/metrics"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+var (
+	prefetchTxMeter    = metrics.NewRegisteredMeter("miner/prefetch/txs", nil)
+	prefetchAbortMeter = metrics.NewRegisteredMeter("miner/prefetch/aborts", nil)
+	prefetchTimer      = metrics.NewRegisteredTimer("miner/prefetch/time", nil)
+)
+
+// prefetcher warms the state trie nodes and contract code touched by the pending
+// transactions while the local witness waits for its slot, by executing them on
+// a throwaway state. The block assembled at the slot then hits the shared state
+// caches instead of the disk, which keeps the seal latency of masternodes on slow
+// storage within the slot.
+type prefetcher struct {
+	config *params.ChainConfig
+	engine consensus.Engine
+	eth    Backend
+
+	parent    common.Hash // Parent block of the last prefetch run
+	interrupt *uint32     // Interrupt flag of the running prefetch
+	lock      sync.Mutex
+}
+
+func newPrefetcher(config *params.ChainConfig, engine consensus.Engine, eth Backend) *prefetcher {
+	return &prefetcher{
+		config: config,
+		engine: engine,
+		eth:    eth,
+	}
+}
+
+// prefetch starts warming the caches for a block on top of parent sealed at the
+// given slot, aborting any run for a previous parent. Repeated requests for the
+// same parent are ignored.
+func (p *prefetcher) prefetch(parent *types.Block, slot uint64, coinbase common.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.parent == parent.Hash() {
+		return
+	}
+	p.abort()
+
+	interrupt := new(uint32)
+	p.parent, p.interrupt = parent.Hash(), interrupt
+
+	go p.run(parent, slot, coinbase, interrupt)
+}
+
+// stop aborts the running prefetch, if any.
+func (p *prefetcher) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.abort()
+}
+
+func (p *prefetcher) abort() {
+	if p.interrupt != nil {
+		atomic.StoreUint32(p.interrupt, 1)
+		p.interrupt = nil
+	}
+}
+
+// header assembles the header of the block on top of parent sealed at the given
+// slot, as far as the transactions see it. The seal dependent fields are taken
+// over from the parent, which the executions don't depend on.
+func (p *prefetcher) header(chain consensus.ChainReader, parent *types.Block, slot uint64, coinbase common.Address) *types.Header {
+	return &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		Time:       new(big.Int).SetUint64(slot),
+		Coinbase:   coinbase,
+		Difficulty: p.engine.CalcDifficulty(chain, slot, parent.Header()),
+		Extra:      common.CopyBytes(parent.Extra()),
+		Witness:    parent.Header().Witness,
+		Protocol:   parent.Header().Protocol,
+	}
+}
+
+// run executes the pending transactions in mining order on top of parent until
+// all are processed or the run is interrupted.
+func (p *prefetcher) run(parent *types.Block, slot uint64, coinbase common.Address, interrupt *uint32) {
+	start := time.Now()
+
+	chain := p.eth.BlockChain()
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		log.Debug("Failed to open prefetch state", "number", parent.Number(), "err", err)
+		return
+	}
+	pending, err := p.eth.TxPool().Pending()
+	if err != nil {
+		return
+	}
+	header := p.header(chain, parent, slot, coinbase)
+	var (
+		signer = types.MakeSigner(p.config, header.Number)
+		txs    = types.NewTransactionsByPriceAndNonce(signer, pending)
+		gas    = header.GasLimit
+		count  int
+	)
+	for gas >= params.TxGas {
+		if atomic.LoadUint32(interrupt) == 1 {
+			prefetchAbortMeter.Mark(1)
+			break
+		}
+		tx := txs.Peek()
+		if tx == nil {
+			break
+		}
+		// Execution errors are irrelevant, the touched state is already loaded
+		statedb.Prepare(tx.Hash(), common.Hash{}, count)
+
+		var used uint64
+		gp := new(core.GasPool).AddGas(header.GasLimit)
+		if _, _, err := core.ApplyTransaction(p.config, chain, &coinbase, gp, statedb, header, tx, &used, vm.Config{}); err != nil {
+			txs.Pop()
+			continue
+		}
+		txs.Shift()
+
+		if used > gas {
+			used = gas
+		}
+		gas -= used
+		count++
+	}
+	prefetchTxMeter.Mark(int64(count))
+	prefetchTimer.UpdateSince(start)
+
+	log.Trace("Prefetched state for next slot", "number", header.Number, "txs", count, "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/accounts"
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/ethash"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// prefetchBackend implements Backend for running the prefetcher.
+type prefetchBackend struct {
+	db     ethdb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+}
+
+func (b *prefetchBackend) AccountManager() *accounts.Manager { return nil }
+func (b *prefetchBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *prefetchBackend) TxPool() *core.TxPool              { return b.txPool }
+func (b *prefetchBackend) ChainDb() ethdb.Database           { return b.db }
+
+// Tests that the prefetcher executes a pending transaction on a header which
+// carries everything the EVM context needs.
+func TestPrefetch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	var (
+		db     = ethdb.NewMemDatabase()
+		config = params.TestChainConfig
+		engine = ethash.NewFaker()
+		funds  = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+		gspec  = core.Genesis{Config: config, Alloc: core.GenesisAlloc{addr: {Balance: funds}}}
+	)
+	genesis := gspec.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Accrue the power to pay for the transaction, from the first block on
+	blocks, _ := core.GenerateChain(config, genesis, engine, db, 2, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	parent := chain.CurrentBlock()
+
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	pool := core.NewTxPool(poolConfig, config, chain)
+	defer pool.Stop()
+
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1000), params.TxGas, big.NewInt(params.GWei), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	p := newPrefetcher(config, engine, &prefetchBackend{db: db, chain: chain, txPool: pool})
+
+	slot := parent.Time().Uint64() + params.Period
+	header := p.header(chain, parent, slot, addr)
+	if header.Difficulty == nil || header.Difficulty.Sign() <= 0 {
+		t.Fatalf("prefetch header difficulty missing: %v", header.Difficulty)
+	}
+	// Running the prefetch must not bring the node down
+	if pending, _ := pool.Pending(); len(pending[addr]) != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want 1", len(pending[addr]))
+	}
+	p.run(parent, slot, addr, new(uint32))
+
+	// Nor the detached run for the slot
+	p.prefetch(parent, slot, addr)
+	p.stop()
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/log"
+	"github.com/etherzero/go-etherzero/metrics"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+const (
+	// watchdogCooldown is the minimum time between two profile snapshots, for a
+	// stalled node missing several slots in a row not to profile itself to death.
+	watchdogCooldown = time.Minute
+
+	// watchdogCPUWindow is how long the CPU is profiled for after a miss.
+	watchdogCPUWindow = 10 * time.Second
+
+	// watchdogRetain is the number of snapshots kept in the watchdog directory,
+	// the oldest ones being deleted first.
+	watchdogRetain = 16
+)
+
+var watchdogMissMeter = metrics.NewRegisteredMeter("miner/watchdog/misses", nil)
+
+// watchdog snapshots the goroutine and CPU profiles of the node whenever the
+// local witness fails to write the block of its slot before the slot ends, so
+// that the cause of the miss can be analysed after the fact.
+type watchdog struct {
+	dir    string                 // Directory the snapshots are written into
+	window time.Duration          // CPU profiling window after a miss
+	timers map[uint64]*time.Timer // Deadline timers of the blocks being sealed
+	last   time.Time              // Time of the last snapshot
+	lock   sync.Mutex
+}
+
+func newWatchdog(dir string) *watchdog {
+	return &watchdog{
+		dir:    dir,
+		window: watchdogCPUWindow,
+		timers: make(map[uint64]*time.Timer),
+	}
+}
+
+// arm starts watching the block number sealed in the given slot, which must be
+// written to the chain before the slot ends.
+func (w *watchdog) arm(number uint64, slot uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.timers[number]; ok {
+		return
+	}
+	deadline := time.Unix(int64(slot+params.Period), 0)
+	w.timers[number] = time.AfterFunc(time.Until(deadline), func() { w.miss(number, slot) })
+}
+
+// disarm stops watching a block number once its block was written.
+func (w *watchdog) disarm(number uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if timer, ok := w.timers[number]; ok {
+		timer.Stop()
+		delete(w.timers, number)
+	}
+}
+
+// stop disarms all the pending deadlines.
+func (w *watchdog) stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for number, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, number)
+	}
+}
+
+// miss is invoked when the deadline of a block passed without it being written.
+func (w *watchdog) miss(number uint64, slot uint64) {
+	w.lock.Lock()
+	if _, ok := w.timers[number]; !ok {
+		w.lock.Unlock()
+		return
+	}
+	delete(w.timers, number)
+
+	watchdogMissMeter.Mark(1)
+	if time.Since(w.last) < watchdogCooldown {
+		w.lock.Unlock()
+		log.Warn("Missed sealing deadline", "number", number, "slot", slot)
+		return
+	}
+	w.last = time.Now()
+	w.lock.Unlock()
+
+	dir, err := w.snapshot(number, slot)
+	if err != nil {
+		log.Error("Failed to snapshot profiles of missed slot", "number", number, "slot", slot, "err", err)
+		return
+	}
+	log.Warn("Missed sealing deadline, profiling", "number", number, "slot", slot, "dir", dir)
+}
+
+// snapshot writes the goroutine profile into a new directory named after the
+// missed slot, and profiles the CPU in the background unless it already is.
+func (w *watchdog) snapshot(number uint64, slot uint64) (string, error) {
+	dir := filepath.Join(w.dir, fmt.Sprintf("%d-%d", slot, number))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	w.prune()
+
+	f, err := os.Create(filepath.Join(dir, "goroutine.txt"))
+	if err != nil {
+		return "", err
+	}
+	err = pprof.Lookup("goroutine").WriteTo(f, 2)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+	cpu, err := os.Create(filepath.Join(dir, "cpu.prof"))
+	if err != nil {
+		return "", err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		log.Debug("CPU profiling unavailable, skipping", "err", err)
+		cpu.Close()
+		os.Remove(cpu.Name())
+		return dir, nil
+	}
+	go func() {
+		time.Sleep(w.window)
+		pprof.StopCPUProfile()
+		cpu.Close()
+	}()
+	return dir, nil
+}
+
+// prune deletes the oldest snapshots beyond the retained ones.
+func (w *watchdog) prune() {
+	infos, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return
+	}
+	var dirs []os.FileInfo
+	for _, info := range infos {
+		if info.IsDir() {
+			dirs = append(dirs, info)
+		}
+	}
+	if len(dirs) <= watchdogRetain {
+		return
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].ModTime().Before(dirs[j].ModTime()) })
+	for _, info := range dirs[:len(dirs)-watchdogRetain] {
+		os.RemoveAll(filepath.Join(w.dir, info.Name()))
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that the watchdog snapshots the profiles of the slots missed only, and
+// once per cooldown.
+func TestWatchdog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watchdog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := newWatchdog(dir)
+	w.window = 10 * time.Millisecond
+
+	// Blocks written before their deadline are never profiled
+	future := uint64(time.Now().Unix()) + 60
+	w.arm(1, future)
+	w.disarm(1)
+
+	// Blocks whose deadline passed are, the later ones within the cooldown not
+	past := uint64(time.Now().Unix()) - params.Period - 1
+	w.arm(2, past)
+	time.Sleep(100 * time.Millisecond)
+	w.arm(3, past)
+	time.Sleep(100 * time.Millisecond)
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("snapshot count mismatch: have %d, want 1", len(infos))
+	}
+	if want := fmt.Sprintf("%d-2", past); infos[0].Name() != want {
+		t.Errorf("snapshot mismatch: have %s, want %s", infos[0].Name(), want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, infos[0].Name(), "goroutine.txt")); err != nil {
+		t.Errorf("missing goroutine profile: %v", err)
+	}
+	if len(w.timers) != 0 {
+		t.Errorf("timers left armed: %d", len(w.timers))
+	}
+}
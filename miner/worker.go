@@ -37,7 +37,6 @@ import (
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/log"
 	"github.com/etherzero/go-etherzero/params"
-	"gopkg.in/fatih/set.v0"
 )
 
 const (
@@ -49,8 +48,6 @@ const (
 	txChanSize = 4096
 	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
 	chainHeadChanSize = 10
-	// chainSideChanSize is the size of channel listening to ChainSideEvent.
-	chainSideChanSize = 10
 
 	voteChanSize = 4096
 )
@@ -70,21 +67,28 @@ type Work struct {
 	config *params.ChainConfig
 	signer types.Signer
 
-	state     *state.StateDB // apply state changes here
-	ancestors *set.Set       // ancestor set (used for checking uncle parent validity)
-	family    *set.Set       // family set (used for checking uncle invalidity)
-	uncles    *set.Set       // uncle set
-	tcount    int            // tx count in cycle
-	gasPool   *core.GasPool  // available gas used to pack transactions
+	state   *state.StateDB // apply state changes here
+	tcount  int            // tx count in cycle
+	gasPool *core.GasPool  // available gas used to pack transactions
 
 	Block *types.Block // the new block
 
 	header    *types.Header
+	author    common.Address // Author of the block once sealed, the beneficiary of its transactions
 	txs       []*types.Transaction
 	receipts  []*types.Receipt
 	createdAt time.Time
 
 	devoteDB *devotedb.DevoteDB
+
+	sealed chan struct{} // Closed once the sealed block was written, or sealing failed
+}
+
+// markSealed signals that sealing the work is over, whatever the outcome.
+func (w *Work) markSealed() {
+	if w.sealed != nil {
+		close(w.sealed)
+	}
 }
 
 type Result struct {
@@ -106,8 +110,6 @@ type worker struct {
 
 	chainHeadCh  chan core.ChainHeadEvent
 	chainHeadSub event.Subscription
-	chainSideCh  chan core.ChainSideEvent
-	chainSideSub event.Subscription
 	wg           sync.WaitGroup
 
 	agents map[Agent]struct{}
@@ -118,8 +120,11 @@ type worker struct {
 	proc    core.Validator
 	chainDb ethdb.Database
 
-	coinbase common.Address
-	extra    []byte
+	coinbase  common.Address
+	extra     []byte
+	systemGas uint64    // Block gas reserved for masternode system transactions
+	skipEmpty bool      // Whether to skip sealing empty blocks where consensus allows
+	watchdog  *watchdog // Profiler of the missed sealing deadlines, nil if disabled
 
 	currentMu sync.Mutex
 	current   *Work
@@ -128,14 +133,15 @@ type worker struct {
 	snapshotBlock *types.Block
 	snapshotState *state.StateDB
 
-	uncleMu        sync.Mutex
-	possibleUncles map[common.Hash]*types.Block
-
 	unconfirmed *unconfirmedBlocks // set of locally mined blocks pending canonicalness confirmations
+	prefetcher  *prefetcher        // state warmer running ahead of the local witness slots
+
+	sealing *Work // Work last handed to the engine for sealing
 
 	// atomic status counters
-	mining int32
-	atWork int32
+	mining    int32
+	atWork    int32
+	finishing int32 // Whether the block being sealed is finished, but no new ones started
 
 	quitCh  chan struct{}
 	stopper chan struct{}
@@ -143,22 +149,22 @@ type worker struct {
 
 func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, eth Backend, mux *event.TypeMux) *worker {
 	worker := &worker{
-		config:         config,
-		engine:         engine,
-		eth:            eth,
-		mux:            mux,
-		txsCh:          make(chan core.NewTxsEvent, txChanSize),
-		chainHeadCh:    make(chan core.ChainHeadEvent, chainHeadChanSize),
-		chainDb:        eth.ChainDb(),
-		recv:           make(chan *Result, resultQueueSize),
-		chain:          eth.BlockChain(),
-		proc:           eth.BlockChain().Validator(),
-		possibleUncles: make(map[common.Hash]*types.Block),
-		coinbase:       coinbase,
-		agents:         make(map[Agent]struct{}),
-		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
-		quitCh:         make(chan struct{}, 1),
-		stopper:        make(chan struct{}, 1),
+		config:      config,
+		engine:      engine,
+		eth:         eth,
+		mux:         mux,
+		txsCh:       make(chan core.NewTxsEvent, txChanSize),
+		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
+		chainDb:     eth.ChainDb(),
+		recv:        make(chan *Result, resultQueueSize),
+		chain:       eth.BlockChain(),
+		proc:        eth.BlockChain().Validator(),
+		coinbase:    coinbase,
+		agents:      make(map[Agent]struct{}),
+		unconfirmed: newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
+		prefetcher:  newPrefetcher(config, engine, eth),
+		quitCh:      make(chan struct{}, 1),
+		stopper:     make(chan struct{}, 1),
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
@@ -185,6 +191,30 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setSystemGas(gas uint64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.systemGas = gas
+}
+
+func (self *worker) setSkipEmpty(skip bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.skipEmpty = skip
+}
+
+func (self *worker) setWatchdog(dir string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.watchdog != nil {
+		self.watchdog.stop()
+	}
+	self.watchdog = nil
+	if dir != "" {
+		self.watchdog = newWatchdog(dir)
+	}
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	if atomic.LoadInt32(&self.mining) == 0 {
 		// return a snapshot to avoid contention on currentMu mutex
@@ -236,9 +266,29 @@ func (self *worker) start() {
 	defer self.mu.Unlock()
 
 	atomic.StoreInt32(&self.mining, 1)
+	atomic.StoreInt32(&self.finishing, 0)
 	go self.mineLoop()
 }
 
+// finish stops starting to seal new blocks and waits for the block being sealed,
+// if any, to be written to the chain, as long as its slot didn't end yet.
+func (self *worker) finish() {
+	self.mu.Lock()
+	atomic.StoreInt32(&self.finishing, 1)
+	work := self.sealing
+	self.mu.Unlock()
+
+	if work == nil {
+		return
+	}
+	deadline := time.Unix(int64(work.Block.Time().Uint64()+params.Period), 0)
+	select {
+	case <-work.sealed:
+	case <-time.After(time.Until(deadline)):
+		log.Warn("Slot ended before its block was sealed", "number", work.Block.Number())
+	}
+}
+
 func (self *worker) seal(work *Work) {
 	if result, err := self.engine.Seal(self.chain, work.Block, self.quitCh); result != nil {
 		log.Info("Successfully sealed new block", "number", result.Number(), "hash", result.Hash(), "diff", result.Difficulty())
@@ -247,6 +297,7 @@ func (self *worker) seal(work *Work) {
 		if err != nil {
 			log.Warn("Block sealing failed", "err", err)
 		}
+		work.markSealed()
 		self.recv <- nil
 	}
 }
@@ -257,8 +308,12 @@ func (self *worker) mine(now int64) {
 		log.Error("Only the devote engine was allowed")
 		return
 	}
+	if atomic.LoadInt32(&self.finishing) == 1 {
+		return
+	}
 
-	err := engine.CheckWitness(self.chain.CurrentBlock(), now)
+	head := self.chain.CurrentBlock()
+	err := engine.CheckWitness(head, now)
 	if err != nil {
 		switch err {
 		case devote.ErrWaitForPrevBlock,
@@ -269,19 +324,46 @@ func (self *worker) mine(now int64) {
 		default:
 			log.Error("Failed to miner the block", "err", err)
 		}
+		// Warm the state caches if the upcoming slot is ours
+		if slot := devote.NextSlot(uint64(now)) + params.Period; engine.IsWitnessAt(head.Header(), slot) {
+			self.mu.Lock()
+			coinbase := self.coinbase
+			self.mu.Unlock()
+
+			self.prefetcher.prefetch(head, slot, coinbase)
+		}
 		return
 	}
+	self.prefetcher.stop()
 
+	self.mu.Lock()
+	skipEmpty, watchdog := self.skipEmpty, self.watchdog
+	self.mu.Unlock()
+	if skipEmpty {
+		if pending, _ := self.eth.TxPool().Stats(); pending == 0 && engine.MaySkipEmpty(head.Header(), uint64(now)) {
+			log.Debug("Skipping empty slot", "number", head.NumberU64()+1, "slot", now)
+			return
+		}
+	}
+	if watchdog != nil {
+		watchdog.arm(head.NumberU64()+1, uint64(now))
+	}
 	work, err := self.commitNewWork()
 	if err != nil {
 		log.Error("Failed to create the new work", "err", err)
 		return
 	}
 	self.mu.Lock()
+	if atomic.LoadInt32(&self.finishing) == 1 {
+		self.mu.Unlock()
+		return
+	}
 	if self.quitCh != nil {
 		close(self.quitCh)
 	}
 	self.quitCh = make(chan struct{})
+	work.sealed = make(chan struct{})
+	self.sealing = work
 	go self.seal(work)
 
 	self.mu.Unlock()
@@ -295,6 +377,7 @@ func (self *worker) mineLoop() {
 			//	drift := time.Duration(discover.NanoDrift())
 			self.mine(now.Unix())
 		case <-self.stopper:
+			self.prefetcher.stop()
 			close(self.quitCh)
 			self.quitCh = make(chan struct{}, 1)
 			self.stopper = make(chan struct{}, 1)
@@ -315,6 +398,9 @@ func (self *worker) stop() {
 
 	atomic.StoreInt32(&self.mining, 0)
 	atomic.StoreInt32(&self.atWork, 0)
+	if self.watchdog != nil {
+		self.watchdog.stop()
+	}
 	close(self.stopper)
 }
 
@@ -358,7 +444,7 @@ func (self *worker) update() {
 					txs[acc] = append(txs[acc], tx)
 				}
 				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
-				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
+				self.current.commitTransactions(self.mux, txset, self.chain, self.current.author, self.systemGas)
 				self.updateSnapshot()
 				self.currentMu.Unlock()
 			} else {
@@ -401,8 +487,14 @@ func (self *worker) wait() {
 			stat, err := self.chain.WriteBlockWithState(block, work.receipts, work.state)
 			if err != nil {
 				log.Error("Failed writing block to chain", "err", err)
+				work.markSealed()
 				continue
 			}
+			self.mu.Lock()
+			if self.watchdog != nil {
+				self.watchdog.disarm(block.NumberU64())
+			}
+			self.mu.Unlock()
 			// Broadcast the block and announce chain insertion event
 			self.mux.Post(core.NewMinedBlockEvent{Block: block})
 			var (
@@ -417,6 +509,7 @@ func (self *worker) wait() {
 
 			// Insert the block into the set of pending ones to wait for confirmations
 			self.unconfirmed.Insert(block.NumberU64(), block.Hash())
+			work.markSealed()
 		}
 	}
 }
@@ -448,23 +541,11 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		config:    self.config,
 		signer:    types.NewEIP155Signer(self.config.ChainID),
 		state:     state,
-		ancestors: set.New(),
-		family:    set.New(),
-		uncles:    set.New(),
 		header:    header,
 		createdAt: time.Now(),
 		devoteDB:  devoteDB,
 	}
 
-	// when 08 is processed ancestors contain 07 (quick block)
-	for _, ancestor := range self.chain.GetBlocksFromHash(parent.Hash(), 7) {
-		for _, uncle := range ancestor.Uncles() {
-			work.family.Add(uncle.Hash())
-		}
-		work.family.Add(ancestor.Hash())
-		work.ancestors.Add(ancestor.Hash())
-	}
-
 	// Keep track of transactions which return errors so they can be removed
 	work.tcount = 0
 	self.current = work
@@ -474,8 +555,6 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 func (self *worker) commitNewWork() (*Work, error) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
-	self.uncleMu.Lock()
-	defer self.uncleMu.Unlock()
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
 
@@ -531,38 +610,31 @@ func (self *worker) commitNewWork() (*Work, error) {
 	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(work.state)
 	}
+	// Execute the transactions for the author the block will have once sealed
+	work.author = header.Coinbase
+	if engine, ok := self.engine.(*devote.Devote); ok {
+		if author, err := engine.LocalAuthor(header); err == nil {
+			work.author = author
+		}
+	}
 	pending, err := self.eth.TxPool().Pending()
 	if err != nil {
 		return nil, fmt.Errorf("got error when fetch pending transactions, err: %s", err)
 	}
 
+	// Commit the masternode system transactions first so that user transaction
+	// floods can't starve them, then fill the block up to the reserved gas. The
+	// reserve stays available to system transactions queued behind user ones of
+	// the same account.
+	if system := systemTransactions(self.config, pending); len(system) > 0 {
+		txs := types.NewTransactionsByPriceAndNonce(self.current.signer, system)
+		work.commitTransactions(self.mux, txs, self.chain, work.author, 0)
+	}
 	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitTransactions(self.mux, txs, self.chain, work.author, self.systemGas)
 
-	// compute uncles for the new block.
-	var (
-		uncles    []*types.Header
-		badUncles []common.Hash
-	)
-	for hash, uncle := range self.possibleUncles {
-		if len(uncles) > 0 {
-			break
-		}
-		if err := self.commitUncle(work, uncle.Header()); err != nil {
-			log.Trace("Bad uncle found and will be removed", "hash", hash)
-			log.Trace(fmt.Sprint(uncle))
-
-			badUncles = append(badUncles, hash)
-		} else {
-			log.Debug("Committing new uncle to block", "hash", hash)
-			uncles = append(uncles, uncle.Header())
-		}
-	}
-	for _, hash := range badUncles {
-		delete(self.possibleUncles, hash)
-	}
 	// Create the new block to seal with the consensus engine
-	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, uncles, work.receipts, work.devoteDB); err != nil {
+	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, nil, work.receipts, work.devoteDB); err != nil {
 		return nil, fmt.Errorf("Finalize block failed:%s", err)
 	}
 
@@ -571,28 +643,13 @@ func (self *worker) commitNewWork() (*Work, error) {
 	// update the count for the miner of new block
 	// We only care about logging if we're actually mining.
 	if atomic.LoadInt32(&self.mining) == 1 {
-		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "elapsed", common.PrettyDuration(time.Since(tstart)))
 		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
 	}
 	self.updateSnapshot()
 	return work, nil
 }
 
-func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
-	hash := uncle.Hash()
-	if work.uncles.Has(hash) {
-		return fmt.Errorf("uncle not unique")
-	}
-	if !work.ancestors.Has(uncle.ParentHash) {
-		return fmt.Errorf("uncle's parent unknown (%x)", uncle.ParentHash[0:4])
-	}
-	if work.family.Has(hash) {
-		return fmt.Errorf("uncle already in family (%x)", hash)
-	}
-	work.uncles.Add(uncle.Hash())
-	return nil
-}
-
 func (self *worker) updateSnapshot() {
 	self.snapshotMu.Lock()
 	defer self.snapshotMu.Unlock()
@@ -606,7 +663,26 @@ func (self *worker) updateSnapshot() {
 	self.snapshotState = self.current.state.Copy()
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
+// systemTransactions returns the leading masternode system transactions of
+// every account, keeping the nonce ordering intact.
+func systemTransactions(config *params.ChainConfig, pending map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	system := make(map[common.Address]types.Transactions)
+	for addr, txs := range pending {
+		n := 0
+		for n < len(txs) && core.IsVoteTx(config, txs[n]) {
+			n++
+		}
+		if n > 0 {
+			system[addr] = txs[:n]
+		}
+	}
+	return system
+}
+
+// commitTransactions applies the given transactions to the pending block. User
+// transactions are not allowed to consume the last reserve gas of the block,
+// which is kept for masternode system transactions.
+func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address, reserve uint64) {
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
 	}
@@ -637,6 +713,13 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			txs.Pop()
 			continue
 		}
+		// Keep the reserved gas for system transactions, the account's later
+		// transactions can't be included either
+		if reserve > 0 && !core.IsVoteTx(env.config, tx) && env.gasPool.Gas() < tx.Gas()+reserve {
+			log.Trace("Reserved gas for system transactions", "sender", from, "hash", tx.Hash())
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
 
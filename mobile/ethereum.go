@@ -84,6 +84,11 @@ func (p *SyncProgress) GetHighestBlock() int64  { return int64(p.progress.Highes
 func (p *SyncProgress) GetPulledStates() int64  { return int64(p.progress.PulledStates) }
 func (p *SyncProgress) GetKnownStates() int64   { return int64(p.progress.KnownStates) }
 
+func (p *SyncProgress) GetCurrentCycle() int64       { return int64(p.progress.CurrentCycle) }
+func (p *SyncProgress) GetHighestCycle() int64       { return int64(p.progress.HighestCycle) }
+func (p *SyncProgress) GetPulledDevoteStates() int64 { return int64(p.progress.PulledDevoteStates) }
+func (p *SyncProgress) GetKnownDevoteStates() int64  { return int64(p.progress.KnownDevoteStates) }
+
 // Topics is a set of topic lists to filter events with.
 type Topics struct{ topics [][]common.Hash }
 
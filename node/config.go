@@ -42,6 +42,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	keystoreMasternodeKey  = "masternodekey"      // Path within the keystore to the encrypted masternode key
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -86,6 +87,13 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// MasternodeKeyPassword, if set, stores the node key (which doubles as the
+	// masternode key) encrypted inside the keystore instead of in plaintext. An
+	// existing plaintext key is migrated on first use. The key is decrypted at
+	// startup and its keystore account stays unlocked while the node runs, so
+	// locking it explicitly stops the node from sealing and pinging.
+	MasternodeKeyPassword string `toml:"-"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -150,6 +158,12 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCRateLimit throttles the calls of every remote client of the HTTP and
+	// websocket RPC interfaces, by IP address, overall and per method. Public
+	// endpoints use it to keep expensive calls such as masternode_list from
+	// starving the node.
+	RPCRateLimit rpc.RateLimit `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -314,6 +328,9 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 		return key
 	}
 
+	if c.MasternodeKeyPassword != "" {
+		return c.masternodeKey()
+	}
 	keyfile := c.ResolvePath(datadirPrivateKey)
 	if key, err := crypto.LoadECDSA(keyfile); err == nil {
 		return key
@@ -335,6 +352,41 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	return key
 }
 
+// masternodeKey retrieves the node key from its encrypted location within the
+// keystore, migrating any plaintext key found in the data folder. If no key can
+// be found, a new one is generated and stored encrypted.
+func (c *Config) masternodeKey() *ecdsa.PrivateKey {
+	scryptN, scryptP, keydir, err := c.AccountConfig()
+	if err != nil {
+		log.Crit(fmt.Sprintf("Failed to resolve keystore for masternode key: %v", err))
+	}
+	keyfile := filepath.Join(keydir, keystoreMasternodeKey)
+	if common.FileExist(keyfile) {
+		key, err := keystore.LoadMasternodeKey(keyfile, c.MasternodeKeyPassword)
+		if err != nil {
+			log.Crit(fmt.Sprintf("Failed to decrypt masternode key: %v", err))
+		}
+		return key
+	}
+	if plainfile := c.ResolvePath(datadirPrivateKey); common.FileExist(plainfile) {
+		key, err := keystore.MigrateMasternodeKey(plainfile, keyfile, c.MasternodeKeyPassword, scryptN, scryptP)
+		if err != nil {
+			log.Crit(fmt.Sprintf("Failed to migrate plaintext masternode key: %v", err))
+		}
+		log.Info("Migrated plaintext masternode key into keystore", "path", keyfile)
+		return key
+	}
+	// No persistent key found, generate and store a new one.
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		log.Crit(fmt.Sprintf("Failed to generate masternode key: %v", err))
+	}
+	if err := keystore.StoreMasternodeKey(keyfile, key, c.MasternodeKeyPassword, scryptN, scryptP); err != nil {
+		log.Error(fmt.Sprintf("Failed to persist masternode key: %v", err))
+	}
+	return key
+}
+
 // StaticNodes returns a list of node enode URLs configured as static nodes.
 func (c *Config) StaticNodes() []*enode.Node {
 	return c.parsePersistentNodes(c.ResolvePath(datadirStaticNodes))
@@ -17,6 +17,7 @@
 package node
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"net"
@@ -27,6 +28,7 @@ import (
 	"sync"
 
 	"github.com/etherzero/go-etherzero/accounts"
+	"github.com/etherzero/go-etherzero/accounts/keystore"
 	"github.com/etherzero/go-etherzero/ethdb"
 	"github.com/etherzero/go-etherzero/event"
 	"github.com/etherzero/go-etherzero/internal/debug"
@@ -134,6 +136,17 @@ func (n *Node) Register(constructor ServiceConstructor) error {
 	return nil
 }
 
+// unlockMasternodeKey unlocks the masternode key, decrypted from the keystore
+// with the configured password, in the keystore itself.
+func (n *Node) unlockMasternodeKey(key *ecdsa.PrivateKey) {
+	backends := n.accman.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		n.log.Warn("No keystore to unlock the masternode key in")
+		return
+	}
+	backends[0].(*keystore.KeyStore).UnlockMasternodeKey(key)
+}
+
 // Start create a live P2P node and starts running it.
 func (n *Node) Start() error {
 	n.lock.Lock()
@@ -151,6 +164,9 @@ func (n *Node) Start() error {
 	// discovery databases.
 	n.serverConfig = n.config.P2P
 	n.serverConfig.PrivateKey = n.config.NodeKey()
+	if n.config.DataDir != "" && n.config.MasternodeKeyPassword != "" {
+		n.unlockMasternodeKey(n.serverConfig.PrivateKey)
+	}
 	n.serverConfig.Name = n.config.NodeName()
 	n.serverConfig.Logger = n.log
 	if n.serverConfig.StaticNodes == nil {
@@ -340,6 +356,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if err != nil {
 		return err
 	}
+	handler.SetRateLimit(n.config.RPCRateLimit)
 	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
@@ -373,6 +390,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if err != nil {
 		return err
 	}
+	handler.SetRateLimit(n.config.RPCRateLimit)
 	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()))
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
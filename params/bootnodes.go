@@ -48,6 +48,13 @@ var MainnetMasternodes = []string{
 	"enode://8375c6b34607d06b5d5b4df1a375cecc1df1237f420cb201c37900f856260e7b90d6fe8f64a30a01a4216c9c9627e22baa0089dee385f27aa0398f6fd2f085e4", // [21]
 }
 
+// MainnetMasternodeBootstrap are the enode URLs of known-good masternodes dialed
+// to join the masternode gossip while the masternode contract can't be read,
+// until superseded by a signed list looked up in DNS.
+var MainnetMasternodeBootstrap = []string{
+	"enode://3b9471c1b4d93a45a1f7aff368d027dc7eeac7c526d80848d9848773b0426f41931ecdafa6f513800f68b5425f5b1a482ccbd6eb4b0f39982c4d3ff0cefe085e@35.182.48.79:21212", // [20], Canada
+}
+
 var TestnetBootnodes = []string{
 	"enode://59ca967b2c9c1442e81026f5ffc2b24f4b3787512194a41e4ab14dfac97e75b700988cac80f973641d40cd65f775f41955b93d2e843ebb03555b16dd9bf983d4@127.0.0.1:9646",
 }
@@ -56,6 +63,12 @@ var TestnetMasternodes = []string{
 	"enode://59ca967b2c9c1442e81026f5ffc2b24f4b3787512194a41e4ab14dfac97e75b700988cac80f973641d40cd65f775f41955b93d2e843ebb03555b16dd9bf983d4", // nodekey: a9b50794ab7a9987aa416c455c13aa6cc8c0448c501a3ce8e4840efe47cb5c29
 }
 
+// TestnetMasternodeBootstrap are the enode URLs of the masternodes dialed to join
+// the masternode gossip of the test network while its contract can't be read.
+var TestnetMasternodeBootstrap = []string{
+	"enode://59ca967b2c9c1442e81026f5ffc2b24f4b3787512194a41e4ab14dfac97e75b700988cac80f973641d40cd65f775f41955b93d2e843ebb03555b16dd9bf983d4@127.0.0.1:9646",
+}
+
 // RinkebyBootnodes are the enode URLs of the P2P bootstrap nodes running on the
 // Rinkeby test network.
 var RinkebyBootnodes = []string{}
@@ -31,6 +31,8 @@ var (
 
 	MasterndeContractAddress  = common.HexToAddress("0x000000000000000000000000000000000000000a")
 	GovernanceContractAddress = common.HexToAddress("0x000000000000000000000000000000000000000b")
+	SponsorRegistryAddress    = common.HexToAddress("0x000000000000000000000000000000000000000c")
+	ConsensusInfoAddress      = common.HexToAddress("0x000000000000000000000000000000000000000d")
 )
 
 var (
@@ -187,6 +189,65 @@ type DevoteConfig struct {
 	Period    uint64   `json:"period"`    // Number of seconds between blocks to enforce
 	Epoch     uint64   `json:"epoch"`     // Epoch length to reset votes and checkpoint
 	Witnesses []string `json:"witnesses"` // Genesis witness list
+
+	KickoutBlock     *big.Int `json:"kickoutBlock,omitempty"`     // Block switching to the threshold based kickout rule (nil = no fork)
+	KickoutThreshold uint64   `json:"kickoutThreshold,omitempty"` // Percentage of its expected blocks a witness must seal to stay eligible
+
+	EpochSummaryBlock *big.Int `json:"epochSummaryBlock,omitempty"` // Block from which cycle elections are summarized in the header extra-data (nil = no fork)
+
+	PaymentQueueBlock *big.Int `json:"paymentQueueBlock,omitempty"` // Block from which the coinbase must be the payee of the masternode payment queue (nil = no fork)
+
+	SuperblockBlock *big.Int `json:"superblockBlock,omitempty"` // Block from which approved governance budgets are paid out in superblocks (nil = no fork)
+	SuperblockCycle uint64   `json:"superblockCycle,omitempty"` // Number of cycles between two superblocks
+
+	Rewards []RewardStage `json:"rewards,omitempty"` // Block reward schedule, the default reward applies before the first stage
+
+	TreasuryBlock *big.Int `json:"treasuryBlock,omitempty"` // Block from which the governance treasury takes its cut of the coinbase reward (nil = no fork)
+
+	MasternodeContract  common.Address       `json:"masternodeContract,omitempty"`  // Address of the genesis masternode contract (zero = MasterndeContractAddress)
+	GovernanceContract  common.Address       `json:"governanceContract,omitempty"`  // Address of the governance contract (zero = GovernanceContractAddress)
+	MasternodeContracts []MasternodeContract `json:"masternodeContracts,omitempty"` // Upgrades of the masternode contract, the genesis contract applies before the first one
+
+	SkipEmptyBlock *big.Int `json:"skipEmptyBlock,omitempty"` // Block from which witnesses may skip empty slots once they sealed in the cycle (nil = no fork)
+
+	SponsorBlock *big.Int `json:"sponsorBlock,omitempty"` // Block from which accounts may sponsor the power of the calls to contracts (nil = no fork)
+
+	ConsensusInfoBlock *big.Int `json:"consensusInfoBlock,omitempty"` // Block from which contracts may query the witnesses and masternodes (nil = no fork)
+
+	UnifiedTrieBlock *big.Int `json:"unifiedTrieBlock,omitempty"` // Block from which the devote records live in a single trie (nil = no fork)
+
+	WitnessesBlock *big.Int `json:"witnessesBlock,omitempty"` // Block from which the witness set size follows MaxWitnesses and the governance contract (nil = no fork)
+	MaxWitnesses   uint64   `json:"maxWitnesses,omitempty"`   // Witnesses elected per cycle unless the governance contract sets another number
+
+	StandbyBlock     *big.Int `json:"standbyBlock,omitempty"`     // Block from which standby witnesses may seal the slots missed by the witnesses (nil = no fork)
+	StandbyWitnesses uint64   `json:"standbyWitnesses,omitempty"` // Standby witnesses elected per cycle after the witnesses
+	StandbyGrace     uint64   `json:"standbyGrace,omitempty"`     // Seconds without a block after which a standby witness may seal the slot
+
+	BeaconBlock *big.Int `json:"beaconBlock,omitempty"` // Block from which the witnesses feed a randomness beacon ordering the next election (nil = no fork)
+
+	VRFBlock   *big.Int `json:"vrfBlock,omitempty"`   // Block from which the slots are privately assigned to the witnesses by a VRF (nil = no fork)
+	VRFLeaders uint64   `json:"vrfLeaders,omitempty"` // Witnesses expected to be eligible for each slot in the VRF mode
+
+	MasternodeSetBlock *big.Int `json:"masternodeSetBlock,omitempty"` // Block from which the hash of the masternode set each cycle is elected from is recorded (nil = no fork)
+
+	AuthorBlock *big.Int `json:"authorBlock,omitempty"` // Block from which the block author is the masternode recovered from the seal instead of the coinbase (nil = no fork)
+
+	DelegationBlock *big.Int `json:"delegationBlock,omitempty"` // Block from which masternodes may seal with a hot key delegated in the extra-data (nil = no fork)
+
+	MasternodeRegistryBlock *big.Int `json:"masternodeRegistryBlock,omitempty"` // Block from which the masternodes are elected from the registry of the devote trie (nil = no fork)
+
+	LowSBlock *big.Int `json:"lowSBlock,omitempty"` // Block from which only the low s form of the seal signature is valid (nil = no fork)
+
+	SystemContracts bool     `json:"systemContracts,omitempty"` // Deploy the system contracts missing from the genesis alloc at their configured addresses
+	Masternodes     []string `json:"masternodes,omitempty"`     // Enodes registered in the masternode contract deployed at genesis
+}
+
+// MasternodeContract is a version of the masternode system contract, in effect
+// from its block until the block of the next upgrade.
+type MasternodeContract struct {
+	Block   *big.Int       `json:"block"`   // First block reading the contract
+	Address common.Address `json:"address"` // Address the contract is deployed at
+	Version uint64         `json:"version"` // ABI version of the contract
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -194,6 +255,183 @@ func (d *DevoteConfig) String() string {
 	return "devote"
 }
 
+// IsKickout returns whether num is either equal to the kickout fork block or
+// greater, evicting inactive witnesses based on the configured threshold.
+func (d *DevoteConfig) IsKickout(num *big.Int) bool {
+	return d != nil && d.KickoutThreshold > 0 && isForked(d.KickoutBlock, num)
+}
+
+// IsEpochSummary returns whether num is either equal to the epoch summary fork
+// block or greater.
+func (d *DevoteConfig) IsEpochSummary(num *big.Int) bool {
+	return d != nil && isForked(d.EpochSummaryBlock, num)
+}
+
+// IsPaymentQueue returns whether num is either equal to the payment queue fork
+// block or greater.
+func (d *DevoteConfig) IsPaymentQueue(num *big.Int) bool {
+	return d != nil && isForked(d.PaymentQueueBlock, num)
+}
+
+// IsSuperblock returns whether num is either equal to the superblock fork block
+// or greater, paying out the approved governance budgets every SuperblockCycle
+// cycles.
+func (d *DevoteConfig) IsSuperblock(num *big.Int) bool {
+	return d != nil && d.SuperblockCycle > 0 && isForked(d.SuperblockBlock, num)
+}
+
+// IsTreasury returns whether num is either equal to the treasury fork block or
+// greater.
+func (d *DevoteConfig) IsTreasury(num *big.Int) bool {
+	return d != nil && isForked(d.TreasuryBlock, num)
+}
+
+// IsSkipEmpty returns whether num is either equal to the skip empty fork block
+// or greater. From then on witnesses only need to seal a single block per cycle
+// to stay eligible, and may skip their other slots while the txpool is empty.
+func (d *DevoteConfig) IsSkipEmpty(num *big.Int) bool {
+	return d != nil && isForked(d.SkipEmptyBlock, num)
+}
+
+// IsSponsor returns whether num is either equal to the sponsor fork block or
+// greater. From then on the sponsor registry is consulted for the account paying
+// the power of a transaction.
+func (d *DevoteConfig) IsSponsor(num *big.Int) bool {
+	return d != nil && isForked(d.SponsorBlock, num)
+}
+
+// IsConsensusInfo returns whether num is either equal to the consensus info fork
+// block or greater, exposing the cycle, its witnesses and the masternodes to
+// contracts.
+func (d *DevoteConfig) IsConsensusInfo(num *big.Int) bool {
+	return d != nil && isForked(d.ConsensusInfoBlock, num)
+}
+
+// IsUnifiedTrie returns whether num is either equal to the unified trie fork
+// block or greater. From then on headers commit to a single devote trie, the
+// records of the cycles before the fork still being read from the legacy tries.
+func (d *DevoteConfig) IsUnifiedTrie(num *big.Int) bool {
+	return d != nil && isForked(d.UnifiedTrieBlock, num)
+}
+
+// IsWitnesses returns whether num is either equal to the witnesses fork block or
+// greater. From then on up to MaxWitnesses witnesses are elected per cycle, or
+// as many as the governance contract sets.
+func (d *DevoteConfig) IsWitnesses(num *big.Int) bool {
+	return d != nil && d.MaxWitnesses > 0 && isForked(d.WitnessesBlock, num)
+}
+
+// IsStandby returns whether num is either equal to the standby fork block or
+// greater. From then on StandbyWitnesses standby witnesses are elected along
+// with the witnesses, and the one in line for a slot may seal it once the chain
+// went without a block for more than StandbyGrace seconds.
+func (d *DevoteConfig) IsStandby(num *big.Int) bool {
+	return d != nil && d.StandbyWitnesses > 0 && isForked(d.StandbyBlock, num)
+}
+
+// IsBeacon returns whether num is either equal to the beacon fork block or
+// greater. From then on the first block of each witness in a cycle reveals a
+// layer of its hash onion, mixed into the beacon ordering the next election.
+func (d *DevoteConfig) IsBeacon(num *big.Int) bool {
+	return d != nil && isForked(d.BeaconBlock, num)
+}
+
+// IsVRF returns whether num is either equal to the VRF fork block or greater.
+// From then on a witness seals a slot only if the output of its VRF, keyed by
+// the governance contract, makes it eligible, instead of taking turns.
+func (d *DevoteConfig) IsVRF(num *big.Int) bool {
+	return d != nil && d.VRFLeaders > 0 && isForked(d.VRFBlock, num)
+}
+
+// IsMasternodeSet returns whether num is either equal to the masternode set fork
+// block or greater. From then on the first block of each cycle records the hash
+// of the masternode set the cycle was elected from in the devote trie.
+func (d *DevoteConfig) IsMasternodeSet(num *big.Int) bool {
+	return d != nil && isForked(d.MasternodeSetBlock, num)
+}
+
+// IsAuthor returns whether num is either equal to the author fork block or
+// greater. From then on the author of a block is the masternode which sealed
+// it, receiving the block reward unless the payment queue names a payee.
+func (d *DevoteConfig) IsAuthor(num *big.Int) bool {
+	return d != nil && isForked(d.AuthorBlock, num)
+}
+
+// IsDelegation returns whether num is either equal to the delegation fork block
+// or greater. From then on a block may be sealed by a hot key, carrying the
+// delegation of the masternode key in its extra-data.
+func (d *DevoteConfig) IsDelegation(num *big.Int) bool {
+	return d != nil && isForked(d.DelegationBlock, num)
+}
+
+// IsMasternodeRegistry returns whether num is either equal to the masternode
+// registry fork block or greater. From then on the devote trie keeps a registry
+// of the masternodes, seeded from the masternode contract and following its
+// events, which the cycles are elected from instead of calls to the contract.
+func (d *DevoteConfig) IsMasternodeRegistry(num *big.Int) bool {
+	return d != nil && isForked(d.MasternodeRegistryBlock, num)
+}
+
+// IsLowS returns whether num is either equal to the low s fork block or greater.
+// From then on a block is only valid with the canonical form of its seal
+// signature, so that it can't be given a second hash.
+func (d *DevoteConfig) IsLowS(num *big.Int) bool {
+	return d != nil && isForked(d.LowSBlock, num)
+}
+
+// GenesisMasternodeContract returns the address of the masternode contract
+// deployed at genesis, in effect until the first upgrade.
+func (d *DevoteConfig) GenesisMasternodeContract() common.Address {
+	if d == nil || d.MasternodeContract == (common.Address{}) {
+		return MasterndeContractAddress
+	}
+	return d.MasternodeContract
+}
+
+// GovernanceContractAddress returns the address of the governance contract.
+func (d *DevoteConfig) GovernanceContractAddress() common.Address {
+	if d == nil || d.GovernanceContract == (common.Address{}) {
+		return GovernanceContractAddress
+	}
+	return d.GovernanceContract
+}
+
+// MasternodeContractAt returns the masternode contract in effect at block num.
+func (d *DevoteConfig) MasternodeContractAt(num *big.Int) MasternodeContract {
+	contract := MasternodeContract{Block: new(big.Int), Address: d.GenesisMasternodeContract(), Version: 1}
+	if d != nil {
+		for _, upgrade := range d.MasternodeContracts {
+			if isForked(upgrade.Block, num) {
+				contract = upgrade
+			}
+		}
+	}
+	return contract
+}
+
+// MasternodeContractAddresses returns the addresses of all the versions of the
+// masternode contract, starting with the genesis one.
+func (d *DevoteConfig) MasternodeContractAddresses() []common.Address {
+	addrs := []common.Address{d.GenesisMasternodeContract()}
+	if d != nil {
+		for _, upgrade := range d.MasternodeContracts {
+			addrs = append(addrs, upgrade.Address)
+		}
+	}
+	return addrs
+}
+
+// IsMasternodeContract reports whether addr is any version of the masternode
+// contract.
+func (d *DevoteConfig) IsMasternodeContract(addr common.Address) bool {
+	for _, contract := range d.MasternodeContractAddresses() {
+		if contract == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -340,6 +578,89 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.DevoteBlock, newcfg.DevoteBlock, head) {
 		return newCompatError("Devote fork block", c.DevoteBlock, newcfg.DevoteBlock)
 	}
+	if c.Devote != nil && newcfg.Devote != nil {
+		if isForkIncompatible(c.Devote.KickoutBlock, newcfg.Devote.KickoutBlock, head) {
+			return newCompatError("Kickout fork block", c.Devote.KickoutBlock, newcfg.Devote.KickoutBlock)
+		}
+		if c.Devote.IsKickout(head) && c.Devote.KickoutThreshold != newcfg.Devote.KickoutThreshold {
+			return newCompatError("Kickout threshold", c.Devote.KickoutBlock, newcfg.Devote.KickoutBlock)
+		}
+		if isForkIncompatible(c.Devote.EpochSummaryBlock, newcfg.Devote.EpochSummaryBlock, head) {
+			return newCompatError("Epoch summary fork block", c.Devote.EpochSummaryBlock, newcfg.Devote.EpochSummaryBlock)
+		}
+		if isForkIncompatible(c.Devote.PaymentQueueBlock, newcfg.Devote.PaymentQueueBlock, head) {
+			return newCompatError("Payment queue fork block", c.Devote.PaymentQueueBlock, newcfg.Devote.PaymentQueueBlock)
+		}
+		if isForkIncompatible(c.Devote.SuperblockBlock, newcfg.Devote.SuperblockBlock, head) {
+			return newCompatError("Superblock fork block", c.Devote.SuperblockBlock, newcfg.Devote.SuperblockBlock)
+		}
+		if c.Devote.IsSuperblock(head) && c.Devote.SuperblockCycle != newcfg.Devote.SuperblockCycle {
+			return newCompatError("Superblock cycle", c.Devote.SuperblockBlock, newcfg.Devote.SuperblockBlock)
+		}
+		if stored, next := rewardsIncompatible(c.Devote.Rewards, newcfg.Devote.Rewards); isForked(stored, head) || isForked(next, head) {
+			return newCompatError("Reward schedule", stored, next)
+		}
+		if isForkIncompatible(c.Devote.TreasuryBlock, newcfg.Devote.TreasuryBlock, head) {
+			return newCompatError("Treasury fork block", c.Devote.TreasuryBlock, newcfg.Devote.TreasuryBlock)
+		}
+		if isForkIncompatible(c.Devote.SkipEmptyBlock, newcfg.Devote.SkipEmptyBlock, head) {
+			return newCompatError("Skip empty fork block", c.Devote.SkipEmptyBlock, newcfg.Devote.SkipEmptyBlock)
+		}
+		if isForkIncompatible(c.Devote.SponsorBlock, newcfg.Devote.SponsorBlock, head) {
+			return newCompatError("Sponsor fork block", c.Devote.SponsorBlock, newcfg.Devote.SponsorBlock)
+		}
+		if isForkIncompatible(c.Devote.ConsensusInfoBlock, newcfg.Devote.ConsensusInfoBlock, head) {
+			return newCompatError("Consensus info fork block", c.Devote.ConsensusInfoBlock, newcfg.Devote.ConsensusInfoBlock)
+		}
+		if isForkIncompatible(c.Devote.UnifiedTrieBlock, newcfg.Devote.UnifiedTrieBlock, head) {
+			return newCompatError("Unified trie fork block", c.Devote.UnifiedTrieBlock, newcfg.Devote.UnifiedTrieBlock)
+		}
+		if isForkIncompatible(c.Devote.WitnessesBlock, newcfg.Devote.WitnessesBlock, head) {
+			return newCompatError("Witnesses fork block", c.Devote.WitnessesBlock, newcfg.Devote.WitnessesBlock)
+		}
+		if c.Devote.IsWitnesses(head) && c.Devote.MaxWitnesses != newcfg.Devote.MaxWitnesses {
+			return newCompatError("Maximum witnesses", c.Devote.WitnessesBlock, newcfg.Devote.WitnessesBlock)
+		}
+		if isForkIncompatible(c.Devote.StandbyBlock, newcfg.Devote.StandbyBlock, head) {
+			return newCompatError("Standby fork block", c.Devote.StandbyBlock, newcfg.Devote.StandbyBlock)
+		}
+		if c.Devote.IsStandby(head) && (c.Devote.StandbyWitnesses != newcfg.Devote.StandbyWitnesses || c.Devote.StandbyGrace != newcfg.Devote.StandbyGrace) {
+			return newCompatError("Standby witnesses", c.Devote.StandbyBlock, newcfg.Devote.StandbyBlock)
+		}
+		if isForkIncompatible(c.Devote.BeaconBlock, newcfg.Devote.BeaconBlock, head) {
+			return newCompatError("Beacon fork block", c.Devote.BeaconBlock, newcfg.Devote.BeaconBlock)
+		}
+		if isForkIncompatible(c.Devote.VRFBlock, newcfg.Devote.VRFBlock, head) {
+			return newCompatError("VRF fork block", c.Devote.VRFBlock, newcfg.Devote.VRFBlock)
+		}
+		if c.Devote.IsVRF(head) && c.Devote.VRFLeaders != newcfg.Devote.VRFLeaders {
+			return newCompatError("VRF leaders", c.Devote.VRFBlock, newcfg.Devote.VRFBlock)
+		}
+		if isForkIncompatible(c.Devote.MasternodeSetBlock, newcfg.Devote.MasternodeSetBlock, head) {
+			return newCompatError("Masternode set fork block", c.Devote.MasternodeSetBlock, newcfg.Devote.MasternodeSetBlock)
+		}
+		if isForkIncompatible(c.Devote.AuthorBlock, newcfg.Devote.AuthorBlock, head) {
+			return newCompatError("Author fork block", c.Devote.AuthorBlock, newcfg.Devote.AuthorBlock)
+		}
+		if isForkIncompatible(c.Devote.DelegationBlock, newcfg.Devote.DelegationBlock, head) {
+			return newCompatError("Delegation fork block", c.Devote.DelegationBlock, newcfg.Devote.DelegationBlock)
+		}
+		if isForkIncompatible(c.Devote.MasternodeRegistryBlock, newcfg.Devote.MasternodeRegistryBlock, head) {
+			return newCompatError("Masternode registry fork block", c.Devote.MasternodeRegistryBlock, newcfg.Devote.MasternodeRegistryBlock)
+		}
+		if isForkIncompatible(c.Devote.LowSBlock, newcfg.Devote.LowSBlock, head) {
+			return newCompatError("Low s fork block", c.Devote.LowSBlock, newcfg.Devote.LowSBlock)
+		}
+		if c.Devote.GenesisMasternodeContract() != newcfg.Devote.GenesisMasternodeContract() {
+			return newCompatError("Masternode contract address", common.Big0, common.Big0)
+		}
+		if c.Devote.GovernanceContractAddress() != newcfg.Devote.GovernanceContractAddress() {
+			return newCompatError("Governance contract address", common.Big0, common.Big0)
+		}
+		if stored, next := contractsIncompatible(c.Devote.MasternodeContracts, newcfg.Devote.MasternodeContracts); isForked(stored, head) || isForked(next, head) {
+			return newCompatError("Masternode contract upgrade", stored, next)
+		}
+	}
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
@@ -352,6 +673,24 @@ func isForkIncompatible(s1, s2, head *big.Int) bool {
 	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
 }
 
+// contractsIncompatible returns the blocks of the first masternode contract
+// upgrade at which the two configs diverge, nil for a config lacking it.
+func contractsIncompatible(s1, s2 []MasternodeContract) (*big.Int, *big.Int) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		switch {
+		case i >= len(s1):
+			return nil, s2[i].Block
+		case i >= len(s2):
+			return s1[i].Block, nil
+		}
+		a, b := s1[i], s2[i]
+		if !configNumEqual(a.Block, b.Block) || a.Address != b.Address || a.Version != b.Version {
+			return a.Block, b.Block
+		}
+	}
+	return nil, nil
+}
+
 // isForked returns whether a fork scheduled at block s is active at the given head block.
 func isForked(s, head *big.Int) bool {
 	if s == nil || head == nil {
@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +72,197 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{KickoutBlock: big.NewInt(10), KickoutThreshold: 50}},
+			new:    &ChainConfig{Devote: &DevoteConfig{KickoutBlock: big.NewInt(20), KickoutThreshold: 50}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Kickout fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{PaymentQueueBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Devote: &DevoteConfig{}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Payment queue fork block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    nil,
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{SuperblockBlock: big.NewInt(10), SuperblockCycle: 30}},
+			new:    &ChainConfig{Devote: &DevoteConfig{SuperblockBlock: big.NewInt(10), SuperblockCycle: 60}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Superblock cycle",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{Rewards: []RewardStage{{Block: big.NewInt(10), Reward: big.NewInt(1)}}}},
+			new:    &ChainConfig{Devote: &DevoteConfig{Rewards: []RewardStage{{Block: big.NewInt(10), Reward: big.NewInt(2)}}}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Reward schedule",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{Rewards: []RewardStage{{Block: big.NewInt(10), Reward: big.NewInt(1)}}}},
+			new:    &ChainConfig{Devote: &DevoteConfig{Rewards: []RewardStage{{Block: big.NewInt(10), Reward: big.NewInt(1)}, {Block: big.NewInt(30), Reward: big.NewInt(2)}}}},
+			head:   20,
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{}},
+			new:    &ChainConfig{Devote: &DevoteConfig{TreasuryBlock: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Treasury fork block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{SkipEmptyBlock: big.NewInt(10)}},
+			new:    &ChainConfig{Devote: &DevoteConfig{SkipEmptyBlock: big.NewInt(30)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Skip empty fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{WitnessesBlock: big.NewInt(10), MaxWitnesses: 21}},
+			new:    &ChainConfig{Devote: &DevoteConfig{WitnessesBlock: big.NewInt(10), MaxWitnesses: 31}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Maximum witnesses",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{WitnessesBlock: big.NewInt(30), MaxWitnesses: 21}},
+			new:    &ChainConfig{Devote: &DevoteConfig{WitnessesBlock: big.NewInt(30), MaxWitnesses: 31}},
+			head:   20,
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{StandbyBlock: big.NewInt(10), StandbyWitnesses: 5, StandbyGrace: 3}},
+			new:    &ChainConfig{Devote: &DevoteConfig{StandbyBlock: big.NewInt(10), StandbyWitnesses: 5, StandbyGrace: 6}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Standby witnesses",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{BeaconBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Devote: &DevoteConfig{}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Beacon fork block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    nil,
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{VRFBlock: big.NewInt(10), VRFLeaders: 1}},
+			new:    &ChainConfig{Devote: &DevoteConfig{VRFBlock: big.NewInt(10), VRFLeaders: 2}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "VRF leaders",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{MasternodeContracts: []MasternodeContract{{Block: big.NewInt(10), Address: common.Address{0x0c}, Version: 1}}}},
+			new:    &ChainConfig{Devote: &DevoteConfig{MasternodeContracts: []MasternodeContract{{Block: big.NewInt(10), Address: common.Address{0x0d}, Version: 1}}}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Masternode contract upgrade",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{AuthorBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Devote: &DevoteConfig{AuthorBlock: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Author fork block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{}},
+			new:    &ChainConfig{Devote: &DevoteConfig{DelegationBlock: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Delegation fork block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{}},
+			new:    &ChainConfig{Devote: &DevoteConfig{MasternodeRegistryBlock: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Masternode registry fork block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{}},
+			new:    &ChainConfig{Devote: &DevoteConfig{LowSBlock: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Low s fork block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{}},
+			new:    &ChainConfig{Devote: &DevoteConfig{MasternodeContract: common.Address{0x0c}}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Masternode contract address",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    big.NewInt(0),
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: &ChainConfig{Devote: &DevoteConfig{MasternodeContract: MasterndeContractAddress}},
+			new:    &ChainConfig{Devote: &DevoteConfig{}},
+			head:   20,
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +272,58 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestMasternodeContractAt(t *testing.T) {
+	config := &DevoteConfig{
+		MasternodeContracts: []MasternodeContract{
+			{Block: big.NewInt(100), Address: common.Address{0x0c}, Version: 1},
+			{Block: big.NewInt(200), Address: common.Address{0x0d}, Version: 2},
+		},
+	}
+	tests := []struct {
+		number  int64
+		address common.Address
+		version uint64
+	}{
+		{0, MasterndeContractAddress, 1},
+		{99, MasterndeContractAddress, 1},
+		{100, common.Address{0x0c}, 1},
+		{200, common.Address{0x0d}, 2},
+	}
+	for _, tt := range tests {
+		contract := config.MasternodeContractAt(big.NewInt(tt.number))
+		if contract.Address != tt.address || contract.Version != tt.version {
+			t.Errorf("block %d: contract mismatch: have %x/v%d, want %x/v%d", tt.number, contract.Address, contract.Version, tt.address, tt.version)
+		}
+	}
+	if !config.IsMasternodeContract(MasterndeContractAddress) || !config.IsMasternodeContract(common.Address{0x0d}) {
+		t.Errorf("masternode contract versions not recognized")
+	}
+}
+
+func TestContractAddressOverrides(t *testing.T) {
+	var config *DevoteConfig
+	if addr := config.GenesisMasternodeContract(); addr != MasterndeContractAddress {
+		t.Errorf("default masternode contract mismatch: have %x, want %x", addr, MasterndeContractAddress)
+	}
+	if addr := config.GovernanceContractAddress(); addr != GovernanceContractAddress {
+		t.Errorf("default governance contract mismatch: have %x, want %x", addr, GovernanceContractAddress)
+	}
+	config = &DevoteConfig{
+		MasternodeContract:  common.Address{0x1a},
+		GovernanceContract:  common.Address{0x1b},
+		MasternodeContracts: []MasternodeContract{{Block: big.NewInt(100), Address: common.Address{0x0c}, Version: 1}},
+	}
+	if addr := config.MasternodeContractAt(big.NewInt(99)).Address; addr != (common.Address{0x1a}) {
+		t.Errorf("genesis masternode contract mismatch: have %x, want %x", addr, common.Address{0x1a})
+	}
+	if addr := config.GovernanceContractAddress(); addr != (common.Address{0x1b}) {
+		t.Errorf("governance contract mismatch: have %x, want %x", addr, common.Address{0x1b})
+	}
+	if config.IsMasternodeContract(MasterndeContractAddress) {
+		t.Errorf("default masternode contract recognized despite the override")
+	}
+	if !config.IsMasternodeContract(common.Address{0x1a}) || !config.IsMasternodeContract(common.Address{0x0c}) {
+		t.Errorf("masternode contract versions not recognized")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	// DefaultBlockReward is the total block reward in wei paid before the first
+	// stage of the reward schedule.
+	DefaultBlockReward = big.NewInt(0.45e+18)
+
+	// DefaultCommunityShare is the percentage of the block reward paid to the
+	// community fund before the first stage of the reward schedule.
+	DefaultCommunityShare = uint64(25)
+
+	errRewardStage = errors.New("reward stage without block or reward")
+	errRewardOrder = errors.New("reward stages not in ascending block order")
+	errRewardShare = errors.New("community share of reward stage above 100%")
+)
+
+// RewardStage is a piece of the block reward schedule, in effect from its block
+// until the block of the next stage.
+type RewardStage struct {
+	Block     *big.Int `json:"block"`             // First block of the stage
+	Reward    *big.Int `json:"reward"`            // Total block reward in wei at the start of the stage
+	Community uint64   `json:"community"`         // Percentage of the reward paid to the community fund, the rest goes to the coinbase
+	Halving   uint64   `json:"halving,omitempty"` // Number of blocks after which the reward halves within the stage (0 = constant)
+}
+
+// reward returns the total block reward of block num within the stage.
+func (s *RewardStage) reward(num *big.Int) *big.Int {
+	if s.Halving == 0 {
+		return new(big.Int).Set(s.Reward)
+	}
+	halvings := new(big.Int).Sub(num, s.Block)
+	halvings.Div(halvings, new(big.Int).SetUint64(s.Halving))
+	if !halvings.IsUint64() || halvings.Uint64() >= uint64(s.Reward.BitLen()) {
+		return new(big.Int)
+	}
+	return new(big.Int).Rsh(s.Reward, uint(halvings.Uint64()))
+}
+
+// BlockReward returns the rewards of block num paid to the coinbase and to the
+// community fund, following the reward schedule. An error is returned if the
+// schedule is malformed.
+func (d *DevoteConfig) BlockReward(num *big.Int) (coinbase *big.Int, community *big.Int, err error) {
+	var (
+		reward = DefaultBlockReward
+		share  = DefaultCommunityShare
+	)
+	if d != nil {
+		var prev *big.Int
+		for i := range d.Rewards {
+			stage := &d.Rewards[i]
+			if stage.Block == nil || stage.Reward == nil || stage.Reward.Sign() < 0 {
+				return nil, nil, errRewardStage
+			}
+			if prev != nil && stage.Block.Cmp(prev) <= 0 {
+				return nil, nil, errRewardOrder
+			}
+			if stage.Community > 100 {
+				return nil, nil, errRewardShare
+			}
+			prev = stage.Block
+
+			if isForked(stage.Block, num) {
+				reward, share = stage.reward(num), stage.Community
+			}
+		}
+	}
+	community = new(big.Int).Mul(reward, new(big.Int).SetUint64(share))
+	community.Div(community, big.NewInt(100))
+
+	return new(big.Int).Sub(reward, community), community, nil
+}
+
+// rewardsIncompatible returns the blocks of the first stage at which the two
+// reward schedules diverge, nil for a schedule lacking the stage.
+func rewardsIncompatible(s1, s2 []RewardStage) (*big.Int, *big.Int) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		switch {
+		case i >= len(s1):
+			return nil, s2[i].Block
+		case i >= len(s2):
+			return s1[i].Block, nil
+		}
+		a, b := s1[i], s2[i]
+		if !configNumEqual(a.Block, b.Block) || !configNumEqual(a.Reward, b.Reward) || a.Community != b.Community || a.Halving != b.Halving {
+			return a.Block, b.Block
+		}
+	}
+	return nil, nil
+}
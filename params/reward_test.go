@@ -0,0 +1,71 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBlockReward(t *testing.T) {
+	config := &DevoteConfig{
+		Rewards: []RewardStage{
+			{Block: big.NewInt(100), Reward: big.NewInt(1000), Community: 10},
+			{Block: big.NewInt(200), Reward: big.NewInt(800), Community: 50, Halving: 10},
+		},
+	}
+	tests := []struct {
+		number              int64
+		coinbase, community *big.Int
+	}{
+		{0, big.NewInt(0.3375e+18), big.NewInt(0.1125e+18)},
+		{99, big.NewInt(0.3375e+18), big.NewInt(0.1125e+18)},
+		{100, big.NewInt(900), big.NewInt(100)},
+		{199, big.NewInt(900), big.NewInt(100)},
+		{200, big.NewInt(400), big.NewInt(400)},
+		{209, big.NewInt(400), big.NewInt(400)},
+		{210, big.NewInt(200), big.NewInt(200)},
+		{235, big.NewInt(50), big.NewInt(50)},
+		{10000, big.NewInt(0), big.NewInt(0)},
+	}
+	for _, tt := range tests {
+		coinbase, community, err := config.BlockReward(big.NewInt(tt.number))
+		if err != nil {
+			t.Fatalf("block %d: failed to compute reward: %v", tt.number, err)
+		}
+		if coinbase.Cmp(tt.coinbase) != 0 || community.Cmp(tt.community) != 0 {
+			t.Errorf("block %d: reward mismatch: have %v/%v, want %v/%v", tt.number, coinbase, community, tt.coinbase, tt.community)
+		}
+	}
+}
+
+func TestBlockRewardInvalid(t *testing.T) {
+	tests := []struct {
+		stages []RewardStage
+		err    error
+	}{
+		{[]RewardStage{{Block: big.NewInt(1)}}, errRewardStage},
+		{[]RewardStage{{Block: big.NewInt(2), Reward: big.NewInt(1)}, {Block: big.NewInt(2), Reward: big.NewInt(1)}}, errRewardOrder},
+		{[]RewardStage{{Block: big.NewInt(1), Reward: big.NewInt(1), Community: 101}}, errRewardShare},
+	}
+	for i, tt := range tests {
+		config := &DevoteConfig{Rewards: tt.stages}
+		if _, _, err := config.BlockReward(big.NewInt(10)); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
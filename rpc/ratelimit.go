@@ -0,0 +1,192 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/etherzero/go-etherzero/metrics"
+)
+
+// rateLimitSweep is the interval between two drops of the buckets of the
+// clients which stopped calling.
+const rateLimitSweep = time.Minute
+
+var throttledMeter = metrics.NewRegisteredMeter("rpc/throttled", nil) // Calls rejected by the rate limits
+
+// Quota is a token bucket: calls are allowed at Rate per second on average, up
+// to Burst at once.
+type Quota struct {
+	Rate  float64 // Calls per second, 0 = unlimited
+	Burst int     // Calls allowed at once, at least 1
+}
+
+// RateLimit configures the quotas of the calls of every remote client, by IP
+// address. Local clients, over IPC or in-process, are never throttled.
+type RateLimit struct {
+	Rate    float64          `toml:",omitempty"` // Calls per second of a client to all methods, 0 = unlimited
+	Burst   int              `toml:",omitempty"` // Calls of a client to all methods allowed at once
+	Methods map[string]Quota `toml:",omitempty"` // Additional quotas of the calls to single methods, by name (e.g. masternode_list)
+}
+
+// Enabled reports whether any of the quotas limits the calls.
+func (cfg RateLimit) Enabled() bool {
+	if cfg.Rate > 0 {
+		return true
+	}
+	for _, quota := range cfg.Methods {
+		if quota.Rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// bucket is the token bucket of a client under a quota.
+type bucket struct {
+	quota  Quota
+	tokens float64
+	last   time.Time
+}
+
+// burst returns the capacity of the bucket.
+func (b *bucket) burst() float64 {
+	if b.quota.Burst < 1 {
+		return 1
+	}
+	return float64(b.quota.Burst)
+}
+
+// level returns the tokens in the bucket by now, refilled for the time elapsed
+// since the last call.
+func (b *bucket) level(now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*b.quota.Rate
+	if burst := b.burst(); tokens > burst {
+		return burst
+	}
+	return tokens
+}
+
+// take takes a token from the bucket, which must hold one.
+func (b *bucket) take(now time.Time) {
+	b.tokens, b.last = b.level(now)-1, now
+}
+
+// rateLimiter tracks the buckets of the remote clients.
+type rateLimiter struct {
+	cfg RateLimit
+
+	buckets map[string]*bucket // Buckets by client IP, and by IP and method for the method quotas
+	swept   time.Time          // Time of the last drop of the unused buckets
+	lock    sync.Mutex
+}
+
+func newRateLimiter(cfg RateLimit) *rateLimiter {
+	return &rateLimiter{cfg: cfg, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from the buckets of the client for the call of method,
+// reporting whether the call is within the quotas. Both buckets must have a
+// token for the call to go through.
+func (l *rateLimiter) allow(ip string, method string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.swept) >= rateLimitSweep {
+		for key, b := range l.buckets {
+			if b.level(now) >= b.burst() {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+	var buckets []*bucket
+	if l.cfg.Rate > 0 {
+		buckets = append(buckets, l.bucket(ip, Quota{Rate: l.cfg.Rate, Burst: l.cfg.Burst}, now))
+	}
+	if quota, ok := l.cfg.Methods[method]; ok && quota.Rate > 0 {
+		buckets = append(buckets, l.bucket(ip+"/"+method, quota, now))
+	}
+	for _, b := range buckets {
+		if b.level(now) < 1 {
+			return false
+		}
+	}
+	for _, b := range buckets {
+		b.take(now)
+	}
+	return true
+}
+
+// bucket returns the bucket of the given key, creating a full one if missing.
+func (l *rateLimiter) bucket(key string, quota Quota, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{quota: quota, last: now}
+		b.tokens = b.burst()
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// remoteIP returns the IP address of the remote client of the call, empty for
+// local clients.
+func remoteIP(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	if remote == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
+
+// SetRateLimit configures the quotas of the calls of the remote clients of the
+// server, disabling the limits if none is set.
+func (s *Server) SetRateLimit(cfg RateLimit) {
+	s.limiterMu.Lock()
+	defer s.limiterMu.Unlock()
+
+	if !cfg.Enabled() {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newRateLimiter(cfg)
+}
+
+// throttle reports whether the call of method by the client of the context is
+// over its quotas, counting the rejected calls.
+func (s *Server) throttle(ctx context.Context, method string) bool {
+	s.limiterMu.RLock()
+	limiter := s.limiter
+	s.limiterMu.RUnlock()
+
+	if limiter == nil {
+		return false
+	}
+	ip := remoteIP(ctx)
+	if ip == "" || limiter.allow(ip, method, time.Now()) {
+		return false
+	}
+	throttledMeter.Mark(1)
+	metrics.GetOrRegisterMeter("rpc/throttled/"+method, nil).Mark(1)
+	return true
+}
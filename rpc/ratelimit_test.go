@@ -0,0 +1,105 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests that the buckets of a client allow bursts, refill over time and are
+// kept apart from the ones of other clients and methods.
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{
+		Rate:    10,
+		Burst:   5,
+		Methods: map[string]Quota{"masternode_list": {Rate: 1, Burst: 2}},
+	})
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 5; i++ {
+		if !limiter.allow("10.0.0.1", "eth_blockNumber", now) {
+			t.Fatalf("call %d of the burst throttled", i)
+		}
+	}
+	if limiter.allow("10.0.0.1", "eth_blockNumber", now) {
+		t.Fatalf("call past the burst allowed")
+	}
+	if !limiter.allow("10.0.0.2", "eth_blockNumber", now) {
+		t.Fatalf("call of another client throttled")
+	}
+	now = now.Add(100 * time.Millisecond)
+	if !limiter.allow("10.0.0.1", "eth_blockNumber", now) {
+		t.Fatalf("call after refill throttled")
+	}
+	// Method quotas apply on top of the client one
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if !limiter.allow("10.0.0.1", "masternode_list", now) {
+			t.Fatalf("method call %d of the burst throttled", i)
+		}
+	}
+	if limiter.allow("10.0.0.1", "masternode_list", now) {
+		t.Fatalf("method call past the burst allowed")
+	}
+	if !limiter.allow("10.0.0.1", "eth_blockNumber", now) {
+		t.Fatalf("throttled method call took a token from the client bucket")
+	}
+	// Idle clients are dropped once their buckets refilled
+	now = now.Add(rateLimitSweep)
+	limiter.allow("10.0.0.3", "eth_blockNumber", now)
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("bucket count mismatch after sweep: have %d, want 1", len(limiter.buckets))
+	}
+}
+
+// Tests that remote HTTP clients over their quota get an error response, while
+// local clients are never throttled.
+func TestRateLimitHTTP(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetRateLimit(RateLimit{Methods: map[string]Quota{"test_rets": {Rate: 0.001, Burst: 1}}})
+
+	call := func(remote string) string {
+		req := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_rets"}`))
+		req.Header.Set("content-type", contentType)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+	if resp := call("10.0.0.1:30303"); strings.Contains(resp, "error") {
+		t.Fatalf("first call throttled: %s", resp)
+	}
+	if resp := call("10.0.0.1:30304"); !strings.Contains(resp, "-32005") {
+		t.Fatalf("call past the quota allowed: %s", resp)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil {
+		t.Fatalf("local call throttled: %v", err)
+	}
+}
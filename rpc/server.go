@@ -256,6 +256,12 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.err != nil {
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
+	if req.callb != nil {
+		method := req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)
+		if s.throttle(ctx, method) {
+			return codec.CreateErrorResponse(&req.id, &rateLimitError{method}), nil
+		}
+	}
 
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
@@ -74,6 +74,9 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   mapset.Set
+
+	limiter   *rateLimiter // Quotas of the calls of the remote clients, nil if unlimited
+	limiterMu sync.RWMutex
 }
 
 // rpcRequest represents a raw incoming RPC request
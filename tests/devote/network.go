@@ -0,0 +1,440 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package devote implements a harness running networks of in-process devote
+// nodes, for regression testing the consensus rules across several cycles.
+//
+// Every node of a network is a masternode with its own chain database and
+// engine. Nodes don't talk over p2p, the network drives them slot by slot
+// instead: the node scheduled as witness seals a block and all online nodes
+// import it, running the full header, seal and state validation. The chain
+// starts a number of cycles in the past so blocks are sealed as fast as they
+// can be imported.
+package devote
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/core/types/devotedb"
+	"github.com/etherzero/go-etherzero/core/types/masternode"
+	"github.com/etherzero/go-etherzero/core/vm"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/ethdb"
+	"github.com/etherzero/go-etherzero/p2p/discv5"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// MaxCycles is the number of cycles a network can run before its blocks would
+// be sealed in the future and thus rejected.
+const MaxCycles = 16
+
+var errNoOnlineNode = errors.New("no online node")
+
+// Node is a masternode of the network, sealing blocks with its own engine on top
+// of its own chain.
+type Node struct {
+	ID      string         // Masternode id the node seals blocks as
+	Account common.Address // Account paid by the blocks the node seals
+
+	key    *ecdsa.PrivateKey
+	db     ethdb.Database
+	engine *devote.Devote
+	chain  *core.BlockChain
+	online bool
+}
+
+// Chain returns the local chain of the node.
+func (n *Node) Chain() *core.BlockChain {
+	return n.chain
+}
+
+// Engine returns the consensus engine of the node.
+func (n *Node) Engine() *devote.Devote {
+	return n.engine
+}
+
+// Online reports whether the node is sealing and importing blocks.
+func (n *Node) Online() bool {
+	return n.online
+}
+
+// seal assembles and signs the block of the given slot on top of the local head,
+// like the miner would, without waiting for the slot to come.
+func (n *Node) seal(slot uint64) (*types.Block, error) {
+	parent := n.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		Time:       new(big.Int).SetUint64(slot),
+		Coinbase:   n.Account,
+	}
+	if err := n.engine.Prepare(n.chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := n.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(n.db), parent.Header().Protocol)
+	if err != nil {
+		return nil, err
+	}
+	block, err := n.engine.Finalize(n.chain, header, statedb, nil, nil, nil, devoteDB)
+	if err != nil {
+		return nil, err
+	}
+	header = block.Header()
+	sighash, err := crypto.Sign(n.engine.SealHash(header).Bytes(), n.key)
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-len(sighash):], sighash)
+	return block.WithSeal(header), nil
+}
+
+// Network is a set of in-process devote nodes following the same chain.
+type Network struct {
+	Nodes []*Node
+
+	genesis *core.Genesis
+	members map[string]uint64 // Block each masternode was registered at
+	vrfKeys map[string][]byte // VRF public key each masternode registered
+	time    uint64            // Slot of the last block sealed
+}
+
+// NewNetwork creates a network of size masternodes, all registered and elected
+// as witnesses in the genesis block. The devote forks are taken from config,
+// which may be nil. The chain id is the mainnet one, so the election follows
+// the mainnet witness and safe sizes.
+func NewNetwork(size int, config *params.DevoteConfig) (*Network, error) {
+	devoteConfig := new(params.DevoteConfig)
+	if config != nil {
+		*devoteConfig = *config
+	}
+	devoteConfig.Period, devoteConfig.Epoch = params.Period, params.Epoch
+
+	keys := make([]*ecdsa.PrivateKey, size)
+	members := make(map[string]uint64)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		members[nodeID(key)] = 0
+	}
+	devoteConfig.Witnesses = make([]string, 0, size)
+	for id := range members {
+		devoteConfig.Witnesses = append(devoteConfig.Witnesses, id)
+	}
+	sort.Strings(devoteConfig.Witnesses)
+
+	chainConfig := &params.ChainConfig{
+		ChainID:        big.NewInt(90),
+		EtherzeroBlock: big.NewInt(0),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		DevoteBlock:    big.NewInt(0),
+		Devote:         devoteConfig,
+	}
+	start := (uint64(time.Now().Unix())/params.Epoch - MaxCycles) * params.Epoch
+
+	net := &Network{
+		genesis: &core.Genesis{
+			Config:     chainConfig,
+			Timestamp:  start,
+			GasLimit:   10000000,
+			Difficulty: big.NewInt(1),
+			Alloc:      core.GenesisAlloc{},
+		},
+		members: members,
+		vrfKeys: make(map[string][]byte),
+		time:    start,
+	}
+	for _, key := range keys {
+		node, err := net.newNode(key)
+		if err != nil {
+			net.Close()
+			return nil, err
+		}
+		net.Nodes = append(net.Nodes, node)
+	}
+	return net, nil
+}
+
+// nodeID returns the masternode id of the given node key.
+func nodeID(key *ecdsa.PrivateKey) string {
+	return masternode.GetMasternodeID(discv5.PubkeyID(&key.PublicKey))
+}
+
+// newNode creates an online node with a fresh chain database.
+func (net *Network) newNode(key *ecdsa.PrivateKey) (*Node, error) {
+	db := ethdb.NewMemDatabase()
+	net.genesis.MustCommit(db)
+
+	engine := devote.NewDevote(net.genesis.Config.Devote, db)
+	chain, err := core.NewBlockChain(db, nil, net.genesis.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{
+		ID:      nodeID(key),
+		Account: crypto.PubkeyToAddress(key.PublicKey),
+		key:     key,
+		db:      db,
+		engine:  engine,
+		chain:   chain,
+		online:  true,
+	}
+	engine.Authorize(node.ID, func(id string, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	vrfKey, err := engine.VRFPublicKey()
+	if err != nil {
+		chain.Stop()
+		return nil, err
+	}
+	net.vrfKeys[node.ID] = vrfKey
+	engine.VRFKeys(net.vrfKey)
+	engine.Masternodes(net.masternodes)
+	engine.PaymentCandidates(net.candidates)
+	engine.GovernanceContract(func(*big.Int) (common.Address, error) {
+		return net.genesis.Config.Devote.GovernanceContractAddress(), nil
+	})
+	return node, nil
+}
+
+// masternodes returns the ids of the masternodes registered at the given block,
+// standing in for the masternode contract.
+func (net *Network) masternodes(number *big.Int) ([]string, error) {
+	var ids []string
+	for id, block := range net.members {
+		if block <= number.Uint64() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// vrfKey returns the VRF public key registered by the given masternode,
+// standing in for the governance contract.
+func (net *Network) vrfKey(governance common.Address, id string, number *big.Int) ([]byte, error) {
+	return net.vrfKeys[id], nil
+}
+
+// candidates returns the masternodes registered at the given block as enabled
+// payment candidates.
+func (net *Network) candidates(number *big.Int) ([]*masternode.Masternode, error) {
+	var nodes []*masternode.Masternode
+	for _, node := range net.Nodes {
+		block, ok := net.members[node.ID]
+		if !ok || block > number.Uint64() {
+			continue
+		}
+		nodes = append(nodes, &masternode.Masternode{
+			ID:          node.ID,
+			NodeID:      discv5.PubkeyID(&node.key.PublicKey),
+			Account:     node.Account,
+			OriginBlock: new(big.Int).SetUint64(block),
+			State:       masternode.MasternodeEnable,
+		})
+	}
+	return nodes, nil
+}
+
+// AddNode creates a new node synced to the head of the network and registers it
+// as a masternode at the head block. It becomes a candidate for the election as
+// soon as the registration is stable.
+func (net *Network) AddNode() (*Node, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	node, err := net.newNode(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := net.sync(node); err != nil {
+		node.chain.Stop()
+		return nil, err
+	}
+	net.members[node.ID] = node.chain.CurrentBlock().NumberU64()
+	net.Nodes = append(net.Nodes, node)
+	return node, nil
+}
+
+// Stop takes the node offline, it misses its slots until started again.
+func (net *Network) Stop(node *Node) {
+	node.online = false
+}
+
+// Start brings the node back online, importing the blocks it missed.
+func (net *Network) Start(node *Node) error {
+	if err := net.sync(node); err != nil {
+		return err
+	}
+	node.online = true
+	return nil
+}
+
+// sync imports the blocks of the network the node doesn't have yet.
+func (net *Network) sync(node *Node) error {
+	head := net.Head()
+	if head == nil {
+		return errNoOnlineNode
+	}
+	var blocks []*types.Block
+	for number := node.chain.CurrentBlock().NumberU64() + 1; number <= head.CurrentBlock().NumberU64(); number++ {
+		blocks = append(blocks, head.GetBlockByNumber(number))
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	_, err := node.chain.InsertChain(blocks)
+	return err
+}
+
+// Head returns the chain of an online node, or nil if all nodes are offline.
+func (net *Network) Head() *core.BlockChain {
+	if node := net.online(); node != nil {
+		return node.chain
+	}
+	return nil
+}
+
+// online returns the first online node, or nil if all nodes are offline.
+func (net *Network) online() *Node {
+	for _, node := range net.Nodes {
+		if node.online {
+			return node
+		}
+	}
+	return nil
+}
+
+// Node returns the node sealing as the given masternode id, or nil.
+func (net *Network) Node(id string) *Node {
+	for _, node := range net.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// Time returns the slot of the last block sealed, the network time.
+func (net *Network) Time() uint64 {
+	return net.time
+}
+
+// Run advances the network by the given number of slots. The online node
+// scheduled as witness of each slot seals a block, which all online nodes
+// import. Slots of offline witnesses are sealed by the standby in line if it
+// may take over, and skipped otherwise. An error is returned if any
+// node fails to seal or to import a block.
+func (net *Network) Run(slots uint64) error {
+	end := net.time + slots*params.Period
+	if end >= uint64(time.Now().Unix()) {
+		return fmt.Errorf("network time %d beyond present", end)
+	}
+	for net.time < end {
+		net.time += params.Period
+
+		block, err := net.sealSlot(func(node *Node) bool {
+			return node.engine.IsWitnessAt(node.chain.CurrentHeader(), net.time)
+		})
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			if block, err = net.sealSlot(func(node *Node) bool {
+				return node.engine.IsStandbyAt(node.chain.CurrentHeader(), net.time)
+			}); err != nil {
+				return err
+			}
+		}
+		if block == nil {
+			continue
+		}
+		for _, node := range net.Nodes {
+			if !node.online {
+				continue
+			}
+			if _, err := node.chain.InsertChain(types.Blocks{block}); err != nil {
+				return fmt.Errorf("node %s failed to import block %d: %v", node.ID, block.NumberU64(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// sealSlot seals the block of the current slot by the first online node the
+// sealer filter accepts, returning nil if there is none.
+func (net *Network) sealSlot(sealer func(node *Node) bool) (*types.Block, error) {
+	for _, node := range net.Nodes {
+		if !node.online || !sealer(node) {
+			continue
+		}
+		block, err := node.seal(net.time)
+		if err != nil {
+			return nil, fmt.Errorf("node %s failed to seal slot %d: %v", node.ID, net.time, err)
+		}
+		return block, nil
+	}
+	return nil, nil
+}
+
+// RunCycles advances the network to the start of the given number of cycles
+// later, sealing the first block of the last one.
+func (net *Network) RunCycles(cycles uint64) error {
+	next := (net.time/params.Epoch + cycles) * params.Epoch
+	return net.Run((next - net.time) / params.Period)
+}
+
+// Witnesses returns the witnesses elected for the given cycle, as recorded by
+// the head chain.
+func (net *Network) Witnesses(cycle uint64) ([]string, error) {
+	node := net.online()
+	if node == nil {
+		return nil, errNoOnlineNode
+	}
+	devoteDB, err := devotedb.NewDevoteByProtocol(devotedb.NewDatabase(node.db), node.chain.CurrentHeader().Protocol)
+	if err != nil {
+		return nil, err
+	}
+	return devoteDB.GetWitnesses(cycle)
+}
+
+// Close stops the chains of all nodes.
+func (net *Network) Close() {
+	for _, node := range net.Nodes {
+		node.chain.Stop()
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2018 The go-etherzero Authors
+// This file is part of the go-etherzero library.
+//
+// The go-etherzero library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-etherzero library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-etherzero library. If not, see <http://www.gnu.org/licenses/>.
+
+package devote
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/etherzero/go-etherzero/common"
+	"github.com/etherzero/go-etherzero/consensus/devote"
+	"github.com/etherzero/go-etherzero/core/rawdb"
+	"github.com/etherzero/go-etherzero/core/types"
+	"github.com/etherzero/go-etherzero/crypto"
+	"github.com/etherzero/go-etherzero/params"
+)
+
+// Tests that blocks are sealed by the witnesses in the order of the recorded
+// schedule, filling every slot while all nodes are online.
+func TestProductionOrder(t *testing.T) {
+	net, err := NewNetwork(17, nil)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	if err := net.RunCycles(2); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := net.Head()
+	head := chain.CurrentBlock()
+	if want := (net.Time() - net.genesis.Timestamp) / params.Period; head.NumberU64() != want {
+		t.Fatalf("block count mismatch: have %d, want %d", head.NumberU64(), want)
+	}
+	for number := uint64(1); number <= head.NumberU64(); number++ {
+		header := chain.GetHeaderByNumber(number)
+		slot := header.Time.Uint64()
+
+		// The schedule is looked up in the cycle of the parent, so the opening
+		// block of a cycle is still sealed by the previous witnesses
+		parent := chain.GetHeaderByNumber(number - 1)
+		witnesses, err := net.Witnesses(parent.Time.Uint64() / params.Epoch)
+		if err != nil {
+			t.Fatalf("failed to get witnesses of block %d: %v", number, err)
+		}
+		if want := witnesses[(slot%params.Epoch/params.Period)%uint64(len(witnesses))]; header.Witness != want {
+			t.Fatalf("block %d: witness mismatch: have %s, want %s", number, header.Witness, want)
+		}
+	}
+}
+
+// Tests that with the payment queue enabled, the block rewards go round robin
+// to the accounts of all masternodes.
+func TestPayments(t *testing.T) {
+	net, err := NewNetwork(17, &params.DevoteConfig{PaymentQueueBlock: big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	if err := net.Run(3 * uint64(len(net.Nodes))); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := net.Head()
+
+	paid := make(map[string]bool)
+	for number := uint64(1); number <= uint64(len(net.Nodes)); number++ {
+		coinbase := chain.GetHeaderByNumber(number).Coinbase.Hex()
+		if paid[coinbase] {
+			t.Fatalf("block %d: account %s paid twice in a round", number, coinbase)
+		}
+		paid[coinbase] = true
+	}
+	for number := uint64(len(net.Nodes)) + 1; number <= chain.CurrentBlock().NumberU64(); number++ {
+		have := chain.GetHeaderByNumber(number).Coinbase
+		want := chain.GetHeaderByNumber(number - uint64(len(net.Nodes))).Coinbase
+		if have != want {
+			t.Fatalf("block %d: payee mismatch: have %x, want %x", number, have, want)
+		}
+	}
+	state, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to get head state: %v", err)
+	}
+	reward, _, _ := net.genesis.Config.Devote.BlockReward(chain.CurrentBlock().Number())
+	for _, node := range net.Nodes {
+		if want := new(big.Int).Mul(reward, big.NewInt(3)); state.GetBalance(node.Account).Cmp(want) != 0 {
+			t.Errorf("node %s: balance mismatch: have %v, want %v", node.ID, state.GetBalance(node.Account), want)
+		}
+	}
+	// The system receipt of every block must report its masternode payment
+	db := net.online().db
+	for number := uint64(1); number <= chain.CurrentBlock().NumberU64(); number++ {
+		header := chain.GetHeaderByNumber(number)
+		receipt := rawdb.ReadSystemReceipt(db, header.Hash(), number)
+		if receipt == nil {
+			t.Fatalf("block %d: missing system receipt", number)
+		}
+		var paid *types.Payment
+		for _, payment := range receipt.Payments {
+			if payment.Kind == types.PaymentMasternode {
+				paid = payment
+			}
+		}
+		if paid == nil || paid.To != header.Coinbase || paid.Amount.Cmp(reward) != 0 {
+			t.Errorf("block %d: masternode payment mismatch: have %+v, want %v to %x", number, paid, reward, header.Coinbase)
+		}
+	}
+}
+
+// Tests that a witness going offline is kicked out of the next election, and
+// that it catches up with the chain when back online.
+func TestKickout(t *testing.T) {
+	net, err := NewNetwork(17, &params.DevoteConfig{KickoutBlock: big.NewInt(0), KickoutThreshold: 50})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	// The witnesses of the genesis cycle are never kicked out, skip it
+	if err := net.RunCycles(1); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	offline := net.Nodes[0]
+	net.Stop(offline)
+
+	if err := net.RunCycles(1); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	witnesses, err := net.Witnesses(net.Time() / params.Epoch)
+	if err != nil {
+		t.Fatalf("failed to get witnesses: %v", err)
+	}
+	if len(witnesses) != len(net.Nodes)-1 {
+		t.Fatalf("witness count mismatch: have %d, want %d", len(witnesses), len(net.Nodes)-1)
+	}
+	for _, witness := range witnesses {
+		if witness == offline.ID {
+			t.Fatalf("offline node %s not kicked out", offline.ID)
+		}
+	}
+	if err := net.Start(offline); err != nil {
+		t.Fatalf("failed to restart node: %v", err)
+	}
+	if err := net.Run(params.Epoch / params.Period / 10); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	if have, want := offline.Chain().CurrentBlock().Hash(), net.Nodes[1].Chain().CurrentBlock().Hash(); have != want {
+		t.Fatalf("restarted node head mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that header batches spanning several cycles are verified concurrently,
+// rejecting headers sealed by a witness outside of its slot.
+func TestBatchVerification(t *testing.T) {
+	net, err := NewNetwork(17, &params.DevoteConfig{EpochSummaryBlock: big.NewInt(0)})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	if err := net.RunCycles(2); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	node := net.Nodes[0]
+	chain := node.Chain()
+
+	headers := make([]*types.Header, chain.CurrentBlock().NumberU64())
+	seals := make([]bool, len(headers))
+	for i := range headers {
+		headers[i] = chain.GetHeaderByNumber(uint64(i + 1))
+		seals[i] = true
+	}
+	verify := func() error {
+		_, results := node.Engine().VerifyHeaders(chain, headers, seals)
+		for i := range headers {
+			if err := <-results; err != nil {
+				return fmt.Errorf("header %d: %v", i+1, err)
+			}
+		}
+		return nil
+	}
+	if err := verify(); err != nil {
+		t.Fatalf("failed to verify valid batch: %v", err)
+	}
+	// Reseal a header of the last cycle by a witness not scheduled for its slot
+	index := len(headers) - 2
+	forged := types.CopyHeader(headers[index])
+	for _, other := range net.Nodes {
+		if other.ID != forged.Witness {
+			forged.Witness = other.ID
+			sighash, _ := crypto.Sign(other.Engine().SealHash(forged).Bytes(), other.key)
+			copy(forged.Extra[len(forged.Extra)-len(sighash):], sighash)
+			break
+		}
+	}
+	headers[index] = forged
+	if err := verify(); err == nil || !strings.Contains(err.Error(), devote.ErrWrongWitness.Error()) {
+		t.Fatalf("forged header error mismatch: have %v, want %v", err, devote.ErrWrongWitness)
+	}
+}
+
+// Tests that the slots of an offline witness are taken over by a standby, and
+// that chains with standby sealed blocks import, one block at a time as well
+// as in batches.
+func TestStandby(t *testing.T) {
+	net, err := NewNetwork(17, &params.DevoteConfig{
+		WitnessesBlock:   big.NewInt(0),
+		MaxWitnesses:     10,
+		StandbyBlock:     big.NewInt(0),
+		StandbyWitnesses: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	// The standbys are elected with the witnesses of the first cycle
+	if err := net.RunCycles(1); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	witnesses, err := net.Witnesses(net.Time() / params.Epoch)
+	if err != nil {
+		t.Fatalf("failed to get witnesses: %v", err)
+	}
+	net.Stop(net.Node(witnesses[0]))
+
+	start := net.Head().CurrentBlock().NumberU64()
+	if err := net.Run(uint64(2 * len(witnesses))); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := net.Head()
+	standbys := 0
+	for number := start + 1; number <= chain.CurrentBlock().NumberU64(); number++ {
+		elected := false
+		for _, witness := range witnesses {
+			if chain.GetHeaderByNumber(number).Witness == witness {
+				elected = true
+				break
+			}
+		}
+		if !elected {
+			standbys++
+		}
+	}
+	if standbys == 0 {
+		t.Fatalf("no block sealed by a standby")
+	}
+	node, err := net.AddNode()
+	if err != nil {
+		t.Fatalf("failed to sync new node: %v", err)
+	}
+	if have, want := node.Chain().CurrentBlock().Hash(), chain.CurrentBlock().Hash(); have != want {
+		t.Fatalf("synced node head mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that in the VRF mode the slots are sealed by the eligible witnesses
+// rather than in round robin, and that such chains import, one block at a time
+// as well as in batches.
+func TestVRF(t *testing.T) {
+	net, err := NewNetwork(17, &params.DevoteConfig{VRFBlock: big.NewInt(1), VRFLeaders: 2})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	if err := net.Run(2 * uint64(len(net.Nodes))); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := net.Head()
+	if chain.CurrentBlock().NumberU64() == 0 {
+		t.Fatalf("no slot sealed")
+	}
+	witnesses, err := net.Witnesses(net.genesis.Timestamp / params.Epoch)
+	if err != nil {
+		t.Fatalf("failed to get witnesses: %v", err)
+	}
+	offturn := 0
+	for number := uint64(1); number <= chain.CurrentBlock().NumberU64(); number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header.MixDigest == (common.Hash{}) {
+			t.Fatalf("block %d: missing vrf proof", number)
+		}
+		if want := witnesses[(header.Time.Uint64()%params.Epoch/params.Period)%uint64(len(witnesses))]; header.Witness != want {
+			offturn++
+		}
+	}
+	if offturn == 0 {
+		t.Fatalf("all blocks sealed by the round robin witness")
+	}
+	node, err := net.AddNode()
+	if err != nil {
+		t.Fatalf("failed to sync new node: %v", err)
+	}
+	if have, want := node.Chain().CurrentBlock().Hash(), chain.CurrentBlock().Hash(); have != want {
+		t.Fatalf("synced node head mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the devote records move into the unified trie at its fork without
+// breaking consensus, the records of the earlier cycles staying readable.
+func TestUnifiedTrie(t *testing.T) {
+	fork := params.Epoch / params.Period / 2
+	net, err := NewNetwork(17, &params.DevoteConfig{UnifiedTrieBlock: new(big.Int).SetUint64(fork)})
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	if err := net.RunCycles(2); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := net.Head()
+	for number := uint64(1); number <= chain.CurrentBlock().NumberU64(); number++ {
+		if have, want := chain.GetHeaderByNumber(number).Protocol.Unified(), number >= fork; have != want {
+			t.Fatalf("block %d: unified mismatch: have %v, want %v", number, have, want)
+		}
+	}
+	for cycle := net.genesis.Timestamp / params.Epoch; cycle <= net.Time()/params.Epoch; cycle++ {
+		if witnesses, err := net.Witnesses(cycle); err != nil || len(witnesses) == 0 {
+			t.Fatalf("cycle %d: witnesses unavailable: %v, err %v", cycle, witnesses, err)
+		}
+	}
+}
+
+// Tests that an engine in observer mode keeps validating the chain but refuses
+// to seal, even when authorized with a masternode key.
+func TestObserver(t *testing.T) {
+	net, err := NewNetwork(17, nil)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer net.Close()
+
+	observer := net.Nodes[0]
+	observer.Engine().Observe()
+	observer.Engine().Authorize(observer.ID, func(string, []byte) ([]byte, error) {
+		t.Fatalf("observer signed a hash")
+		return nil, nil
+	})
+	if err := net.Run(params.Epoch / params.Period / 10); err != nil {
+		t.Fatalf("failed to run network: %v", err)
+	}
+	chain := observer.Chain()
+	if have, want := chain.CurrentBlock().Hash(), net.Nodes[1].Chain().CurrentBlock().Hash(); have != want {
+		t.Fatalf("observer head mismatch: have %x, want %x", have, want)
+	}
+	block := types.NewBlockWithHeader(&types.Header{
+		ParentHash: chain.CurrentBlock().Hash(),
+		Number:     new(big.Int).Add(chain.CurrentBlock().Number(), big.NewInt(1)),
+	})
+	if _, err := observer.Engine().Seal(chain, block, nil); err != devote.ErrObserver {
+		t.Fatalf("observer seal error mismatch: have %v, want %v", err, devote.ErrObserver)
+	}
+}